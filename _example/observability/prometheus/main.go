@@ -17,6 +17,9 @@ import (
 type PrometheusCollector struct {
 	mu sync.Mutex
 
+	// Prefix for every metric name, so the metrics fit existing dashboards
+	prefix string
+
 	// In production, these would be prometheus.Counter/Histogram
 	attemptsTotal    map[string]int            // key: method
 	retriesTotal     map[string]map[string]int // key: method, then reason
@@ -25,8 +28,14 @@ type PrometheusCollector struct {
 	requestDurations []float64                 // Would be prometheus.Histogram
 }
 
-func NewPrometheusCollector() *PrometheusCollector {
+// NewPrometheusCollector creates a collector whose metric names start with
+// prefix (e.g. "myapp_http_retry"); an empty prefix uses "http_retry".
+func NewPrometheusCollector(prefix string) *PrometheusCollector {
+	if prefix == "" {
+		prefix = "http_retry"
+	}
 	return &PrometheusCollector{
+		prefix:        prefix,
 		attemptsTotal: make(map[string]int),
 		retriesTotal:  make(map[string]map[string]int),
 		requestsTotal: make(map[string]map[bool]int),
@@ -47,12 +56,14 @@ func (p *PrometheusCollector) RecordAttempt(
 	p.attemptDurations = append(p.attemptDurations, duration.Seconds())
 
 	fmt.Printf(
-		"[Prometheus] http_retry_attempts_total{method=%q,status=%d} +1\n",
+		"[Prometheus] %s_attempts_total{method=%q,status=%d} +1\n",
+		p.prefix,
 		method,
 		statusCode,
 	)
 	fmt.Printf(
-		"[Prometheus] http_retry_attempt_duration_seconds{method=%q} observe %.3fs\n",
+		"[Prometheus] %s_attempt_duration_seconds{method=%q} observe %.3fs\n",
+		p.prefix,
 		method,
 		duration.Seconds(),
 	)
@@ -68,7 +79,12 @@ func (p *PrometheusCollector) RecordRetry(method string, reason string, attemptN
 	}
 	p.retriesTotal[method][reason]++
 
-	fmt.Printf("[Prometheus] http_retry_retries_total{method=%q,reason=%q} +1\n", method, reason)
+	fmt.Printf(
+		"[Prometheus] %s_retries_total{method=%q,reason=%q} +1\n",
+		p.prefix,
+		method,
+		reason,
+	)
 }
 
 func (p *PrometheusCollector) RecordRequestComplete(
@@ -92,14 +108,21 @@ func (p *PrometheusCollector) RecordRequestComplete(
 	p.requestsTotal[method][success]++
 	p.requestDurations = append(p.requestDurations, totalDuration.Seconds())
 
-	fmt.Printf("[Prometheus] http_retry_requests_total{method=%q,success=%v} +1\n", method, success)
 	fmt.Printf(
-		"[Prometheus] http_retry_request_duration_seconds{method=%q} observe %.3fs\n",
+		"[Prometheus] %s_requests_total{method=%q,success=%v} +1\n",
+		p.prefix,
+		method,
+		success,
+	)
+	fmt.Printf(
+		"[Prometheus] %s_request_duration_seconds{method=%q} observe %.3fs\n",
+		p.prefix,
 		method,
 		totalDuration.Seconds(),
 	)
 	fmt.Printf(
-		"[Prometheus] http_retry_request_attempts{method=%q} observe %d\n",
+		"[Prometheus] %s_request_attempts{method=%q} observe %d\n",
+		p.prefix,
 		method,
 		totalAttempts,
 	)
@@ -110,26 +133,33 @@ func (p *PrometheusCollector) PrintMetrics() {
 	defer p.mu.Unlock()
 
 	fmt.Println("\n=== Prometheus Metrics Summary ===")
-	fmt.Println("# HELP http_retry_attempts_total Total number of HTTP attempts")
-	fmt.Println("# TYPE http_retry_attempts_total counter")
+	fmt.Printf("# HELP %s_attempts_total Total number of HTTP attempts\n", p.prefix)
+	fmt.Printf("# TYPE %s_attempts_total counter\n", p.prefix)
 	for method, count := range p.attemptsTotal {
-		fmt.Printf("http_retry_attempts_total{method=%q} %d\n", method, count)
+		fmt.Printf("%s_attempts_total{method=%q} %d\n", p.prefix, method, count)
 	}
 
-	fmt.Println("\n# HELP http_retry_retries_total Total number of retries by reason")
-	fmt.Println("# TYPE http_retry_retries_total counter")
+	fmt.Printf("\n# HELP %s_retries_total Total number of retries by reason\n", p.prefix)
+	fmt.Printf("# TYPE %s_retries_total counter\n", p.prefix)
 	for method, reasons := range p.retriesTotal {
 		for reason, count := range reasons {
-			fmt.Printf("http_retry_retries_total{method=%q,reason=%q} %d\n", method, reason, count)
+			fmt.Printf(
+				"%s_retries_total{method=%q,reason=%q} %d\n",
+				p.prefix,
+				method,
+				reason,
+				count,
+			)
 		}
 	}
 
-	fmt.Println("\n# HELP http_retry_requests_total Total number of completed requests")
-	fmt.Println("# TYPE http_retry_requests_total counter")
+	fmt.Printf("\n# HELP %s_requests_total Total number of completed requests\n", p.prefix)
+	fmt.Printf("# TYPE %s_requests_total counter\n", p.prefix)
 	for method, outcomes := range p.requestsTotal {
 		for success, count := range outcomes {
 			fmt.Printf(
-				"http_retry_requests_total{method=%q,success=%v} %d\n",
+				"%s_requests_total{method=%q,success=%v} %d\n",
+				p.prefix,
 				method,
 				success,
 				count,
@@ -139,8 +169,8 @@ func (p *PrometheusCollector) PrintMetrics() {
 }
 
 func main() {
-	// Create Prometheus collector
-	promCollector := NewPrometheusCollector()
+	// Create Prometheus collector with a prefix matching existing dashboards
+	promCollector := NewPrometheusCollector("myapp_http_retry")
 
 	// Create test server that fails twice then succeeds
 	attempts := 0
@@ -160,6 +190,8 @@ func main() {
 		retry.WithInitialRetryDelay(50*time.Millisecond),
 		retry.WithJitter(false),
 		retry.WithMetrics(promCollector),
+		// Skip per-connection events; this collector doesn't record them
+		retry.WithDisabledMetrics(retry.MetricConnections),
 	)
 	if err != nil {
 		panic(err)
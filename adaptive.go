@@ -0,0 +1,226 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Tuning of the adaptive concurrency limit (WithAdaptiveConcurrency).
+const (
+	adaptiveInitialLimit = 20
+	adaptiveMinLimit     = 1
+	adaptiveMaxLimit     = 200
+	adaptiveTolerance    = 1.5  // Latency increase tolerated before shrinking
+	adaptiveSmoothing    = 0.2  // Weight of each new limit estimate
+	adaptiveLongWindow   = 600  // Samples in the long-term latency average
+	adaptiveDropFactor   = 0.9  // Limit multiplier on an overload signal
+	adaptiveDriftRecover = 0.95 // Long-term latency decay when it runs far above the current one
+)
+
+// AdaptiveOption configures WithAdaptiveConcurrency.
+type AdaptiveOption func(*adaptiveLimiter)
+
+// AdaptiveLimits sets the initial limit of a host and the bounds the limit
+// moves within. Non-positive values keep the defaults of 20, 1 and 200.
+func AdaptiveLimits(initial, minLimit, maxLimit int) AdaptiveOption {
+	return func(l *adaptiveLimiter) {
+		if minLimit > 0 {
+			l.minLimit = float64(minLimit)
+		}
+		if maxLimit > 0 {
+			l.maxLimit = float64(maxLimit)
+		}
+		if initial > 0 {
+			l.initial = float64(initial)
+		}
+	}
+}
+
+// OnLimitChange calls fn whenever the whole-number limit of a host changes,
+// e.g. to export it as a gauge. fn must not block.
+func OnLimitChange(fn func(host string, limit int)) AdaptiveOption {
+	return func(l *adaptiveLimiter) {
+		l.onChange = fn
+	}
+}
+
+// adaptiveLimiter adapts a per-host concurrency limit to the latency of the
+// host, in the style of Netflix's gradient limiter.
+type adaptiveLimiter struct {
+	initial, minLimit, maxLimit float64
+	onChange                    func(host string, limit int)
+
+	mu    sync.Mutex
+	hosts map[string]*adaptiveHost
+}
+
+// adaptiveHost is the limit of one host and the latency it is based on.
+type adaptiveHost struct {
+	limit    float64
+	inFlight int
+	shortRTT float64       // Latest latency, in nanoseconds
+	longRTT  float64       // Long-term average latency, in nanoseconds
+	released chan struct{} // Closed and replaced whenever capacity may be free
+}
+
+// newAdaptiveLimiter returns a limiter configured by opts.
+func newAdaptiveLimiter(opts []AdaptiveOption) *adaptiveLimiter {
+	l := &adaptiveLimiter{
+		initial:  adaptiveInitialLimit,
+		minLimit: adaptiveMinLimit,
+		maxLimit: adaptiveMaxLimit,
+		hosts:    make(map[string]*adaptiveHost),
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.maxLimit = max(l.maxLimit, l.minLimit)
+	l.initial = min(max(l.initial, l.minLimit), l.maxLimit)
+	return l
+}
+
+// host returns the state of host, creating it on first use. Callers must
+// hold l.mu.
+func (l *adaptiveLimiter) host(host string) *adaptiveHost {
+	h, ok := l.hosts[host]
+	if !ok {
+		h = &adaptiveHost{limit: l.initial, released: make(chan struct{})}
+		l.hosts[host] = h
+	}
+	return h
+}
+
+// acquire blocks until host is below its limit or ctx is done. The returned
+// function ends the attempt with its outcome, which updates the limit.
+func (l *adaptiveLimiter) acquire(
+	ctx context.Context,
+	host string,
+) (func(*http.Response, error), error) {
+	for {
+		l.mu.Lock()
+		h := l.host(host)
+		if h.inFlight < int(h.limit) {
+			h.inFlight++
+			l.mu.Unlock()
+			start := time.Now()
+			return func(resp *http.Response, err error) {
+				l.release(host, h, time.Since(start), resp, err)
+			}, nil
+		}
+		released := h.released
+		l.mu.Unlock()
+
+		select {
+		case <-released:
+			// Re-check: another waiter may have taken the capacity
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// release ends an attempt to host that took rtt, adjusting the limit to its
+// outcome.
+func (l *adaptiveLimiter) release(
+	host string,
+	h *adaptiveHost,
+	rtt time.Duration,
+	resp *http.Response,
+	err error,
+) {
+	l.mu.Lock()
+	before := int(h.limit)
+	inFlight := h.inFlight
+	h.inFlight--
+	switch {
+	case overloaded(resp, err):
+		h.limit = max(h.limit*adaptiveDropFactor, l.minLimit)
+	case err == nil:
+		l.update(h, float64(rtt), inFlight)
+	}
+	// Other errors (e.g. refused connections) say nothing about the latency
+	close(h.released)
+	h.released = make(chan struct{})
+	after := int(h.limit)
+	l.mu.Unlock()
+
+	if after != before && l.onChange != nil {
+		l.onChange(host, after)
+	}
+}
+
+// update adjusts the limit of h for a latency sample taken with inFlight
+// attempts in flight. Callers must hold l.mu.
+func (l *adaptiveLimiter) update(h *adaptiveHost, rtt float64, inFlight int) {
+	h.shortRTT = rtt
+	if h.longRTT == 0 {
+		h.longRTT = rtt
+	} else {
+		h.longRTT += (rtt - h.longRTT) * 2 / (adaptiveLongWindow + 1)
+	}
+	if h.longRTT > 2*h.shortRTT {
+		h.longRTT *= adaptiveDriftRecover // Recover from a past latency spike
+	}
+
+	// Too few attempts in flight to tell whether the limit is right
+	if float64(inFlight) < h.limit/2 {
+		return
+	}
+
+	gradient := max(0.5, min(1, adaptiveTolerance*h.longRTT/h.shortRTT))
+	estimate := h.limit*gradient + math.Sqrt(h.limit)
+	h.limit = h.limit*(1-adaptiveSmoothing) + estimate*adaptiveSmoothing
+	h.limit = min(max(h.limit, l.minLimit), l.maxLimit)
+}
+
+// limit returns the current whole-number limit of host, or the initial limit
+// for a host not requested yet, without tracking it.
+func (l *adaptiveLimiter) limit(host string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if h, ok := l.hosts[host]; ok {
+		return int(h.limit)
+	}
+	return int(l.initial)
+}
+
+// overloaded reports whether an attempt's outcome signals an overloaded
+// host: a timeout, or a 429, 503 or 504 response.
+func overloaded(resp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, context.DeadlineExceeded)
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// acquireAdaptiveSlot waits for the adaptive concurrency limit of host when
+// WithAdaptiveConcurrency is set. The returned function, never nil, ends the
+// attempt with its outcome.
+func (c *Client) acquireAdaptiveSlot(
+	ctx context.Context,
+	host string,
+) (func(*http.Response, error), error) {
+	if c.adaptive == nil {
+		return func(*http.Response, error) {}, nil
+	}
+	defer c.admission.queue()()
+	return c.adaptive.acquire(ctx, host)
+}
+
+// ConcurrencyLimit returns the adaptive concurrency limit of host: its initial
+// limit until the client sends it requests, or 0 without
+// WithAdaptiveConcurrency.
+func (c *Client) ConcurrencyLimit(host string) int {
+	if c.adaptive == nil {
+		return 0
+	}
+	return c.adaptive.limit(host)
+}
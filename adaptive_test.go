@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAdaptiveLimiter_Gradient(t *testing.T) {
+	l := newAdaptiveLimiter([]AdaptiveOption{AdaptiveLimits(10, 1, 100)})
+	h := l.host("api")
+	ms := float64(time.Millisecond)
+
+	// Steady latency with the limit in use: grow
+	for range 5 {
+		l.update(h, 10*ms, int(h.limit))
+	}
+	grown := h.limit
+	if grown <= 10 {
+		t.Fatalf("Expected the limit to grow at steady latency, got %.1f", grown)
+	}
+
+	// Few attempts in flight: no evidence either way
+	l.update(h, 10*ms, 1)
+	if h.limit != grown {
+		t.Errorf("Expected an app-limited sample to keep the limit, got %.1f", h.limit)
+	}
+
+	// Queueing at the host: shrink
+	for range 5 {
+		l.update(h, 50*ms, int(h.limit))
+	}
+	if h.limit >= grown {
+		t.Errorf("Expected the limit to shrink as latency rises, got %.1f (was %.1f)", h.limit, grown)
+	}
+}
+
+func TestAdaptiveLimiter_Bounds(t *testing.T) {
+	l := newAdaptiveLimiter([]AdaptiveOption{AdaptiveLimits(4, 2, 5)})
+	h := l.host("api")
+	for range 50 {
+		l.update(h, float64(time.Millisecond), int(h.limit))
+	}
+	if got := l.limit("api"); got != 5 {
+		t.Errorf("Expected the limit capped at 5, got %d", got)
+	}
+	for range 50 {
+		l.release("api", h, time.Millisecond, &http.Response{StatusCode: http.StatusServiceUnavailable}, nil)
+	}
+	if got := l.limit("api"); got != 2 {
+		t.Errorf("Expected the limit floored at 2, got %d", got)
+	}
+}
+
+func TestWithAdaptiveConcurrency(t *testing.T) {
+	server, hits, release := blockingServer(t)
+	client, err := NewClient(WithAdaptiveConcurrency(AdaptiveLimits(1, 1, 10)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	host := mustParseURL(t, server.URL).Host
+	if got := client.ConcurrencyLimit(host); got != 1 {
+		t.Fatalf("Expected the initial limit of 1, got %d", got)
+	}
+
+	first := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		first <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	// The second attempt waits for capacity
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := client.Get(ctx, server.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the attempt over the limit to wait, got %v", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected 1 request at the host, got %d", n)
+	}
+
+	close(release)
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWithAdaptiveConcurrency_Overload(t *testing.T) {
+	server, _ := countingServer(t, http.StatusServiceUnavailable)
+	var changes []int
+	client, err := NewClient(
+		WithMaxRetries(0),
+		WithAdaptiveConcurrency(
+			AdaptiveLimits(10, 1, 10),
+			OnLimitChange(func(_ string, limit int) { changes = append(changes, limit) }),
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	host := mustParseURL(t, server.URL).Host
+	if got := client.ConcurrencyLimit(host); got != 9 || len(changes) != 1 || changes[0] != 9 {
+		t.Errorf("Expected a 503 to cut the limit to 9, got %d (changes %v)", got, changes)
+	}
+}
+
+func TestWithAdaptiveConcurrency_UnknownHost(t *testing.T) {
+	client, err := NewClient(WithAdaptiveConcurrency(AdaptiveLimits(15, 1, 50)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := client.ConcurrencyLimit("api.example.com"); got != 15 {
+		t.Errorf("Expected the initial limit for an unknown host, got %d", got)
+	}
+	if n := len(client.adaptive.hosts); n != 0 {
+		t.Errorf("Expected the lookup not to track the host, got %d hosts", n)
+	}
+}
+
+func TestWithAdaptiveConcurrency_Disabled(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := client.ConcurrencyLimit("api.example.com"); got != 0 {
+		t.Errorf("Expected no limit without WithAdaptiveConcurrency, got %d", got)
+	}
+}
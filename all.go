@@ -0,0 +1,147 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// Call describes one request made by All.
+type Call struct {
+	Method string
+	URL    string
+	Opts   []RequestOption
+}
+
+// Result is the outcome of one Call. Resp is nil when Err is set, except for
+// RetryErrors that come with the last response (e.g. WithErrorDecoder). The
+// caller must close every non-nil Resp.Body.
+type Result struct {
+	Call Call
+	Resp *http.Response
+	Err  error
+}
+
+// AllOption configures All.
+type AllOption func(*allConfig)
+
+type allConfig struct {
+	failFast    bool
+	maxParallel int // 0 = run every call at once
+}
+
+// FailFast makes All cancel the calls still in flight (and skip the ones not
+// yet started) as soon as one call fails, and return only that failure.
+// Without it, All waits for every call and joins all failures.
+func FailFast() AllOption {
+	return func(cfg *allConfig) {
+		cfg.failFast = true
+	}
+}
+
+// MaxParallel limits All to n calls in flight at a time. n <= 0 means no limit.
+func MaxParallel(n int) AllOption {
+	return func(cfg *allConfig) {
+		cfg.maxParallel = n
+	}
+}
+
+// All runs calls concurrently through client, each with the client's retry
+// policy, and returns their results in the same order as calls. The error
+// joins the failures of the individual calls (see errors.Join), each prefixed
+// with its method and URL, and is nil when every call succeeded.
+//
+// Example:
+//
+//	results, err := retry.All(ctx, client, []retry.Call{
+//	    {Method: http.MethodGet, URL: "https://api.example.com/users/1"},
+//	    {Method: http.MethodPost, URL: "https://api.example.com/audit",
+//	        Opts: []retry.RequestOption{retry.WithJSON(event)}},
+//	}, retry.FailFast())
+//	for _, r := range results {
+//	    if r.Resp != nil {
+//	        defer r.Resp.Body.Close()
+//	    }
+//	}
+func All(ctx context.Context, client *Client, calls []Call, opts ...AllOption) ([]Result, error) {
+	var cfg allConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var slots chan struct{}
+	if cfg.maxParallel > 0 {
+		slots = make(chan struct{}, cfg.maxParallel)
+	}
+
+	// Each call gets its own context so that failing fast cancels only the
+	// unfinished calls, leaving the bodies of finished ones readable
+	results := make([]Result, len(calls))
+	contexts := make([]context.Context, len(calls))
+	cancels := make([]context.CancelFunc, len(calls))
+	for i, call := range calls {
+		results[i].Call = call
+		contexts[i], cancels[i] = context.WithCancel(ctx)
+	}
+
+	var mu sync.Mutex
+	done := make([]bool, len(calls))
+	var failed error // First failure, when failing fast
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Go(func() {
+			resp, err := runCall(contexts[i], client, call, slots)
+			wrapBodyWithCancel(resp, cancels[i])
+
+			mu.Lock()
+			defer mu.Unlock()
+			done[i] = true
+			results[i].Resp, results[i].Err = resp, err
+			if err == nil || !cfg.failFast || failed != nil {
+				return
+			}
+			failed = fmt.Errorf("%s %s: %w", call.Method, call.URL, err)
+			for j, cancel := range cancels {
+				if !done[j] {
+					cancel()
+				}
+			}
+		})
+	}
+	wg.Wait()
+
+	if cfg.failFast {
+		return results, failed
+	}
+	var errs []error
+	for _, r := range results {
+		if r.Err != nil {
+			errs = append(errs, fmt.Errorf("%s %s: %w", r.Call.Method, r.Call.URL, r.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// runCall makes one call of All, waiting for a slot when slots is non-nil.
+func runCall(
+	ctx context.Context,
+	client *Client,
+	call Call,
+	slots chan struct{},
+) (*http.Response, error) {
+	if slots != nil {
+		select {
+		case slots <- struct{}{}:
+			defer func() { <-slots }()
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return client.doRequest(ctx, call.Method, call.URL, call.Opts...)
+}
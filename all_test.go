@@ -0,0 +1,115 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newAllTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		default:
+			_, _ = w.Write([]byte(r.Method + " " + r.URL.Path))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestAll_CollectAll(t *testing.T) {
+	server := newAllTestServer(t)
+	client, err := NewClient(WithMaxRetries(0), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	results, err := All(context.Background(), client, []Call{
+		{Method: http.MethodGet, URL: server.URL + "/a"},
+		{Method: http.MethodGet, URL: server.URL + "/fail"},
+		{Method: http.MethodPost, URL: server.URL + "/b", Opts: []RequestOption{
+			WithBody("text/plain", strings.NewReader("x")),
+		}},
+	}, MaxParallel(1))
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || !strings.Contains(err.Error(), "GET "+server.URL+"/fail") {
+		t.Fatalf("Expected joined error naming the failed call, got %v", err)
+	}
+	if len(results) != 3 || results[1].Err == nil {
+		t.Fatalf("Expected 3 results with the second failed, got %+v", results)
+	}
+
+	for _, i := range []int{0, 2} {
+		r := results[i]
+		if r.Err != nil {
+			t.Fatalf("Call %d failed: %v", i, r.Err)
+		}
+		body, _ := io.ReadAll(r.Resp.Body)
+		r.Resp.Body.Close()
+		if expected := r.Call.Method + " " + strings.TrimPrefix(r.Call.URL, server.URL); string(body) != expected {
+			t.Errorf("Call %d: expected %q, got %q", i, expected, body)
+		}
+	}
+}
+
+func TestAll_FailFast(t *testing.T) {
+	server := newAllTestServer(t)
+	client, err := NewClient(WithMaxRetries(0), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	start := time.Now()
+	results, err := All(context.Background(), client, []Call{
+		{Method: http.MethodGet, URL: server.URL + "/slow"},
+		{Method: http.MethodGet, URL: server.URL + "/fail"},
+	}, FailFast())
+
+	if err == nil || !strings.Contains(err.Error(), "/fail") || strings.Contains(err.Error(), "/slow") {
+		t.Fatalf("Expected only the failing call's error, got %v", err)
+	}
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("Expected the slow call to be cancelled, got %v", results[0].Err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected All to return early, took %v", elapsed)
+	}
+}
+
+func TestAll_Success(t *testing.T) {
+	server := newAllTestServer(t)
+	client, err := NewClient(WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	results, err := All(context.Background(), client, []Call{
+		{Method: http.MethodGet, URL: server.URL + "/a"},
+		{Method: http.MethodDelete, URL: server.URL + "/b"},
+	}, FailFast())
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	for _, r := range results {
+		if r.Resp == nil || r.Resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected 200 for %s %s, got %+v", r.Call.Method, r.Call.URL, r)
+		}
+		if r.Resp != nil {
+			r.Resp.Body.Close()
+		}
+	}
+}
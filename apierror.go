@@ -0,0 +1,183 @@
+package retry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// APIError is a typed error decoded from an error response body by
+// DecodeAPIError. Retrieve it from a RetryError with errors.As.
+type APIError struct {
+	StatusCode int    // HTTP status code of the response
+	Code       string // Application error code, if the body carried one
+	Message    string // Human-readable message, if the body carried one
+	RequestID  string // Server request ID, from the body or the X-Request-Id header
+}
+
+// Error implements the error interface
+func (e *APIError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "HTTP %d", e.StatusCode)
+	if e.Code != "" {
+		fmt.Fprintf(&b, " %s", e.Code)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&b, ": %s", e.Message)
+	}
+	if e.RequestID != "" {
+		fmt.Fprintf(&b, " (request id: %s)", e.RequestID)
+	}
+	return b.String()
+}
+
+// apiErrorBody covers the common JSON error shapes:
+// {"code":..., "message":...}, {"error":"..."} and {"error":{"code":..., "message":...}}.
+type apiErrorBody struct {
+	Code      json.RawMessage `json:"code"`
+	Message   string          `json:"message"`
+	RequestID string          `json:"request_id"`
+	Error     json.RawMessage `json:"error"`
+}
+
+// DecodeAPIError is a ready-made WithErrorDecoder function. It returns an
+// *APIError with the status code, the code and message found in a JSON body
+// (top-level or nested under "error"), and the request ID from the body or
+// the X-Request-Id header. Bodies that are not JSON yield an APIError with the
+// status code only.
+func DecodeAPIError(resp *http.Response) error {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return apiErr
+	}
+
+	var body apiErrorBody
+	if json.Unmarshal(data, &body) != nil {
+		return apiErr
+	}
+
+	// "error" is either a message string or a nested error object
+	var nested apiErrorBody
+	var message string
+	switch {
+	case json.Unmarshal(body.Error, &message) == nil:
+		body.Message = firstNonEmpty(body.Message, message)
+	case json.Unmarshal(body.Error, &nested) == nil:
+		if len(body.Code) == 0 {
+			body.Code = nested.Code
+		}
+		body.Message = firstNonEmpty(body.Message, nested.Message)
+		body.RequestID = firstNonEmpty(body.RequestID, nested.RequestID)
+	}
+
+	apiErr.Code = rawString(body.Code)
+	apiErr.Message = body.Message
+	apiErr.RequestID = firstNonEmpty(body.RequestID, apiErr.RequestID)
+	return apiErr
+}
+
+// rawString renders a JSON string or number as a plain string.
+func rawString(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	return strings.TrimSpace(string(raw))
+}
+
+// firstNonEmpty returns the first non-empty string.
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// defaultErrorBodyMaxBytes bounds how much of an error body is handed to the
+// error decoder unless WithErrorBodyCapture says otherwise.
+const defaultErrorBodyMaxBytes = 64 << 10
+
+// ErrorBodyCapture controls how much of an error response body WithErrorDecoder
+// reads and what happens to the body afterwards.
+type ErrorBodyCapture struct {
+	// MaxBytes caps how many bytes the decoder sees (0 = 64 KiB, negative = no limit)
+	MaxBytes int64
+	// ContentTypes lists the media types to decode, e.g. "application/json" or
+	// "application/*" (empty = all). Other responses are returned undecoded.
+	ContentTypes []string
+	// Consume discards the body after decoding instead of re-buffering it for
+	// the caller, who then reads an empty body.
+	Consume bool
+}
+
+// accepts reports whether an error body with the given Content-Type header
+// should be decoded.
+func (e ErrorBodyCapture) accepts(contentType string) bool {
+	if len(e.ContentTypes) == 0 {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, accepted := range e.ContentTypes {
+		accepted = strings.ToLower(accepted)
+		if accepted == mediaType {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(accepted, "/*"); ok &&
+			strings.HasPrefix(mediaType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// decodeError runs the WithErrorDecoder function against a final response with
+// an error status (>= 400). The decoder reads a bounded copy of the body (see
+// WithErrorBodyCapture); the caller still reads the whole body unless it is
+// consumed. Returns nil when there is nothing to decode.
+func (c *Client) decodeError(resp *http.Response) error {
+	if c.errorDecoder == nil || resp == nil || resp.StatusCode < http.StatusBadRequest ||
+		!c.errorBody.accepts(resp.Header.Get("Content-Type")) {
+		return nil
+	}
+
+	maxBytes := c.errorBody.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = defaultErrorBodyMaxBytes
+	}
+
+	var decoded error
+	if maxBytes < 0 {
+		if _, err := bufferResponseBody(resp); err != nil {
+			return err
+		}
+		decoded = c.errorDecoder(resp)
+		rewindResponseBody(resp)
+	} else {
+		// Hand the decoder a copy limited to the first maxBytes; the caller's
+		// body replays that prefix before the unread remainder
+		prefix := peekBody(resp, int(maxBytes))
+		limited := *resp
+		limited.Body = io.NopCloser(bytes.NewReader(prefix))
+		decoded = c.errorDecoder(&limited)
+	}
+
+	if c.errorBody.Consume && resp.Body != nil {
+		resp.Body.Close()
+		resp.Body = http.NoBody
+	}
+	return decoded
+}
@@ -0,0 +1,221 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeAPIError(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		body     string
+		expected APIError
+	}{
+		{
+			"top-level fields",
+			"",
+			`{"code":"not_found","message":"no such user","request_id":"abc"}`,
+			APIError{StatusCode: 404, Code: "not_found", Message: "no such user", RequestID: "abc"},
+		},
+		{
+			"nested error object",
+			"hdr-1",
+			`{"error":{"code":1042,"message":"quota exceeded"}}`,
+			APIError{StatusCode: 404, Code: "1042", Message: "quota exceeded", RequestID: "hdr-1"},
+		},
+		{
+			"error string",
+			"",
+			`{"error":"bad token"}`,
+			APIError{StatusCode: 404, Message: "bad token"},
+		},
+		{
+			"not json",
+			"hdr-2",
+			`<html>Not Found</html>`,
+			APIError{StatusCode: 404, RequestID: "hdr-2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: 404,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if tt.header != "" {
+				resp.Header.Set("X-Request-Id", tt.header)
+			}
+
+			var apiErr *APIError
+			if !errors.As(DecodeAPIError(resp), &apiErr) {
+				t.Fatal("Expected an *APIError")
+			}
+			if *apiErr != tt.expected {
+				t.Errorf("Expected %+v, got %+v", tt.expected, *apiErr)
+			}
+		})
+	}
+}
+
+func TestWithErrorDecoder_NonRetryableStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte(`{"code":"not_found","message":"no such user"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithErrorDecoder(DecodeAPIError), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp == nil {
+		t.Fatal("Expected the response to be returned")
+	}
+	defer resp.Body.Close()
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 1 {
+		t.Fatalf("Expected RetryError after 1 attempt, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "not_found" {
+		t.Fatalf("Expected decoded APIError, got %v", err)
+	}
+
+	// The body is still readable by the caller
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "no such user") {
+		t.Errorf("Expected body to be rewound, got %q", body)
+	}
+}
+
+func TestWithErrorDecoder_RetriesExhausted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte(`{"error":{"code":"overloaded","message":"try later"}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithErrorDecoder(DecodeAPIError),
+		WithMaxRetries(2),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		defer resp.Body.Close()
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 3 {
+		t.Fatalf("Expected RetryError after 3 attempts, got %v", err)
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "overloaded" || apiErr.StatusCode != 503 {
+		t.Fatalf("Expected decoded APIError, got %v", err)
+	}
+}
+
+func TestWithErrorDecoder_SuccessUntouched(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`ok`))
+	}))
+	defer server.Close()
+
+	called := false
+	client, err := NewClient(WithErrorDecoder(func(*http.Response) error {
+		called = true
+		return errors.New("unexpected")
+	}), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if called {
+		t.Error("Decoder must not run for successful responses")
+	}
+}
+
+func TestWithErrorBodyCapture(t *testing.T) {
+	body := `{"code":"bad"}` + strings.Repeat(" ", 100)
+
+	tests := []struct {
+		name        string
+		capture     ErrorBodyCapture
+		contentType string
+		decoded     string // body seen by the decoder ("" = decoder not called)
+		remaining   string // body read by the caller
+	}{
+		{"default", ErrorBodyCapture{}, "application/json", body, body},
+		{"max bytes", ErrorBodyCapture{MaxBytes: 14}, "application/json", `{"code":"bad"}`, body},
+		{"unlimited", ErrorBodyCapture{MaxBytes: -1}, "application/json", body, body},
+		{
+			"content type accepted",
+			ErrorBodyCapture{ContentTypes: []string{"application/json"}},
+			"application/json; charset=utf-8", body, body,
+		},
+		{
+			"wildcard content type",
+			ErrorBodyCapture{ContentTypes: []string{"application/*"}},
+			"application/problem+json", body, body,
+		},
+		{
+			"content type rejected",
+			ErrorBodyCapture{ContentTypes: []string{"application/json"}},
+			"text/html", "", body,
+		},
+		{"consume", ErrorBodyCapture{MaxBytes: 14, Consume: true}, "application/json", `{"code":"bad"}`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var decoded string
+			client, err := NewClient(
+				WithErrorDecoder(func(resp *http.Response) error {
+					data, _ := io.ReadAll(resp.Body)
+					decoded = string(data)
+					return nil
+				}),
+				WithErrorBodyCapture(tt.capture),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp := &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Header:     http.Header{"Content-Type": {tt.contentType}},
+				Body:       io.NopCloser(strings.NewReader(body)),
+			}
+			_ = client.decodeError(resp)
+
+			if decoded != tt.decoded {
+				t.Errorf("Expected decoder to see %q, got %q", tt.decoded, decoded)
+			}
+			if remaining, _ := io.ReadAll(resp.Body); string(remaining) != tt.remaining {
+				t.Errorf("Expected caller to read %q, got %q", tt.remaining, remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+)
+
+// AttemptInfo describes the attempt an outgoing request belongs to. Per-attempt
+// middleware reads it with AttemptInfoFromContext(req.Context()), e.g. to log
+// retries differently from first attempts or to send an attempt header.
+type AttemptInfo struct {
+	Attempt    int           // Attempt number (1-indexed; greater than 1 for retries)
+	Elapsed    time.Duration // Time elapsed since the first attempt started
+	LastErr    error         // Error of the previous attempt (nil on the first attempt)
+	LastStatus int           // HTTP status code of the previous attempt (0 if none)
+}
+
+// IsRetry reports whether the attempt is a retry rather than the first attempt.
+func (a AttemptInfo) IsRetry() bool {
+	return a.Attempt > 1
+}
+
+// attemptInfoKey carries the AttemptInfo of the current attempt in the context.
+type attemptInfoKey struct{}
+
+// withAttemptInfo returns a copy of ctx carrying info.
+func withAttemptInfo(ctx context.Context, info AttemptInfo) context.Context {
+	return context.WithValue(ctx, attemptInfoKey{}, info)
+}
+
+// AttemptInfoFromContext returns the AttemptInfo of the attempt whose request
+// carries ctx. ok is false outside the client's attempts.
+func AttemptInfoFromContext(ctx context.Context) (info AttemptInfo, ok bool) {
+	info, ok = ctx.Value(attemptInfoKey{}).(AttemptInfo)
+	return info, ok
+}
+
+// AttemptTransformer adjusts the request of an attempt; see
+// WithAttemptTransformer.
+type AttemptTransformer func(attempt int, req *http.Request) error
+
+// transformAttempt runs the client's AttemptTransformers on the request of
+// attempt (1 for the first attempt). Their errors are permanent.
+func (c *Client) transformAttempt(attempt int, req *http.Request) error {
+	for _, transform := range c.attemptTransformers {
+		if err := transform(attempt, req); err != nil {
+			return Permanent(fmt.Errorf("retry: transform attempt: %w", err))
+		}
+	}
+	return nil
+}
+
+// RetryObserver is notified before each retry of an operation, with the same
+// RetryInfo passed to WithOnRetry. Returning a non-nil error aborts the
+// operation: no further attempts are made and the error is returned as the
+// RetryError's LastErr.
+type RetryObserver func(info RetryInfo) error
+
+// retryObserversKey carries the RetryObservers of the current operation.
+type retryObserversKey struct{}
+
+// ContextWithRetryObserver returns a copy of ctx that subscribes fn to the
+// retries of the operation run with it. Request-level middleware uses it to
+// follow the retry loop it wraps without reimplementing it:
+//
+//	func(next retry.RetryFunc) retry.RetryFunc {
+//	    return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+//	        ctx = retry.ContextWithRetryObserver(ctx, func(info retry.RetryInfo) error {
+//	            if info.Attempt > 2 {
+//	                return errRetryBudgetExhausted
+//	            }
+//	            return nil
+//	        })
+//	        return next(ctx, req)
+//	    }
+//	}
+//
+// Observers added by several middleware are all called, outermost first.
+func ContextWithRetryObserver(ctx context.Context, fn RetryObserver) context.Context {
+	observers, _ := ctx.Value(retryObserversKey{}).([]RetryObserver)
+	return context.WithValue(ctx, retryObserversKey{}, append(slices.Clip(observers), fn))
+}
+
+// notifyRetryObservers calls the observers in ctx and returns the first error.
+func notifyRetryObservers(ctx context.Context, info RetryInfo) error {
+	observers, _ := ctx.Value(retryObserversKey{}).([]RetryObserver)
+	for _, fn := range observers {
+		if err := fn(info); err != nil {
+			return err
+		}
+	}
+	return nil
+}
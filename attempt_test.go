@@ -0,0 +1,88 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithAttemptTransformer(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.URL.RawQuery+" "+r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithMaxRetries(2),
+		WithInitialRetryDelay(time.Millisecond),
+		WithAttemptTransformer(func(attempt int, req *http.Request) error {
+			if attempt > 1 {
+				q := req.URL.Query()
+				q.Set("page_size", "20")
+				req.URL.RawQuery = q.Encode()
+			}
+			return nil
+		}),
+		WithAttemptTransformer(func(attempt int, req *http.Request) error {
+			if attempt == 3 {
+				req.Header.Set("Authorization", "backup")
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err := client.Get(context.Background(), server.URL+"?page_size=100",
+		WithHeader("Authorization", "primary")); err == nil {
+		resp.Body.Close()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{"page_size=100 primary", "page_size=20 primary", "page_size=20 backup"}
+	if len(seen) != len(want) {
+		t.Fatalf("Expected %d attempts, got %v", len(want), seen)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Errorf("Attempt %d: expected %q, got %q", i+1, want[i], seen[i])
+		}
+	}
+}
+
+func TestWithAttemptTransformer_Error(t *testing.T) {
+	server, hits := countingServer(t, http.StatusServiceUnavailable)
+	errNoBackup := errors.New("no backup key")
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithAttemptTransformer(func(attempt int, _ *http.Request) error {
+			if attempt > 1 {
+				return errNoBackup
+			}
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL)
+	if !errors.Is(err, errNoBackup) {
+		t.Errorf("Expected the transformer's error, got %v", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected the operation to stop after 1 attempt, got %d", n)
+	}
+}
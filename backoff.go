@@ -0,0 +1,120 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// RetryAfterJitterMode controls how jitter is applied to a server-provided
+// Retry-After delay (see WithRetryAfterJitterMode).
+type RetryAfterJitterMode int
+
+const (
+	// RetryAfterJitterNone waits exactly what the server asked for (default).
+	RetryAfterJitterNone RetryAfterJitterMode = iota
+	// RetryAfterJitterAdditive adds 0-25% on top, never waiting less than asked.
+	RetryAfterJitterAdditive
+	// RetryAfterJitterFull applies the same ±25% jitter as exponential backoff.
+	RetryAfterJitterFull
+)
+
+// apply returns the Retry-After delay d with jitter applied according to m.
+func (m RetryAfterJitterMode) apply(d time.Duration) time.Duration {
+	switch m {
+	case RetryAfterJitterNone:
+	case RetryAfterJitterAdditive:
+		// #nosec G404 - Cryptographic randomness not required for jitter
+		return time.Duration(float64(d) * (1 + rand.Float64()*0.25))
+	case RetryAfterJitterFull:
+		return applyJitter(d)
+	}
+	return d
+}
+
+// BackoffStrategy is an exponential backoff curve used by WithStatusBackoff.
+// Zero fields fall back to the client's global settings (WithInitialRetryDelay,
+// WithMaxRetryDelay, WithRetryDelayMultiple).
+type BackoffStrategy struct {
+	InitialDelay time.Duration // Delay before the first retry
+	MaxDelay     time.Duration // Upper bound for any single delay
+	Multiplier   float64       // Growth factor between retries (must be >= 1)
+}
+
+// delay returns the backoff for the given 0-indexed retry and the cap to apply
+// after Retry-After and jitter.
+func (s BackoffStrategy) delay(c *Client, attempt int) (time.Duration, time.Duration) {
+	initial := s.InitialDelay
+	if initial <= 0 {
+		initial = c.initialRetryDelay
+	}
+	maxDelay := s.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = c.maxRetryDelay
+	}
+	multiplier := s.Multiplier
+	if multiplier < 1.0 {
+		multiplier = c.retryDelayMultiple
+	}
+
+	next := float64(initial) * math.Pow(multiplier, float64(attempt))
+	if next > float64(maxDelay) {
+		return maxDelay, maxDelay
+	}
+	return time.Duration(next), maxDelay
+}
+
+// BackoffState carries the backoff of a client across calls, for long-lived
+// polling or consumer loops where each call is one iteration: while calls keep
+// failing, the first retry of the next call continues from the delay the last
+// one reached instead of starting over at the initial delay, and only after
+// resetAfter consecutive successful calls does the backoff return to the
+// initial delay. Use it with WithBackoffState. It is safe for concurrent use.
+type BackoffState struct {
+	mu         sync.Mutex
+	resetAfter int           // Successful calls needed to reset
+	base       time.Duration // Base delay of the last retry (0 = initial)
+	successes  int           // Consecutive successful calls
+}
+
+// NewBackoffState creates a BackoffState that resets after resetAfter
+// consecutive successful calls (at least 1).
+func NewBackoffState(resetAfter int) *BackoffState {
+	return &BackoffState{resetAfter: max(resetAfter, 1)}
+}
+
+// Reset returns the backoff to the initial delay.
+func (b *BackoffState) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.base, b.successes = 0, 0
+}
+
+// delayBase returns the base delay carried from earlier calls (0 = initial).
+func (b *BackoffState) delayBase() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.base
+}
+
+// recordRetry carries base, the base delay of a retry, to later calls.
+func (b *BackoffState) recordRetry(base time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.base = base
+}
+
+// recordCall counts a finished call towards the reset.
+func (b *BackoffState) recordCall(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !success {
+		b.successes = 0
+		return
+	}
+	b.successes++
+	if b.successes >= b.resetAfter {
+		b.base, b.successes = 0, 0
+	}
+}
@@ -0,0 +1,250 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithStatusBackoff(t *testing.T) {
+	transport := &sequenceTransport{
+		statuses: []int{
+			http.StatusServiceUnavailable,
+			http.StatusServiceUnavailable,
+			http.StatusBadGateway,
+			http.StatusOK,
+		},
+	}
+	var delays []time.Duration
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithInitialRetryDelay(40*time.Millisecond),
+		WithJitter(false),
+		WithStatusBackoff(map[int]BackoffStrategy{
+			http.StatusServiceUnavailable: {InitialDelay: time.Millisecond, Multiplier: 3},
+		}),
+		WithOnRetry(func(info RetryInfo) { delays = append(delays, info.Delay) }),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	// 503s follow the dedicated curve; the 502 keeps the global curve (40ms * 2^2)
+	expected := []time.Duration{time.Millisecond, 3 * time.Millisecond, 160 * time.Millisecond}
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d retries, got %v", len(expected), delays)
+	}
+	for i := range expected {
+		if delays[i] != expected[i] {
+			t.Errorf("Retry %d: expected delay %v, got %v", i+1, expected[i], delays[i])
+		}
+	}
+}
+
+func TestWithStatusBackoff_MaxDelayOverridesGlobalCap(t *testing.T) {
+	client, err := NewClient(
+		WithMaxRetryDelay(time.Second),
+		WithJitter(false),
+		WithStatusBackoff(map[int]BackoffStrategy{
+			http.StatusTooManyRequests: {MaxDelay: time.Minute},
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	resp.Header.Set("Retry-After", "30")
+
+	_, delay, _ := client.nextRetryDelay(req, resp, 0, 0, false)
+	if delay != 30*time.Second {
+		t.Errorf("Expected Retry-After of 30s within the strategy cap, got %v", delay)
+	}
+}
+
+func TestBackoffStrategy_DefaultsToClientSettings(t *testing.T) {
+	client, err := NewClient(
+		WithInitialRetryDelay(100*time.Millisecond),
+		WithMaxRetryDelay(300*time.Millisecond),
+		WithRetryDelayMultiple(2),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var s BackoffStrategy
+	for attempt, expected := range []time.Duration{
+		100 * time.Millisecond,
+		200 * time.Millisecond,
+		300 * time.Millisecond,
+	} {
+		delay, maxDelay := s.delay(client, attempt)
+		if delay != expected || maxDelay != 300*time.Millisecond {
+			t.Errorf("Attempt %d: expected %v (cap 300ms), got %v (cap %v)",
+				attempt, expected, delay, maxDelay)
+		}
+	}
+}
+
+func TestWithRetryAfterJitterMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     RetryAfterJitterMode
+		min, max time.Duration
+	}{
+		{"none", RetryAfterJitterNone, 4 * time.Second, 4 * time.Second},
+		{"additive", RetryAfterJitterAdditive, 4 * time.Second, 5 * time.Second},
+		{"full", RetryAfterJitterFull, 3 * time.Second, 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(
+				WithMaxRetryDelay(time.Minute),
+				WithRetryAfterJitterMode(tt.mode),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp := &http.Response{Header: http.Header{}}
+			resp.Header.Set("Retry-After", "4")
+
+			for range 50 {
+				actual, retryAfter := client.applyDelayModifiers(time.Second, resp)
+				if retryAfter != 4*time.Second {
+					t.Fatalf("Expected Retry-After 4s, got %v", retryAfter)
+				}
+				if actual < tt.min || actual > tt.max {
+					t.Fatalf("Expected delay in [%v, %v], got %v", tt.min, tt.max, actual)
+				}
+			}
+		})
+	}
+}
+
+func TestWithRetryOnConflict(t *testing.T) {
+	transport := &sequenceTransport{
+		statuses: []int{http.StatusConflict, http.StatusLocked, http.StatusConflict, http.StatusOK},
+	}
+	var delays []time.Duration
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithInitialRetryDelay(time.Hour), // Would hang if the global curve were used
+		WithJitter(false),
+		WithRetryOnConflict(10*time.Millisecond),
+		WithOnRetry(func(info RetryInfo) { delays = append(delays, info.Delay) }),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	expected := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d retries, got %v", len(expected), delays)
+	}
+	for i := range expected {
+		if delays[i] != expected[i] {
+			t.Errorf("Retry %d: expected delay %v, got %v", i+1, expected[i], delays[i])
+		}
+	}
+}
+
+func TestWithRetryOnConflict_OffByDefault(t *testing.T) {
+	transport := &sequenceTransport{statuses: []int{http.StatusConflict, http.StatusOK}}
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusConflict || transport.calls != 1 {
+		t.Errorf("Expected 409 returned without retry, got %d after %d calls",
+			resp.StatusCode, transport.calls)
+	}
+}
+
+func TestWithBackoffState(t *testing.T) {
+	const (
+		unavailable = http.StatusServiceUnavailable
+		ok          = http.StatusOK
+	)
+	transport := &sequenceTransport{statuses: []int{
+		unavailable, unavailable, // Call 1: retry after 1ms
+		unavailable, unavailable, // Call 2: retry continues at 2ms
+		ok, ok, // Calls 3-4: two successes reset the backoff
+		unavailable, ok, // Call 5: retry starts over at 1ms
+	}}
+	var delays []time.Duration
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxRetries(1),
+		WithInitialRetryDelay(time.Millisecond),
+		WithRetryDelayMultiple(2),
+		WithJitter(false),
+		WithBackoffState(NewBackoffState(2)),
+		WithOnRetry(func(info RetryInfo) { delays = append(delays, info.Delay) }),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for range 5 {
+		resp, _ := client.Get(context.Background(), "http://example.invalid")
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+
+	expected := []time.Duration{time.Millisecond, 2 * time.Millisecond, time.Millisecond}
+	if len(delays) != len(expected) {
+		t.Fatalf("Expected %d retries, got %v", len(expected), delays)
+	}
+	for i := range expected {
+		if delays[i] != expected[i] {
+			t.Errorf("Retry %d: expected delay %v, got %v", i+1, expected[i], delays[i])
+		}
+	}
+}
+
+func TestBackoffState_SuccessStreakInterrupted(t *testing.T) {
+	state := NewBackoffState(2)
+	state.recordRetry(time.Second)
+
+	state.recordCall(true)
+	state.recordCall(false)
+	state.recordCall(true)
+	if got := state.delayBase(); got != time.Second {
+		t.Errorf("Expected backoff kept after an interrupted streak, got %v", got)
+	}
+
+	state.recordCall(true)
+	if got := state.delayBase(); got != 0 {
+		t.Errorf("Expected backoff reset after 2 consecutive successes, got %v", got)
+	}
+}
@@ -0,0 +1,56 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// throttledBody wraps a request or response body so reads are paced by a
+// shared byte-rate TokenBucketLimiter.
+type throttledBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *TokenBucketLimiter
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	// Never ask for more than one bucket's worth, or waitN could never succeed
+	if limit := int(b.limiter.capacity); len(p) > limit {
+		p = p[:limit]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := b.limiter.waitN(b.ctx, float64(n)); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// throttleRequestBody paces the upload of req's body, if any.
+func (c *Client) throttleRequestBody(ctx context.Context, req *http.Request) {
+	if c.bandwidth == nil || req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	req.Body = &throttledBody{ReadCloser: req.Body, ctx: ctx, limiter: c.bandwidth}
+
+	// The transport rewinds bodies via GetBody, so replays must be paced too
+	if getBody := req.GetBody; getBody != nil {
+		req.GetBody = func() (io.ReadCloser, error) {
+			body, err := getBody()
+			if err != nil {
+				return nil, err
+			}
+			return &throttledBody{ReadCloser: body, ctx: ctx, limiter: c.bandwidth}, nil
+		}
+	}
+}
+
+// throttleResponseBody paces the download of resp's body, if any.
+func (c *Client) throttleResponseBody(ctx context.Context, resp *http.Response) {
+	if c.bandwidth == nil || resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+	resp.Body = &throttledBody{ReadCloser: resp.Body, ctx: ctx, limiter: c.bandwidth}
+}
@@ -0,0 +1,96 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithBandwidthLimit_ThrottlesDownload(t *testing.T) {
+	payload := bytes.Repeat([]byte("x"), 3000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write(payload)
+	}))
+	defer server.Close()
+
+	// 1000 B/s with a 1000 B burst: 3000 bytes take at least ~2s
+	client, err := NewClient(WithBandwidthLimit(1000), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("Unexpected read error: %v", err)
+	}
+	if !bytes.Equal(data, payload) {
+		t.Errorf("Expected %d bytes, got %d", len(payload), len(data))
+	}
+	if elapsed := time.Since(start); elapsed < 1900*time.Millisecond {
+		t.Errorf("Expected download to be throttled to ~2s, took %v", elapsed)
+	}
+}
+
+func TestWithBandwidthLimit_ThrottlesUploadAcrossRetries(t *testing.T) {
+	payload := bytes.Repeat([]byte("y"), 1500)
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if !bytes.Equal(body, payload) {
+			t.Errorf("Attempt %d: expected full body, got %d bytes", attempts, len(body))
+		}
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// Two uploads of 1500 bytes at 1000 B/s share one bucket: at least ~2s
+	client, err := NewClient(
+		WithBandwidthLimit(1000),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := client.Post(context.Background(), server.URL,
+		WithBody("application/octet-stream", bytes.NewReader(payload)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if elapsed := time.Since(start); elapsed < 1900*time.Millisecond {
+		t.Errorf("Expected uploads to be throttled to ~2s, took %v", elapsed)
+	}
+}
+
+func TestWithBandwidthLimit_Disabled(t *testing.T) {
+	client, err := NewClient(WithBandwidthLimit(0))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.bandwidth != nil {
+		t.Error("Expected no bandwidth limiter for non-positive limit")
+	}
+}
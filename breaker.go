@@ -0,0 +1,419 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned by Breaker.Allow while the circuit is open, or
+// half-open with all its probes in flight.
+var ErrCircuitOpen = errors.New("retry: circuit open")
+
+// Defaults of NewCircuitBreaker.
+const (
+	defaultBreakerThreshold = 5
+	defaultBreakerWindow    = time.Minute
+	defaultBreakerBuckets   = 10
+	defaultBreakerTimeout   = 30 * time.Second
+	defaultBreakerProbes    = 1
+)
+
+// anyGeneration records an outcome not tied to an admission (RecordSuccess,
+// RecordFailure) against the current state.
+const anyGeneration = ^uint64(0)
+
+// CircuitState is the state of a Breaker.
+type CircuitState int
+
+const (
+	CircuitClosed   CircuitState = iota // Requests flow; failures are counted
+	CircuitOpen                         // Requests are rejected until the open timeout passes
+	CircuitHalfOpen                     // A few probe requests test whether the host recovered
+)
+
+// String returns the state's name: "closed", "open" or "half-open".
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("CircuitState(%d)", int(s))
+}
+
+// BreakerOption configures a Breaker.
+type BreakerOption func(*Breaker)
+
+// FailureThreshold opens the circuit once n requests failed within the
+// rolling window (default 5). Non-positive values are ignored.
+func FailureThreshold(n int) BreakerOption {
+	return func(b *Breaker) {
+		if n > 0 {
+			b.threshold = n
+		}
+	}
+}
+
+// FailureRatio also opens the circuit once at least ratio (0-1] of the
+// requests within the rolling window failed, provided there were at least
+// minRequests of them, so low traffic cannot open it on a single failure.
+func FailureRatio(ratio float64, minRequests int) BreakerOption {
+	return func(b *Breaker) {
+		if ratio > 0 && ratio <= 1 {
+			b.ratio = ratio
+			b.minRequests = max(minRequests, 1)
+		}
+	}
+}
+
+// RollingWindow sets how far back failures are counted (default 1 minute),
+// split into buckets (default 10) that expire one at a time. Non-positive
+// values keep the defaults.
+func RollingWindow(window time.Duration, buckets int) BreakerOption {
+	return func(b *Breaker) {
+		if window > 0 {
+			b.window = window
+		}
+		if buckets > 0 {
+			b.buckets = make([]breakerBucket, buckets)
+		}
+	}
+}
+
+// OpenTimeout sets how long the circuit stays open before letting probes
+// through (default 30s). Non-positive values are ignored.
+func OpenTimeout(d time.Duration) BreakerOption {
+	return func(b *Breaker) {
+		if d > 0 {
+			b.openTimeout = d
+		}
+	}
+}
+
+// HalfOpenProbes sets how many probe requests a half-open circuit lets
+// through at once (default 1). The circuit closes once that many succeed in a
+// row, and opens again on any failure. Non-positive values are ignored.
+func HalfOpenProbes(n int) BreakerOption {
+	return func(b *Breaker) {
+		if n > 0 {
+			b.probes = n
+		}
+	}
+}
+
+// OnStateChange calls fn on every state transition, e.g. to log it or page
+// someone. fn is called without the Breaker's lock held, but must not block.
+func OnStateChange(fn func(from, to CircuitState)) BreakerOption {
+	return func(b *Breaker) {
+		b.onChange = fn
+	}
+}
+
+// BreakerMetricsCollector is an optional extension of MetricsCollector. When
+// the collector passed to BreakerMetrics also implements this interface, the
+// Breaker reports its state transitions and the requests it rejects, so
+// dashboards and alerts can track open circuits.
+type BreakerMetricsCollector interface {
+	// RecordBreakerStateChange records a transition of the named breaker
+	RecordBreakerStateChange(name string, from, to CircuitState)
+
+	// RecordBreakerRejection records a request the named breaker refused
+	// while in state (open, or half-open with all its probes in flight)
+	RecordBreakerRejection(name string, state CircuitState)
+}
+
+// BreakerMetrics reports the Breaker's state transitions and rejections,
+// labeled with name (e.g. the host it guards), to collector when it
+// implements BreakerMetricsCollector. Pass the collector given to
+// WithMetrics; other collectors are ignored.
+//
+//	retry.PerHostCircuitBreakerMiddleware(func(host string) retry.CircuitBreaker {
+//	    return retry.NewCircuitBreaker(retry.BreakerMetrics(host, collector))
+//	})
+func BreakerMetrics(name string, collector MetricsCollector) BreakerOption {
+	return func(b *Breaker) {
+		b.name = name
+		b.metrics, _ = collector.(BreakerMetricsCollector)
+	}
+}
+
+// BreakerStats is a point-in-time snapshot of a Breaker.
+type BreakerStats struct {
+	State    CircuitState
+	Requests int // Requests recorded within the rolling window
+	Failures int // Failed requests within the rolling window
+
+	ConsecutiveFailures int   // Failures since the last success
+	Rejected            int64 // Requests refused by Allow or Admit since creation
+	Opened              int64 // Times the circuit opened since creation
+}
+
+// breakerBucket counts the requests of one slice of the rolling window.
+type breakerBucket struct {
+	epoch    int64 // Index of the slice since the Unix epoch
+	requests int
+	failures int
+}
+
+// Breaker is the built-in CircuitBreaker: a closed, open and half-open state
+// machine over failures counted in a rolling window. Create one with
+// NewCircuitBreaker. It is safe for concurrent use.
+type Breaker struct {
+	threshold   int
+	ratio       float64
+	minRequests int
+	window      time.Duration
+	openTimeout time.Duration
+	probes      int
+	onChange    func(from, to CircuitState)
+	name        string // Label of BreakerMetrics
+	metrics     BreakerMetricsCollector
+
+	mu                  sync.Mutex
+	state               CircuitState
+	generation          uint64 // Bumped on every state change
+	buckets             []breakerBucket
+	openedAt            time.Time
+	probesInFlight      int
+	probeSuccesses      int
+	consecutiveFailures int
+	rejected            int64
+	opened              int64
+}
+
+// NewCircuitBreaker creates a closed Breaker configured by opts. It opens
+// after 5 failures within a minute by default, and lets one probe through
+// after 30s:
+//
+//	cb := retry.NewCircuitBreaker(
+//	    retry.FailureRatio(0.5, 20),
+//	    retry.OpenTimeout(10*time.Second),
+//	    retry.OnStateChange(func(from, to retry.CircuitState) {
+//	        log.Printf("circuit %s -> %s", from, to)
+//	    }),
+//	)
+//	client, _ := retry.NewClient(
+//	    retry.WithRequestMiddleware(retry.CircuitBreakerMiddleware(cb)),
+//	)
+func NewCircuitBreaker(opts ...BreakerOption) *Breaker {
+	b := &Breaker{
+		threshold:   defaultBreakerThreshold,
+		window:      defaultBreakerWindow,
+		openTimeout: defaultBreakerTimeout,
+		probes:      defaultBreakerProbes,
+		buckets:     make([]breakerBucket, defaultBreakerBuckets),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Allow lets a request through while the circuit is closed, and up to
+// HalfOpenProbes requests at a time while it is half-open. Otherwise it
+// returns an error wrapping ErrCircuitOpen. An open circuit becomes
+// half-open once OpenTimeout has passed.
+//
+// RecordSuccess and RecordFailure cannot tell which request an outcome
+// belongs to; use Admit so a request let through before the circuit changed
+// state cannot be mistaken for a probe.
+func (b *Breaker) Allow() error {
+	_, err := b.admit()
+	return err
+}
+
+// Admit is like Allow, but returns the function to call with the request's
+// outcome instead of RecordSuccess or RecordFailure. Outcomes of requests
+// admitted before the circuit last changed state are ignored, so a slow
+// request let through while the circuit was closed cannot close it once
+// half-open. CircuitBreakerMiddleware uses it.
+func (b *Breaker) Admit() (record func(success bool), err error) {
+	gen, err := b.admit()
+	if err != nil {
+		return nil, err
+	}
+	return func(success bool) { b.record(gen, !success) }, nil
+}
+
+// admit decides whether to let a request through, and returns the
+// generation it was admitted in.
+func (b *Breaker) admit() (uint64, error) {
+	b.mu.Lock()
+	now := time.Now()
+	from := b.state
+	if b.state == CircuitOpen && now.Sub(b.openedAt) >= b.openTimeout {
+		b.setState(CircuitHalfOpen, now)
+	}
+
+	var err error
+	switch b.state {
+	case CircuitOpen:
+		err = fmt.Errorf("%w (retry in %v)", ErrCircuitOpen,
+			(b.openTimeout - now.Sub(b.openedAt)).Round(time.Millisecond))
+	case CircuitHalfOpen:
+		if b.probesInFlight >= b.probes {
+			err = fmt.Errorf("%w (half-open, probing)", ErrCircuitOpen)
+		} else {
+			b.probesInFlight++
+		}
+	}
+	if err != nil {
+		b.rejected++
+	}
+	gen, to := b.generation, b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+	if err != nil && b.metrics != nil {
+		b.metrics.RecordBreakerRejection(b.name, to)
+	}
+	return gen, err
+}
+
+// RecordSuccess records a successful request. It closes a half-open circuit
+// once HalfOpenProbes probes succeeded.
+func (b *Breaker) RecordSuccess() {
+	b.record(anyGeneration, false)
+}
+
+// RecordFailure records a failed request. It opens a closed circuit that
+// reaches its failure threshold or ratio, and reopens a half-open one.
+func (b *Breaker) RecordFailure() {
+	b.record(anyGeneration, true)
+}
+
+// record counts the outcome of a request admitted in generation gen and
+// moves the state machine. Outcomes of earlier generations are ignored.
+func (b *Breaker) record(gen uint64, failed bool) {
+	b.mu.Lock()
+	if gen != anyGeneration && gen != b.generation {
+		b.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	from := b.state
+
+	bucket := b.bucket(now)
+	bucket.requests++
+	if failed {
+		bucket.failures++
+		b.consecutiveFailures++
+	} else {
+		b.consecutiveFailures = 0
+	}
+
+	switch b.state {
+	case CircuitClosed:
+		if failed && b.tripped(now) {
+			b.setState(CircuitOpen, now)
+		}
+	case CircuitHalfOpen:
+		b.probesInFlight = max(b.probesInFlight-1, 0)
+		if failed {
+			b.setState(CircuitOpen, now)
+		} else if b.probeSuccesses++; b.probeSuccesses >= b.probes {
+			b.setState(CircuitClosed, now)
+		}
+	}
+	to := b.state
+	b.mu.Unlock()
+
+	b.notify(from, to)
+}
+
+// State returns the current state of the circuit.
+func (b *Breaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Stats returns a snapshot of the Breaker's state and counters.
+func (b *Breaker) Stats() BreakerStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	requests, failures := b.counts(time.Now())
+	return BreakerStats{
+		State:               b.state,
+		Requests:            requests,
+		Failures:            failures,
+		ConsecutiveFailures: b.consecutiveFailures,
+		Rejected:            b.rejected,
+		Opened:              b.opened,
+	}
+}
+
+// tripped reports whether the failures within the window call for opening
+// the circuit. Callers must hold b.mu.
+func (b *Breaker) tripped(now time.Time) bool {
+	requests, failures := b.counts(now)
+	if failures >= b.threshold {
+		return true
+	}
+	return b.ratio > 0 && requests >= b.minRequests &&
+		float64(failures) >= b.ratio*float64(requests)
+}
+
+// setState moves the circuit to state, resetting what the new state counts.
+// Callers must hold b.mu.
+func (b *Breaker) setState(state CircuitState, now time.Time) {
+	b.state = state
+	b.generation++
+	b.probesInFlight, b.probeSuccesses = 0, 0
+	switch state {
+	case CircuitOpen:
+		b.openedAt = now
+		b.opened++
+	case CircuitClosed:
+		clear(b.buckets) // Start counting afresh
+	}
+}
+
+// notify reports a state transition to the OnStateChange callback and
+// BreakerMetrics.
+func (b *Breaker) notify(from, to CircuitState) {
+	if from == to {
+		return
+	}
+	if b.onChange != nil {
+		b.onChange(from, to)
+	}
+	if b.metrics != nil {
+		b.metrics.RecordBreakerStateChange(b.name, from, to)
+	}
+}
+
+// epoch returns the index of the window slice containing now.
+func (b *Breaker) epoch(now time.Time) int64 {
+	width := max(b.window/time.Duration(len(b.buckets)), 1)
+	return now.UnixNano() / int64(width)
+}
+
+// bucket returns the bucket of now, resetting it if it held an older slice.
+// Callers must hold b.mu.
+func (b *Breaker) bucket(now time.Time) *breakerBucket {
+	epoch := b.epoch(now)
+	bucket := &b.buckets[epoch%int64(len(b.buckets))]
+	if bucket.epoch != epoch {
+		*bucket = breakerBucket{epoch: epoch}
+	}
+	return bucket
+}
+
+// counts sums the requests and failures within the rolling window. Callers
+// must hold b.mu.
+func (b *Breaker) counts(now time.Time) (requests, failures int) {
+	oldest := b.epoch(now) - int64(len(b.buckets)) + 1
+	for _, bucket := range b.buckets {
+		if bucket.epoch >= oldest {
+			requests += bucket.requests
+			failures += bucket.failures
+		}
+	}
+	return requests, failures
+}
@@ -0,0 +1,252 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+)
+
+var _ CircuitBreaker = (*Breaker)(nil)
+
+func TestBreaker_Transitions(t *testing.T) {
+	var mu sync.Mutex
+	var changes []string
+	cb := NewCircuitBreaker(
+		FailureThreshold(3),
+		OpenTimeout(20*time.Millisecond),
+		OnStateChange(func(from, to CircuitState) {
+			mu.Lock()
+			defer mu.Unlock()
+			changes = append(changes, from.String()+"->"+to.String())
+		}),
+	)
+
+	for range 3 {
+		if err := cb.Allow(); err != nil {
+			t.Fatalf("Expected a closed circuit to allow requests, got %v", err)
+		}
+		cb.RecordFailure()
+	}
+	if cb.State() != CircuitOpen {
+		t.Fatalf("Expected the circuit to open after 3 failures, got %v", cb.State())
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := cb.Allow(); err != nil {
+		t.Fatalf("Expected a probe after the open timeout, got %v", err)
+	}
+	if err := cb.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected a second concurrent probe to be rejected, got %v", err)
+	}
+	cb.RecordFailure()
+	if cb.State() != CircuitOpen {
+		t.Fatalf("Expected a failed probe to reopen the circuit, got %v", cb.State())
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := cb.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	cb.RecordSuccess()
+	if cb.State() != CircuitClosed {
+		t.Fatalf("Expected a successful probe to close the circuit, got %v", cb.State())
+	}
+
+	stats := cb.Stats()
+	if stats.Opened != 2 || stats.Rejected != 2 || stats.Failures != 0 {
+		t.Errorf("Expected 2 opens, 2 rejections and a fresh window, got %+v", stats)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	want := []string{
+		"closed->open", "open->half-open", "half-open->open",
+		"open->half-open", "half-open->closed",
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("Expected transitions %v, got %v", want, changes)
+	}
+	for i := range want {
+		if changes[i] != want[i] {
+			t.Errorf("Transition %d: expected %s, got %s", i, want[i], changes[i])
+		}
+	}
+}
+
+func TestBreaker_HalfOpenProbes(t *testing.T) {
+	cb := NewCircuitBreaker(
+		FailureThreshold(1),
+		OpenTimeout(time.Millisecond),
+		HalfOpenProbes(2),
+	)
+	cb.RecordFailure()
+	time.Sleep(5 * time.Millisecond)
+
+	for range 2 {
+		if err := cb.Allow(); err != nil {
+			t.Fatalf("Expected 2 concurrent probes, got %v", err)
+		}
+	}
+	if err := cb.Allow(); err == nil {
+		t.Error("Expected a third probe to be rejected")
+	}
+	cb.RecordSuccess()
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("Expected the circuit to stay half-open after 1 of 2 probes, got %v", cb.State())
+	}
+	cb.RecordSuccess()
+	if cb.State() != CircuitClosed {
+		t.Errorf("Expected the circuit to close after 2 probes, got %v", cb.State())
+	}
+}
+
+func TestBreaker_LateOutcomeIsNotAProbe(t *testing.T) {
+	cb := NewCircuitBreaker(FailureThreshold(1), OpenTimeout(time.Millisecond))
+	slow, err := cb.Admit() // Admitted while closed, finishes late
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed, err := cb.Admit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed(false)
+	time.Sleep(5 * time.Millisecond)
+
+	probe, err := cb.Admit()
+	if err != nil {
+		t.Fatalf("Expected a probe after the open timeout, got %v", err)
+	}
+	slow(true)
+	if cb.State() != CircuitHalfOpen {
+		t.Fatalf("Expected a late outcome not to close the circuit, got %v", cb.State())
+	}
+	if _, err := cb.Admit(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected the probe to still be in flight, got %v", err)
+	}
+	probe(true)
+	if cb.State() != CircuitClosed {
+		t.Errorf("Expected the probe's success to close the circuit, got %v", cb.State())
+	}
+}
+
+func TestBreaker_RollingWindow(t *testing.T) {
+	cb := NewCircuitBreaker(
+		FailureThreshold(3),
+		RollingWindow(40*time.Millisecond, 4),
+	)
+	cb.RecordFailure()
+	cb.RecordFailure()
+	if stats := cb.Stats(); stats.Failures != 2 || stats.Requests != 2 {
+		t.Fatalf("Expected 2 failures in the window, got %+v", stats)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if stats := cb.Stats(); stats.Failures != 0 {
+		t.Fatalf("Expected old failures to leave the window, got %+v", stats)
+	}
+	cb.RecordFailure()
+	if cb.State() != CircuitClosed {
+		t.Errorf("Expected expired failures not to count, got %v", cb.State())
+	}
+	if n := cb.Stats().ConsecutiveFailures; n != 3 {
+		t.Errorf("Expected 3 consecutive failures, got %d", n)
+	}
+}
+
+func TestBreaker_FailureRatio(t *testing.T) {
+	cb := NewCircuitBreaker(
+		FailureThreshold(100),
+		FailureRatio(0.5, 4),
+	)
+	cb.RecordFailure()
+	cb.RecordFailure()
+	if cb.State() != CircuitClosed {
+		t.Fatalf("Expected no trip below the minimum request count, got %v", cb.State())
+	}
+	cb.RecordSuccess()
+	cb.RecordSuccess()
+	cb.RecordSuccess()
+	cb.RecordFailure() // 3 of 6 failed
+	if cb.State() != CircuitOpen {
+		t.Errorf("Expected the circuit to open at a 50%% failure ratio, got %v", cb.State())
+	}
+}
+
+func TestBreaker_WithMiddleware(t *testing.T) {
+	server, hits := countingServer(t, http.StatusInternalServerError)
+	cb := NewCircuitBreaker(FailureThreshold(2), OpenTimeout(time.Minute))
+	client, err := NewClient(
+		WithMaxRetries(0),
+		WithRequestMiddleware(CircuitBreakerMiddleware(cb)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 3 {
+		if resp, err := client.Get(context.Background(), server.URL); err == nil {
+			resp.Body.Close()
+		}
+	}
+	_, err = client.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Expected ErrCircuitOpen, got %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("Expected the open circuit to stop requests after 2, got %d", n)
+	}
+}
+
+// breakerMetricsCollector records breaker metrics on top of MockMetricsCollector.
+type breakerMetricsCollector struct {
+	MockMetricsCollector
+	changes    []string
+	rejections []string
+}
+
+func (m *breakerMetricsCollector) RecordBreakerStateChange(name string, from, to CircuitState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changes = append(m.changes, name+":"+from.String()+"->"+to.String())
+}
+
+func (m *breakerMetricsCollector) RecordBreakerRejection(name string, state CircuitState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rejections = append(m.rejections, name+":"+state.String())
+}
+
+func TestBreaker_Metrics(t *testing.T) {
+	collector := &breakerMetricsCollector{}
+	cb := NewCircuitBreaker(
+		FailureThreshold(1),
+		OpenTimeout(10*time.Millisecond),
+		BreakerMetrics("api", collector),
+	)
+	cb.RecordFailure()
+	_ = cb.Allow()
+	time.Sleep(20 * time.Millisecond)
+	if err := cb.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	_ = cb.Allow() // The probe is in flight
+	cb.RecordSuccess()
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	wantChanges := []string{"api:closed->open", "api:open->half-open", "api:half-open->closed"}
+	if !slices.Equal(collector.changes, wantChanges) {
+		t.Errorf("Expected transitions %v, got %v", wantChanges, collector.changes)
+	}
+	wantRejections := []string{"api:open", "api:half-open"}
+	if !slices.Equal(collector.rejections, wantRejections) {
+		t.Errorf("Expected rejections %v, got %v", wantRejections, collector.rejections)
+	}
+}
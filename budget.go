@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is returned (as the RetryError's LastErr) when a
+// retry is refused because the shared Budget has no retries left.
+var ErrRetryBudgetExhausted = errors.New("retry: retry budget exhausted")
+
+// budgetWindow is how many requests' worth of retries a Budget can save up.
+const budgetWindow = 100
+
+// Budget limits retries to a fraction of the requests made through it, so that
+// during an outage retries add at most that fraction of extra load instead of
+// multiplying it. Every request earns ratio retries; every retry spends one.
+// First attempts are never refused. Budget is safe for concurrent use, so one
+// instance can be shared by any number of clients.
+type Budget struct {
+	mu      sync.Mutex
+	ratio   float64 // Retries earned per request
+	balance float64 // Retries currently available
+	max     float64 // Cap on saved-up retries
+
+	reserve *TokenBucketLimiter // Minimum retries per second (nil = none)
+}
+
+// NewBudget creates a budget allowing retries for ratio of requests (e.g. 0.1
+// for 10% extra load), plus at least minRetriesPerSecond retries per second so
+// low-traffic clients can still retry. Negative arguments are treated as 0.
+func NewBudget(ratio float64, minRetriesPerSecond int) *Budget {
+	ratio = max(ratio, 0)
+	b := &Budget{
+		ratio: ratio,
+		max:   max(ratio*budgetWindow, 1),
+	}
+	if minRetriesPerSecond > 0 {
+		b.reserve = NewTokenBucketLimiter(minRetriesPerSecond, time.Second)
+	}
+	return b
+}
+
+// deposit credits the budget for one request.
+func (b *Budget) deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.balance = min(b.balance+b.ratio, b.max)
+}
+
+// withdraw spends one retry, reporting whether one was available.
+func (b *Budget) withdraw() bool {
+	b.mu.Lock()
+	if b.balance >= 1 {
+		b.balance--
+		b.mu.Unlock()
+		return true
+	}
+	b.mu.Unlock()
+	return b.reserve != nil && b.reserve.Allow()
+}
+
+// available returns the retries currently saved up.
+func (b *Budget) available() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.balance
+}
+
+// BudgetMiddleware creates request-level middleware that draws retries from a
+// shared budget. Each client call credits the budget before the retry loop
+// runs, and each retry the loop wants to make is charged to it; once the
+// budget is empty, the operation stops with a RetryError wrapping
+// ErrRetryBudgetExhausted instead of retrying.
+//
+// Example:
+//
+//	budget := retry.NewBudget(0.1, 5) // retries add at most 10% load, min 5/s
+//	client, _ := retry.NewClient(
+//	    retry.WithRequestMiddleware(retry.BudgetMiddleware(budget)),
+//	)
+func BudgetMiddleware(budget *Budget) RequestMiddleware {
+	return func(next RetryFunc) RetryFunc {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			budget.deposit()
+			ctx = context.WithValue(ctx, budgetKey{}, budget)
+			ctx = ContextWithRetryObserver(ctx, func(RetryInfo) error {
+				if !budget.withdraw() {
+					return ErrRetryBudgetExhausted
+				}
+				return nil
+			})
+			return next(ctx, req)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestBudget(t *testing.T) {
+	b := NewBudget(0.5, 0)
+	if b.withdraw() {
+		t.Fatal("Expected an empty budget to refuse a retry")
+	}
+
+	b.deposit()
+	b.deposit()
+	if !b.withdraw() {
+		t.Fatal("Expected two requests at ratio 0.5 to earn one retry")
+	}
+	if b.withdraw() {
+		t.Fatal("Expected the budget to be spent")
+	}
+
+	// Savings are capped
+	for range 1000 {
+		b.deposit()
+	}
+	if b.balance != 0.5*budgetWindow {
+		t.Errorf("Expected balance capped at %v, got %v", 0.5*budgetWindow, b.balance)
+	}
+}
+
+func TestBudget_MinRetriesPerSecond(t *testing.T) {
+	b := NewBudget(0, 2)
+	if !b.withdraw() || !b.withdraw() {
+		t.Fatal("Expected the reserve to allow 2 retries")
+	}
+	if b.withdraw() {
+		t.Error("Expected the reserve to be spent")
+	}
+}
+
+func TestBudgetMiddleware(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithRequestMiddleware(BudgetMiddleware(NewBudget(1, 0))),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// The call earns one retry: the first attempt and one retry are made
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("Expected RetryError wrapping ErrRetryBudgetExhausted, got %v", err)
+	}
+	if retryErr.Attempts != 2 || atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("Expected 2 attempts, got %d (%d requests)", retryErr.Attempts, requests)
+	}
+}
@@ -0,0 +1,127 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrTruncatedResponse is returned (wrapped) when a buffered response body
+// ends before the length announced by Content-Length or before the terminating
+// chunk. DefaultRetryableChecker retries it like any other transport error;
+// custom checkers can match it with errors.Is.
+var ErrTruncatedResponse = errors.New("retry: truncated response body")
+
+// bufferBodyKey marks a request whose response bodies are buffered before the
+// retry decision even without WithBufferResponseBody (DoAndDecode).
+type bufferBodyKey struct{}
+
+// withBufferedBody is the RequestOption setting bufferBodyKey.
+func withBufferedBody(req *http.Request) {
+	*req = *req.WithContext(context.WithValue(req.Context(), bufferBodyKey{}, true))
+}
+
+// buffersBody reports whether req's response bodies are buffered before the
+// retry decision.
+func (c *Client) buffersBody(req *http.Request) bool {
+	return c.bufferResponses || req.Context().Value(bufferBodyKey{}) != nil
+}
+
+// bufferResponseBody reads resp's body fully into memory and closes it, so
+// that resp.Trailer is populated before the retryable checker runs. When the
+// body cannot be read the response is discarded and the read error returned,
+// making the attempt a failure like any other transport error.
+func bufferResponseBody(resp *http.Response) (*http.Response, error) {
+	if resp == nil || resp.Body == nil || resp.Body == http.NoBody {
+		return resp, nil
+	}
+	if _, ok := resp.Body.(*bufferedBody); ok {
+		return resp, nil
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err := bodyReadError(err, len(data), resp.ContentLength); err != nil {
+		return nil, err
+	}
+
+	resp.Body = &bufferedBody{Reader: bytes.NewReader(data)}
+	resp.ContentLength = int64(len(data))
+	return resp, nil
+}
+
+// bodyReadError classifies the outcome of reading a whole response body of n
+// bytes: a body cut short (ErrTruncatedResponse) or any other read error.
+func bodyReadError(err error, n int, contentLength int64) error {
+	switch {
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return fmt.Errorf("%w: %w", ErrTruncatedResponse, err)
+	case err != nil:
+		return fmt.Errorf("retry: read response body: %w", err)
+	case int64(n) < contentLength:
+		// Transports normally enforce this, but middleware-built responses may not
+		return fmt.Errorf("%w: got %d of %d bytes", ErrTruncatedResponse, n, contentLength)
+	}
+	return nil
+}
+
+// failedBody is a response body whose reads fail with err, so a body that
+// could not be read in full cannot pass for a complete one.
+type failedBody struct {
+	err error
+}
+
+// Read implements io.Reader.
+func (b *failedBody) Read([]byte) (int, error) { return 0, b.err }
+
+// bufferedBody is an in-memory response body that can be rewound.
+type bufferedBody struct {
+	*bytes.Reader
+}
+
+// Close implements io.Closer; there is nothing to release.
+func (*bufferedBody) Close() error { return nil }
+
+// rewindResponseBody moves a buffered response body back to its start.
+func rewindResponseBody(resp *http.Response) {
+	if b, ok := resp.Body.(*bufferedBody); ok {
+		_, _ = b.Seek(0, io.SeekStart)
+	}
+}
+
+// Trailers inspected by TrailerRetryableChecker.
+const (
+	trailerGRPCStatus     = "Grpc-Status"
+	trailerStreamingError = "X-Streaming-Error"
+)
+
+// TrailerRetryableChecker wraps next so that responses whose trailers report
+// a failure are retried even though the status line was 2xx. A response is
+// retried when its grpc-status trailer is UNAVAILABLE (14) or
+// RESOURCE_EXHAUSTED (8), or when an X-Streaming-Error trailer is present.
+// Everything else is decided by next.
+//
+// Trailers only arrive after the body has been read, so this checker requires
+// WithBufferResponseBody(true):
+//
+//	client, _ := retry.NewClient(
+//	    retry.WithBufferResponseBody(true),
+//	    retry.WithRetryableChecker(retry.TrailerRetryableChecker(retry.DefaultRetryableChecker)),
+//	)
+func TrailerRetryableChecker(next RetryableChecker) RetryableChecker {
+	return func(err error, resp *http.Response) bool {
+		if err == nil && resp != nil {
+			switch resp.Trailer.Get(trailerGRPCStatus) {
+			case "8", "14":
+				return true
+			}
+			if resp.Trailer.Get(trailerStreamingError) != "" {
+				return true
+			}
+		}
+		return next(err, resp)
+	}
+}
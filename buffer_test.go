@@ -0,0 +1,247 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+)
+
+func TestWithBufferResponseBody_RetriesOnFailureTrailer(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "Grpc-Status")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("data"))
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.Header().Set("Grpc-Status", "14")
+			return
+		}
+		w.Header().Set("Grpc-Status", "0")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithBufferResponseBody(true),
+		WithRetryableChecker(TrailerRetryableChecker(DefaultRetryableChecker)),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if got := resp.Trailer.Get("Grpc-Status"); got != "0" {
+		t.Errorf("Expected final grpc-status 0, got %q", got)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "data" {
+		t.Errorf("Expected buffered body to be readable, got %q", body)
+	}
+}
+
+func TestWithBufferResponseBody_RetriesTruncatedBody(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			// Promise more bytes than are sent, then drop the connection
+			w.Header().Set("Content-Length", "100")
+			_, _ = w.Write([]byte("partial"))
+			return
+		}
+		_, _ = w.Write([]byte("complete"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithBufferResponseBody(true),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "complete" || attempts != 2 {
+		t.Errorf("Expected complete body after 2 attempts, got %q after %d", body, attempts)
+	}
+}
+
+func TestTrailerRetryableChecker(t *testing.T) {
+	tests := []struct {
+		name     string
+		trailer  http.Header
+		expected bool
+	}{
+		{"no trailers", nil, false},
+		{"grpc ok", http.Header{"Grpc-Status": []string{"0"}}, false},
+		{"grpc unavailable", http.Header{"Grpc-Status": []string{"14"}}, true},
+		{"grpc resource exhausted", http.Header{"Grpc-Status": []string{"8"}}, true},
+		{"grpc invalid argument", http.Header{"Grpc-Status": []string{"3"}}, false},
+		{"streaming error", http.Header{"X-Streaming-Error": []string{"reset"}}, true},
+	}
+
+	checker := TrailerRetryableChecker(DefaultRetryableChecker)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: http.StatusOK, Trailer: tt.trailer}
+			if got := checker(nil, resp); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if !checker(errors.New("network"), nil) {
+		t.Error("Errors should fall through to the wrapped checker")
+	}
+}
+
+func TestBufferResponseBody_Truncated(t *testing.T) {
+	tests := []struct {
+		name string
+		resp *http.Response
+	}{
+		{
+			name: "unexpected EOF",
+			resp: &http.Response{
+				Body: io.NopCloser(io.MultiReader(
+					strings.NewReader("par"),
+					iotest.ErrReader(io.ErrUnexpectedEOF),
+				)),
+				ContentLength: -1,
+			},
+		},
+		{
+			name: "short of Content-Length",
+			resp: &http.Response{
+				Body:          io.NopCloser(strings.NewReader("short")),
+				ContentLength: 100,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := bufferResponseBody(tt.resp)
+			if !errors.Is(err, ErrTruncatedResponse) {
+				t.Errorf("Expected ErrTruncatedResponse, got %v", err)
+			}
+			if resp != nil {
+				t.Error("Expected truncated response to be discarded")
+			}
+		})
+	}
+}
+
+func TestBufferResponseBody_UnknownLength(t *testing.T) {
+	resp, err := bufferResponseBody(&http.Response{
+		Body:          io.NopCloser(strings.NewReader("complete")),
+		ContentLength: -1,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if resp.ContentLength != int64(len("complete")) {
+		t.Errorf("Expected ContentLength to be set from buffered body, got %d", resp.ContentLength)
+	}
+}
+
+func TestWithBufferResponseBody_RetriesTruncatedChunkedBody(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) > 1 {
+			_, _ = w.Write([]byte("complete"))
+			return
+		}
+		// Send one chunk, then drop the connection before the terminating chunk
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n7\r\npartial\r\n")
+		_ = buf.Flush()
+	}))
+	defer server.Close()
+
+	var retryErr error
+	client, err := NewClient(
+		WithBufferResponseBody(true),
+		WithInitialRetryDelay(time.Millisecond),
+		WithOnRetry(func(info RetryInfo) { retryErr = info.Err }),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != "complete" {
+		t.Errorf("Expected complete body instead of partial data, got %q", body)
+	}
+	if !errors.Is(retryErr, ErrTruncatedResponse) {
+		t.Errorf("Expected retry caused by ErrTruncatedResponse, got %v", retryErr)
+	}
+}
+
+func TestWithBufferResponseBody_DropsTruncatedBodyWhenExhausted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n7\r\npartial\r\n")
+		_ = buf.Flush()
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithBufferResponseBody(true),
+		WithMaxRetries(1),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		resp.Body.Close()
+		t.Error("Expected no response once retries ran out on truncated bodies")
+	}
+	if !errors.Is(err, ErrTruncatedResponse) {
+		t.Errorf("Expected ErrTruncatedResponse, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package retry
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// CheckerBuilder declaratively assembles a RetryableChecker. Create one with
+// NewChecker, chain rules, and call Build:
+//
+//	checker := retry.NewChecker().
+//	    RetryStatuses(500, 502, 503, 504, 429).
+//	    RetryNetworkErrors().
+//	    NoRetryOn(501).
+//	    MaxBodyPeek(1024, jsonCodeChecker).
+//	    Build()
+//
+// Rules are evaluated in a fixed order: errors are decided by the error rules;
+// NoRetryOn statuses are never retried; RetryStatuses are always retried;
+// otherwise the body peek (if any) decides.
+type CheckerBuilder struct {
+	networkErrors bool
+	errorChecker  RetryableChecker
+	retry         map[int]bool
+	noRetry       map[int]bool
+	peekBytes     int
+	peekChecker   func(body []byte) bool
+}
+
+// NewChecker starts a CheckerBuilder that retries nothing.
+func NewChecker() *CheckerBuilder {
+	return &CheckerBuilder{
+		retry:   make(map[int]bool),
+		noRetry: make(map[int]bool),
+	}
+}
+
+// RetryStatuses retries responses with any of the given status codes.
+func (b *CheckerBuilder) RetryStatuses(codes ...int) *CheckerBuilder {
+	for _, code := range codes {
+		b.retry[code] = true
+	}
+	return b
+}
+
+// NoRetryOn never retries responses with any of the given status codes,
+// overriding RetryStatuses and the body peek.
+func (b *CheckerBuilder) NoRetryOn(codes ...int) *CheckerBuilder {
+	for _, code := range codes {
+		b.noRetry[code] = true
+	}
+	return b
+}
+
+// RetryNetworkErrors retries every request that failed with an error.
+func (b *CheckerBuilder) RetryNetworkErrors() *CheckerBuilder {
+	b.networkErrors = true
+	return b
+}
+
+// RetryErrors retries errors accepted by checker, typically built with
+// MatchErrors. It is ignored when RetryNetworkErrors is set.
+func (b *CheckerBuilder) RetryErrors(checker RetryableChecker) *CheckerBuilder {
+	b.errorChecker = checker
+	return b
+}
+
+// MaxBodyPeek retries responses for which fn returns true when given up to n
+// bytes from the start of the body. The body is restored, so the caller still
+// reads it in full.
+func (b *CheckerBuilder) MaxBodyPeek(n int, fn func(body []byte) bool) *CheckerBuilder {
+	b.peekBytes = n
+	b.peekChecker = fn
+	return b
+}
+
+// Build returns the RetryableChecker described by the builder.
+func (b *CheckerBuilder) Build() RetryableChecker {
+	cb := *b
+	cb.retry = cloneStatusSet(b.retry)
+	cb.noRetry = cloneStatusSet(b.noRetry)
+	return cb.check
+}
+
+// check implements the built RetryableChecker.
+func (b *CheckerBuilder) check(err error, resp *http.Response) bool {
+	if err != nil {
+		if b.networkErrors {
+			return true
+		}
+		return b.errorChecker != nil && b.errorChecker(err, resp)
+	}
+
+	if resp == nil || b.noRetry[resp.StatusCode] {
+		return false
+	}
+	if b.retry[resp.StatusCode] {
+		return true
+	}
+	if b.peekChecker != nil && b.peekBytes > 0 {
+		return b.peekChecker(peekBody(resp, b.peekBytes))
+	}
+	return false
+}
+
+// cloneStatusSet copies a set of status codes.
+func cloneStatusSet(set map[int]bool) map[int]bool {
+	clone := make(map[int]bool, len(set))
+	for code := range set {
+		clone[code] = true
+	}
+	return clone
+}
+
+// peekedBody replays the peeked prefix before the rest of the original body.
+type peekedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// peekBody returns up to n bytes from the start of resp's body and restores
+// the body so it can still be read from the beginning.
+func peekBody(resp *http.Response, n int) []byte {
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return nil
+	}
+
+	buf := make([]byte, n)
+	read, _ := io.ReadFull(resp.Body, buf)
+	buf = buf[:read]
+	resp.Body = &peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(buf), resp.Body),
+		Closer: resp.Body,
+	}
+	return buf
+}
+
+// AnyOf returns a RetryableChecker that retries when any of checkers does.
+// With no checkers it never retries.
+func AnyOf(checkers ...RetryableChecker) RetryableChecker {
+	return func(err error, resp *http.Response) bool {
+		for _, check := range checkers {
+			if check(err, resp) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// AllOf returns a RetryableChecker that retries only when every one of
+// checkers does. With no checkers it always retries.
+func AllOf(checkers ...RetryableChecker) RetryableChecker {
+	return func(err error, resp *http.Response) bool {
+		for _, check := range checkers {
+			if !check(err, resp) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Not returns a RetryableChecker that retries exactly when checker does not.
+func Not(checker RetryableChecker) RetryableChecker {
+	return func(err error, resp *http.Response) bool {
+		return !checker(err, resp)
+	}
+}
@@ -0,0 +1,130 @@
+package retry
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestCheckerBuilder(t *testing.T) {
+	jsonCodeChecker := func(body []byte) bool {
+		return bytes.Contains(body, []byte(`"code":"TRY_AGAIN"`))
+	}
+	checker := NewChecker().
+		RetryStatuses(500, 502, 503, 504, 429).
+		RetryNetworkErrors().
+		NoRetryOn(502).
+		MaxBodyPeek(64, jsonCodeChecker).
+		Build()
+
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		body     string
+		expected bool
+	}{
+		{"network error", errors.New("connection refused"), 0, "", true},
+		{"retry status", nil, 503, "", true},
+		{"no retry overrides retry status", nil, 502, "", false},
+		{"unlisted status", nil, 501, "", false},
+		{"body peek match", nil, 200, `{"code":"TRY_AGAIN"}`, true},
+		{"body peek miss", nil, 200, `{"code":"OK"}`, false},
+		{"match beyond peek limit", nil, 200, strings.Repeat(" ", 64) + `{"code":"TRY_AGAIN"}`, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resp *http.Response
+			if tt.err == nil {
+				resp = &http.Response{StatusCode: tt.status, Body: io.NopCloser(strings.NewReader(tt.body))}
+			}
+			if got := checker(tt.err, resp); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+			if resp != nil {
+				if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+					t.Errorf("Expected body to be restored, got %q", body)
+				}
+			}
+		})
+	}
+}
+
+func TestCheckerBuilder_RetryErrors(t *testing.T) {
+	checker := NewChecker().
+		RetryErrors(MatchErrors().Substring("reset").Build()).
+		Build()
+
+	if !checker(errors.New("connection reset by peer"), nil) {
+		t.Error("Expected matching error to be retried")
+	}
+	if checker(errors.New("certificate expired"), nil) {
+		t.Error("Expected non-matching error not to be retried")
+	}
+}
+
+func TestCheckerBuilder_BuildSnapshotsRules(t *testing.T) {
+	b := NewChecker().RetryStatuses(503)
+	checker := b.Build()
+	b.RetryStatuses(500).NoRetryOn(503)
+
+	if !checker(nil, &http.Response{StatusCode: 503}) {
+		t.Error("Rules changed after Build must not affect the built checker")
+	}
+	if checker(nil, &http.Response{StatusCode: 500}) {
+		t.Error("Statuses added after Build must not affect the built checker")
+	}
+}
+
+func TestCheckerCombinators(t *testing.T) {
+	always := func(error, *http.Response) bool { return true }
+	never := func(error, *http.Response) bool { return false }
+
+	tests := []struct {
+		name     string
+		checker  RetryableChecker
+		expected bool
+	}{
+		{"AnyOf empty", AnyOf(), false},
+		{"AnyOf one true", AnyOf(never, always), true},
+		{"AnyOf all false", AnyOf(never, never), false},
+		{"AllOf empty", AllOf(), true},
+		{"AllOf all true", AllOf(always, always), true},
+		{"AllOf one false", AllOf(always, never), false},
+		{"Not true", Not(always), false},
+		{"Not false", Not(never), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.checker(nil, &http.Response{StatusCode: 200}); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCheckerCombinators_Compose(t *testing.T) {
+	// Default behavior or a body signal, but never for 503s from this API
+	checker := AllOf(
+		AnyOf(DefaultRetryableChecker, NewChecker().MaxBodyPeek(32, func(body []byte) bool {
+			return bytes.Contains(body, []byte("retry"))
+		}).Build()),
+		Not(NewChecker().RetryStatuses(http.StatusServiceUnavailable).Build()),
+	)
+
+	body := func(s string) io.ReadCloser { return io.NopCloser(strings.NewReader(s)) }
+	if !checker(nil, &http.Response{StatusCode: 500, Body: body("")}) {
+		t.Error("Expected 500 to be retried")
+	}
+	if checker(nil, &http.Response{StatusCode: 503, Body: body("")}) {
+		t.Error("Expected 503 to be excluded")
+	}
+	if !checker(nil, &http.Response{StatusCode: 200, Body: body("please retry")}) {
+		t.Error("Expected body signal to be retried")
+	}
+}
@@ -0,0 +1,140 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// clockSkewPeekBytes is how much of an error body IsClockSkewError inspects.
+const clockSkewPeekBytes = 4096
+
+// clockSkewMarkers are error codes and messages of signature checks that
+// rejected the request time, matched case-insensitively.
+var clockSkewMarkers = [][]byte{
+	[]byte("requesttimetooskewed"), // S3
+	[]byte("requestexpired"),       // AWS APIs
+	[]byte("signature expired"),    // AWS SigV4 message
+	[]byte("signature not yet current"),
+	[]byte("clock skew"),
+	[]byte("time too skewed"),
+}
+
+// ClockSkewPolicy configures WithClockSkewRetry.
+type ClockSkewPolicy struct {
+	// Sign signs an attempt's request (SigV4, HMAC, ...) as of now, the
+	// client's estimate of the server's time. Required.
+	Sign func(req *http.Request, now time.Time) error
+
+	// Detect reports whether resp rejected the request's signature time
+	// (default IsClockSkewError).
+	Detect func(resp *http.Response) bool
+}
+
+// clockSkew signs requests with a clock corrected by the server's Date header
+// (WithClockSkewRetry).
+type clockSkew struct {
+	policy ClockSkewPolicy
+	offset atomic.Int64 // Server time minus local time, in nanoseconds
+}
+
+// IsClockSkewError reports whether resp is a 400, 401 or 403 response whose
+// body names a request time or signature expiry error, such as S3's
+// RequestTimeTooSkewed or AWS's "Signature expired". The body can still be
+// read from the beginning afterwards.
+func IsClockSkewError(resp *http.Response) bool {
+	if resp == nil {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusUnauthorized, http.StatusForbidden:
+	default:
+		return false
+	}
+	body := bytes.ToLower(peekBody(resp, clockSkewPeekBytes))
+	for _, marker := range clockSkewMarkers {
+		if bytes.Contains(body, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// now returns the client's estimate of the server's current time.
+func (s *clockSkew) now() time.Time {
+	return time.Now().Add(time.Duration(s.offset.Load()))
+}
+
+// resync updates the clock offset from the Date header of resp when resp
+// rejected the request time, and reports whether it did.
+func (s *clockSkew) resync(resp *http.Response) bool {
+	detect := s.policy.Detect
+	if detect == nil {
+		detect = IsClockSkewError
+	}
+	if !detect(resp) {
+		return false
+	}
+	date, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return false // Nothing to resynchronize from
+	}
+	s.offset.Store(int64(time.Until(date)))
+	return true
+}
+
+// signRequest signs req when WithClockSkewRetry is set. Signing failures are
+// permanent.
+func (c *Client) signRequest(req *http.Request) error {
+	if c.clockSkew == nil {
+		return nil
+	}
+	if err := c.clockSkew.policy.Sign(req, c.clockSkew.now()); err != nil {
+		return Permanent(fmt.Errorf("retry: sign request: %w", err))
+	}
+	return nil
+}
+
+// resendSkewed re-signs and resends req once when resp rejected its signature
+// time, after resynchronizing the clock. Otherwise, or when req's body cannot
+// be replayed, it returns resp unchanged.
+func (c *Client) resendSkewed(
+	ctx context.Context,
+	req *http.Request,
+	resp *http.Response,
+) (*http.Response, error) {
+	if c.clockSkew == nil || !c.clockSkew.resync(resp) {
+		return resp, nil
+	}
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.GetBody == nil {
+			return resp, nil
+		}
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil
+		}
+		req.Body = body
+	}
+	if err := c.signRequest(req); err != nil {
+		return resp, nil
+	}
+
+	// Drain so the connection can be reused for the resend
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return c.send(ctx, req)
+}
+
+// ClockOffset returns the offset of the server's clock from the local one,
+// as last resynchronized by WithClockSkewRetry (0 until then).
+func (c *Client) ClockOffset() time.Duration {
+	if c.clockSkew == nil {
+		return 0
+	}
+	return time.Duration(c.clockSkew.offset.Load())
+}
@@ -0,0 +1,130 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// skewedServer checks the X-Signed-At header against a server clock running
+// an hour ahead, rejecting stale signatures like S3 does. It echoes the body.
+func skewedServer(t *testing.T) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		now := time.Now().Add(time.Hour)
+		w.Header().Set("Date", now.UTC().Format(http.TimeFormat))
+		signedAt, err := time.Parse(time.RFC3339, r.Header.Get("X-Signed-At"))
+		if err != nil || now.Sub(signedAt).Abs() > time.Minute {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, "<Error><Code>RequestTimeTooSkewed</Code></Error>")
+			return
+		}
+		_, _ = io.Copy(w, r.Body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+// signAt signs a request by stamping its signing time.
+func signAt(req *http.Request, now time.Time) error {
+	req.Header.Set("X-Signed-At", now.UTC().Format(time.RFC3339))
+	return nil
+}
+
+func TestWithClockSkewRetry(t *testing.T) {
+	server, hits := skewedServer(t)
+	client, err := NewClient(
+		WithMaxRetries(0),
+		WithClockSkewRetry(ClockSkewPolicy{Sign: signAt}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Post(context.Background(), server.URL,
+		WithBody("text/plain", strings.NewReader("payload")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(body) != "payload" {
+		t.Errorf("Expected the re-signed request to succeed with its body, got %d %q",
+			resp.StatusCode, body)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("Expected 1 rejected and 1 re-signed request, got %d", n)
+	}
+	if offset := client.ClockOffset(); (offset - time.Hour).Abs() > 2*time.Second {
+		t.Errorf("Expected a clock offset of about 1h, got %v", offset)
+	}
+
+	// Later requests are signed with the corrected clock
+	resp, err = client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if n := hits.Load(); n != 3 || resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the next request to pass at once, got %d requests", n)
+	}
+}
+
+func TestWithClockSkewRetry_SignError(t *testing.T) {
+	server, hits := skewedServer(t)
+	errNoCreds := errors.New("no credentials")
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithClockSkewRetry(ClockSkewPolicy{
+			Sign: func(*http.Request, time.Time) error { return errNoCreds },
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL)
+	if !errors.Is(err, errNoCreds) {
+		t.Errorf("Expected the signing error, got %v", err)
+	}
+	if n := hits.Load(); n != 0 {
+		t.Errorf("Expected no request without a signature, got %d", n)
+	}
+}
+
+func TestIsClockSkewError(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   bool
+	}{
+		{"S3", http.StatusForbidden, "<Code>RequestTimeTooSkewed</Code>", true},
+		{"SigV4", http.StatusForbidden, `{"message":"Signature expired: 20260101T000000Z"}`, true},
+		{"bad request", http.StatusBadRequest, `{"__type":"RequestExpired"}`, true},
+		{"other 403", http.StatusForbidden, "<Code>AccessDenied</Code>", false},
+		{"server error", http.StatusInternalServerError, "clock skew", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if got := IsClockSkewError(resp); got != tt.want {
+				t.Errorf("IsClockSkewError = %v, want %v", got, tt.want)
+			}
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Errorf("Expected the body to stay readable, got %q", body)
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package retry
+
+import "slices"
+
+// Clone returns a new client built from the options c was created with,
+// followed by opts. Use it to derive a client that differs in a few settings,
+// e.g. dropping a named middleware with WithoutMiddleware or swapping it with
+// WithNamedRequestMiddleware. The clone has its own state (stats, learned
+// rate limits, connection tracking); objects passed to the original options,
+// such as a rate limiter or http.Client, are shared.
+func (c *Client) Clone(opts ...Option) (*Client, error) {
+	return NewClient(append(slices.Clone(c.options), opts...)...)
+}
+
+// MiddlewareNames returns the names of the registered per-attempt and
+// request-level middleware, in chain order. Middleware added without a name
+// (WithPerAttemptMiddleware, WithRequestMiddleware) is listed as "".
+func (c *Client) MiddlewareNames() (perAttempt, request []string) {
+	return slices.Clone(c.perAttemptMiddlewareNames), slices.Clone(c.requestMiddlewareNames)
+}
+
+// setNamed replaces the entry called name in place, keeping its position in
+// the chain, or appends it when there is none.
+func setNamed[T any](items []T, names []string, name string, item T) ([]T, []string) {
+	if i := slices.Index(names, name); i >= 0 {
+		items[i] = item
+		return items, names
+	}
+	return append(items, item), append(names, name)
+}
+
+// removeNamed drops the entries whose name is in remove.
+func removeNamed[T any](items []T, names []string, remove []string) ([]T, []string) {
+	var keptItems []T
+	var keptNames []string
+	for i, name := range names {
+		if name == "" || !slices.Contains(remove, name) {
+			keptItems = append(keptItems, items[i])
+			keptNames = append(keptNames, name)
+		}
+	}
+	return keptItems, keptNames
+}
@@ -0,0 +1,164 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// coalescer merges identical GET requests into one upstream call
+// (WithRequestCoalescing).
+type coalescer struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall // By coalesceKey
+}
+
+// coalescedCall is one upstream call shared by identical requests.
+type coalescedCall struct {
+	done    chan struct{} // Closed once resp, body and err are set
+	resp    *http.Response
+	body    []byte
+	err     error
+	waiters int                // Callers still waiting, under coalescer.mu
+	ctx     context.Context    // The call's context, shared by its callers
+	cancel  context.CancelFunc // Cancels the call once no caller waits
+}
+
+// newCoalescer returns a coalescer sharing calls for window after they end.
+func newCoalescer(window time.Duration) *coalescer {
+	return &coalescer{window: window, calls: make(map[string]*coalescedCall)}
+}
+
+// wrap returns a RetryFunc sending GET requests without a body through the
+// coalescer.
+func (co *coalescer) wrap(next RetryFunc) RetryFunc {
+	return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		if req.Method != http.MethodGet || (req.Body != nil && req.Body != http.NoBody) {
+			return next(ctx, req)
+		}
+		key := coalesceKey(req)
+
+		co.mu.Lock()
+		call, ok := co.calls[key]
+		if !ok || call.abandoned() {
+			call = &coalescedCall{done: make(chan struct{})}
+			co.calls[key] = call
+			call.ctx, call.cancel = sharedContext(ctx)
+			go co.run(call.ctx, call, key, next, req.WithContext(call.ctx))
+		}
+		call.waiters++
+		co.mu.Unlock()
+
+		select {
+		case <-call.done:
+			return call.response()
+		case <-ctx.Done():
+			co.mu.Lock()
+			call.waiters--
+			if call.waiters == 0 {
+				call.cancel() // Nobody is left to use the response
+				co.forget(key, call)
+			}
+			co.mu.Unlock()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// run makes the upstream call, reads its body for the callers, and keeps the
+// call joinable for the coalescing window.
+func (co *coalescer) run(
+	ctx context.Context,
+	call *coalescedCall,
+	key string,
+	next RetryFunc,
+	req *http.Request,
+) {
+	defer call.cancel()
+
+	call.resp, call.err = next(ctx, req)
+	if call.resp != nil {
+		body, err := io.ReadAll(call.resp.Body)
+		call.resp.Body.Close()
+		call.body = body
+		if err != nil && call.err == nil {
+			call.resp, call.err = nil, err
+		}
+	}
+	close(call.done)
+
+	if ctx.Err() != nil {
+		// The result of a canceled or timed-out call is no one else's to share
+		co.mu.Lock()
+		co.forget(key, call)
+		co.mu.Unlock()
+		return
+	}
+	time.AfterFunc(co.window, func() {
+		co.mu.Lock()
+		defer co.mu.Unlock()
+		co.forget(key, call)
+	})
+}
+
+// forget stops new requests from joining call. Callers must hold co.mu.
+func (co *coalescer) forget(key string, call *coalescedCall) {
+	if co.calls[key] == call {
+		delete(co.calls, key)
+	}
+}
+
+// abandoned reports whether call was canceled or ran out of time before it
+// completed, so it must not be joined.
+func (call *coalescedCall) abandoned() bool {
+	select {
+	case <-call.done:
+		return false
+	default:
+		return call.ctx.Err() != nil
+	}
+}
+
+// response returns a caller's copy of the call's response, with a body of its
+// own.
+func (call *coalescedCall) response() (*http.Response, error) {
+	if call.resp == nil {
+		return nil, call.err
+	}
+	resp := *call.resp
+	resp.Header = call.resp.Header.Clone()
+	resp.Trailer = call.resp.Trailer.Clone()
+	resp.Body = io.NopCloser(bytes.NewReader(call.body))
+	return &resp, call.err
+}
+
+// sharedContext returns the context of a call shared by several callers: the
+// first caller's values and deadline, but not its cancellation.
+func sharedContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	shared := context.WithoutCancel(ctx)
+	if deadline, ok := ctx.Deadline(); ok {
+		return context.WithDeadline(shared, deadline)
+	}
+	return context.WithCancel(shared)
+}
+
+// coalesceKey identifies identical requests: same URL and headers.
+func coalesceKey(req *http.Request) string {
+	var b strings.Builder
+	b.WriteString(req.URL.String())
+	for _, name := range slices.Sorted(maps.Keys(req.Header)) {
+		b.WriteString("\n" + name + ": " + strings.Join(req.Header[name], ", "))
+	}
+	if req.Host != "" {
+		b.WriteString("\nHost: " + req.Host)
+	}
+	return b.String()
+}
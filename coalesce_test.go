@@ -0,0 +1,189 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// blockingServer counts requests and answers "hot" once release is closed.
+func blockingServer(t *testing.T) (*httptest.Server, *atomic.Int32, chan struct{}) {
+	t.Helper()
+	var hits atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		<-release
+		w.Header().Set("X-Served", "yes")
+		_, _ = io.WriteString(w, "hot")
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits, release
+}
+
+func TestWithRequestCoalescing_InFlight(t *testing.T) {
+	server, hits, release := blockingServer(t)
+	client, err := NewClient(WithRequestCoalescing(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 10
+	var wg sync.WaitGroup
+	bodies := make([]string, callers)
+	for i := range callers {
+		wg.Go(func() {
+			resp, err := client.Get(context.Background(), server.URL+"/key")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			bodies[i] = string(body) + resp.Header.Get("X-Served")
+		})
+	}
+	time.Sleep(50 * time.Millisecond) // Let every caller join
+	close(release)
+	wg.Wait()
+
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", n)
+	}
+	for i, body := range bodies {
+		if body != "hotyes" {
+			t.Errorf("Caller %d: expected the shared response, got %q", i, body)
+		}
+	}
+}
+
+func TestWithRequestCoalescing_Window(t *testing.T) {
+	server, hits := countingServer(t, http.StatusOK)
+	client, err := NewClient(WithRequestCoalescing(30 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	get()
+	get() // Within the window: served by the first call
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected 1 upstream call within the window, got %d", n)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	get()
+	if n := hits.Load(); n != 2 {
+		t.Errorf("Expected a new upstream call after the window, got %d calls", n)
+	}
+}
+
+func TestWithRequestCoalescing_NotIdentical(t *testing.T) {
+	server, hits := countingServer(t, http.StatusOK)
+	client, err := NewClient(WithRequestCoalescing(time.Second), WithNoLogging())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, send := range []func() (*http.Response, error){
+		func() (*http.Response, error) {
+			return client.Get(context.Background(), server.URL, WithHeader("Authorization", "a"))
+		},
+		func() (*http.Response, error) {
+			return client.Get(context.Background(), server.URL, WithHeader("Authorization", "b"))
+		},
+		func() (*http.Response, error) { return client.Post(context.Background(), server.URL) },
+		func() (*http.Response, error) { return client.Post(context.Background(), server.URL) },
+	} {
+		resp, err := send()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if n := hits.Load(); n != 4 {
+		t.Errorf("Expected 4 upstream calls, got %d", n)
+	}
+}
+
+func TestWithRequestCoalescing_CallerCanceled(t *testing.T) {
+	server, hits, release := blockingServer(t)
+	client, err := NewClient(WithRequestCoalescing(10 * time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The first caller gives up; the call goes on for the second one
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error, 1)
+	go func() {
+		_, err := client.Get(ctx, server.URL)
+		firstErr <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	second := make(chan string, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			second <- err.Error()
+			return
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		second <- string(body)
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the canceled caller to fail, got %v", err)
+	}
+	close(release)
+	if body := <-second; body != "hot" {
+		t.Errorf("Expected the remaining caller to get the response, got %q", body)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected 1 upstream call, got %d", n)
+	}
+}
+
+func TestWithRequestCoalescing_AbandonedCall(t *testing.T) {
+	server, hits, release := blockingServer(t)
+	client, err := NewClient(WithRequestCoalescing(time.Second), WithNoLogging())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	if _, err := client.Get(ctx, server.URL); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Expected the first caller to time out, got %v", err)
+	}
+	close(release)
+
+	// The abandoned call must not be joined, nor its failure shared
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Expected a fresh call for a live caller, got %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "hot" {
+		t.Errorf("Expected the fresh call's response, got %q", body)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("Expected 2 upstream calls, got %d", n)
+	}
+}
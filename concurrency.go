@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"context"
+	"sync"
+)
+
+// hostLimiter caps the number of in-flight attempts per destination host.
+type hostLimiter struct {
+	limit int
+
+	mu    sync.Mutex
+	slots map[string]chan struct{}
+}
+
+// sem returns the semaphore for host, creating it on first use.
+func (l *hostLimiter) sem(host string) chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	s, ok := l.slots[host]
+	if !ok {
+		s = make(chan struct{}, l.limit)
+		l.slots[host] = s
+	}
+	return s
+}
+
+// acquire blocks until a slot for host is free or ctx is done. The returned
+// function releases the slot.
+func (l *hostLimiter) acquire(ctx context.Context, host string) (func(), error) {
+	s := l.sem(host)
+	select {
+	case s <- struct{}{}:
+		return func() { <-s }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// acquireHostSlot takes a per-host concurrency slot for an attempt to host
+// when WithMaxConcurrentPerHost is set. The returned release function is never nil.
+func (c *Client) acquireHostSlot(ctx context.Context, host string) (func(), error) {
+	if c.hostLimiter == nil {
+		return func() {}, nil
+	}
+	defer c.admission.queue()()
+	return c.hostLimiter.acquire(ctx, host)
+}
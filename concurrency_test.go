@@ -0,0 +1,109 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithMaxConcurrentPerHost(t *testing.T) {
+	var inFlight, peak int32
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(100 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer fast.Close()
+
+	client, err := NewClient(WithMaxConcurrentPerHost(2), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for range 6 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(context.Background(), slow.URL)
+			if err != nil {
+				t.Errorf("Unexpected error: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+
+	// A healthy host must not queue behind the slow one
+	time.Sleep(20 * time.Millisecond)
+	start := time.Now()
+	resp, err := client.Get(context.Background(), fast.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected fast host to be unaffected, took %v", elapsed)
+	}
+
+	wg.Wait()
+	if got := atomic.LoadInt32(&peak); got != 2 {
+		t.Errorf("Expected at most 2 concurrent attempts to the slow host, peak was %d", got)
+	}
+}
+
+func TestWithMaxConcurrentPerHost_ContextCanceledWhileQueued(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client, err := NewClient(WithMaxConcurrentPerHost(1), WithMaxRetries(0), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded while waiting for a slot, got %v", err)
+	}
+}
+
+func TestWithMaxConcurrentPerHost_Disabled(t *testing.T) {
+	client, err := NewClient(WithMaxConcurrentPerHost(0))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.hostLimiter != nil {
+		t.Error("Expected no host limiter for non-positive limit")
+	}
+}
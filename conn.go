@@ -0,0 +1,57 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+)
+
+// attemptTrace captures connection-level details of a single attempt via
+// httptrace. Hooks may fire on transport goroutines, so all fields are atomic.
+type attemptTrace struct {
+	gotConn atomic.Bool // true once the transport handed the attempt a connection
+	reused  atomic.Bool // true if that connection came from the idle pool
+
+	// Phases reached, for classifying timeouts
+	tlsStarted atomic.Bool // true once a TLS handshake started
+	gotHeaders atomic.Bool // true once the response headers arrived
+}
+
+// withTrace returns a context that feeds t from httptrace hooks.
+// Any ClientTrace already present in ctx keeps firing (hooks are composed).
+func (t *attemptTrace) withTrace(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.reused.Store(info.Reused)
+			t.gotConn.Store(true)
+		},
+		TLSHandshakeStart: func() {
+			t.tlsStarted.Store(true)
+		},
+	})
+}
+
+// recordConnection reports the connection outcome captured by t to the client
+// stats, the optional ConnectionMetricsCollector, and the attempt span.
+// Attempts that never obtained a connection (e.g. dial failures) are skipped.
+func (c *Client) recordConnection(req *http.Request, t *attemptTrace, span Span) {
+	if !t.gotConn.Load() {
+		return
+	}
+	reused := t.reused.Load()
+
+	if reused {
+		c.stats.reusedConns.Add(1)
+	} else {
+		c.stats.newConns.Add(1)
+	}
+
+	if c.connMetrics != nil {
+		c.connMetrics.RecordConnection(req.Method, req.URL.Host, reused)
+	}
+
+	if c.tracerEnabled {
+		span.SetAttributes(Attribute{Key: "http.conn_reused", Value: reused})
+	}
+}
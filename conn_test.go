@@ -0,0 +1,130 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// connRecord stores information about a recorded connection
+type connRecord struct {
+	Method string
+	Host   string
+	Reused bool
+}
+
+// connMetricsCollector implements MetricsCollector and ConnectionMetricsCollector
+type connMetricsCollector struct {
+	MockMetricsCollector
+	conns []connRecord
+	mu    sync.Mutex
+}
+
+func (m *connMetricsCollector) RecordConnection(method string, host string, reused bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.conns = append(m.conns, connRecord{Method: method, Host: host, Reused: reused})
+}
+
+func TestClient_ConnectionReuseTracking(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	collector := &connMetricsCollector{}
+	mockTracer := &MockTracer{}
+	client, err := NewClient(
+		WithHTTPClient(server.Client()),
+		WithInitialRetryDelay(time.Millisecond),
+		WithJitter(false),
+		WithMetrics(collector),
+		WithTracer(mockTracer),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	stats := client.Stats()
+	if stats.NewConnections != 1 {
+		t.Errorf("Expected 1 new connection, got %d", stats.NewConnections)
+	}
+	if stats.ReusedConnections != 1 {
+		t.Errorf("Expected 1 reused connection, got %d", stats.ReusedConnections)
+	}
+
+	if len(collector.conns) != 2 {
+		t.Fatalf("Expected 2 connection records, got %d", len(collector.conns))
+	}
+	if collector.conns[0].Reused || !collector.conns[1].Reused {
+		t.Errorf("Expected [new, reused], got %+v", collector.conns)
+	}
+	if collector.conns[0].Host != server.Listener.Addr().String() {
+		t.Errorf("Expected host %q, got %q", server.Listener.Addr(), collector.conns[0].Host)
+	}
+
+	// Spans: request + 2 attempts; each attempt carries http.conn_reused
+	if len(mockTracer.Spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(mockTracer.Spans))
+	}
+	for i, span := range mockTracer.Spans[1:] {
+		found := false
+		for _, attr := range span.Attributes {
+			if attr.Key == "http.conn_reused" {
+				found = true
+				if attr.Value != (i == 1) {
+					t.Errorf("attempt %d: expected http.conn_reused=%v, got %v", i+1, i == 1, attr.Value)
+				}
+			}
+		}
+		if !found {
+			t.Errorf("attempt %d: missing http.conn_reused attribute", i+1)
+		}
+	}
+}
+
+func TestClient_ConnectionTracking_NoConnection(t *testing.T) {
+	// A transport that never obtains a connection must not be counted.
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: RoundTripperFunc(
+			func(*http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       http.NoBody,
+				}, nil
+			},
+		)}),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if stats := client.Stats(); stats != (Stats{}) {
+		t.Errorf("Expected zero stats, got %+v", stats)
+	}
+}
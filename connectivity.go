@@ -0,0 +1,288 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// connectivityPollInterval is how often a Deliverer holding deliveries for
+	// connectivity asks the ConnectivityChecker whether the link is back.
+	connectivityPollInterval = time.Second
+
+	// connectivityDialFailures is how many consecutive network failures make
+	// a ConnectivityMonitor report offline.
+	connectivityDialFailures = 3
+
+	// connectivityLookupHosts is how many hosts failed name lookups must span
+	// before a ConnectivityMonitor blames the network rather than one host's
+	// DNS.
+	connectivityLookupHosts = 2
+
+	// connectivityRetest is how long a ConnectivityMonitor reports offline
+	// before probing (or, without a probe URL, letting attempts dial) again.
+	connectivityRetest = 5 * time.Second
+
+	// connectivityProbeTimeout bounds a ConnectivityMonitor's probe request.
+	connectivityProbeTimeout = 5 * time.Second
+)
+
+// ErrOffline is returned (as the RetryError's LastErr) when a client with a
+// ConnectivityChecker skips an attempt because the device is offline.
+var ErrOffline = errors.New("retry: offline")
+
+// ConnectivityChecker reports whether the network is reachable, e.g. from the
+// platform's reachability API on mobile or a cheap probe of a known host. It
+// is called before every attempt, so Online should answer from cached state
+// rather than probing the network each time.
+type ConnectivityChecker interface {
+	Online(ctx context.Context) bool
+}
+
+// ConnectivityFunc adapts a function to a ConnectivityChecker.
+type ConnectivityFunc func(ctx context.Context) bool
+
+// Online calls f.
+func (f ConnectivityFunc) Online(ctx context.Context) bool {
+	return f(ctx)
+}
+
+// ConnectivityMonitor is the default ConnectivityChecker, for platforms
+// without a reachability API. It is fed the dial results of the clients
+// using it: after 3 consecutive attempts failed because no network was
+// reachable, or name lookups got no answer for at least 2 different hosts,
+// it reports offline. A refused or reset connection proves the network is up
+// and only the host down, so it never counts. With a probe URL, the monitor
+// then sends a HEAD request to it in the background every 5s, and reports
+// online again once one gets any response. Without one, it reports online
+// again 5s after the last failure, letting the next attempt find out; any
+// attempt that reaches its host ends the outage.
+//
+// Online answers from this state without touching the network. One monitor
+// may be shared by several clients.
+type ConnectivityMonitor struct {
+	probeURL    string
+	probeClient *http.Client
+	retest      time.Duration
+
+	mu          sync.Mutex
+	failures    int                 // Consecutive network failures
+	unreachable bool                // One of them found no route to any network
+	lookupHosts map[string]struct{} // Hosts whose name lookups got no answer
+	lastFailure time.Time           // Time of the last network failure
+	lastProbe   time.Time           // Start of the last probe
+	probing     bool
+}
+
+// NewConnectivityMonitor returns a ConnectivityMonitor probing probeURL (e.g.
+// a cheap endpoint of your API) while offline; an empty probeURL disables
+// probing.
+//
+// Example:
+//
+//	client, err := retry.NewClient(
+//	    retry.WithConnectivityChecker(retry.NewConnectivityMonitor("https://api.example.com/ping")),
+//	)
+func NewConnectivityMonitor(probeURL string) *ConnectivityMonitor {
+	return &ConnectivityMonitor{
+		probeURL:    probeURL,
+		probeClient: &http.Client{Timeout: connectivityProbeTimeout},
+		retest:      connectivityRetest,
+	}
+}
+
+// Online reports whether the network is considered reachable, and starts a
+// probe when one is due.
+func (m *ConnectivityMonitor) Online(ctx context.Context) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if !m.down() {
+		return true
+	}
+	if m.probeURL == "" {
+		return time.Since(m.lastFailure) >= m.retest
+	}
+	if !m.probing && time.Since(m.lastProbe) >= m.retest {
+		m.probing, m.lastProbe = true, time.Now()
+		go m.probe(context.WithoutCancel(ctx))
+	}
+	return false
+}
+
+// RecordDial feeds the outcome of an attempt to the monitor. An unreachable
+// network or an unanswered name lookup counts toward an outage; a nil err, a
+// refused connection or any other error from a reached host proves the
+// network is up. Dials failing either way, such as timeouts, are ignored.
+// Clients using the monitor call it after every attempt; call it yourself to
+// share the monitor with other code dialing out.
+func (m *ConnectivityMonitor) RecordDial(err error) {
+	host, unreachable, failed := networkFailure(err)
+	if !failed && (errors.Is(err, context.Canceled) || ambiguousDial(err)) {
+		return // Tells nothing about the network
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !failed {
+		m.reset()
+		return
+	}
+	m.failures++
+	m.lastFailure = time.Now()
+	if unreachable {
+		m.unreachable = true
+	} else {
+		if m.lookupHosts == nil {
+			m.lookupHosts = make(map[string]struct{})
+		}
+		m.lookupHosts[host] = struct{}{}
+	}
+}
+
+// down reports whether the recorded failures show the network down. Callers
+// must hold m.mu.
+func (m *ConnectivityMonitor) down() bool {
+	return m.failures >= connectivityDialFailures &&
+		(m.unreachable || len(m.lookupHosts) >= connectivityLookupHosts)
+}
+
+// reset ends an outage. Callers must hold m.mu.
+func (m *ConnectivityMonitor) reset() {
+	m.failures, m.unreachable = 0, false
+	clear(m.lookupHosts)
+}
+
+// probe sends a HEAD request to the probe URL; any response ends the outage.
+func (m *ConnectivityMonitor) probe(ctx context.Context) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, m.probeURL, nil)
+	if err == nil {
+		var resp *http.Response
+		if resp, err = m.probeClient.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.probing = false
+	if err == nil {
+		m.reset()
+	}
+}
+
+// Online reports whether the client's ConnectivityChecker considers the
+// network reachable; always true without one. Use it to adapt the
+// application, e.g. to show an offline banner or defer background syncs.
+func (c *Client) Online(ctx context.Context) bool {
+	return !c.offline(ctx)
+}
+
+// offline reports whether the client's ConnectivityChecker says the device is
+// offline.
+func (c *Client) offline(ctx context.Context) bool {
+	return c.connectivity != nil && !c.connectivity.Online(ctx)
+}
+
+// recordDial feeds an attempt's error to the client's ConnectivityMonitor.
+func (c *Client) recordDial(err error) {
+	if m, ok := c.connectivity.(*ConnectivityMonitor); ok {
+		m.RecordDial(err)
+	}
+}
+
+// networkFault reports whether err is a network failure while the device is
+// offline: the network's fault rather than the host's, so it is kept out of
+// host health (WithRetrySuppression, WithPhiDetector).
+func (c *Client) networkFault(ctx context.Context, err error) bool {
+	_, _, failed := networkFailure(err)
+	return failed && c.offline(ctx)
+}
+
+// networkFailure reports whether err shows the network rather than its host
+// unreachable: no route to any network (unreachable), or a name lookup of
+// host that got no answer. A lookup answered with "no such host" proves the
+// resolver, and so the network, reachable.
+func networkFailure(err error) (host string, unreachable, failed bool) {
+	if errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.ENETDOWN) {
+		return "", true, true
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && !dnsErr.IsNotFound {
+		return dnsErr.Name, false, true
+	}
+	return "", false, false
+}
+
+// ambiguousDial reports whether err is a failed dial telling nothing about
+// the network, such as a timeout: unlike a refused or reset connection, it
+// may come from the network or from the host.
+func ambiguousDial(err error) bool {
+	var dnsErr *net.DNSError
+	if !isDialFailure(err) || errors.As(err, &dnsErr) {
+		return false
+	}
+	return !errors.Is(err, syscall.ECONNREFUSED) && !errors.Is(err, syscall.ECONNRESET)
+}
+
+// park holds a delivery that failed because the device is offline, without
+// counting it as a delivery, and replays it once connectivity returns. It is
+// kept in the store meanwhile, so a restart picks it up too.
+func (d *Deliverer) park(state RetryState) {
+	ctx := d.lifetime()
+	if ctx.Err() != nil {
+		return // Shutting down; Start picks it up from the store
+	}
+
+	state.Attempt--
+	state.NextAt = time.Now()
+	if err := d.store.Save(ctx, state); err != nil {
+		d.client.logger.Warn("failed to save delivery", "id", state.ID, "error", err.Error())
+	}
+
+	d.mu.Lock()
+	d.parked = append(d.parked, state)
+	watching := d.watching
+	d.watching = true
+	d.mu.Unlock()
+
+	if !watching {
+		go d.awaitConnectivity(ctx)
+	}
+}
+
+// awaitConnectivity polls the client's ConnectivityChecker until the device is
+// online, then replays the parked deliveries in the order they were parked.
+func (d *Deliverer) awaitConnectivity(ctx context.Context) {
+	ticker := time.NewTicker(connectivityPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			d.mu.Lock()
+			d.watching = false
+			d.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+		if d.client.offline(ctx) {
+			continue
+		}
+
+		d.mu.Lock()
+		parked := d.parked
+		d.parked, d.watching = nil, false
+		d.mu.Unlock()
+
+		for _, state := range parked {
+			d.deliver(ctx, state)
+		}
+		return
+	}
+}
@@ -0,0 +1,327 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// switchableConnectivity is a ConnectivityChecker tests can flip.
+type switchableConnectivity struct {
+	online atomic.Bool
+}
+
+func (s *switchableConnectivity) Online(context.Context) bool {
+	return s.online.Load()
+}
+
+func TestWithConnectivityChecker_Offline(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithConnectivityChecker(ConnectivityFunc(func(context.Context) bool {
+		return false
+	})))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("Expected ErrOffline, got %v", err)
+	}
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 0 {
+		t.Errorf("Expected no attempts, got %+v", retryErr)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("requests = %d, want 0", got)
+	}
+}
+
+func TestWithConnectivityChecker_OfflineBetweenRetries(t *testing.T) {
+	connectivity := &switchableConnectivity{}
+	connectivity.online.Store(true)
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		connectivity.online.Store(false)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithConnectivityChecker(connectivity),
+		WithInitialRetryDelay(time.Millisecond),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL)
+	var retryErr *RetryError
+	if !errors.Is(err, ErrOffline) || !errors.As(err, &retryErr) {
+		t.Fatalf("Expected ErrOffline, got %v", err)
+	}
+	if retryErr.Attempts != 1 || retryErr.LastStatus != http.StatusServiceUnavailable {
+		t.Errorf("Expected one failed attempt, got %+v", retryErr)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+func TestDeliverer_ReplaysWhenOnline(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	connectivity := &switchableConnectivity{}
+	client, err := NewOfflineTolerantClient(connectivity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newTestStateStore(t)
+	receipts := newReceiptRecorder()
+	deliverer := NewDeliverer(client, store, WithReceipts(receipts.record))
+
+	id, err := deliverer.Deliver(context.Background(), http.MethodPost, server.URL,
+		WithJSON(map[string]string{"event": "created"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	states, err := store.List(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(states) != 1 || states[0].ID != id || states[0].Attempt != 0 {
+		t.Fatalf("Expected the delivery queued without counting a delivery, got %+v", states)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Fatalf("requests = %d while offline, want 0", got)
+	}
+
+	connectivity.online.Store(true)
+	receipt := receipts.wait(t)
+	if !receipt.Delivered() || receipt.ID != id || receipt.Deliveries != 1 {
+		t.Errorf("receipt = %+v, want delivered %s after 1 delivery", receipt, id)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
+
+// dialError is what a dial looks like to the client without a network.
+var dialError = &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}
+
+// lookupError is a name lookup of host that got no answer.
+func lookupError(host string) error {
+	return &net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Name: host, Err: "i/o timeout", IsTimeout: true}}
+}
+
+// unreachableNetwork is a DialFunc failing like a device without a network
+// until up is set.
+type unreachableNetwork struct {
+	up atomic.Bool
+}
+
+func (n *unreachableNetwork) dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if n.up.Load() {
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, network, addr)
+	}
+	return nil, &net.OpError{Op: "dial", Net: network, Err: os.NewSyscallError("connect", syscall.ENETUNREACH)}
+}
+
+func TestConnectivityMonitor_DialFailures(t *testing.T) {
+	ctx := context.Background()
+	monitor := NewConnectivityMonitor("")
+	monitor.retest = time.Hour
+
+	monitor.RecordDial(dialError)
+	monitor.RecordDial(dialError)
+	monitor.RecordDial(context.Canceled) // Ignored
+	if !monitor.Online(ctx) {
+		t.Fatal("Expected online after 2 failed dials")
+	}
+	monitor.RecordDial(dialError)
+	if monitor.Online(ctx) {
+		t.Fatal("Expected offline after 3 failed dials")
+	}
+
+	monitor.RecordDial(errors.New("tls: handshake failure")) // The host was reached
+	if !monitor.Online(ctx) {
+		t.Error("Expected online once an attempt reached its host")
+	}
+}
+
+func TestConnectivityMonitor_RefusedIsOnline(t *testing.T) {
+	ctx := context.Background()
+	monitor := NewConnectivityMonitor("")
+	monitor.retest = time.Hour
+	refused := &net.OpError{Op: "dial", Net: "tcp", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}
+	timeout := &net.OpError{Op: "dial", Net: "tcp", Err: os.ErrDeadlineExceeded}
+
+	for range 2 * connectivityDialFailures {
+		monitor.RecordDial(refused)
+	}
+	if !monitor.Online(ctx) {
+		t.Fatal("Expected refused connections not to count: the network is up")
+	}
+
+	monitor.RecordDial(dialError)
+	monitor.RecordDial(dialError)
+	monitor.RecordDial(timeout) // Ignored
+	monitor.RecordDial(refused) // The network is up
+	monitor.RecordDial(dialError)
+	if !monitor.Online(ctx) {
+		t.Error("Expected a refused connection to end the failure streak")
+	}
+}
+
+func TestConnectivityMonitor_LookupFailures(t *testing.T) {
+	ctx := context.Background()
+	monitor := NewConnectivityMonitor("")
+	monitor.retest = time.Hour
+
+	for range connectivityDialFailures {
+		monitor.RecordDial(lookupError("api.example.com"))
+	}
+	if !monitor.Online(ctx) {
+		t.Fatal("Expected lookups failing for one host to blame its DNS, not the network")
+	}
+	monitor.RecordDial(&net.DNSError{Name: "typo.example.com", Err: "no such host", IsNotFound: true})
+	if !monitor.Online(ctx) {
+		t.Fatal("Expected an answered lookup to prove the network up")
+	}
+
+	monitor.RecordDial(lookupError("api.example.com"))
+	monitor.RecordDial(lookupError("api.example.com"))
+	monitor.RecordDial(lookupError("cdn.example.com"))
+	if monitor.Online(ctx) {
+		t.Error("Expected offline once lookups failed for 2 hosts")
+	}
+}
+
+func TestConnectivityMonitor_RetestWithoutProbe(t *testing.T) {
+	monitor := NewConnectivityMonitor("")
+	monitor.retest = 20 * time.Millisecond
+	for range connectivityDialFailures {
+		monitor.RecordDial(dialError)
+	}
+	if monitor.Online(context.Background()) {
+		t.Fatal("Expected offline after failed dials")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if !monitor.Online(context.Background()) {
+		t.Error("Expected online once the retest interval passed, to let an attempt dial")
+	}
+}
+
+func TestConnectivityMonitor_Probe(t *testing.T) {
+	var probes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		probes.Add(1)
+		if r.Method != http.MethodHead {
+			t.Errorf("Expected a HEAD probe, got %s", r.Method)
+		}
+		w.WriteHeader(http.StatusServiceUnavailable) // Any response proves connectivity
+	}))
+	defer server.Close()
+
+	monitor := NewConnectivityMonitor(server.URL)
+	for range connectivityDialFailures {
+		monitor.RecordDial(dialError)
+	}
+	if monitor.Online(context.Background()) {
+		t.Fatal("Expected offline until the probe answers")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !monitor.Online(context.Background()) {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected online after a successful probe")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if n := probes.Load(); n != 1 {
+		t.Errorf("Expected 1 probe, got %d", n)
+	}
+}
+
+func TestClient_Online(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !client.Online(context.Background()) {
+		t.Error("Expected a client without ConnectivityChecker to be online")
+	}
+
+	connectivity := &switchableConnectivity{}
+	client, err = NewClient(WithConnectivityChecker(connectivity))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Online(context.Background()) {
+		t.Error("Expected offline while the checker reports offline")
+	}
+	connectivity.online.Store(true)
+	if !client.Online(context.Background()) {
+		t.Error("Expected online once the checker reports online")
+	}
+}
+
+func TestConnectivityMonitor_KeepsHostHealth(t *testing.T) {
+	const addr = "127.0.0.1:9"
+	monitor := NewConnectivityMonitor("")
+	monitor.retest = time.Hour
+	client, err := NewClient(
+		WithMaxRetries(5),
+		WithInitialRetryDelay(time.Millisecond),
+		WithDialer(new(unreachableNetwork).dial),
+		WithConnectivityChecker(monitor),
+		WithRetrySuppression(1, time.Minute),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), "http://"+addr+"/")
+	var retryErr *RetryError
+	if !errors.Is(err, ErrOffline) || !errors.As(err, &retryErr) {
+		t.Fatalf("Expected ErrOffline, got %v", err)
+	}
+	if retryErr.Attempts != connectivityDialFailures {
+		t.Errorf("Expected %d attempts before going offline, got %d",
+			connectivityDialFailures, retryErr.Attempts)
+	}
+	if client.Online(context.Background()) {
+		t.Error("Expected the client offline")
+	}
+
+	// The dial that found the network down is not held against the host
+	client.hostHealth.mu.Lock()
+	defer client.hostHealth.mu.Unlock()
+	if n := client.hostHealth.hosts[addr].cur.attempts; n != connectivityDialFailures-1 {
+		t.Errorf("Expected %d attempts in host health, got %d", connectivityDialFailures-1, n)
+	}
+}
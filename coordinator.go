@@ -0,0 +1,201 @@
+package retry
+
+import (
+	"errors"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Coordinator tracks aggregate retry pressure per destination host across
+// every Client registered with it (via WithCoordinator). When the retries
+// against a host within a window reach the threshold, the host enters a
+// cooldown: retries from all registered clients are pushed out until the
+// cooldown ends (with a random spread), instead of a fleet of goroutines
+// retrying in lockstep against a dependency that is already failing hard.
+//
+// Initial attempts are never delayed; only retries are. A Coordinator is safe
+// for concurrent use and is typically created once per process.
+//
+// By default retries are delayed past the cooldown. CooldownFailFast and
+// CooldownFallback stop them instead, and OnCooldown reports each cooldown.
+type Coordinator struct {
+	threshold int
+	window    time.Duration
+	cooldown  time.Duration
+
+	failFast   bool                                            // Refuse retries during a cooldown
+	fallback   func(req *http.Request) (*http.Response, error) // Serve retries during a cooldown
+	onCooldown func(CooldownEvent)                             // Called when a cooldown starts
+
+	mu    sync.Mutex
+	hosts map[string]*hostPressure
+}
+
+// ErrHostCoolingDown is returned (as the RetryError's LastErr) when a
+// Coordinator with CooldownFailFast refuses a retry to a host in cooldown.
+var ErrHostCoolingDown = errors.New("retry: host is cooling down after a retry storm")
+
+// CooldownEvent describes a cooldown started by a Coordinator.
+type CooldownEvent struct {
+	Host     string        // Host put in cooldown
+	Retries  int           // Retries against the host within the window
+	Window   time.Duration // Window the retries were counted in
+	Duration time.Duration // Length of the cooldown
+}
+
+// CoordinatorOption configures a Coordinator.
+type CoordinatorOption func(*Coordinator)
+
+// CooldownFailFast makes the Coordinator refuse retries to a host in cooldown
+// instead of delaying them: the operation stops with a RetryError wrapping
+// ErrHostCoolingDown. First attempts are still sent.
+func CooldownFailFast() CoordinatorOption {
+	return func(co *Coordinator) {
+		co.failFast = true
+	}
+}
+
+// CooldownFallback makes the Coordinator answer retries to a host in cooldown
+// with fn (e.g. a cached or default response) instead of delaying them. fn
+// receives the original request; its result is returned to the caller.
+func CooldownFallback(fn func(req *http.Request) (*http.Response, error)) CoordinatorOption {
+	return func(co *Coordinator) {
+		co.fallback = fn
+	}
+}
+
+// OnCooldown calls fn whenever a host enters cooldown, e.g. to emit an event
+// or page someone about a misconfigured retry policy.
+func OnCooldown(fn func(CooldownEvent)) CoordinatorOption {
+	return func(co *Coordinator) {
+		co.onCooldown = fn
+	}
+}
+
+// hostPressure is the per-host state of a Coordinator.
+type hostPressure struct {
+	windowStart   time.Time
+	retries       int
+	cooldownUntil time.Time
+}
+
+// NewCoordinator creates a Coordinator that starts a cooldown of the given
+// duration for a host once threshold retries against it have been observed
+// within window. Non-positive arguments fall back to 100 retries, 10s and 30s.
+func NewCoordinator(
+	threshold int,
+	window, cooldown time.Duration,
+	opts ...CoordinatorOption,
+) *Coordinator {
+	if threshold <= 0 {
+		threshold = 100
+	}
+	if window <= 0 {
+		window = 10 * time.Second
+	}
+	if cooldown <= 0 {
+		cooldown = 30 * time.Second
+	}
+	co := &Coordinator{
+		threshold: threshold,
+		window:    window,
+		cooldown:  cooldown,
+		hosts:     make(map[string]*hostPressure),
+	}
+	for _, opt := range opts {
+		opt(co)
+	}
+	return co
+}
+
+// RecordRetry records a retry against host and reports whether it started a cooldown.
+func (co *Coordinator) RecordRetry(host string) bool {
+	if !co.recordRetry(host) {
+		return false
+	}
+	if co.onCooldown != nil {
+		co.onCooldown(CooldownEvent{
+			Host:     host,
+			Retries:  co.threshold,
+			Window:   co.window,
+			Duration: co.cooldown,
+		})
+	}
+	return true
+}
+
+// recordRetry counts a retry against host and starts a cooldown once the
+// threshold is reached.
+func (co *Coordinator) recordRetry(host string) bool {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	now := time.Now()
+	hp, ok := co.hosts[host]
+	if !ok {
+		hp = &hostPressure{windowStart: now}
+		co.hosts[host] = hp
+	}
+	if now.Sub(hp.windowStart) >= co.window {
+		hp.windowStart = now
+		hp.retries = 0
+	}
+	hp.retries++
+
+	if hp.retries < co.threshold || now.Before(hp.cooldownUntil) {
+		return false
+	}
+	hp.cooldownUntil = now.Add(co.cooldown)
+	hp.windowStart = now
+	hp.retries = 0
+	return true
+}
+
+// CooldownRemaining returns how long host remains in cooldown (0 if it is not).
+func (co *Coordinator) CooldownRemaining(host string) time.Duration {
+	co.mu.Lock()
+	defer co.mu.Unlock()
+
+	hp, ok := co.hosts[host]
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(hp.cooldownUntil)
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// stopsRetries reports whether retries to host are refused or served by the
+// fallback rather than delayed, because host is in cooldown.
+func (co *Coordinator) stopsRetries(host string) bool {
+	return co != nil && (co.failFast || co.fallback != nil) && co.CooldownRemaining(host) > 0
+}
+
+// coordinateDelay records a retry against host with the coordinator and
+// returns delay, extended to outlast any active cooldown for that host. The
+// extension is spread by up to 25% so cooled-down retries don't resume in
+// lockstep. A coordinator that stops retries during a cooldown (see
+// stopsRetries) keeps delay as it is.
+func (c *Client) coordinateDelay(host string, delay time.Duration) time.Duration {
+	if c.coordinator == nil {
+		return delay
+	}
+
+	if c.coordinator.RecordRetry(host) && c.loggerEnabled {
+		c.logger.Warn("retry pressure threshold reached, cooling down host",
+			"host", host,
+			"cooldown_ms", c.coordinator.cooldown.Milliseconds(),
+		)
+	}
+
+	remaining := c.coordinator.CooldownRemaining(host)
+	if remaining <= delay || c.coordinator.failFast || c.coordinator.fallback != nil {
+		return delay
+	}
+	// #nosec G404 - Cryptographic randomness not required for jitter
+	return remaining + time.Duration(rand.Int63n(int64(remaining/4)+1))
+}
@@ -0,0 +1,174 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCoordinator_RecordRetry(t *testing.T) {
+	co := NewCoordinator(3, time.Minute, time.Hour)
+
+	if co.RecordRetry("a") || co.RecordRetry("a") {
+		t.Fatal("Cooldown must not start below threshold")
+	}
+	if co.RecordRetry("b") {
+		t.Fatal("Hosts must be tracked independently")
+	}
+	if !co.RecordRetry("a") {
+		t.Fatal("Expected third retry to start a cooldown")
+	}
+	if co.CooldownRemaining("a") <= 0 {
+		t.Error("Expected host a to be cooling down")
+	}
+	if co.CooldownRemaining("b") != 0 {
+		t.Error("Expected host b not to be cooling down")
+	}
+	if co.CooldownRemaining("unknown") != 0 {
+		t.Error("Expected unknown host not to be cooling down")
+	}
+}
+
+func TestCoordinator_WindowExpires(t *testing.T) {
+	co := NewCoordinator(2, 20*time.Millisecond, time.Hour)
+
+	co.RecordRetry("a")
+	time.Sleep(30 * time.Millisecond)
+	if co.RecordRetry("a") {
+		t.Error("Retries from an expired window must not count toward the threshold")
+	}
+}
+
+func TestNewCoordinator_Defaults(t *testing.T) {
+	co := NewCoordinator(0, 0, 0)
+	if co.threshold != 100 || co.window != 10*time.Second || co.cooldown != 30*time.Second {
+		t.Errorf("Unexpected defaults: %d %v %v", co.threshold, co.window, co.cooldown)
+	}
+}
+
+func TestWithCoordinator_DelaysRetriesDuringCooldown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	co := NewCoordinator(1, time.Minute, 80*time.Millisecond)
+	var delays []time.Duration
+	client, err := NewClient(
+		WithMaxRetries(1),
+		WithInitialRetryDelay(time.Millisecond),
+		WithJitter(false),
+		WithCoordinator(co),
+		WithOnRetry(func(info RetryInfo) { delays = append(delays, info.Delay) }),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+	resp.Body.Close()
+
+	if len(delays) != 1 {
+		t.Fatalf("Expected 1 retry, got %d", len(delays))
+	}
+	// Threshold 1: the first retry starts the cooldown and must outlast it
+	if delays[0] < 70*time.Millisecond {
+		t.Errorf("Expected retry delay extended to cooldown, got %v", delays[0])
+	}
+}
+
+func TestWithCoordinator_NoCooldownKeepsDelay(t *testing.T) {
+	client, err := NewClient(WithCoordinator(NewCoordinator(10, time.Minute, time.Hour)))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if got := client.coordinateDelay("a", 5*time.Millisecond); got != 5*time.Millisecond {
+		t.Errorf("Expected delay unchanged below threshold, got %v", got)
+	}
+}
+
+func TestWithCoordinator_FailFastDuringCooldown(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	var events []CooldownEvent
+	co := NewCoordinator(1, time.Minute, time.Hour,
+		CooldownFailFast(),
+		OnCooldown(func(e CooldownEvent) { events = append(events, e) }),
+	)
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithCoordinator(co),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrHostCoolingDown) {
+		t.Fatalf("Expected ErrHostCoolingDown, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the retry refused immediately, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected only the first attempt to be sent, got %d requests", got)
+	}
+
+	host := strings.TrimPrefix(server.URL, "http://")
+	if len(events) != 1 || events[0].Host != host || events[0].Duration != time.Hour {
+		t.Errorf("Expected one cooldown event for %s, got %+v", host, events)
+	}
+}
+
+func TestWithCoordinator_FallbackDuringCooldown(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	co := NewCoordinator(1, time.Minute, time.Hour,
+		CooldownFallback(func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"X-Fallback": {"true"}},
+				Body:       http.NoBody,
+				Request:    req,
+			}, nil
+		}),
+	)
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithCoordinator(co),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Expected the fallback response, got error %v", err)
+	}
+	resp.Body.Close()
+	if resp.Header.Get("X-Fallback") != "true" {
+		t.Errorf("Expected the fallback response, got status %d", resp.StatusCode)
+	}
+}
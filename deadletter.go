@@ -0,0 +1,92 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// DeadLetter is a delivery that a Deliverer or Relay gave up on, either
+// because the receiver rejected it or because it ran out of deliveries
+// (WithMaxDeliveries).
+type DeadLetter struct {
+	Message    RetryState // Full request snapshot, including headers and body
+	Err        error      // Last error, usually a *RetryError
+	StatusCode int        // Status of the last response (0 if there was none)
+	Deliveries int        // Times the request was handed to the client
+	Time       time.Time  // When the delivery was given up
+}
+
+// DeadLetterSink receives the deliveries a Deliverer or Relay gives up on, so
+// failures are never silently dropped. Until DeadLetter succeeds, the delivery
+// stays queued and is handed to the sink again after its next redelivery.
+type DeadLetterSink interface {
+	DeadLetter(ctx context.Context, letter DeadLetter) error
+}
+
+// DeadLetterFunc adapts a function to a DeadLetterSink, e.g. to publish dead
+// letters to Kafka or SQS.
+type DeadLetterFunc func(ctx context.Context, letter DeadLetter) error
+
+// DeadLetter calls f.
+func (f DeadLetterFunc) DeadLetter(ctx context.Context, letter DeadLetter) error {
+	return f(ctx, letter)
+}
+
+// FileDeadLetterSink appends dead letters to a file as JSON Lines, one object
+// per letter with the fields of DeadLetter and Err as a string.
+type FileDeadLetterSink struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// NewFileDeadLetterSink opens (or creates) path for appending dead letters.
+// Close the sink when done.
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("retry: open dead-letter file: %w", err)
+	}
+	return &FileDeadLetterSink{file: file}, nil
+}
+
+// deadLetterRecord is the JSON form of a DeadLetter.
+type deadLetterRecord struct {
+	Message    RetryState `json:"message"`
+	Error      string     `json:"error"`
+	StatusCode int        `json:"status_code,omitempty"`
+	Deliveries int        `json:"deliveries"`
+	Time       time.Time  `json:"time"`
+}
+
+// DeadLetter appends letter to the file and syncs it to disk.
+func (s *FileDeadLetterSink) DeadLetter(_ context.Context, letter DeadLetter) error {
+	record := deadLetterRecord{
+		Message:    letter.Message,
+		StatusCode: letter.StatusCode,
+		Deliveries: letter.Deliveries,
+		Time:       letter.Time,
+	}
+	if letter.Err != nil {
+		record.Error = letter.Err.Error()
+	}
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Close closes the file.
+func (s *FileDeadLetterSink) Close() error {
+	return s.file.Close()
+}
@@ -0,0 +1,171 @@
+package retry
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDeliverer_DeadLetterAfterMaxDeliveries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithMaxRetries(0))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	store, err := NewFileStateStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStateStore() error = %v", err)
+	}
+
+	letters := make(chan DeadLetter, 1)
+	receipts := newReceiptRecorder()
+	deliverer := NewDeliverer(client, store,
+		WithReceipts(receipts.record),
+		WithRedeliveryBackoff(5*time.Millisecond, 5*time.Millisecond),
+		WithMaxDeliveries(2),
+		WithDeadLetterSink(DeadLetterFunc(func(_ context.Context, letter DeadLetter) error {
+			letters <- letter
+			return nil
+		})),
+	)
+
+	id, err := deliverer.Deliver(context.Background(), http.MethodPost, server.URL,
+		WithBody("text/plain", strings.NewReader("event")))
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	receipt := receipts.wait(t)
+	if receipt.Delivered() || receipt.Deliveries != 2 {
+		t.Errorf("receipt = %+v, want given up after 2 deliveries", receipt)
+	}
+
+	letter := <-letters
+	var retryErr *RetryError
+	if !errors.As(letter.Err, &retryErr) || retryErr.LastStatus != http.StatusServiceUnavailable {
+		t.Errorf("dead letter error = %v, want a RetryError with status 503", letter.Err)
+	}
+	if letter.Message.ID != id || string(letter.Message.Body) != "event" {
+		t.Errorf("dead letter message = %+v, want the request snapshot", letter.Message)
+	}
+	if letter.Deliveries != 2 || letter.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("dead letter = %d deliveries, status %d; want 2, 503",
+			letter.Deliveries, letter.StatusCode)
+	}
+	if states, _ := store.List(context.Background()); len(states) != 0 {
+		t.Errorf("store = %+v, want empty after dead-lettering", states)
+	}
+}
+
+func TestRelay_DeadLetterSinkFailureKeepsMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	outbox := newMemoryOutbox()
+	msg, err := NewOutboxMessage(http.MethodPost, server.URL)
+	if err != nil {
+		t.Fatalf("NewOutboxMessage() error = %v", err)
+	}
+	tx := &memoryTx{}
+	_ = outbox.Enqueue(context.Background(), tx, msg)
+	outbox.commit(tx)
+
+	client, err := NewClient(WithMaxRetries(0))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	var mu sync.Mutex
+	calls := 0
+	receipts := newReceiptRecorder()
+	relay := NewRelay(client, outbox, 5*time.Millisecond,
+		WithReceipts(receipts.record),
+		WithRedeliveryBackoff(5*time.Millisecond, 5*time.Millisecond),
+		WithDeadLetterSink(DeadLetterFunc(func(context.Context, DeadLetter) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls++
+			if calls == 1 {
+				return errors.New("sink unavailable")
+			}
+			return nil
+		})),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() { _ = relay.Run(ctx) }()
+
+	receipt := receipts.wait(t)
+	if receipt.Delivered() || receipt.Deliveries != 2 {
+		t.Errorf("receipt = %+v, want rejected on the 2nd delivery", receipt)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if calls != 2 {
+		t.Errorf("sink called %d times, want 2 (the first call failed)", calls)
+	}
+	if n := outbox.len(); n != 0 {
+		t.Errorf("outbox holds %d messages, want 0", n)
+	}
+}
+
+func TestFileDeadLetterSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	sink, err := NewFileDeadLetterSink(path)
+	if err != nil {
+		t.Fatalf("NewFileDeadLetterSink() error = %v", err)
+	}
+
+	for _, id := range []string{"a", "b"} {
+		err := sink.DeadLetter(context.Background(), DeadLetter{
+			Message:    RetryState{ID: id, Method: http.MethodPost, Body: []byte("{}")},
+			Err:        &RetryError{Attempts: 3, LastStatus: http.StatusBadGateway},
+			StatusCode: http.StatusBadGateway,
+			Deliveries: 1,
+			Time:       time.Now(),
+		})
+		if err != nil {
+			t.Fatalf("DeadLetter() error = %v", err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer file.Close()
+
+	var ids []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record deadLetterRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line %q is not JSON: %v", scanner.Text(), err)
+		}
+		if record.Error == "" || record.StatusCode != http.StatusBadGateway {
+			t.Errorf("record = %+v, want the error and status", record)
+		}
+		ids = append(ids, record.Message.ID)
+	}
+	if strings.Join(ids, ",") != "a,b" {
+		t.Errorf("ids = %v, want [a b]", ids)
+	}
+}
@@ -0,0 +1,110 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ErrUnsupportedContentType is returned by DecodeResponse and DoAndDecode
+// when no decoder is known for the response's Content-Type.
+var ErrUnsupportedContentType = errors.New("retry: unsupported content type")
+
+// BodyDecoder decodes a response body into v.
+type BodyDecoder func(r io.Reader, v any) error
+
+// decodeJSON is the built-in BodyDecoder for JSON.
+func decodeJSON(r io.Reader, v any) error {
+	return json.NewDecoder(r).Decode(v)
+}
+
+// decodeXML is the built-in BodyDecoder for XML.
+func decodeXML(r io.Reader, v any) error {
+	return xml.NewDecoder(r).Decode(v)
+}
+
+// decoderFor returns the decoder for a Content-Type header value.
+func (c *Client) decoderFor(contentType string) (BodyDecoder, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, contentType)
+	}
+	if decoder, ok := c.decoders[mediaType]; ok {
+		return decoder, nil
+	}
+
+	switch {
+	case mediaType == "application/json" || mediaType == "text/json" ||
+		strings.HasSuffix(mediaType, "+json"):
+		return decodeJSON, nil
+	case mediaType == "application/xml" || mediaType == "text/xml" ||
+		strings.HasSuffix(mediaType, "+xml"):
+		return decodeXML, nil
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentType, mediaType)
+}
+
+// DecodeResponse decodes resp's body into v with the decoder for its
+// Content-Type (see WithDecoder). An empty body (204 No Content, 304 Not
+// Modified, Content-Length: 0) leaves v untouched. The caller still closes
+// the body.
+func (c *Client) DecodeResponse(resp *http.Response, v any) error {
+	if resp.StatusCode == http.StatusNoContent || resp.StatusCode == http.StatusNotModified ||
+		resp.ContentLength == 0 {
+		return nil
+	}
+	decoder, err := c.decoderFor(resp.Header.Get("Content-Type"))
+	if err != nil {
+		return err
+	}
+	if err := decoder(resp.Body, v); err != nil {
+		return fmt.Errorf("retry: decode response: %w", err)
+	}
+	return nil
+}
+
+// DoAndDecode sends a request with the client's retry behavior and decodes a
+// successful (2xx) response into v by its Content-Type, so one call works
+// against APIs that answer in several formats. Combine it with WithAccept to
+// negotiate the format. Other statuses are returned as an *APIError (see
+// DecodeAPIError) unless the request failed with a RetryError first.
+//
+// Bodies are read within the retry loop, as with WithBufferResponseBody, so a
+// body cut short (ErrTruncatedResponse) is retried rather than decoded. The
+// returned response, for its status and headers, has its body read and
+// closed already.
+//
+// Example:
+//
+//	var user User
+//	_, err := client.DoAndDecode(ctx, http.MethodGet, "https://api.example.com/users/1", &user,
+//	    retry.WithAccept("application/msgpack", "application/json;q=0.9"))
+func (c *Client) DoAndDecode(
+	ctx context.Context,
+	method string,
+	url string,
+	v any,
+	opts ...RequestOption,
+) (*http.Response, error) {
+	resp, err := c.doRequest(ctx, method, url, append(slices.Clip(opts), withBufferedBody)...)
+	if resp == nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	defer func() { _, _ = io.Copy(io.Discard, resp.Body) }()
+
+	switch {
+	case err != nil:
+		return resp, err
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		return resp, DecodeAPIError(resp)
+	}
+	return resp, c.DecodeResponse(resp, v)
+}
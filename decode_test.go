@@ -0,0 +1,138 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type decodedUser struct {
+	Name string `json:"name" xml:"name"`
+}
+
+// negotiatingServer answers /user in the format preferred by the Accept header.
+func negotiatingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch accept := r.Header.Get("Accept"); {
+		case r.URL.Path == "/missing":
+			w.Header().Set("Content-Type", "application/problem+json")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `{"code":"not_found","message":"no such user"}`)
+		case strings.HasPrefix(accept, "text/csv"):
+			w.Header().Set("Content-Type", "text/csv")
+			_, _ = io.WriteString(w, "name\nxml")
+		case strings.HasPrefix(accept, "application/xml"):
+			w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+			_, _ = io.WriteString(w, `<user><name>xml</name></user>`)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"name":"json"}`)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDoAndDecode_ByContentType(t *testing.T) {
+	server := negotiatingServer(t)
+	client, err := NewClient(WithDecoder("text/csv", func(r io.Reader, v any) error {
+		data, err := io.ReadAll(r)
+		v.(*decodedUser).Name = "csv:" + strings.Split(string(data), "\n")[1]
+		return err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for accept, want := range map[string]string{
+		"application/json": "json",
+		"application/xml":  "xml",
+		"text/csv":         "csv:xml",
+	} {
+		var user decodedUser
+		resp, err := client.DoAndDecode(context.Background(), http.MethodGet, server.URL+"/user",
+			&user, WithAccept(accept, "application/json;q=0.5"))
+		if err != nil {
+			t.Fatalf("%s: %v", accept, err)
+		}
+		if user.Name != want || resp.StatusCode != http.StatusOK {
+			t.Errorf("%s: got %q (status %d), want %q", accept, user.Name, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestDoAndDecode_Errors(t *testing.T) {
+	server := negotiatingServer(t)
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var user decodedUser
+	_, err = client.DoAndDecode(context.Background(), http.MethodGet, server.URL+"/user",
+		&user, WithAccept("text/csv"))
+	if !errors.Is(err, ErrUnsupportedContentType) {
+		t.Errorf("Expected ErrUnsupportedContentType, got %v", err)
+	}
+
+	resp, err := client.DoAndDecode(context.Background(), http.MethodGet, server.URL+"/missing", &user)
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != "not_found" ||
+		resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a decoded APIError, got %v", err)
+	}
+}
+
+func TestDoAndDecode_RetriesTruncatedChunkedBody(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) > 1 {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = io.WriteString(w, `{"name":"complete"}`)
+			return
+		}
+		// Send one chunk, then drop the connection before the terminating chunk
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Errorf("Hijack failed: %v", err)
+			return
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Type: application/json\r\n" +
+			"Transfer-Encoding: chunked\r\n\r\n9\r\n{\"name\":\"\r\n")
+		_ = buf.Flush()
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithInitialRetryDelay(time.Millisecond), WithNoLogging())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var user decodedUser
+	if _, err := client.DoAndDecode(context.Background(), http.MethodGet, server.URL, &user); err != nil {
+		t.Fatalf("Expected the truncated body to be retried, got %v", err)
+	}
+	if user.Name != "complete" || attempts.Load() != 2 {
+		t.Errorf("Expected the second attempt decoded, got %+v after %d attempts", user, attempts.Load())
+	}
+}
+
+func TestDecodeResponse_EmptyBody(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Header: http.Header{}}
+	user := decodedUser{Name: "unchanged"}
+	if err := client.DecodeResponse(resp, &user); err != nil || user.Name != "unchanged" {
+		t.Errorf("Expected an empty body to leave v untouched, got %v, %q", err, user.Name)
+	}
+}
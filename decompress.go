@@ -0,0 +1,122 @@
+package retry
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// ErrDecompressionLimit is returned (wrapped) when reading a gzip-encoded
+// response body exceeds the WithDecompressionLimit size or ratio. It is never
+// retried, since the server would send the same body again.
+var ErrDecompressionLimit = errors.New("retry: decompressed response body too large")
+
+// decompressionRatioFloor is the decompressed size below which the ratio
+// limit is not enforced: small, repetitive bodies compress extremely well.
+const decompressionRatioFloor = 1 << 20 // 1 MiB
+
+// decompressionLimit bounds transparently decompressed response bodies.
+type decompressionLimit struct {
+	maxBytes int64   // Max decompressed bytes (0 = unlimited)
+	maxRatio float64 // Max decompressed/compressed ratio (0 = unlimited)
+}
+
+// check reports an error once decompressed bytes, produced from compressed
+// bytes, break the limit.
+func (l *decompressionLimit) check(decompressed, compressed int64) error {
+	if l.maxBytes > 0 && decompressed > l.maxBytes {
+		return Permanent(fmt.Errorf("%w: more than %d bytes", ErrDecompressionLimit, l.maxBytes))
+	}
+	if l.maxRatio > 0 && decompressed > decompressionRatioFloor &&
+		float64(decompressed) > l.maxRatio*float64(max(compressed, 1)) {
+		return Permanent(fmt.Errorf("%w: compression ratio above %g",
+			ErrDecompressionLimit, l.maxRatio))
+	}
+	return nil
+}
+
+// requestGzip asks for a gzip-encoded response on behalf of the transport, so
+// that the client decompresses it under the limit instead of the transport
+// decompressing it unchecked. Like the transport, it leaves requests that set
+// their own Accept-Encoding, Range requests and HEAD requests alone.
+func (c *Client) requestGzip(req *http.Request) bool {
+	if c.decompressionLimit == nil || req.Method == http.MethodHead ||
+		req.Header.Get("Accept-Encoding") != "" || req.Header.Get("Range") != "" {
+		return false
+	}
+	req.Header.Set("Accept-Encoding", "gzip")
+	return true
+}
+
+// decompressResponse replaces a gzip-encoded body with a limited, decompressed
+// one, adjusting the headers as the transport would.
+func (c *Client) decompressResponse(resp *http.Response) {
+	if resp == nil || !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") ||
+		resp.Body == nil || resp.Body == http.NoBody {
+		return
+	}
+	resp.Body = &limitedGzipBody{
+		body:       resp.Body,
+		compressed: &countingReader{r: resp.Body},
+		limit:      c.decompressionLimit,
+	}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+// limitedGzipBody decompresses a gzip body lazily, failing once the output
+// breaks the decompression limit.
+type limitedGzipBody struct {
+	body       io.ReadCloser
+	compressed *countingReader
+	zr         *gzip.Reader
+	limit      *decompressionLimit
+	n          int64 // Decompressed bytes returned so far
+	err        error // Sticky error
+}
+
+func (b *limitedGzipBody) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	if b.zr == nil {
+		zr, err := gzip.NewReader(b.compressed)
+		if err != nil {
+			b.err = err
+			return 0, err
+		}
+		b.zr = zr
+	}
+
+	n, err := b.zr.Read(p)
+	b.n += int64(n)
+	if limitErr := b.limit.check(b.n, b.compressed.n); limitErr != nil {
+		b.err = limitErr
+		return n, limitErr
+	}
+	if err != nil {
+		b.err = err
+	}
+	return n, err
+}
+
+func (b *limitedGzipBody) Close() error {
+	return b.body.Close()
+}
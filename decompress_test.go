@@ -0,0 +1,134 @@
+package retry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newGzipServer serves body gzip-encoded to clients that accept gzip.
+func newGzipServer(t *testing.T, body []byte, requests *int32) *httptest.Server {
+	t.Helper()
+	var compressed bytes.Buffer
+	zw := gzip.NewWriter(&compressed)
+	_, _ = zw.Write(body)
+	_ = zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(requests, 1)
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			_, _ = w.Write(body)
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		_, _ = w.Write(compressed.Bytes())
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestWithDecompressionLimit(t *testing.T) {
+	small := []byte("hello, world")
+	bomb := make([]byte, 8<<20) // 8 MiB of zeros compresses ~1000:1
+
+	tests := []struct {
+		name     string
+		body     []byte
+		maxBytes int64
+		maxRatio float64
+		wantErr  bool
+	}{
+		{"within limits", small, 1 << 20, 100, false},
+		{"size limit", bomb, 1 << 20, 0, true},
+		{"ratio limit", bomb, 0, 100, true},
+		{"ratio below floor", bytes.Repeat([]byte("a"), 512<<10), 0, 10, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			server := newGzipServer(t, tt.body, &requests)
+
+			client, err := NewClient(WithDecompressionLimit(tt.maxBytes, tt.maxRatio), WithNoLogging())
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp, err := client.Get(context.Background(), server.URL)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			defer resp.Body.Close()
+			if !resp.Uncompressed || resp.Header.Get("Content-Encoding") != "" {
+				t.Error("Expected a transparently decompressed response")
+			}
+
+			data, err := io.ReadAll(resp.Body)
+			if tt.wantErr {
+				if !errors.Is(err, ErrDecompressionLimit) {
+					t.Errorf("Expected ErrDecompressionLimit, got %v", err)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(data, tt.body) {
+				t.Errorf("Expected the decompressed body, got %d bytes, err %v", len(data), err)
+			}
+		})
+	}
+}
+
+func TestWithDecompressionLimit_BufferedNotRetried(t *testing.T) {
+	var requests int32
+	server := newGzipServer(t, make([]byte, 8<<20), &requests)
+
+	client, err := NewClient(
+		WithDecompressionLimit(1<<20, 0),
+		WithBufferResponseBody(true),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrDecompressionLimit) {
+		t.Fatalf("Expected ErrDecompressionLimit, got %v", err)
+	}
+	if n := atomic.LoadInt32(&requests); n != 1 {
+		t.Errorf("Expected 1 request, got %d", n)
+	}
+}
+
+func TestWithDecompressionLimit_OwnAcceptEncoding(t *testing.T) {
+	var requests int32
+	server := newGzipServer(t, make([]byte, 8<<20), &requests)
+
+	client, err := NewClient(WithDecompressionLimit(1<<20, 0), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// A caller asking for gzip itself gets the compressed body untouched
+	resp, err := client.Get(context.Background(), server.URL, WithHeader("Accept-Encoding", "gzip"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "gzip" {
+		t.Error("Expected the compressed body to be passed through")
+	}
+	if _, err := io.ReadAll(resp.Body); err != nil {
+		t.Errorf("Unexpected read error: %v", err)
+	}
+}
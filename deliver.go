@@ -0,0 +1,365 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// IdempotencyKeyHeader carries the key Deliverer stamps on every delivery, so
+// the receiver can drop the duplicates at-least-once delivery implies.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// Default redelivery backoff of a Deliverer.
+const (
+	DefaultRedeliveryDelay    = time.Minute
+	DefaultMaxRedeliveryDelay = time.Hour
+)
+
+// Receipt reports the final outcome of a delivery.
+type Receipt struct {
+	ID         string // Idempotency key of the delivery
+	StatusCode int    // Status of the final response (0 if there was none)
+	Deliveries int    // Times the request was handed to the client, each with its retry policy
+	Err        error  // nil when delivered; otherwise why the delivery was given up
+}
+
+// Delivered reports whether the receiver accepted the request.
+func (r Receipt) Delivered() bool {
+	return r.Err == nil
+}
+
+// Deliverer sends requests with at-least-once semantics: each request is
+// saved to a StateStore before it is sent, tried right away with the client's
+// retry policy, and, if the retries run out on a transient failure, kept in
+// the store and redelivered with backoff until the receiver accepts it,
+// rejects it permanently, or WithMaxDeliveries runs out. A new process picks
+// up undelivered requests with Start.
+type Deliverer struct {
+	client *Client
+	store  StateStore
+	policy deliveryPolicy
+
+	mu       sync.Mutex
+	ctx      context.Context // Lifetime of scheduled redeliveries (Start)
+	parked   []RetryState    // Deliveries waiting for connectivity
+	watching bool            // A goroutine is waiting for connectivity
+}
+
+// DelivererOption configures a Deliverer or Relay.
+type DelivererOption func(*deliveryPolicy)
+
+// deliveryPolicy holds the settings shared by Deliverer and Relay.
+type deliveryPolicy struct {
+	onReceipt func(Receipt)
+	delay     time.Duration // First redelivery delay
+	maxDelay  time.Duration // Cap on the doubling redelivery delay
+
+	maxDeliveries int            // Give up after this many deliveries (0 = never)
+	deadLetters   DeadLetterSink // Receives failed deliveries (nil = none)
+}
+
+// newDeliveryPolicy returns the default policy with opts applied.
+func newDeliveryPolicy(opts []DelivererOption) deliveryPolicy {
+	p := deliveryPolicy{
+		onReceipt: func(Receipt) {},
+		delay:     DefaultRedeliveryDelay,
+		maxDelay:  DefaultMaxRedeliveryDelay,
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// nextDelivery returns when a request delivered deliveries times is due again.
+func (p deliveryPolicy) nextDelivery(deliveries int) time.Time {
+	delay := p.delay
+	for i := 1; i < deliveries && delay < p.maxDelay; i++ {
+		delay *= 2
+	}
+	return time.Now().Add(min(delay, p.maxDelay))
+}
+
+// drop decides whether a delivery leaves the queue after deliverOnce
+// returned receipt and final. A transient failure becomes final once the
+// delivery has used up WithMaxDeliveries. A failed final delivery is handed to
+// the dead-letter sink first, and stays queued if the sink fails.
+func (p deliveryPolicy) drop(
+	ctx context.Context,
+	logger Logger,
+	msg RetryState,
+	receipt Receipt,
+	final bool,
+) bool {
+	exhausted := p.maxDeliveries > 0 && msg.Attempt >= p.maxDeliveries
+	if !final && (ctx.Err() != nil || !exhausted) {
+		return false
+	}
+	if receipt.Err == nil || p.deadLetters == nil {
+		return true
+	}
+
+	letter := DeadLetter{
+		Message:    msg,
+		Err:        receipt.Err,
+		StatusCode: receipt.StatusCode,
+		Deliveries: receipt.Deliveries,
+		Time:       time.Now(),
+	}
+	if err := p.deadLetters.DeadLetter(context.WithoutCancel(ctx), letter); err != nil {
+		logger.Warn("failed to dead-letter delivery", "id", msg.ID, "error", err.Error())
+		return false
+	}
+	return true
+}
+
+// WithReceipts calls fn with the final outcome of every delivery: when the
+// receiver accepts it (2xx or 3xx), rejects it permanently (a status or error
+// the client does not retry), or it runs out of deliveries. fn runs on the
+// delivering goroutine.
+func WithReceipts(fn func(Receipt)) DelivererOption {
+	return func(p *deliveryPolicy) {
+		p.onReceipt = fn
+	}
+}
+
+// WithRedeliveryBackoff sets the delay before the first redelivery and the
+// cap it doubles up to on each later one. Non-positive values keep the
+// defaults (DefaultRedeliveryDelay, DefaultMaxRedeliveryDelay).
+func WithRedeliveryBackoff(delay, maxDelay time.Duration) DelivererOption {
+	return func(p *deliveryPolicy) {
+		if delay > 0 {
+			p.delay = delay
+		}
+		if maxDelay > 0 {
+			p.maxDelay = maxDelay
+		}
+	}
+}
+
+// WithMaxDeliveries gives up on a delivery after n deliveries (each with the
+// client's full retry policy) that failed transiently, reporting it like a
+// rejection. n <= 0, the default, redelivers until the receiver answers.
+func WithMaxDeliveries(n int) DelivererOption {
+	return func(p *deliveryPolicy) {
+		p.maxDeliveries = n
+	}
+}
+
+// WithDeadLetterSink hands every delivery given up on, rejected or out of
+// deliveries, to sink before it is removed from the queue.
+func WithDeadLetterSink(sink DeadLetterSink) DelivererOption {
+	return func(p *deliveryPolicy) {
+		p.deadLetters = sink
+	}
+}
+
+// NewDeliverer creates a Deliverer sending through client and queueing to
+// store. Give it a store of its own rather than the client's WithStateStore
+// store: Start redelivers everything the store holds.
+//
+// Example:
+//
+//	store, _ := retry.NewFileStateStore("/var/lib/myapp/outbound")
+//	deliverer := retry.NewDeliverer(client, store,
+//	    retry.WithReceipts(func(r retry.Receipt) {
+//	        log.Printf("delivery %s: delivered=%v", r.ID, r.Delivered())
+//	    }),
+//	)
+//	if err := deliverer.Start(ctx); err != nil { ... }
+//	id, err := deliverer.Deliver(ctx, http.MethodPost, url, retry.WithJSON(event))
+func NewDeliverer(client *Client, store StateStore, opts ...DelivererOption) *Deliverer {
+	return &Deliverer{
+		client: client,
+		store:  store,
+		policy: newDeliveryPolicy(opts),
+		ctx:    context.Background(),
+	}
+}
+
+// Start schedules redelivery of the requests a previous process left in the
+// store, and ties all scheduled redeliveries to ctx: once ctx is canceled,
+// nothing more is sent and undelivered requests stay in the store.
+func (d *Deliverer) Start(ctx context.Context) error {
+	d.mu.Lock()
+	d.ctx = ctx
+	d.mu.Unlock()
+
+	states, err := d.store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("retry: list queued deliveries: %w", err)
+	}
+	for _, state := range states {
+		d.scheduleRedelivery(state)
+	}
+	return nil
+}
+
+// lifetime returns the context scheduled redeliveries run with.
+func (d *Deliverer) lifetime() context.Context {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.ctx
+}
+
+// Deliver builds a request like the client's convenience methods do, stamps
+// it with an Idempotency-Key header (unless opts set one), saves it to the
+// store and sends it. It returns the idempotency key, which identifies the
+// delivery in its Receipt. Deliver waits for the first round of retries only:
+// by the time it returns the request has either reached a final outcome,
+// reported through WithReceipts, or been queued for redelivery. It returns an
+// error only when the request cannot be built or saved, in which case nothing
+// was sent.
+//
+// The body must be replayable (WithBody, WithJSON, ...), since it is stored
+// with the request.
+func (d *Deliverer) Deliver(
+	ctx context.Context,
+	method string,
+	url string,
+	opts ...RequestOption,
+) (string, error) {
+	req, err := d.client.newRequest(ctx, method, url, opts...)
+	if err != nil {
+		return "", err
+	}
+	state, err := newDeliveryState(req)
+	if err != nil {
+		return "", err
+	}
+	if err := d.store.Save(ctx, state); err != nil {
+		return "", fmt.Errorf("retry: queue delivery: %w", err)
+	}
+
+	d.deliver(ctx, state)
+	return state.ID, nil
+}
+
+// newDeliveryState captures req, with its body, as a delivery due now. The
+// request is stamped with an Idempotency-Key header unless it has one, and
+// the key becomes the delivery ID.
+func newDeliveryState(req *http.Request) (RetryState, error) {
+	id := req.Header.Get(IdempotencyKeyHeader)
+	if id == "" {
+		id = newRequestID()
+		req.Header.Set(IdempotencyKeyHeader, id)
+	}
+	body, err := requestBody(req)
+	if err != nil {
+		return RetryState{}, err
+	}
+	return RetryState{
+		ID:     id,
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+		Body:   body,
+		NextAt: time.Now(),
+	}, nil
+}
+
+// requestBody returns a copy of req's body, read through GetBody.
+func requestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody == nil {
+		return nil, errors.New("retry: delivery body cannot be replayed")
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// deliver sends state once with the client's retry policy and settles the
+// outcome: a receipt for a final one, a scheduled redelivery otherwise, or a
+// replay once the device is back online.
+func (d *Deliverer) deliver(ctx context.Context, state RetryState) {
+	state.Attempt++
+	receipt, final := d.client.deliverOnce(ctx, state)
+	switch {
+	case errors.Is(receipt.Err, ErrOffline):
+		d.park(state)
+	case d.policy.drop(ctx, d.client.logger, state, receipt, final):
+		d.settle(state, receipt)
+	default:
+		d.requeue(state)
+	}
+}
+
+// deliverOnce sends a delivery with the client's retry policy. final is false
+// when it should be delivered again: after a transient failure, or when ctx
+// ended the attempts early. receipt.Err is the last error either way.
+func (c *Client) deliverOnce(ctx context.Context, state RetryState) (receipt Receipt, final bool) {
+	ctx = context.WithValue(ctx, deliveringKey{}, true)
+	receipt = Receipt{ID: state.ID, Deliveries: state.Attempt}
+	req, err := c.newStateRequest(ctx, state)
+	if err != nil {
+		receipt.Err = err
+		return receipt, true
+	}
+
+	resp, err := c.doPrepared(ctx, req)
+	receipt.StatusCode, receipt.Err = statusCodeOf(resp), err
+	if resp != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+
+	// An error status the client would not retry is a rejection, unless the
+	// caller's context ended the attempts early
+	rejected := ctx.Err() == nil && resp != nil &&
+		resp.StatusCode >= http.StatusBadRequest && !c.isRetryable(nil, resp)
+
+	switch {
+	case err == nil && resp.StatusCode < http.StatusBadRequest:
+		return receipt, true
+	case isPermanent(err) || rejected:
+		if err == nil {
+			receipt.Err = fmt.Errorf("retry: delivery rejected with status %d", resp.StatusCode)
+		}
+		return receipt, true
+	default:
+		return receipt, false
+	}
+}
+
+// settle removes a delivery with a final outcome from the store and reports
+// it.
+func (d *Deliverer) settle(state RetryState, receipt Receipt) {
+	if err := d.store.Delete(context.WithoutCancel(d.lifetime()), state.ID); err != nil {
+		d.client.logger.Warn("failed to delete delivery", "id", state.ID, "error", err.Error())
+	}
+	d.policy.onReceipt(receipt)
+}
+
+// requeue saves state with the time of its next delivery and schedules it.
+func (d *Deliverer) requeue(state RetryState) {
+	ctx := d.lifetime()
+	if ctx.Err() != nil {
+		return // Shutting down; Start picks it up from the store
+	}
+
+	state.NextAt = d.policy.nextDelivery(state.Attempt)
+	if err := d.store.Save(ctx, state); err != nil {
+		// Still scheduled in this process; only a restart would lose it
+		d.client.logger.Warn("failed to save delivery", "id", state.ID, "error", err.Error())
+	}
+	d.scheduleRedelivery(state)
+}
+
+// scheduleRedelivery delivers state again once it is due.
+func (d *Deliverer) scheduleRedelivery(state RetryState) {
+	schedule(d.lifetime(), state.NextAt, func(ctx context.Context) (*http.Response, error) {
+		d.deliver(ctx, state)
+		return nil, nil
+	}, nil)
+}
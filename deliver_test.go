@@ -0,0 +1,184 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// receiptRecorder collects delivery receipts.
+type receiptRecorder struct {
+	mu       sync.Mutex
+	receipts []Receipt
+	done     chan struct{}
+}
+
+func newReceiptRecorder() *receiptRecorder {
+	return &receiptRecorder{done: make(chan struct{}, 10)}
+}
+
+func (r *receiptRecorder) record(receipt Receipt) {
+	r.mu.Lock()
+	r.receipts = append(r.receipts, receipt)
+	r.mu.Unlock()
+	r.done <- struct{}{}
+}
+
+func (r *receiptRecorder) wait(t *testing.T) Receipt {
+	t.Helper()
+	select {
+	case <-r.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("no receipt")
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.receipts[len(r.receipts)-1]
+}
+
+func newTestDeliverer(t *testing.T, receipts *receiptRecorder) (*Deliverer, StateStore) {
+	t.Helper()
+	client, err := NewClient(WithMaxRetries(0))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	store, err := NewFileStateStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStateStore() error = %v", err)
+	}
+	return NewDeliverer(client, store,
+		WithReceipts(receipts.record),
+		WithRedeliveryBackoff(10*time.Millisecond, 20*time.Millisecond),
+	), store
+}
+
+func TestDeliverer_FastPath(t *testing.T) {
+	var gotKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKey = r.Header.Get(IdempotencyKeyHeader)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	receipts := newReceiptRecorder()
+	deliverer, store := newTestDeliverer(t, receipts)
+
+	id, err := deliverer.Deliver(context.Background(), http.MethodPost, server.URL,
+		WithJSON(map[string]string{"event": "created"}))
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	receipt := receipts.wait(t)
+	if !receipt.Delivered() || receipt.ID != id || receipt.Deliveries != 1 {
+		t.Errorf("receipt = %+v, want delivered %s after 1 delivery", receipt, id)
+	}
+	if receipt.StatusCode != http.StatusAccepted {
+		t.Errorf("StatusCode = %d, want 202", receipt.StatusCode)
+	}
+	if gotKey != id {
+		t.Errorf("Idempotency-Key = %q, want %q", gotKey, id)
+	}
+	if states, _ := store.List(context.Background()); len(states) != 0 {
+		t.Errorf("store = %+v, want empty after delivery", states)
+	}
+}
+
+func TestDeliverer_Redelivery(t *testing.T) {
+	var requests int32
+	keys := make(chan string, 3)
+	bodies := make(chan string, 3)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		keys <- r.Header.Get(IdempotencyKeyHeader)
+		bodies <- string(body)
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	receipts := newReceiptRecorder()
+	deliverer, _ := newTestDeliverer(t, receipts)
+
+	id, err := deliverer.Deliver(context.Background(), http.MethodPost, server.URL,
+		WithJSON(map[string]string{"event": "created"}),
+		WithHeader(IdempotencyKeyHeader, "event-42"))
+	if err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	if id != "event-42" {
+		t.Errorf("id = %q, want the caller's key event-42", id)
+	}
+
+	receipt := receipts.wait(t)
+	if !receipt.Delivered() || receipt.Deliveries != 3 {
+		t.Errorf("receipt = %+v, want delivered after 3 deliveries", receipt)
+	}
+	for range 3 {
+		if key, body := <-keys, <-bodies; key != "event-42" || body != `{"event":"created"}` {
+			t.Errorf("delivery sent key %q, body %q; want the original request", key, body)
+		}
+	}
+}
+
+func TestDeliverer_Rejected(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	receipts := newReceiptRecorder()
+	deliverer, store := newTestDeliverer(t, receipts)
+
+	if _, err := deliverer.Deliver(context.Background(), http.MethodPost, server.URL); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+
+	receipt := receipts.wait(t)
+	if receipt.Delivered() || receipt.StatusCode != http.StatusBadRequest {
+		t.Errorf("receipt = %+v, want rejected with 400", receipt)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+	if states, _ := store.List(context.Background()); len(states) != 0 {
+		t.Errorf("store = %+v, want empty after rejection", states)
+	}
+}
+
+func TestDeliverer_Start(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	receipts := newReceiptRecorder()
+	deliverer, store := newTestDeliverer(t, receipts)
+
+	// Queued by a previous process after two deliveries
+	queued := RetryState{ID: "event-7", Method: http.MethodPost, URL: server.URL, Attempt: 2}
+	if err := store.Save(context.Background(), queued); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := deliverer.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	receipt := receipts.wait(t)
+	if !receipt.Delivered() || receipt.ID != "event-7" || receipt.Deliveries != 3 {
+		t.Errorf("receipt = %+v, want event-7 delivered on the 3rd delivery", receipt)
+	}
+}
@@ -0,0 +1,39 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+)
+
+// noEarlyDataKey marks attempt contexts that must not use TLS early data.
+type noEarlyDataKey struct{}
+
+// EarlyDataAllowed reports whether a request made with ctx may be sent as
+// TLS 1.3 early data (0-RTT). It returns false for every attempt that follows
+// a 425 Too Early response within the same logical request.
+//
+// net/http never sends early data, so this only matters for custom
+// 0-RTT-capable transports (or per-attempt middleware configuring them),
+// which should check it and wait for the full handshake when it is false:
+//
+//	if !retry.EarlyDataAllowed(req.Context()) {
+//	    // complete the handshake before sending the request
+//	}
+func EarlyDataAllowed(ctx context.Context) bool {
+	disallowed, _ := ctx.Value(noEarlyDataKey{}).(bool)
+	return !disallowed
+}
+
+// withoutEarlyData returns a context for which EarlyDataAllowed reports false.
+func withoutEarlyData(ctx context.Context) context.Context {
+	if !EarlyDataAllowed(ctx) {
+		return ctx
+	}
+	return context.WithValue(ctx, noEarlyDataKey{}, true)
+}
+
+// isTooEarly reports whether resp is a 425 Too Early: the server refused to
+// process a request that may have been replayed from TLS early data.
+func isTooEarly(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusTooEarly
+}
@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestEarlyDataAllowed(t *testing.T) {
+	ctx := context.Background()
+	if !EarlyDataAllowed(ctx) {
+		t.Error("Expected early data to be allowed by default")
+	}
+
+	ctx = withoutEarlyData(ctx)
+	if EarlyDataAllowed(ctx) {
+		t.Error("Expected early data to be disallowed")
+	}
+	if withoutEarlyData(ctx) != ctx {
+		t.Error("Expected withoutEarlyData to be idempotent")
+	}
+}
+
+func TestWithRetryOnTooEarly(t *testing.T) {
+	var earlyData []bool
+	attempts := 0
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		attempts++
+		earlyData = append(earlyData, EarlyDataAllowed(req.Context()))
+		status := http.StatusOK
+		if attempts == 1 {
+			status = http.StatusTooEarly
+		}
+		return &http.Response{StatusCode: status, Body: http.NoBody}, nil
+	})
+
+	var delays []time.Duration
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithInitialRetryDelay(time.Hour), // Must not be used for 425
+		WithRetryOnTooEarly(20*time.Millisecond),
+		WithOnRetry(func(info RetryInfo) { delays = append(delays, info.Delay) }),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 after retry, got %d", resp.StatusCode)
+	}
+	if len(delays) != 1 || delays[0] != 20*time.Millisecond {
+		t.Errorf("Expected one retry after 20ms, got %v", delays)
+	}
+	if len(earlyData) != 2 || !earlyData[0] || earlyData[1] {
+		t.Errorf("Expected early data allowed only on the first attempt, got %v", earlyData)
+	}
+}
+
+func TestWithRetryOnTooEarly_DefaultDelay(t *testing.T) {
+	client, err := NewClient(WithRetryOnTooEarly(0))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.tooEarlyDelay != defaultTooEarlyDelay {
+		t.Errorf("Expected default delay %v, got %v", defaultTooEarlyDelay, client.tooEarlyDelay)
+	}
+}
+
+func TestTooEarly_NotRetriedByDefault(t *testing.T) {
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.isRetryable(nil, &http.Response{StatusCode: http.StatusTooEarly}) {
+		t.Error("Expected 425 not to be retried without WithRetryOnTooEarly")
+	}
+}
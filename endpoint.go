@@ -0,0 +1,44 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidEndpoint is returned (marked Permanent) when a WithEndpointSelector
+// callback returns a base URL that cannot be parsed.
+var ErrInvalidEndpoint = errors.New("retry: invalid endpoint")
+
+// EndpointSelector chooses the host an attempt targets; see
+// WithEndpointSelector.
+type EndpointSelector func(attempt int, req *http.Request, lastErr error) string
+
+// selectEndpointHost points an attempt request at the host chosen by the
+// client's EndpointSelector, and reports whether it changed the host.
+func (c *Client) selectEndpointHost(attempt int, req *http.Request, lastErr error) (bool, error) {
+	if c.endpointSelector == nil {
+		return false, nil
+	}
+	target := c.endpointSelector(attempt, req, lastErr)
+	if target == "" {
+		return false, nil
+	}
+
+	scheme, host := req.URL.Scheme, target
+	if strings.Contains(target, "://") {
+		u, err := url.Parse(target)
+		if err != nil || u.Host == "" {
+			return false, Permanent(fmt.Errorf("%w: %q", ErrInvalidEndpoint, target))
+		}
+		scheme, host = u.Scheme, u.Host
+	}
+	if strings.EqualFold(host, req.URL.Host) && scheme == req.URL.Scheme {
+		return false, nil
+	}
+	req.URL.Scheme, req.URL.Host = scheme, host
+	req.Host = "" // Follow the URL
+	return true, nil
+}
@@ -0,0 +1,93 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWithEndpointSelector(t *testing.T) {
+	failing, failingHits := countingServer(t, http.StatusServiceUnavailable)
+	healthy, healthyHits := countingServer(t, http.StatusOK)
+	healthyURL, _ := url.Parse(healthy.URL)
+
+	var attempts []int
+	var lastStatuses []int
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithEndpointSelector(func(attempt int, req *http.Request, lastErr error) string {
+			attempts = append(attempts, attempt)
+			info, _ := AttemptInfoFromContext(req.Context())
+			lastStatuses = append(lastStatuses, info.LastStatus)
+			if attempt == 1 {
+				return "" // Keep the request's host
+			}
+			return healthyURL.Host
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), failing.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if failingHits.Load() != 1 || healthyHits.Load() != 1 {
+		t.Errorf("Expected 1 attempt on each host, got %d and %d",
+			failingHits.Load(), healthyHits.Load())
+	}
+	if len(attempts) != 2 || attempts[0] != 1 || attempts[1] != 2 {
+		t.Errorf("Expected the selector called for attempts 1 and 2, got %v", attempts)
+	}
+	if lastStatuses[1] != http.StatusServiceUnavailable {
+		t.Errorf("Expected the previous status in the attempt info, got %v", lastStatuses)
+	}
+}
+
+func TestWithEndpointSelector_BaseURL(t *testing.T) {
+	server, hits := countingServer(t, http.StatusOK)
+
+	client, err := NewClient(WithEndpointSelector(func(int, *http.Request, error) string {
+		return server.URL
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), "https://unreachable.invalid/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if hits.Load() != 1 {
+		t.Errorf("Expected the request sent to the selected base URL, got %d", hits.Load())
+	}
+}
+
+func TestWithEndpointSelector_Invalid(t *testing.T) {
+	calls := 0
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithEndpointSelector(func(int, *http.Request, error) string {
+			calls++
+			return "http://[::1"
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), "http://example.com")
+	if !errors.Is(err, ErrInvalidEndpoint) {
+		t.Errorf("Expected ErrInvalidEndpoint, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected the invalid endpoint not retried, got %d calls", calls)
+	}
+}
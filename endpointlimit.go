@@ -0,0 +1,74 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrInvalidRateLimit is returned by NewClient for an endpoint rate limit
+// with a non-positive rate or a malformed path pattern.
+var ErrInvalidRateLimit = errors.New("retry: invalid rate limit")
+
+// Rate is a request rate: N requests per Per, e.g. Rate{N: 10, Per: time.Second}.
+type Rate struct {
+	N   int
+	Per time.Duration
+}
+
+// endpointLimit is the token bucket of one WithEndpointRateLimits key.
+type endpointLimit struct {
+	key     string // Path pattern if it starts with "/", host otherwise
+	limiter *TokenBucketLimiter
+}
+
+// newEndpointLimits validates rates and creates their token buckets, in key
+// order.
+func newEndpointLimits(rates map[string]Rate) ([]endpointLimit, error) {
+	limits := make([]endpointLimit, 0, len(rates))
+	for _, key := range slices.Sorted(maps.Keys(rates)) {
+		rate := rates[key]
+		if rate.N <= 0 || rate.Per <= 0 {
+			return nil, fmt.Errorf("%w: %q: rate %d per %v", ErrInvalidRateLimit, key, rate.N, rate.Per)
+		}
+		if strings.HasPrefix(key, "/") {
+			if err := validateRoutePattern(key); err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrInvalidRateLimit, err)
+			}
+		} else if key == "" {
+			return nil, fmt.Errorf("%w: empty host", ErrInvalidRateLimit)
+		}
+		limits = append(limits, endpointLimit{key: key, limiter: NewTokenBucketLimiter(rate.N, rate.Per)})
+	}
+	return limits, nil
+}
+
+// matches reports whether req is sent to the limit's endpoint.
+func (l *endpointLimit) matches(req *http.Request) bool {
+	if strings.HasPrefix(l.key, "/") {
+		return matchRoute(l.key, req.URL.Path)
+	}
+	return strings.EqualFold(l.key, req.URL.Host) || strings.EqualFold(l.key, req.URL.Hostname())
+}
+
+// waitEndpointLimits blocks until every endpoint rate limit matching req
+// allows it, or ctx is done.
+func (c *Client) waitEndpointLimits(ctx context.Context, req *http.Request) error {
+	for i := range c.endpointLimits {
+		if !c.endpointLimits[i].matches(req) {
+			continue
+		}
+		done := c.admission.queue()
+		err := c.endpointLimits[i].limiter.Wait(ctx)
+		done()
+		if err != nil {
+			return fmt.Errorf("rate limit %s: %w", c.endpointLimits[i].key, err)
+		}
+	}
+	return nil
+}
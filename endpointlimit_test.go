@@ -0,0 +1,82 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestWithEndpointRateLimits(t *testing.T) {
+	server, _ := countingServer(t, http.StatusOK)
+	client, err := NewClient(WithEndpointRateLimits(map[string]Rate{
+		"/search/**": {N: 1, Per: time.Hour},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(ctx context.Context, p string) error {
+		resp, err := client.Get(ctx, server.URL+p)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	if err := get(context.Background(), "/search/a"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The bucket is empty: /search waits, other endpoints do not
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := get(ctx, "/search/b"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected /search to wait for its bucket, got %v", err)
+	}
+	for range 5 {
+		if err := get(context.Background(), "/orders"); err != nil {
+			t.Errorf("Expected /orders not to be limited, got %v", err)
+		}
+	}
+}
+
+func TestWithEndpointRateLimits_Host(t *testing.T) {
+	server, hits := countingServer(t, http.StatusServiceUnavailable)
+	host := mustParseURL(t, server.URL).Hostname()
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithEndpointRateLimits(map[string]Rate{host: {N: 2, Per: time.Hour}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Retries draw from the bucket too
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the third attempt to wait for the bucket, got %v", err)
+	}
+	if n := hits.Load(); n != 2 {
+		t.Errorf("Expected 2 attempts within the limit, got %d", n)
+	}
+}
+
+func TestWithEndpointRateLimits_Invalid(t *testing.T) {
+	for name, rates := range map[string]map[string]Rate{
+		"zero rate":   {"/a": {N: 0, Per: time.Second}},
+		"zero period": {"api.example.com": {N: 1}},
+		"bad pattern": {"/a/[": {N: 1, Per: time.Second}},
+		"empty host":  {"": {N: 1, Per: time.Second}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewClient(WithEndpointRateLimits(rates))
+			if !errors.Is(err, ErrInvalidRateLimit) {
+				t.Errorf("Expected ErrInvalidRateLimit, got %v", err)
+			}
+		})
+	}
+}
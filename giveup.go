@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"time"
+)
+
+// Reasons for stopping retries before they ran out, reported as
+// RetryError.GiveUpReason, in the "give_up_reason" log field and in the
+// "retry.give_up_reason" span attribute.
+const (
+	GiveUpBudgetExceeded    = "budget_exceeded"    // The operation used up WithLatencyBudget
+	GiveUpRetriesSuppressed = "retries_suppressed" // The host is unhealthy (WithRetrySuppression)
+	GiveUpDeadline          = "deadline"           // Deadline too close (WithDeadlineAwareBackoff)
+	GiveUpShed              = "shed"               // Low priority while overloaded (WithAdmissionControl)
+)
+
+// giveUpEarly returns why an operation for req that started at start should
+// not retry its failed attempt, which took lastAttempt, or "" to retry. When
+// retries are suppressed it also returns the host's success rate.
+func (c *Client) giveUpEarly(
+	ctx context.Context,
+	req *http.Request,
+	start time.Time,
+	lastAttempt time.Duration,
+) (string, float64) {
+	if c.latencyBudget > 0 && time.Since(start) >= c.latencyBudget {
+		return GiveUpBudgetExceeded, 0
+	}
+	if c.deadlineAware && timeLeft(ctx) < lastAttempt {
+		return GiveUpDeadline, 0
+	}
+	if skip, rate := c.hostHealth.suppresses(req.URL.Host, time.Now()); skip {
+		return GiveUpRetriesSuppressed, rate
+	}
+	if c.admission.shed(priorityOf(ctx, req)) != nil {
+		return GiveUpShed, 0
+	}
+	return "", 0
+}
+
+// timeLeft returns the time until ctx's deadline, or the maximum duration when
+// it has none.
+func timeLeft(ctx context.Context) time.Duration {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Until(deadline)
+}
+
+// fitDeadline shortens a retry delay so that the retry can finish before ctx's
+// deadline, assuming it takes as long as the last attempt: of the time left
+// beyond such an attempt, at most half is spent waiting, leaving the rest as a
+// margin (WithDeadlineAwareBackoff).
+func (c *Client) fitDeadline(ctx context.Context, delay, lastAttempt time.Duration) time.Duration {
+	if !c.deadlineAware {
+		return delay
+	}
+	return max(min(delay, (timeLeft(ctx)-lastAttempt)/2), 0)
+}
@@ -0,0 +1,154 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithLatencyBudget(t *testing.T) {
+	tests := []struct {
+		name         string
+		budget       time.Duration
+		wantAttempts int32
+		wantReason   string
+	}{
+		{"budget used by first attempt", 20 * time.Millisecond, 1, GiveUpBudgetExceeded},
+		{"budget used by second attempt", 50 * time.Millisecond, 2, GiveUpBudgetExceeded},
+		{"within budget", time.Minute, 4, ""},
+		{"disabled", 0, 4, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				time.Sleep(30 * time.Millisecond)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(
+				WithMaxRetries(3),
+				WithInitialRetryDelay(time.Millisecond),
+				WithJitter(false),
+				WithLatencyBudget(tt.budget),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := client.Get(context.Background(), server.URL)
+			var retryErr *RetryError
+			if !errors.As(err, &retryErr) {
+				t.Fatalf("Expected a RetryError, got %v", err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("Expected the failed response, got %d", resp.StatusCode)
+			}
+			if got := attempts.Load(); got != tt.wantAttempts {
+				t.Errorf("attempts = %d, want %d", got, tt.wantAttempts)
+			}
+			if retryErr.Attempts != int(tt.wantAttempts) {
+				t.Errorf("RetryError.Attempts = %d, want %d", retryErr.Attempts, tt.wantAttempts)
+			}
+			if retryErr.GiveUpReason != tt.wantReason {
+				t.Errorf("GiveUpReason = %q, want %q", retryErr.GiveUpReason, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestGiveUpReason_Logged(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	logger := &MockLogger{}
+	client, err := NewClient(WithLatencyBudget(time.Millisecond), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(context.Background(), server.URL)
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	resp.Body.Close()
+
+	for _, entry := range logger.ErrorLogs {
+		if entry.Message != "request failed after all retries" {
+			continue
+		}
+		for i := 0; i+1 < len(entry.Args); i += 2 {
+			if entry.Args[i] == "give_up_reason" && entry.Args[i+1] == GiveUpBudgetExceeded {
+				return
+			}
+		}
+	}
+	t.Error("Expected give_up_reason in the final log entry")
+}
+
+func TestWithDeadlineAwareBackoff(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	newClient := func(aware bool) *Client {
+		client, err := NewClient(
+			WithMaxRetries(5),
+			WithInitialRetryDelay(time.Second),
+			WithJitter(false),
+			WithDeadlineAwareBackoff(aware),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return client
+	}
+
+	// The 1s delay is shortened to 25ms so that the second attempt fits; the
+	// third does not, so the operation gives up instead of sleeping into the
+	// deadline
+	ctx, cancel := context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	resp, err := newClient(true).Get(ctx, server.URL)
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Expected a RetryError, got %v", err)
+	}
+	resp.Body.Close()
+	if retryErr.GiveUpReason != GiveUpDeadline {
+		t.Errorf("GiveUpReason = %q, want %q", retryErr.GiveUpReason, GiveUpDeadline)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Errorf("attempts = %d, want 2", got)
+	}
+	if elapsed := time.Since(start); elapsed >= 150*time.Millisecond {
+		t.Errorf("Expected to return before the deadline, took %v", elapsed)
+	}
+
+	// Without it, the client sleeps until the deadline cancels the delay
+	attempts.Store(0)
+	ctx, cancel = context.WithTimeout(context.Background(), 150*time.Millisecond)
+	defer cancel()
+	_, err = newClient(false).Get(ctx, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to end the operation, got %v", err)
+	}
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("attempts = %d, want 1", got)
+	}
+}
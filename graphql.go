@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+)
+
+// defaultGraphQLRetryCodes are the error extension codes GraphQLRetryableChecker
+// treats as transient when none are given.
+var defaultGraphQLRetryCodes = []string{"RATE_LIMITED", "INTERNAL", "INTERNAL_SERVER_ERROR"}
+
+// maxGraphQLPeekBytes caps how much of a response body GraphQLRetryableChecker
+// reads to find errors. GraphQL errors come with small payloads; larger bodies
+// are passed through without being inspected.
+const maxGraphQLPeekBytes = 1 << 20
+
+// graphQLResponse is the subset of a GraphQL response the checker inspects.
+type graphQLResponse struct {
+	Errors []struct {
+		Extensions struct {
+			Code     string `json:"code"`
+			Category string `json:"category"`
+		} `json:"extensions"`
+	} `json:"errors"`
+}
+
+// GraphQLRetryableChecker wraps next so that 2xx GraphQL responses carrying an
+// error whose extensions.code (or extensions.category) matches one of codes
+// are retried. GraphQL servers report most failures with a 200 status, which
+// status-based checkers cannot see. If no codes are given, RATE_LIMITED,
+// INTERNAL and INTERNAL_SERVER_ERROR are used. Everything else is decided by next.
+//
+// The checker reads up to 1 MiB of the response body and replaces it with an
+// in-memory copy, so the caller can still read it. A body that cannot be read
+// in full is handed to next as the error (wrapping ErrTruncatedResponse when it
+// was cut short), which DefaultRetryableChecker retries:
+//
+//	client, _ := retry.NewClient(
+//	    retry.WithRetryableChecker(retry.GraphQLRetryableChecker(retry.DefaultRetryableChecker)),
+//	)
+func GraphQLRetryableChecker(next RetryableChecker, codes ...string) RetryableChecker {
+	if len(codes) == 0 {
+		codes = defaultGraphQLRetryCodes
+	}
+	retryable := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		retryable[code] = true
+	}
+
+	return func(err error, resp *http.Response) bool {
+		if err == nil && resp != nil && resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			parsed, readErr := peekGraphQLErrors(resp)
+			if readErr != nil {
+				return next(readErr, nil)
+			}
+			for _, e := range parsed.Errors {
+				if retryable[e.Extensions.Code] || retryable[e.Extensions.Category] {
+					return true
+				}
+			}
+		}
+		return next(err, resp)
+	}
+}
+
+// peekGraphQLErrors decodes the errors of a GraphQL response, leaving resp.Body
+// readable from the start. Bodies that are not GraphQL JSON, or larger than
+// maxGraphQLPeekBytes, yield no errors. When the body cannot be read in full,
+// the read error is returned, mapped to ErrTruncatedResponse for a body cut
+// short, and reading resp.Body fails with it after the bytes that were read.
+func peekGraphQLErrors(resp *http.Response) (graphQLResponse, error) {
+	var parsed graphQLResponse
+	if resp.Body == nil || resp.Body == http.NoBody {
+		return parsed, nil
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxGraphQLPeekBytes))
+	if err == nil && len(data) == maxGraphQLPeekBytes {
+		// Too large to inspect; the rest of the body is left unread
+		resp.Body = &peekedBody{
+			Reader: io.MultiReader(bytes.NewReader(data), resp.Body),
+			Closer: resp.Body,
+		}
+		return parsed, nil
+	}
+	resp.Body.Close()
+	if err := bodyReadError(err, len(data), resp.ContentLength); err != nil {
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(data), &failedBody{err: err}))
+		return parsed, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	_ = json.Unmarshal(data, &parsed)
+	return parsed, nil
+}
@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGraphQLRetryableChecker_RetriesTransientErrors(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"slow down","extensions":{"code":"RATE_LIMITED"}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithRetryableChecker(GraphQLRetryableChecker(DefaultRetryableChecker)),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Post(context.Background(), server.URL,
+		WithBody("application/json", strings.NewReader(`{"query":"{ ok }"}`)))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if string(body) != `{"data":{"ok":true}}` {
+		t.Errorf("Expected body to remain readable, got %q", body)
+	}
+}
+
+func TestGraphQLRetryableChecker(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		body     string
+		codes    []string
+		expected bool
+	}{
+		{"data only", http.StatusOK, `{"data":{}}`, nil, false},
+		{"rate limited", http.StatusOK, `{"errors":[{"extensions":{"code":"RATE_LIMITED"}}]}`, nil, true},
+		{"internal", http.StatusOK, `{"errors":[{"extensions":{"code":"INTERNAL"}}]}`, nil, true},
+		{"category", http.StatusOK, `{"errors":[{"extensions":{"category":"INTERNAL"}}]}`, nil, true},
+		{"validation", http.StatusOK, `{"errors":[{"extensions":{"code":"BAD_USER_INPUT"}}]}`, nil, false},
+		{"custom codes", http.StatusOK, `{"errors":[{"extensions":{"code":"THROTTLED"}}]}`, []string{"THROTTLED"}, true},
+		{"not json", http.StatusOK, `<html>`, nil, false},
+		{"server error falls through", http.StatusServiceUnavailable, ``, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checker := GraphQLRetryableChecker(DefaultRetryableChecker, tt.codes...)
+			resp := &http.Response{
+				StatusCode: tt.status,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			if got := checker(nil, resp); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+			if body, _ := io.ReadAll(resp.Body); string(body) != tt.body {
+				t.Errorf("Expected body to be restored, got %q", body)
+			}
+		})
+	}
+}
+
+func TestGraphQLRetryableChecker_TruncatedBody(t *testing.T) {
+	checker := GraphQLRetryableChecker(func(err error, _ *http.Response) bool {
+		return errors.Is(err, ErrTruncatedResponse)
+	})
+	resp := &http.Response{
+		StatusCode:    http.StatusOK,
+		ContentLength: 100,
+		Body:          io.NopCloser(strings.NewReader(`{"data":`)),
+	}
+	if !checker(nil, resp) {
+		t.Error("Expected a truncated body to reach next as ErrTruncatedResponse")
+	}
+	body, err := io.ReadAll(resp.Body)
+	if !errors.Is(err, ErrTruncatedResponse) || string(body) != `{"data":` {
+		t.Errorf("Expected the partial body followed by the read error, got %q, %v", body, err)
+	}
+}
+
+func TestGraphQLRetryableChecker_LargeBody(t *testing.T) {
+	large := `{"errors":[{"extensions":{"code":"INTERNAL"}}],"data":"` +
+		strings.Repeat("x", maxGraphQLPeekBytes) + `"}`
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(large)),
+	}
+	if GraphQLRetryableChecker(DefaultRetryableChecker)(nil, resp) {
+		t.Error("Expected a body over the peek cap not to be inspected")
+	}
+	if body, _ := io.ReadAll(resp.Body); string(body) != large {
+		t.Errorf("Expected the body to stay readable in full, got %d bytes", len(body))
+	}
+}
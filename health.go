@@ -0,0 +1,121 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// healthMinAttempts is how many recent attempts to a host WithRetrySuppression
+// needs before its success rate is trusted; fewer never suppress retries.
+const healthMinAttempts = 10
+
+// ErrRetriesSuppressed is returned (wrapped in the RetryError's LastErr) when
+// WithRetrySuppression skipped the retries of an operation because the host's
+// recent success rate shows an outage.
+var ErrRetriesSuppressed = errors.New("retry: retries suppressed while host is unhealthy")
+
+// hostHealth tracks the recent success rate of attempts per host over a
+// sliding window, approximated by weighting the previous window's counts by
+// how much of it still overlaps the sliding one.
+type hostHealth struct {
+	minSuccessRate float64
+	window         time.Duration
+
+	mu    sync.Mutex
+	hosts map[string]*healthWindow
+}
+
+// healthWindow holds the attempt counts of one host.
+type healthWindow struct {
+	start     time.Time // Start of the current window
+	cur, prev healthCounts
+}
+
+// healthCounts counts attempts and their successes.
+type healthCounts struct {
+	attempts  int
+	successes int
+}
+
+// advance rolls w forward so that now falls in its current window.
+func (w *healthWindow) advance(now time.Time, window time.Duration) {
+	elapsed := now.Sub(w.start)
+	if elapsed < window {
+		return
+	}
+	if elapsed < 2*window {
+		w.prev = w.cur
+	} else {
+		w.prev = healthCounts{}
+	}
+	w.cur = healthCounts{}
+	w.start = w.start.Add(elapsed.Truncate(window))
+}
+
+// successRate returns the success rate over the sliding window ending at now
+// and the (weighted) number of attempts it is based on.
+func (w *healthWindow) successRate(now time.Time, window time.Duration) (float64, float64) {
+	weight := 1 - float64(now.Sub(w.start))/float64(window)
+	attempts := float64(w.prev.attempts)*weight + float64(w.cur.attempts)
+	successes := float64(w.prev.successes)*weight + float64(w.cur.successes)
+	if attempts == 0 {
+		return 1, 0
+	}
+	return successes / attempts, attempts
+}
+
+// record counts an attempt to host; ok is false when it failed in a way the
+// client would retry (a network error or retryable status).
+func (h *hostHealth) record(host string, ok bool, now time.Time) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.hosts == nil {
+		h.hosts = make(map[string]*healthWindow)
+	}
+	w, found := h.hosts[host]
+	if !found {
+		w = &healthWindow{start: now}
+		h.hosts[host] = w
+	}
+	w.advance(now, h.window)
+	w.cur.attempts++
+	if ok {
+		w.cur.successes++
+	}
+}
+
+// suppresses reports whether retries to host are skipped at now, along with
+// the host's success rate.
+func (h *hostHealth) suppresses(host string, now time.Time) (bool, float64) {
+	if h == nil {
+		return false, 1
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	w, found := h.hosts[host]
+	if !found {
+		return false, 1
+	}
+	w.advance(now, h.window)
+	rate, attempts := w.successRate(now, h.window)
+	return attempts >= healthMinAttempts && rate < h.minSuccessRate, rate
+}
+
+// suppressionError wraps the last error of an operation whose retries were
+// suppressed, or describes the suppression when there was none (a retryable
+// status).
+func suppressionError(host string, rate float64, lastErr error) error {
+	if lastErr != nil {
+		return fmt.Errorf("%w: %w", ErrRetriesSuppressed, lastErr)
+	}
+	return fmt.Errorf("%w: %s success rate %.0f%%", ErrRetriesSuppressed, host, rate*100)
+}
@@ -0,0 +1,135 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHostHealth_SuppressesBelowThreshold(t *testing.T) {
+	h := &hostHealth{minSuccessRate: 0.5, window: time.Minute}
+	now := time.Now()
+
+	for range healthMinAttempts - 1 {
+		h.record("a", false, now)
+	}
+	if skip, _ := h.suppresses("a", now); skip {
+		t.Fatal("Too few attempts must not suppress retries")
+	}
+
+	h.record("a", false, now)
+	skip, rate := h.suppresses("a", now)
+	if !skip || rate != 0 {
+		t.Fatalf("Expected suppression at rate 0, got %v at %v", skip, rate)
+	}
+	if skip, _ := h.suppresses("b", now); skip {
+		t.Error("Hosts must be tracked independently")
+	}
+}
+
+func TestHostHealth_Recovers(t *testing.T) {
+	h := &hostHealth{minSuccessRate: 0.5, window: time.Minute}
+	now := time.Now()
+
+	for range 20 {
+		h.record("a", false, now)
+	}
+
+	// Successes in the next window outweigh the fading failures
+	later := now.Add(90 * time.Second)
+	for range 20 {
+		h.record("a", true, later)
+	}
+	if skip, rate := h.suppresses("a", later); skip {
+		t.Errorf("Expected recovery, got rate %v", rate)
+	}
+
+	// Old failures are forgotten entirely after two windows
+	h.record("b", false, now)
+	for range healthMinAttempts {
+		h.record("b", false, now)
+	}
+	if skip, _ := h.suppresses("b", now.Add(3*time.Minute)); skip {
+		t.Error("Expired attempts must not suppress retries")
+	}
+}
+
+func TestWithRetrySuppression_SkipsRetriesDuringOutage(t *testing.T) {
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithMaxRetries(4),
+		WithInitialRetryDelay(time.Millisecond),
+		WithJitter(false),
+		WithRetrySuppression(0.5, time.Minute),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two operations fill the window with failed attempts
+	for range 2 {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err == nil {
+			t.Fatal("Expected an error")
+		}
+		resp.Body.Close()
+	}
+
+	requests.Store(0)
+	resp, err := client.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrRetriesSuppressed) {
+		t.Fatalf("Expected ErrRetriesSuppressed, got %v", err)
+	}
+	resp.Body.Close()
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.Attempts != 1 || retryErr.LastStatus != 503 ||
+		retryErr.GiveUpReason != GiveUpRetriesSuppressed {
+		t.Errorf("Expected a single attempt ending in 503, got %+v", retryErr)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected 1 request while suppressed, got %d", got)
+	}
+
+	// First attempts keep flowing and bring the success rate back up
+	healthy.Store(true)
+	for range 20 {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	healthy.Store(false)
+	requests.Store(0)
+	resp, err = client.Get(context.Background(), server.URL)
+	if errors.Is(err, ErrRetriesSuppressed) {
+		t.Fatal("Expected retries to resume after recovery")
+	}
+	resp.Body.Close()
+	if got := requests.Load(); got != 5 {
+		t.Errorf("Expected 5 requests after recovery, got %d", got)
+	}
+}
+
+func TestWithRetrySuppression_Disabled(t *testing.T) {
+	client, err := NewClient(WithRetrySuppression(0, time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.hostHealth != nil {
+		t.Error("Expected suppression to be disabled")
+	}
+}
@@ -0,0 +1,77 @@
+package retry
+
+import (
+	"net/http"
+	"sync"
+)
+
+// idleCloser is implemented by transports that can drop their idle
+// keep-alive connections (e.g. *http.Transport, *http2.Transport).
+type idleCloser interface {
+	CloseIdleConnections()
+}
+
+// closeIdleConnections closes idle connections on the underlying transport.
+// It targets the transport beneath any per-attempt middleware, since the
+// middleware wrappers do not forward CloseIdleConnections.
+func (c *Client) closeIdleConnections() {
+	if closer, ok := c.baseTransport.(idleCloser); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// failureStreaks counts consecutive failed attempts per host.
+type failureStreaks struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// record updates the streak for host and reports whether it just reached
+// threshold. The streak is reset when it fires or when an attempt succeeds.
+func (s *failureStreaks) record(host string, failed bool, threshold int) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !failed {
+		delete(s.counts, host)
+		return false
+	}
+
+	if s.counts == nil {
+		s.counts = make(map[string]int)
+	}
+	s.counts[host]++
+	if s.counts[host] < threshold {
+		return false
+	}
+	delete(s.counts, host)
+	return true
+}
+
+// trackFailureStreak records the outcome of an attempt against req's host and
+// closes idle connections once WithCloseIdleOnFailureStreak's threshold is hit.
+func (c *Client) trackFailureStreak(req *http.Request, failed bool) {
+	if c.closeIdleStreak <= 0 {
+		return
+	}
+	if !c.failureStreaks.record(req.URL.Host, failed, c.closeIdleStreak) {
+		return
+	}
+
+	if c.loggerEnabled {
+		c.logger.Warn("closing idle connections after consecutive failures",
+			"host", req.URL.Host,
+			"failures", c.closeIdleStreak,
+		)
+	}
+	c.closeIdleConnections()
+}
+
+// isMisdirected reports whether resp is a 421 Misdirected Request: the pooled
+// connection reached a server that cannot answer for this origin (typically
+// HTTP/2 connection coalescing). RFC 9110 allows retrying it over a different
+// connection, so the client retries the first 421 of a request immediately
+// after closing idle connections.
+func isMisdirected(resp *http.Response) bool {
+	return resp != nil && resp.StatusCode == http.StatusMisdirectedRequest
+}
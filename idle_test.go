@@ -0,0 +1,205 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// closeCountingTransport returns a fixed status and counts CloseIdleConnections calls
+type closeCountingTransport struct {
+	status    int
+	closeIdle int32
+}
+
+func (t *closeCountingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: t.status, Body: http.NoBody}, nil
+}
+
+func (t *closeCountingTransport) CloseIdleConnections() {
+	atomic.AddInt32(&t.closeIdle, 1)
+}
+
+func TestWithCloseIdleOnFailureStreak(t *testing.T) {
+	transport := &closeCountingTransport{status: http.StatusServiceUnavailable}
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxRetries(4),
+		WithInitialRetryDelay(time.Millisecond),
+		WithJitter(false),
+		WithCloseIdleOnFailureStreak(2),
+		// Middleware wrapping must not hide the underlying transport
+		WithPerAttemptMiddleware(func(next http.RoundTripper) http.RoundTripper {
+			return next
+		}),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err == nil {
+		t.Fatal("Expected error after exhausting retries")
+	}
+	resp.Body.Close()
+
+	// 5 failed attempts with threshold 2: fires after attempts 2 and 4
+	if got := atomic.LoadInt32(&transport.closeIdle); got != 2 {
+		t.Errorf("Expected 2 CloseIdleConnections calls, got %d", got)
+	}
+}
+
+func TestWithCloseIdleOnFailureStreak_DisabledByDefault(t *testing.T) {
+	transport := &closeCountingTransport{status: http.StatusServiceUnavailable}
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithCloseIdleOnFailureStreak(0), // Invalid, should be ignored
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, _ := client.Get(context.Background(), "http://example.invalid")
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&transport.closeIdle); got != 0 {
+		t.Errorf("Expected no CloseIdleConnections calls, got %d", got)
+	}
+}
+
+func TestFailureStreaks_Record(t *testing.T) {
+	var s failureStreaks
+
+	if s.record("a", true, 2) {
+		t.Error("first failure should not reach threshold")
+	}
+	if s.record("b", true, 2) {
+		t.Error("streaks must be tracked per host")
+	}
+	if s.record("a", false, 2) {
+		t.Error("success should never fire")
+	}
+	if s.record("a", true, 2) {
+		t.Error("success should have reset the streak")
+	}
+	if !s.record("a", true, 2) {
+		t.Error("second consecutive failure should reach threshold")
+	}
+	if s.record("a", true, 2) {
+		t.Error("streak should reset after firing")
+	}
+	if !s.record("b", true, 2) {
+		t.Error("host b should reach threshold independently")
+	}
+}
+
+// sequenceTransport returns the given statuses in order and counts CloseIdleConnections calls
+type sequenceTransport struct {
+	statuses  []int
+	calls     int32
+	closeIdle int32
+}
+
+func (t *sequenceTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	i := atomic.AddInt32(&t.calls, 1) - 1
+	return &http.Response{StatusCode: t.statuses[i], Body: http.NoBody}, nil
+}
+
+func (t *sequenceTransport) CloseIdleConnections() {
+	atomic.AddInt32(&t.closeIdle, 1)
+}
+
+func TestClient_MisdirectedRequest_RetriesImmediatelyOnFreshConnection(t *testing.T) {
+	transport := &sequenceTransport{
+		statuses: []int{http.StatusMisdirectedRequest, http.StatusOK},
+	}
+	var delays []time.Duration
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithInitialRetryDelay(time.Hour), // Would hang if the 421 retry were not immediate
+		WithOnRetry(func(info RetryInfo) { delays = append(delays, info.Delay) }),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200 after retry, got %d", resp.StatusCode)
+	}
+	if len(delays) != 1 || delays[0] != 0 {
+		t.Errorf("Expected a single immediate retry, got delays %v", delays)
+	}
+	if got := atomic.LoadInt32(&transport.closeIdle); got != 1 {
+		t.Errorf("Expected idle connections closed once, got %d", got)
+	}
+}
+
+func TestClient_MisdirectedRequest_BacksOffWhenRepeated(t *testing.T) {
+	transport := &sequenceTransport{
+		statuses: []int{
+			http.StatusMisdirectedRequest,
+			http.StatusMisdirectedRequest,
+			http.StatusMisdirectedRequest,
+			http.StatusMisdirectedRequest,
+		},
+	}
+	var delays []time.Duration
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxRetries(3),
+		WithInitialRetryDelay(5*time.Millisecond),
+		WithJitter(false),
+		WithOnRetry(func(info RetryInfo) { delays = append(delays, info.Delay) }),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err == nil {
+		resp.Body.Close()
+	}
+
+	if len(delays) != 3 || delays[0] != 0 || delays[1] <= 0 || delays[2] <= delays[1] {
+		t.Errorf("Expected only the first 421 retried immediately, then backoff, got %v", delays)
+	}
+}
+
+func TestClient_MisdirectedRequest_OptOut(t *testing.T) {
+	transport := &sequenceTransport{
+		statuses: []int{http.StatusMisdirectedRequest, http.StatusOK},
+	}
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithNonRetryableStatuses(http.StatusMisdirectedRequest),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://example.invalid")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMisdirectedRequest || transport.calls != 1 {
+		t.Errorf("Expected 421 returned without retry, got %d after %d calls",
+			resp.StatusCode, transport.calls)
+	}
+}
@@ -0,0 +1,140 @@
+package retry
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+// KeepAliveProbe configures StartKeepAliveProbes.
+type KeepAliveProbe struct {
+	URLs     []string      // Probe targets, typically one cheap endpoint per host
+	Interval time.Duration // Time between probe rounds (default 30s)
+	Method   string        // Probe method: HEAD (default) or OPTIONS
+	Timeout  time.Duration // Timeout of each probe (default 5s)
+}
+
+// StartKeepAliveProbes periodically sends a lightweight request to each of
+// probe.URLs over the client's connection pool, until ctx is done. A probe
+// keeps the pooled keep-alive connection to its host warm, and when the
+// connection has died (server idle timeout, NAT or load balancer dropping it)
+// the failure is absorbed by the probe: idle connections are closed, so the
+// next real request dials a fresh connection instead of spending a retry on
+// the dead one.
+//
+// Probes bypass the retry loop, metrics and tracing, but pass through
+// per-attempt middleware (e.g. to authenticate). Pick an interval shorter
+// than the server's idle timeout. Any response status counts as a live
+// connection. Probe outcomes feed the host's health for WithRetrySuppression
+// and WithPhiDetector, with retryable statuses counting as failures.
+//
+// Example:
+//
+//	ctx, stop := context.WithCancel(context.Background())
+//	defer stop()
+//	client.StartKeepAliveProbes(ctx, retry.KeepAliveProbe{
+//	    URLs:     []string{"https://api.example.com/healthz"},
+//	    Interval: 20 * time.Second,
+//	})
+func (c *Client) StartKeepAliveProbes(ctx context.Context, probe KeepAliveProbe) {
+	if probe.Interval <= 0 {
+		probe.Interval = 30 * time.Second
+	}
+	if probe.Method == "" {
+		probe.Method = http.MethodHead
+	}
+	if probe.Timeout <= 0 {
+		probe.Timeout = 5 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(probe.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				for _, url := range probe.URLs {
+					c.probeConnection(ctx, probe, url)
+				}
+			}
+		}
+	}()
+}
+
+// probeConnection sends one probe, closing idle connections if it fails.
+func (c *Client) probeConnection(ctx context.Context, probe KeepAliveProbe, url string) {
+	probeCtx, cancel := context.WithTimeout(ctx, probe.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(probeCtx, probe.Method, url, nil)
+	if err != nil {
+		return
+	}
+	c.setUserAgent(req)
+
+	resp, err := c.httpClient.Do(req)
+	if ctx.Err() != nil {
+		if err == nil {
+			resp.Body.Close()
+		}
+		return // Stopped while probing
+	}
+
+	// Feed the host's health to WithRetrySuppression and WithPhiDetector
+	c.recordDial(err)
+	if !c.networkFault(ctx, err) {
+		healthy := err == nil && !c.isRetryable(nil, resp)
+		c.hostHealth.record(req.URL.Host, healthy, time.Now())
+		c.observePhi(req.URL.Host, healthy)
+	}
+
+	if err == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return
+	}
+
+	if c.loggerEnabled {
+		c.logger.Warn("keep-alive probe failed, closing idle connections",
+			attrURL, url,
+			"error", err.Error(),
+		)
+	}
+	c.closeIdleConnections()
+}
+
+// background returns the context of the client's background work, canceled
+// by Close.
+func (c *Client) background() context.Context {
+	if c.backgroundCtx == nil {
+		c.backgroundCtx, c.stopBackground = context.WithCancel(context.Background())
+	}
+	return c.backgroundCtx
+}
+
+// startKeepAliveProbes starts the probes configured with WithKeepaliveProbe,
+// running until Close.
+func (c *Client) startKeepAliveProbes() {
+	for _, probe := range c.keepAliveProbes {
+		c.StartKeepAliveProbes(c.background(), probe)
+	}
+}
+
+// Close stops the client's background work (the probes started by
+// WithKeepaliveProbe and WithRegionFailover, replays of WithOfflineQueue) and
+// closes its idle connections, and those of its route policies. In-flight requests are not affected, and the
+// client remains usable for requests afterwards; requests still queued
+// offline stay in the store for the next client.
+func (c *Client) Close() error {
+	if c.stopBackground != nil {
+		c.stopBackground()
+	}
+	c.closeIdleConnections()
+	for _, route := range c.routes {
+		route.client.closeIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package retry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStartKeepAliveProbes(t *testing.T) {
+	var probes, conns int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			atomic.AddInt32(&probes, 1)
+		}
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: &http.Transport{}}),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	client.StartKeepAliveProbes(ctx, KeepAliveProbe{
+		URLs:     []string{server.URL},
+		Interval: 10 * time.Millisecond,
+	})
+	time.Sleep(100 * time.Millisecond)
+	stop()
+
+	if n := atomic.LoadInt32(&probes); n < 3 {
+		t.Errorf("Expected several probes, got %d", n)
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("Expected probes to reuse one connection, got %d", n)
+	}
+
+	// No more probes after ctx is done
+	time.Sleep(20 * time.Millisecond)
+	n := atomic.LoadInt32(&probes)
+	time.Sleep(50 * time.Millisecond)
+	if after := atomic.LoadInt32(&probes); after != n {
+		t.Errorf("Expected probing to stop, got %d more probes", after-n)
+	}
+}
+
+func TestStartKeepAliveProbes_FailureClosesIdle(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	deadURL := server.URL
+	server.Close()
+
+	logger := &MockLogger{}
+	client, err := NewClient(WithLogger(logger))
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	client.StartKeepAliveProbes(ctx, KeepAliveProbe{URLs: []string{deadURL}, Interval: 10 * time.Millisecond})
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		logger.mu.Lock()
+		warned := len(logger.WarnLogs) > 0
+		logger.mu.Unlock()
+		if warned {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Error("Expected a failed probe to be logged")
+}
+
+func TestWithKeepaliveProbe_FeedsHealthUntilClose(t *testing.T) {
+	var probes int32
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&probes, 1)
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	detector := NewPhiDetector(1)
+	client, err := NewClient(
+		WithKeepaliveProbe(server.URL, 10*time.Millisecond),
+		WithPhiDetector(detector),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	defer client.Close()
+
+	// Successful probes act as heartbeats; failing ones let suspicion accrue
+	time.Sleep(100 * time.Millisecond)
+	failing.Store(true)
+	host := server.Listener.Addr().String()
+	deadline := time.Now().Add(5 * time.Second)
+	for !detector.Suspected(host) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Host never became suspected, phi %v", detector.Phi(host))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := client.Close(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	n := atomic.LoadInt32(&probes)
+	time.Sleep(50 * time.Millisecond)
+	if after := atomic.LoadInt32(&probes); after != n {
+		t.Errorf("Expected probing to stop after Close, got %d more probes", after-n)
+	}
+}
@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"slices"
+	"strings"
+)
+
+// Link is one link of an RFC 8288 Link header.
+type Link struct {
+	URL    string            // Target URI as written, possibly relative
+	Rel    string            // Relation types, space-separated, e.g. "next" or "prev last"
+	Params map[string]string // Other parameters by lowercased name, e.g. "title"
+}
+
+// HasRel reports whether l has relation type rel, compared case-insensitively.
+func (l Link) HasRel(rel string) bool {
+	return slices.ContainsFunc(strings.Fields(l.Rel), func(r string) bool {
+		return strings.EqualFold(r, rel)
+	})
+}
+
+// ParseLinkHeader parses the value of a Link header, e.g.
+//
+//	<https://api.example.com/items?page=2>; rel="next", </items?page=9>; rel=last
+//
+// Quoted parameter values may contain commas and semicolons. Malformed parts
+// are skipped rather than failing the whole header. A response can carry
+// several Link headers; parse each of resp.Header.Values("Link"). Relative
+// URLs are returned as written: resolve them with the request URL's Parse.
+func ParseLinkHeader(h string) []Link {
+	var links []Link
+	for {
+		start := strings.IndexByte(h, '<')
+		if start < 0 {
+			return links
+		}
+		end := strings.IndexByte(h[start:], '>')
+		if end < 0 {
+			return links
+		}
+		l := Link{URL: strings.TrimSpace(h[start+1 : start+end]), Params: map[string]string{}}
+		h = parseLinkParams(h[start+end+1:], l.Params)
+		l.Rel = l.Params["rel"]
+		delete(l.Params, "rel")
+		links = append(links, l)
+	}
+}
+
+// parseLinkParams parses the ";name=value" parameters at the start of h into
+// params, stopping at the comma that ends the link, and returns the rest of h.
+// The first occurrence of a parameter wins, as RFC 8288 requires for rel.
+func parseLinkParams(h string, params map[string]string) string {
+	for {
+		h = strings.TrimLeft(h, " \t")
+		if h == "" || h[0] != ';' {
+			// End of the link; skip anything malformed up to the next one
+			if i := strings.IndexByte(h, ','); i >= 0 {
+				return h[i+1:]
+			}
+			return ""
+		}
+
+		h = strings.TrimLeft(h[1:], " \t")
+		i := strings.IndexAny(h, "=;,")
+		if i < 0 {
+			i = len(h)
+		}
+		name := strings.ToLower(strings.TrimSpace(h[:i]))
+		h = h[i:]
+
+		var value string
+		if h != "" && h[0] == '=' {
+			value, h = parseLinkParamValue(strings.TrimLeft(h[1:], " \t"))
+		}
+		if _, seen := params[name]; name != "" && !seen {
+			params[name] = value
+		}
+	}
+}
+
+// parseLinkParamValue parses a token or quoted string at the start of h and
+// returns it and the rest of h.
+func parseLinkParamValue(h string) (string, string) {
+	if h == "" || h[0] != '"' {
+		i := strings.IndexAny(h, ";,")
+		if i < 0 {
+			i = len(h)
+		}
+		return strings.TrimSpace(h[:i]), h[i:]
+	}
+
+	var b strings.Builder
+	for i := 1; i < len(h); i++ {
+		switch h[i] {
+		case '\\':
+			if i+1 < len(h) {
+				i++
+				b.WriteByte(h[i])
+			}
+		case '"':
+			return b.String(), h[i+1:]
+		default:
+			b.WriteByte(h[i])
+		}
+	}
+	return b.String(), "" // Unterminated quoted string
+}
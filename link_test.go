@@ -0,0 +1,69 @@
+package retry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   []Link
+	}{
+		{
+			name:   "empty",
+			header: "",
+			want:   nil,
+		},
+		{
+			name: "github style",
+			header: `<https://api.github.com/issues?page=2>; rel="next", ` +
+				`<https://api.github.com/issues?page=5>; rel="last"`,
+			want: []Link{
+				{URL: "https://api.github.com/issues?page=2", Rel: "next", Params: map[string]string{}},
+				{URL: "https://api.github.com/issues?page=5", Rel: "last", Params: map[string]string{}},
+			},
+		},
+		{
+			name:   "quoted values with separators and escapes",
+			header: `</a>; title="one, two; \"three\""; REL=next; rel="ignored", </b>;rel=prev`,
+			want: []Link{
+				{URL: "/a", Rel: "next", Params: map[string]string{"title": `one, two; "three"`}},
+				{URL: "/b", Rel: "prev", Params: map[string]string{}},
+			},
+		},
+		{
+			name:   "malformed parts skipped",
+			header: `junk, </a>; rel="next" junk, <unterminated`,
+			want: []Link{
+				{URL: "/a", Rel: "next", Params: map[string]string{}},
+			},
+		},
+		{
+			name:   "parameter without value",
+			header: `</a>; crossorigin; rel="prev next"`,
+			want: []Link{
+				{URL: "/a", Rel: "prev next", Params: map[string]string{"crossorigin": ""}},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseLinkHeader(tt.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseLinkHeader() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLink_HasRel(t *testing.T) {
+	l := Link{Rel: "prev NEXT"}
+	if !l.HasRel("next") || !l.HasRel("Prev") {
+		t.Error("Expected case-insensitive matches of each relation type")
+	}
+	if l.HasRel("last") || l.HasRel("ne") {
+		t.Error("Expected no match for other relation types")
+	}
+}
@@ -10,6 +10,46 @@ type Logger interface {
 	Error(msg string, args ...any)
 }
 
+// FieldLogger is an optional extension of Logger for loggers that support
+// child loggers with base fields, like slog.Logger.With. When the logger passed
+// to WithLogger implements it, the client creates one child logger per request
+// carrying the method and URL instead of repeating them on every log call.
+// Other loggers receive the same fields prepended to each call's args.
+type FieldLogger interface {
+	// With returns a logger that includes args in every log entry
+	With(args ...any) Logger
+}
+
+// withFields returns a logger that adds args to every log entry.
+func withFields(logger Logger, args ...any) Logger {
+	if fl, ok := logger.(FieldLogger); ok {
+		return fl.With(args...)
+	}
+	return &fieldsLogger{logger: logger, fields: args}
+}
+
+// fieldsLogger prepends base fields to every call for loggers that don't
+// implement FieldLogger.
+type fieldsLogger struct {
+	logger Logger
+	fields []any
+}
+
+func (l *fieldsLogger) Debug(msg string, args ...any) { l.logger.Debug(msg, l.args(args)...) }
+func (l *fieldsLogger) Info(msg string, args ...any)  { l.logger.Info(msg, l.args(args)...) }
+func (l *fieldsLogger) Warn(msg string, args ...any)  { l.logger.Warn(msg, l.args(args)...) }
+func (l *fieldsLogger) Error(msg string, args ...any) { l.logger.Error(msg, l.args(args)...) }
+
+// With implements FieldLogger so base fields accumulate.
+func (l *fieldsLogger) With(args ...any) Logger {
+	return &fieldsLogger{logger: l.logger, fields: l.args(args)}
+}
+
+// args returns the base fields followed by args.
+func (l *fieldsLogger) args(args []any) []any {
+	return append(append(make([]any, 0, len(l.fields)+len(args)), l.fields...), args...)
+}
+
 // nopLogger provides no-op implementation
 type nopLogger struct{}
 
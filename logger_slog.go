@@ -25,3 +25,8 @@ func (s *SlogAdapter) Warn(msg string, args ...any) {
 func (s *SlogAdapter) Error(msg string, args ...any) {
 	s.logger.Error(msg, args...)
 }
+
+// With implements FieldLogger using slog.Logger.With.
+func (s *SlogAdapter) With(args ...any) Logger {
+	return &SlogAdapter{logger: s.logger.With(args...)}
+}
@@ -1,9 +1,13 @@
 package retry
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -230,3 +234,31 @@ func TestClient_WithNoLogging(t *testing.T) {
 		t.Errorf("Expected 2 attempts, got %d", attempts)
 	}
 }
+
+func TestWithFields(t *testing.T) {
+	t.Run("plain logger gets fields prepended", func(t *testing.T) {
+		mock := &MockLogger{}
+		logger := withFields(mock, "method", "GET")
+		logger = logger.(FieldLogger).With("url", "http://example.com")
+		logger.Warn("failed", "attempt", 1)
+
+		expected := []any{"method", "GET", "url", "http://example.com", "attempt", 1}
+		if len(mock.WarnLogs) != 1 || fmt.Sprint(mock.WarnLogs[0].Args) != fmt.Sprint(expected) {
+			t.Errorf("Expected args %v, got %v", expected, mock.WarnLogs)
+		}
+	})
+
+	t.Run("slog adapter uses With", func(t *testing.T) {
+		var buf bytes.Buffer
+		adapter := NewSlogAdapter(slog.New(slog.NewTextHandler(&buf, nil)))
+		logger := withFields(adapter, "method", "GET")
+		if _, ok := logger.(*SlogAdapter); !ok {
+			t.Fatalf("Expected a child SlogAdapter, got %T", logger)
+		}
+		logger.Info("retrying", "attempt", 2)
+
+		if out := buf.String(); !strings.Contains(out, "method=GET attempt=2") {
+			t.Errorf("Expected base fields in output, got %q", out)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ErrorMatcher builds a RetryableChecker that retries errors matching any of
+// its rules. Create one with MatchErrors, chain rules, and call Build:
+//
+//	checker := retry.MatchErrors().
+//	    Substring("connection reset").
+//	    Is(syscall.ECONNREFUSED).
+//	    Timeout().
+//	    Build()
+//
+// The resulting checker only looks at errors: it returns false when err is nil,
+// so it is meant to be combined with a status-based checker.
+type ErrorMatcher struct {
+	rules []func(error) bool
+}
+
+// MatchErrors starts a new ErrorMatcher with no rules.
+func MatchErrors() *ErrorMatcher {
+	return &ErrorMatcher{}
+}
+
+// Substring matches errors whose message contains any of substrs.
+func (m *ErrorMatcher) Substring(substrs ...string) *ErrorMatcher {
+	return m.Func(func(err error) bool {
+		msg := err.Error()
+		for _, s := range substrs {
+			if strings.Contains(msg, s) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Is matches errors for which errors.Is reports a match with any of targets.
+func (m *ErrorMatcher) Is(targets ...error) *ErrorMatcher {
+	return m.Func(func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Timeout matches context deadline errors and net.Error timeouts.
+func (m *ErrorMatcher) Timeout() *ErrorMatcher {
+	return m.Func(func(err error) bool {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return true
+		}
+		var netErr net.Error
+		return errors.As(err, &netErr) && netErr.Timeout()
+	})
+}
+
+// Func matches errors for which fn returns true.
+func (m *ErrorMatcher) Func(fn func(error) bool) *ErrorMatcher {
+	m.rules = append(m.rules, fn)
+	return m
+}
+
+// Build returns a RetryableChecker that reports true when err matches any rule.
+func (m *ErrorMatcher) Build() RetryableChecker {
+	rules := append([]func(error) bool(nil), m.rules...)
+	return func(err error, _ *http.Response) bool {
+		if err == nil {
+			return false
+		}
+		for _, rule := range rules {
+			if rule(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
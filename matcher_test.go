@@ -0,0 +1,67 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+	"testing"
+)
+
+// timeoutError is a net.Error reporting a timeout
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestErrorMatcher(t *testing.T) {
+	checker := MatchErrors().
+		Substring("connection reset").
+		Is(syscall.ECONNREFUSED).
+		Timeout().
+		Build()
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{"nil error", nil, false},
+		{"substring", errors.New("read tcp: connection reset by peer"), true},
+		{"wrapped errno", fmt.Errorf("dial: %w", syscall.ECONNREFUSED), true},
+		{"deadline", fmt.Errorf("attempt: %w", context.DeadlineExceeded), true},
+		{"net timeout", &net.OpError{Op: "read", Err: timeoutError{}}, true},
+		{"unmatched", errors.New("tls: bad certificate"), false},
+		{"canceled", context.Canceled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := checker(tt.err, nil); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestErrorMatcher_IgnoresResponses(t *testing.T) {
+	checker := MatchErrors().Func(func(error) bool { return true }).Build()
+	if checker(nil, &http.Response{StatusCode: http.StatusServiceUnavailable}) {
+		t.Error("Expected matcher to ignore responses without an error")
+	}
+}
+
+func TestErrorMatcher_BuildSnapshotsRules(t *testing.T) {
+	m := MatchErrors().Substring("reset")
+	checker := m.Build()
+	m.Substring("refused")
+
+	if checker(errors.New("connection refused"), nil) {
+		t.Error("Rules added after Build must not affect the built checker")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"net/http"
+	"sort"
 	"time"
 )
 
@@ -25,6 +26,92 @@ type MetricsCollector interface {
 	)
 }
 
+// ConnectionMetricsCollector is an optional extension of MetricsCollector.
+// When the collector passed to WithMetrics also implements this interface,
+// the client reports whether each attempt dialed a new connection or reused
+// an idle keep-alive connection from the pool.
+type ConnectionMetricsCollector interface {
+	// RecordConnection records the connection used by a single attempt
+	RecordConnection(method string, host string, reused bool)
+}
+
+// RetryWaitMetricsCollector is an optional extension of MetricsCollector.
+// When the collector passed to WithMetrics also implements this interface,
+// the client reports the time slept before every retry, telling waits the
+// server asked for with Retry-After apart from the client's own backoff, so
+// throttling upstreams can be identified and quantified.
+type RetryWaitMetricsCollector interface {
+	// RecordRetryWait records a wait before a retry to host; retryAfter is
+	// true when the wait followed the host's Retry-After header
+	RecordRetryWait(method string, host string, wait time.Duration, retryAfter bool)
+}
+
+// MetricInstrument identifies one of the metrics the client reports, for
+// WithDisabledMetrics.
+type MetricInstrument int
+
+const (
+	MetricAttempts    MetricInstrument = iota // RecordAttempt: one event per attempt
+	MetricRetries                             // RecordRetry: one event per retry decision
+	MetricRequests                            // RecordRequestComplete: one event per request
+	MetricConnections                         // RecordConnection: connection reuse per attempt
+	MetricPool                                // PoolMetricsCollector: dials, closes, open connections
+	MetricSuspicion                           // SuspicionMetricsCollector: phi per host and attempt
+	MetricRetryWaits                          // RetryWaitMetricsCollector: time slept before each retry
+)
+
+// recordsMetric reports whether the client should report instrument.
+func (c *Client) recordsMetric(instrument MetricInstrument) bool {
+	return c.metricsEnabled && !c.disabledMetrics[instrument]
+}
+
+// TaggedMetricsCollector is an optional extension of MetricsCollector.
+// When the collector passed to WithMetrics also implements this interface,
+// requests carrying tags from WithMetricTag are recorded on the collector
+// returned by WithTags, so metrics can be sliced by feature, tenant or
+// operation. WithTags is called once per request and should be cheap.
+type TaggedMetricsCollector interface {
+	// WithTags returns a collector that attaches tags to everything it records
+	WithTags(tags map[string]string) MetricsCollector
+}
+
+// metricTagsKey carries WithMetricTag tags in the request context.
+type metricTagsKey struct{}
+
+// metricTagsOf returns the WithMetricTag tags for a request, looking at the
+// operation context first and the request's own context second.
+func metricTagsOf(ctx context.Context, req *http.Request) map[string]string {
+	if tags, ok := ctx.Value(metricTagsKey{}).(map[string]string); ok {
+		return tags
+	}
+	tags, _ := req.Context().Value(metricTagsKey{}).(map[string]string)
+	return tags
+}
+
+// metricsFor returns the collector to record a request on: the tagged
+// collector when the request has tags and the collector supports them.
+func (c *Client) metricsFor(tags map[string]string) MetricsCollector {
+	if c.taggedMetrics == nil || len(tags) == 0 {
+		return c.metrics
+	}
+	return c.taggedMetrics.WithTags(tags)
+}
+
+// tagAttributes converts metric tags to span attributes in key order.
+func tagAttributes(tags map[string]string) []Attribute {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]Attribute, 0, len(keys))
+	for _, key := range keys {
+		attrs = append(attrs, Attribute{Key: key, Value: tags[key]})
+	}
+	return attrs
+}
+
 // nopMetricsCollector provides no-op implementation to avoid nil checks
 type nopMetricsCollector struct{}
 
@@ -39,22 +126,47 @@ const (
 	RetryReasonTimeout     = "timeout"
 	RetryReasonCanceled    = "canceled"
 	RetryReasonNetworkErr  = "network_error"
+	RetryReasonTruncated   = "truncated"
+	RetryReasonInvalid     = "invalid_response"
 	RetryReasonRateLimited = "rate_limited"
+	RetryReasonMisdirected = "misdirected"
+	RetryReasonTooEarly    = "too_early"
+	RetryReasonConflict    = "conflict"
+	RetryReasonLocked      = "locked"
 	RetryReason5xx         = "5xx"
 	RetryReason4xx         = "4xx"
 	RetryReasonUnknown     = "unknown"
 	RetryReasonOther       = "other"
 )
 
+// Timeout reasons by phase (see TimeoutKind). RetryReasonTimeout remains for
+// timeouts that could not be attributed to a phase.
+const (
+	RetryReasonConnectTimeout = "connect_timeout"
+	RetryReasonTLSTimeout     = "tls_handshake_timeout"
+	RetryReasonHeaderTimeout  = "response_header_timeout"
+	RetryReasonBodyTimeout    = "body_read_timeout"
+)
+
 // determineRetryReason categorizes the retry reason (for metrics and logging)
 func determineRetryReason(err error, resp *http.Response) string {
 	if err != nil {
+		var timeoutErr *TimeoutError
+		if errors.As(err, &timeoutErr) {
+			return string(timeoutErr.Kind) + "_timeout"
+		}
 		if errors.Is(err, context.DeadlineExceeded) {
 			return RetryReasonTimeout
 		}
 		if errors.Is(err, context.Canceled) {
 			return RetryReasonCanceled
 		}
+		if errors.Is(err, ErrTruncatedResponse) {
+			return RetryReasonTruncated
+		}
+		if errors.Is(err, ErrInvalidResponse) {
+			return RetryReasonInvalid
+		}
 		return RetryReasonNetworkErr
 	}
 
@@ -65,6 +177,14 @@ func determineRetryReason(err error, resp *http.Response) string {
 	switch {
 	case resp.StatusCode == 429:
 		return RetryReasonRateLimited
+	case resp.StatusCode == http.StatusMisdirectedRequest:
+		return RetryReasonMisdirected
+	case resp.StatusCode == http.StatusTooEarly:
+		return RetryReasonTooEarly
+	case resp.StatusCode == http.StatusConflict:
+		return RetryReasonConflict
+	case resp.StatusCode == http.StatusLocked:
+		return RetryReasonLocked
 	case resp.StatusCode >= 500:
 		return RetryReason5xx
 	case resp.StatusCode >= 400:
@@ -3,6 +3,8 @@ package retry
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"sync"
@@ -227,12 +229,42 @@ func TestDetermineRetryReason(t *testing.T) {
 			resp:     nil,
 			expected: "network_error",
 		},
+		{
+			name:     "truncated body",
+			err:      fmt.Errorf("%w: %w", ErrTruncatedResponse, io.ErrUnexpectedEOF),
+			resp:     nil,
+			expected: "truncated",
+		},
 		{
 			name:     "429 rate limited",
 			err:      nil,
 			resp:     &http.Response{StatusCode: 429},
 			expected: "rate_limited",
 		},
+		{
+			name:     "421 misdirected",
+			err:      nil,
+			resp:     &http.Response{StatusCode: 421},
+			expected: "misdirected",
+		},
+		{
+			name:     "409 conflict",
+			err:      nil,
+			resp:     &http.Response{StatusCode: 409},
+			expected: "conflict",
+		},
+		{
+			name:     "423 locked",
+			err:      nil,
+			resp:     &http.Response{StatusCode: 423},
+			expected: "locked",
+		},
+		{
+			name:     "425 too early",
+			err:      nil,
+			resp:     &http.Response{StatusCode: 425},
+			expected: "too_early",
+		},
 		{
 			name:     "5xx error",
 			err:      nil,
@@ -316,3 +348,133 @@ func TestMetrics_NonRetryableError_RecordsFailure(t *testing.T) {
 			complete.TotalAttempts)
 	}
 }
+
+// taggedMetricsCollector implements TaggedMetricsCollector on top of MockMetricsCollector
+type taggedMetricsCollector struct {
+	MockMetricsCollector
+	tagged map[string]*MockMetricsCollector // collectors by "key=value,..." tag set
+	mu     sync.Mutex
+}
+
+func (m *taggedMetricsCollector) WithTags(tags map[string]string) MetricsCollector {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var key string
+	for _, attr := range tagAttributes(tags) {
+		key += fmt.Sprintf("%s=%s,", attr.Key, attr.Value)
+	}
+	if m.tagged[key] == nil {
+		m.tagged[key] = &MockMetricsCollector{}
+	}
+	return m.tagged[key]
+}
+
+func TestWithMetricTag(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	collector := &taggedMetricsCollector{tagged: make(map[string]*MockMetricsCollector)}
+	tracer := &MockTracer{}
+	client, err := NewClient(
+		WithInitialRetryDelay(time.Millisecond),
+		WithMetrics(collector),
+		WithTracer(tracer),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL,
+		WithMetricTag("tenant", "acme"),
+		WithMetricTag("operation", "list_users"),
+		WithTimeout(time.Minute), // Must not drop the tags
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	tagged := collector.tagged["operation=list_users,tenant=acme,"]
+	if tagged == nil {
+		t.Fatalf("Expected metrics under the request tags, got %v", collector.tagged)
+	}
+	if len(tagged.Attempts) != 2 || len(tagged.Retries) != 1 || len(tagged.RequestsComplete) != 1 {
+		t.Errorf("Expected 2 attempts, 1 retry, 1 completion; got %d, %d, %d",
+			len(tagged.Attempts), len(tagged.Retries), len(tagged.RequestsComplete))
+	}
+	if len(collector.Attempts) != 0 {
+		t.Errorf("Expected no untagged attempts, got %d", len(collector.Attempts))
+	}
+
+	// Tags are also request span attributes
+	found := 0
+	for _, attr := range tracer.Spans[0].Attributes {
+		if (attr.Key == "tenant" && attr.Value == "acme") ||
+			(attr.Key == "operation" && attr.Value == "list_users") {
+			found++
+		}
+	}
+	if found != 2 {
+		t.Errorf("Expected tags on the request span, got %v", tracer.Spans[0].Attributes)
+	}
+
+	// Untagged requests use the base collector
+	resp, err = client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if len(collector.RequestsComplete) != 1 {
+		t.Errorf("Expected untagged request on the base collector, got %d", len(collector.RequestsComplete))
+	}
+}
+
+func TestWithDisabledMetrics(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	collector := &connMetricsCollector{}
+	client, err := NewClient(
+		WithInitialRetryDelay(time.Millisecond),
+		WithMetrics(collector),
+		WithDisabledMetrics(MetricAttempts, MetricConnections),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(collector.Attempts) != 0 {
+		t.Errorf("Expected no attempt events, got %d", len(collector.Attempts))
+	}
+	if len(collector.conns) != 0 {
+		t.Errorf("Expected no connection events, got %d", len(collector.conns))
+	}
+	if len(collector.Retries) != 1 || len(collector.RequestsComplete) != 1 {
+		t.Errorf("Expected retry and completion events to be kept, got %d and %d",
+			len(collector.Retries), len(collector.RequestsComplete))
+	}
+}
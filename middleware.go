@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"strings"
+	"sync"
 	"time"
 )
 
@@ -151,12 +153,18 @@ type CircuitBreaker interface {
 	RecordFailure()
 }
 
+// admittingBreaker is a CircuitBreaker that ties each outcome to the request
+// it admitted, such as Breaker.
+type admittingBreaker interface {
+	Admit() (record func(success bool), err error)
+}
+
 // CircuitBreakerMiddleware creates request-level middleware that implements circuit breaker pattern.
 // The circuit breaker prevents requests when the service is unhealthy (circuit open).
 //
 // Example:
 //
-//	cb := NewCircuitBreaker(5, time.Minute) // Open after 5 failures for 1 minute
+//	cb := retry.NewCircuitBreaker(retry.FailureThreshold(5), retry.OpenTimeout(time.Minute))
 //	client, _ := retry.NewClient(
 //	    retry.WithRequestMiddleware(retry.CircuitBreakerMiddleware(cb)),
 //	)
@@ -166,8 +174,22 @@ type CircuitBreaker interface {
 func CircuitBreakerMiddleware(cb CircuitBreaker) RequestMiddleware {
 	return func(next RetryFunc) RetryFunc {
 		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
-			// Check if circuit breaker allows the request
-			if err := cb.Allow(); err != nil {
+			// Check if circuit breaker allows the request, tying the outcome to
+			// this admission when the breaker supports it
+			record := func(success bool) {
+				if success {
+					cb.RecordSuccess()
+				} else {
+					cb.RecordFailure()
+				}
+			}
+			var err error
+			if ab, ok := cb.(admittingBreaker); ok {
+				record, err = ab.Admit()
+			} else {
+				err = cb.Allow()
+			}
+			if err != nil {
 				return nil, fmt.Errorf("circuit breaker: %w", err)
 			}
 
@@ -175,17 +197,100 @@ func CircuitBreakerMiddleware(cb CircuitBreaker) RequestMiddleware {
 			resp, err := next(ctx, req)
 
 			// Record result
-			if err != nil || (resp != nil && resp.StatusCode >= 500) {
-				cb.RecordFailure()
-			} else {
-				cb.RecordSuccess()
-			}
+			record(err == nil && (resp == nil || resp.StatusCode < 500))
 
 			return resp, err
 		}
 	}
 }
 
+// PerHostCircuitBreakerMiddleware is like CircuitBreakerMiddleware but keeps an
+// independent circuit breaker for every destination host (req.URL.Host), so an
+// outage on one host does not open the circuit for the others.
+// newBreaker is called once per host, the first time that host is requested.
+//
+// Example:
+//
+//	client, _ := retry.NewClient(
+//	    retry.WithRequestMiddleware(retry.PerHostCircuitBreakerMiddleware(
+//	        func(host string) retry.CircuitBreaker { return retry.NewCircuitBreaker() },
+//	    )),
+//	)
+func PerHostCircuitBreakerMiddleware(newBreaker func(host string) CircuitBreaker) RequestMiddleware {
+	var mu sync.Mutex
+	breakers := make(map[string]CircuitBreaker)
+
+	breakerFor := func(host string) CircuitBreaker {
+		mu.Lock()
+		defer mu.Unlock()
+
+		cb, ok := breakers[host]
+		if !ok {
+			cb = newBreaker(host)
+			breakers[host] = cb
+		}
+		return cb
+	}
+
+	return func(next RetryFunc) RetryFunc {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			return CircuitBreakerMiddleware(breakerFor(req.URL.Host))(next)(ctx, req)
+		}
+	}
+}
+
+// When applies per-attempt middleware mw only to requests for which matcher
+// returns true; other requests bypass it. Use it when a client is shared
+// across destinations but a middleware, such as request signing, only
+// applies to one of them.
+//
+// Example:
+//
+//	client, _ := retry.NewClient(
+//	    retry.WithPerAttemptMiddleware(
+//	        retry.When(retry.MatchHosts("api.partner.com"), signingMiddleware),
+//	    ),
+//	)
+func When(matcher func(*http.Request) bool, mw Middleware) Middleware {
+	return func(next http.RoundTripper) http.RoundTripper {
+		wrapped := mw(next)
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			if matcher(req) {
+				return wrapped.RoundTrip(req)
+			}
+			return next.RoundTrip(req)
+		})
+	}
+}
+
+// WhenRequest is the request-level analog of When: mw wraps the retry
+// operation only for requests for which matcher returns true.
+func WhenRequest(matcher func(*http.Request) bool, mw RequestMiddleware) RequestMiddleware {
+	return func(next RetryFunc) RetryFunc {
+		wrapped := mw(next)
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			if matcher(req) {
+				return wrapped(ctx, req)
+			}
+			return next(ctx, req)
+		}
+	}
+}
+
+// MatchHosts returns a request matcher for When and WhenRequest that matches
+// requests to any of hosts (compared case-insensitively, without the port).
+func MatchHosts(hosts ...string) func(*http.Request) bool {
+	return func(req *http.Request) bool {
+		host := req.URL.Hostname()
+		for _, h := range hosts {
+			if strings.EqualFold(host, h) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
 // TracingRequestMiddleware creates request-level middleware that adds distributed tracing.
 // It creates a single span for the entire retry operation (not per attempt).
 //
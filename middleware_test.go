@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -558,6 +560,60 @@ func TestCircuitBreakerMiddleware(t *testing.T) {
 	}
 }
 
+// TestPerHostCircuitBreakerMiddleware verifies breakers are independent per host
+func TestPerHostCircuitBreakerMiddleware(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer healthy.Close()
+	down := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer down.Close()
+
+	var mu sync.Mutex
+	breakers := make(map[string]*testCircuitBreaker)
+	client, err := NewClient(
+		WithMaxRetries(0),
+		WithRequestMiddleware(PerHostCircuitBreakerMiddleware(func(host string) CircuitBreaker {
+			mu.Lock()
+			defer mu.Unlock()
+			cb := &testCircuitBreaker{}
+			breakers[host] = cb
+			return cb
+		})),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	for range 2 {
+		if resp, _ := client.Get(context.Background(), down.URL); resp != nil {
+			resp.Body.Close()
+		}
+		resp, err := client.Get(context.Background(), healthy.URL)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+
+	if len(breakers) != 2 {
+		t.Fatalf("Expected one breaker per host, got %d", len(breakers))
+	}
+	downCB := breakers[strings.TrimPrefix(down.URL, "http://")]
+	healthyCB := breakers[strings.TrimPrefix(healthy.URL, "http://")]
+	if downCB.failureCount != 2 || downCB.successCount != 0 {
+		t.Errorf("Expected down host breaker to record 2 failures, got %d/%d",
+			downCB.failureCount, downCB.successCount)
+	}
+	if healthyCB.successCount != 2 || healthyCB.failureCount != 0 {
+		t.Errorf("Expected healthy host breaker to record 2 successes, got %d/%d",
+			healthyCB.successCount, healthyCB.failureCount)
+	}
+}
+
 // TestTracingRequestMiddleware verifies tracing middleware
 func TestTracingRequestMiddleware(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -745,3 +801,231 @@ func TestLoggingMiddleware_NilResponseNoError(t *testing.T) {
 		t.Fatalf("expected (nil, nil) to pass through, got resp=%v err=%v", resp, err)
 	}
 }
+
+func TestAttemptInfoFromContext(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var infos []AttemptInfo
+	recorder := func(next http.RoundTripper) http.RoundTripper {
+		return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+			info, ok := AttemptInfoFromContext(req.Context())
+			if !ok {
+				t.Error("Expected attempt info in the request context")
+			}
+			infos = append(infos, info)
+			return next.RoundTrip(req)
+		})
+	}
+
+	client, err := NewClient(
+		WithInitialRetryDelay(time.Millisecond),
+		WithPerAttemptMiddleware(recorder),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(infos) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(infos))
+	}
+	if infos[0].IsRetry() || infos[0].LastStatus != 0 || infos[0].Elapsed > time.Second {
+		t.Errorf("Unexpected first attempt info: %+v", infos[0])
+	}
+	for i, info := range infos[1:] {
+		if info.Attempt != i+2 || !info.IsRetry() || info.LastStatus != http.StatusServiceUnavailable {
+			t.Errorf("Unexpected retry info: %+v", info)
+		}
+		if info.Elapsed <= infos[i].Elapsed {
+			t.Errorf("Expected elapsed time to grow, got %v after %v", info.Elapsed, infos[i].Elapsed)
+		}
+	}
+
+	if _, ok := AttemptInfoFromContext(context.Background()); ok {
+		t.Error("Expected no attempt info outside an attempt")
+	}
+}
+
+func TestWhen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Signature")))
+	}))
+	defer server.Close()
+
+	var requestMiddlewareCalls int32
+	countRequests := func(next RetryFunc) RetryFunc {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&requestMiddlewareCalls, 1)
+			return next(ctx, req)
+		}
+	}
+
+	client, err := NewClient(
+		WithPerAttemptMiddleware(When(MatchHosts("127.0.0.1"),
+			HeaderMiddleware(map[string]string{"X-Signature": "signed"}))),
+		WithRequestMiddleware(WhenRequest(MatchHosts("127.0.0.1"), countRequests)),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// The test server is reachable as both 127.0.0.1 and localhost
+	tests := []struct {
+		url      string
+		expected string
+		calls    int32
+	}{
+		{server.URL, "signed", 1},
+		{strings.Replace(server.URL, "127.0.0.1", "localhost", 1), "", 1},
+	}
+
+	for _, tt := range tests {
+		resp, err := client.Get(context.Background(), tt.url)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != tt.expected {
+			t.Errorf("%s: expected signature %q, got %q", tt.url, tt.expected, body)
+		}
+		if n := atomic.LoadInt32(&requestMiddlewareCalls); n != tt.calls {
+			t.Errorf("%s: expected %d request middleware calls, got %d", tt.url, tt.calls, n)
+		}
+	}
+}
+
+func TestClone_NamedMiddleware(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Header.Get("X-Auth") + "," + r.Header.Get("X-Trace")))
+	}))
+	defer server.Close()
+
+	var limited int32
+	rateLimit := func(next RetryFunc) RetryFunc {
+		return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&limited, 1)
+			return next(ctx, req)
+		}
+	}
+
+	base, err := NewClient(
+		WithNamedRequestMiddleware("ratelimit", rateLimit),
+		WithNamedPerAttemptMiddleware("auth", HeaderMiddleware(map[string]string{"X-Auth": "base"})),
+		WithPerAttemptMiddleware(HeaderMiddleware(map[string]string{"X-Trace": "on"})),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	clone, err := base.Clone(
+		WithoutMiddleware("ratelimit"),
+		WithNamedPerAttemptMiddleware("auth", HeaderMiddleware(map[string]string{"X-Auth": "clone"})),
+	)
+	if err != nil {
+		t.Fatalf("Failed to clone client: %v", err)
+	}
+
+	perAttempt, request := clone.MiddlewareNames()
+	if strings.Join(perAttempt, ",") != "auth," || len(request) != 0 {
+		t.Errorf("Unexpected clone middleware: %q, %q", perAttempt, request)
+	}
+
+	tests := []struct {
+		client   *Client
+		expected string
+		limited  int32
+	}{
+		{base, "base,on", 1},
+		{clone, "clone,on", 1},
+	}
+
+	for i, tt := range tests {
+		resp, err := tt.client.Get(context.Background(), server.URL)
+		if err != nil {
+			t.Fatalf("Request failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		if string(body) != tt.expected {
+			t.Errorf("client %d: expected %q, got %q", i, tt.expected, body)
+		}
+		if n := atomic.LoadInt32(&limited); n != tt.limited {
+			t.Errorf("client %d: expected %d rate limiter calls, got %d", i, tt.limited, n)
+		}
+	}
+}
+
+func TestContextWithRetryObserver(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	errBudget := errors.New("budget exhausted")
+	var outer, inner []int
+	observe := func(seen *[]int, limit int) RequestMiddleware {
+		return func(next RetryFunc) RetryFunc {
+			return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				ctx = ContextWithRetryObserver(ctx, func(info RetryInfo) error {
+					*seen = append(*seen, info.Attempt)
+					if info.StatusCode != http.StatusServiceUnavailable {
+						t.Errorf("Expected status 503 in RetryInfo, got %d", info.StatusCode)
+					}
+					if info.Attempt > limit {
+						return errBudget
+					}
+					return nil
+				})
+				return next(ctx, req)
+			}
+		}
+	}
+
+	client, err := NewClient(
+		WithMaxRetries(5),
+		WithInitialRetryDelay(time.Millisecond),
+		WithRequestMiddleware(observe(&outer, 2), observe(&inner, 5)),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || !errors.Is(err, errBudget) {
+		t.Fatalf("Expected RetryError wrapping the observer error, got %v", err)
+	}
+	if retryErr.Attempts != 3 || atomic.LoadInt32(&requests) != 3 {
+		t.Errorf("Expected 3 attempts, got %d (%d requests)", retryErr.Attempts, requests)
+	}
+	// The outer observer aborts on the third retry, before the inner one sees it
+	if fmt.Sprint(outer) != "[1 2 3]" || fmt.Sprint(inner) != "[1 2]" {
+		t.Errorf("Unexpected observed retries: outer %v, inner %v", outer, inner)
+	}
+}
@@ -0,0 +1,144 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// s3TestServer is a minimal in-memory S3 multipart endpoint. The first
+// attempt of failPart fails with a 503 (or every attempt, when failAlways).
+type s3TestServer struct {
+	mu         sync.Mutex
+	parts      map[int][]byte
+	object     []byte
+	failPart   int
+	failAlways bool
+	failed     bool
+	aborted    bool
+	inFlight   int
+	maxFlight  int
+}
+
+func (s *s3TestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		_, _ = fmt.Fprint(w, `<InitiateMultipartUploadResult><UploadId>up-1</UploadId></InitiateMultipartUploadResult>`)
+
+	case r.Method == http.MethodPut:
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		data, _ := io.ReadAll(r.Body)
+
+		s.mu.Lock()
+		s.inFlight++
+		s.maxFlight = max(s.maxFlight, s.inFlight)
+		fail := number == s.failPart && (s.failAlways || !s.failed)
+		s.failed = s.failed || fail
+		s.mu.Unlock()
+
+		time.Sleep(5 * time.Millisecond)
+
+		s.mu.Lock()
+		s.inFlight--
+		if !fail {
+			s.parts[number] = data
+		}
+		s.mu.Unlock()
+
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("ETag", fmt.Sprintf(`"etag-%d"`, number))
+
+	case r.Method == http.MethodPost && query.Get("uploadId") == "up-1":
+		var complete struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		if err := xml.NewDecoder(r.Body).Decode(&complete); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		for i, part := range complete.Parts {
+			if part.PartNumber != i+1 || part.ETag != fmt.Sprintf(`"etag-%d"`, i+1) {
+				s.mu.Unlock()
+				_, _ = fmt.Fprint(w, `<Error><Code>InvalidPart</Code><Message>bad part</Message></Error>`)
+				return
+			}
+			s.object = append(s.object, s.parts[part.PartNumber]...)
+		}
+		s.mu.Unlock()
+		_, _ = fmt.Fprint(w, `<CompleteMultipartUploadResult><ETag>"final"</ETag></CompleteMultipartUploadResult>`)
+
+	case r.Method == http.MethodDelete && query.Get("uploadId") == "up-1":
+		s.mu.Lock()
+		s.aborted = true
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	s3 := &s3TestServer{parts: map[int][]byte{}, failPart: 3}
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	client, err := NewClient(WithInitialRetryDelay(time.Millisecond), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	data := []byte(strings.Repeat("0123456789", 10))
+	upload := &MultipartUpload{URL: server.URL + "/bucket/key", PartSize: 15, Concurrency: 2}
+	if err := client.UploadMultipart(context.Background(), upload, bytes.NewReader(data), int64(len(data))); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if upload.UploadID != "up-1" || upload.ETag != `"final"` {
+		t.Errorf("Unexpected upload result: %+v", upload)
+	}
+	if !bytes.Equal(s3.object, data) {
+		t.Errorf("Expected assembled object %q, got %q", data, s3.object)
+	}
+	if len(s3.parts) != 7 || s3.maxFlight > 2 {
+		t.Errorf("Expected 7 parts with at most 2 in flight, got %d parts, %d in flight",
+			len(s3.parts), s3.maxFlight)
+	}
+	if s3.aborted {
+		t.Error("Upload must not be aborted")
+	}
+}
+
+func TestUploadMultipart_AbortOnFailure(t *testing.T) {
+	s3 := &s3TestServer{parts: map[int][]byte{}, failPart: 2, failAlways: true}
+	server := httptest.NewServer(s3)
+	defer server.Close()
+
+	client, err := NewClient(WithMaxRetries(1), WithInitialRetryDelay(time.Millisecond), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	upload := &MultipartUpload{URL: server.URL + "/bucket/key", PartSize: 10}
+	err = client.UploadMultipart(context.Background(), upload, strings.NewReader(strings.Repeat("x", 50)), 50)
+	if err == nil || !strings.Contains(err.Error(), "multipart part 2") {
+		t.Fatalf("Expected part 2 to fail, got %v", err)
+	}
+	if !s3.aborted {
+		t.Error("Expected the upload to be aborted")
+	}
+}
@@ -0,0 +1,81 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// ErrQueuedOffline is returned (as the RetryError's LastErr, with the queued
+// request's ID) when a client with WithOfflineQueue parks a request while
+// offline instead of retrying it. It wraps ErrOffline.
+var ErrQueuedOffline = fmt.Errorf("%w: request queued for replay", ErrOffline)
+
+// offlineQueue holds the requests a client parks while offline
+// (WithOfflineQueue) and replays them through a Deliverer.
+type offlineQueue struct {
+	store     StateStore
+	opts      []DelivererOption
+	deliverer *Deliverer
+}
+
+// deliveringKey marks the context of a Deliverer's attempts. A Deliverer
+// parks its deliveries itself, so they are not queued again.
+type deliveringKey struct{}
+
+// setupOfflineQueue creates the Deliverer replaying parked requests, and the
+// connectivity monitor detecting when to park them unless one was given.
+func (c *Client) setupOfflineQueue() {
+	if c.connectivity == nil {
+		c.connectivity = NewConnectivityMonitor("")
+	}
+	q := c.offlineQueue
+	q.deliverer = NewDeliverer(c, q.store, q.opts...)
+}
+
+// startOfflineQueue schedules the requests a previous process left in the
+// store.
+func (c *Client) startOfflineQueue() error {
+	return c.offlineQueue.deliverer.Start(c.background())
+}
+
+// parkOffline queues req for replay when the client has an offline queue and
+// req is idempotent, and returns the error ending the operation: ErrOffline,
+// or ErrQueuedOffline with the ID of the queued request.
+func (c *Client) parkOffline(ctx context.Context, req *http.Request) error {
+	if c.offlineQueue == nil || ctx.Value(deliveringKey{}) != nil || !isIdempotent(req) {
+		return ErrOffline
+	}
+	state, err := newDeliveryState(req.Clone(context.WithoutCancel(ctx)))
+	if err != nil {
+		return ErrOffline // The body cannot be replayed
+	}
+
+	state.Attempt++ // Counted like a delivery, which park takes back
+	c.offlineQueue.deliverer.park(state)
+	return fmt.Errorf("%w (id %s)", ErrQueuedOffline, state.ID)
+}
+
+// isIdempotent reports whether req can be replayed without the risk of
+// applying it twice: an idempotent method (RFC 9110) or an Idempotency-Key.
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace,
+		http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get(IdempotencyKeyHeader) != ""
+}
+
+// isDialFailure reports whether err comes from resolving or connecting to the
+// host, before any byte of the request was sent.
+func isDialFailure(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr)
+}
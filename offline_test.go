@@ -0,0 +1,98 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithOfflineQueue_ParksAndReplays(t *testing.T) {
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.Method+" "+r.URL.Path)
+	}))
+	defer server.Close()
+
+	connectivity := &switchableConnectivity{}
+	store := newTestStateStore(t)
+	receipts := newReceiptRecorder()
+	client, err := NewClient(WithOfflineQueue(store, connectivity, WithReceipts(receipts.record)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	_, err = client.Get(context.Background(), server.URL+"/first")
+	if !errors.Is(err, ErrQueuedOffline) || !errors.Is(err, ErrOffline) {
+		t.Fatalf("Expected ErrQueuedOffline, got %v", err)
+	}
+	_, err = client.Post(context.Background(), server.URL+"/second",
+		WithHeader(IdempotencyKeyHeader, "second"), WithJSON(map[string]int{"n": 2}))
+	if !errors.Is(err, ErrQueuedOffline) {
+		t.Fatalf("Expected a POST with an Idempotency-Key to be queued, got %v", err)
+	}
+	_, err = client.Post(context.Background(), server.URL+"/unsafe")
+	if !errors.Is(err, ErrOffline) || errors.Is(err, ErrQueuedOffline) {
+		t.Fatalf("Expected a POST without an Idempotency-Key to fail, got %v", err)
+	}
+	if states, _ := store.List(context.Background()); len(states) != 2 {
+		t.Fatalf("Expected 2 queued requests in the store, got %d", len(states))
+	}
+
+	connectivity.online.Store(true)
+	first, second := receipts.wait(t), receipts.wait(t)
+	if !first.Delivered() || !second.Delivered() || second.ID != "second" {
+		t.Errorf("Unexpected replay receipts %+v, %+v", first, second)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(paths) != 2 || paths[0] != "GET /first" || paths[1] != "POST /second" {
+		t.Errorf("Expected replays in order, got %v", paths)
+	}
+	if states, _ := store.List(context.Background()); len(states) != 0 {
+		t.Errorf("Expected an empty store after replay, got %d", len(states))
+	}
+}
+
+func TestWithOfflineQueue_DialFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	network := &unreachableNetwork{}
+	receipts := newReceiptRecorder()
+	client, err := NewClient(
+		WithMaxRetries(5),
+		WithInitialRetryDelay(time.Millisecond),
+		WithDialer(network.dial),
+		WithOfflineQueue(newTestStateStore(t), nil, WithReceipts(receipts.record)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	client.connectivity.(*ConnectivityMonitor).retest = 10 * time.Millisecond
+
+	_, err = client.Get(context.Background(), server.URL+"/items")
+	var retryErr *RetryError
+	if !errors.Is(err, ErrQueuedOffline) || !errors.As(err, &retryErr) {
+		t.Fatalf("Expected ErrQueuedOffline, got %v", err)
+	}
+	if retryErr.Attempts != connectivityDialFailures {
+		t.Errorf("Expected the request parked after %d failed dials, got %d attempts",
+			connectivityDialFailures, retryErr.Attempts)
+	}
+
+	// The network comes back: the replay dials again and reaches the server
+	network.up.Store(true)
+
+	if receipt := receipts.wait(t); !receipt.Delivered() {
+		t.Errorf("Expected the replay to be delivered, got %+v", receipt)
+	}
+}
@@ -2,9 +2,12 @@ package retry
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"io"
+	"maps"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -14,7 +17,9 @@ type OnRetryFunc func(info RetryInfo)
 // Option configures a Client
 type Option func(*Client)
 
-// WithMaxRetries sets the maximum number of retry attempts
+// WithMaxRetries sets the maximum number of retries after the initial
+// attempt: WithMaxRetries(2) makes up to 3 attempts. See WithMaxAttempts to
+// count the initial attempt too.
 func WithMaxRetries(n int) Option {
 	return func(c *Client) {
 		if n >= 0 {
@@ -23,6 +28,18 @@ func WithMaxRetries(n int) Option {
 	}
 }
 
+// WithMaxAttempts sets the maximum number of attempts, counting the initial
+// attempt: WithMaxAttempts(3) makes up to 3 attempts, i.e. WithMaxRetries(2).
+// WithMaxAttempts(1) disables retries. If n < 1, it is ignored. The last of
+// WithMaxRetries and WithMaxAttempts wins.
+func WithMaxAttempts(n int) Option {
+	return func(c *Client) {
+		if n >= 1 {
+			c.maxRetries = n - 1
+		}
+	}
+}
+
 // WithInitialRetryDelay sets the initial delay before the first retry
 func WithInitialRetryDelay(d time.Duration) Option {
 	return func(c *Client) {
@@ -61,6 +78,69 @@ func WithHTTPClient(httpClient *http.Client) Option {
 	}
 }
 
+// WithTransportDefaults makes the client send requests through a transport
+// built by NewTransport(cfg) instead of http.DefaultTransport. Other settings
+// of an http.Client passed earlier with WithHTTPClient are kept; a later
+// WithHTTPClient replaces the whole client, transport included.
+//
+// Example:
+//
+//	client, err := retry.NewClient(
+//		retry.WithTransportDefaults(retry.TransportConfig{
+//			ResponseHeaderTimeout: 5 * time.Second,
+//			MaxIdleConnsPerHost:   64,
+//		}),
+//	)
+func WithTransportDefaults(cfg TransportConfig) Option {
+	return func(c *Client) {
+		newClient := *c.httpClient
+		newClient.Transport = NewTransport(cfg)
+		c.httpClient = &newClient
+	}
+}
+
+// WithDialer makes the client open connections with dial, e.g. to route
+// through a SOCKS5 proxy, set socket options such as SO_MARK, or resolve names
+// with a custom resolver, without building an http.Transport by hand. Retries,
+// connection tracking and observability are unaffected. The client installs
+// dial on a clone of its *http.Transport (the default, or one passed with
+// WithHTTPClient); NewClient fails if the transport is of another type.
+// TLS runs on top of the returned connection.
+//
+// Example:
+//
+//	socks, _ := proxy.SOCKS5("tcp", "127.0.0.1:1080", nil, proxy.Direct)
+//	client, err := retry.NewClient(
+//		retry.WithDialer(socks.(proxy.ContextDialer).DialContext),
+//	)
+func WithDialer(dial DialFunc) Option {
+	return func(c *Client) {
+		c.dialer = dial
+	}
+}
+
+// WithSafeDefaults bounds clients that would otherwise wait forever for a
+// response: when no client, per-attempt or overall timeout is set and the
+// transport has no response header timeout, http.DefaultTransport is replaced
+// by NewTransport's defaults, and a custom *http.Transport is cloned with a
+// 30s response header timeout. Clients that are already bounded are left
+// untouched.
+func WithSafeDefaults() Option {
+	return func(c *Client) {
+		c.safeDefaults = true
+	}
+}
+
+// WithStrictTimeouts makes NewClient fail with an error wrapping
+// ErrUnboundedClient when no timeout bounds an attempt (see WithSafeDefaults),
+// for services that want misconfiguration caught at startup rather than fixed
+// silently. It takes precedence over WithSafeDefaults.
+func WithStrictTimeouts() Option {
+	return func(c *Client) {
+		c.strictTimeouts = true
+	}
+}
+
 // WithRetryableChecker sets a custom function to determine retryable errors
 func WithRetryableChecker(checker RetryableChecker) Option {
 	return func(c *Client) {
@@ -70,6 +150,126 @@ func WithRetryableChecker(checker RetryableChecker) Option {
 	}
 }
 
+// WithRetryableStatuses marks the given HTTP status codes as retryable,
+// regardless of what the retryable checker decides. For example, to retry
+// 425 Too Early in addition to the defaults:
+//
+//	retry.WithRetryableStatuses(http.StatusTooEarly)
+//
+// Multiple calls accumulate; the last option naming a status wins.
+func WithRetryableStatuses(codes ...int) Option {
+	return func(c *Client) {
+		c.setStatusOverrides(codes, true)
+	}
+}
+
+// WithNonRetryableStatuses marks the given HTTP status codes as never retryable,
+// regardless of what the retryable checker decides. For example, to opt out of
+// retrying 408 Request Timeout:
+//
+//	retry.WithNonRetryableStatuses(http.StatusRequestTimeout)
+//
+// Multiple calls accumulate; the last option naming a status wins.
+func WithNonRetryableStatuses(codes ...int) Option {
+	return func(c *Client) {
+		c.setStatusOverrides(codes, false)
+	}
+}
+
+// WithStatusBackoff uses a dedicated backoff curve when retrying the given
+// HTTP status codes, instead of the global one. For example, 429 from a
+// third-party API can wait long while 503 from an internal service retries
+// quickly:
+//
+//	retry.WithStatusBackoff(map[int]retry.BackoffStrategy{
+//	    http.StatusTooManyRequests:    {InitialDelay: 5 * time.Second, MaxDelay: 2 * time.Minute},
+//	    http.StatusServiceUnavailable: {InitialDelay: 50 * time.Millisecond, MaxDelay: time.Second},
+//	})
+//
+// Retry-After and jitter still apply, capped by the strategy's MaxDelay.
+// Statuses not in the map, and network errors, use the global curve.
+// Calling it again adds to or replaces earlier entries.
+func WithStatusBackoff(strategies map[int]BackoffStrategy) Option {
+	return func(c *Client) {
+		if c.statusBackoff == nil {
+			c.statusBackoff = make(map[int]BackoffStrategy, len(strategies))
+		}
+		for code, strategy := range strategies {
+			c.statusBackoff[code] = strategy
+		}
+	}
+}
+
+// WithRetryOnTooEarly opts in to retrying 425 Too Early responses, which a server
+// sends when it refuses to process a request that may have been replayed from
+// TLS 1.3 early data (0-RTT). The retry waits delay, or the server's Retry-After
+// if present, and its context reports EarlyDataAllowed == false so 0-RTT-capable
+// transports complete the handshake first. If delay <= 0, 100ms is used.
+//
+// This is only relevant when a custom transport enables 0-RTT; net/http never
+// sends early data.
+func WithRetryOnTooEarly(delay time.Duration) Option {
+	return func(c *Client) {
+		if delay <= 0 {
+			delay = defaultTooEarlyDelay
+		}
+		c.tooEarlyDelay = delay
+		c.setStatusOverrides([]int{http.StatusTooEarly}, true)
+	}
+}
+
+// WithRetryOnConflict opts in to retrying 409 Conflict (optimistic-concurrency
+// conflicts) and 423 Locked responses, which against eventually-consistent
+// backends frequently succeed on the next try. They use a dedicated short
+// backoff starting at delay and doubling up to 10x delay, independent of the
+// global curve; a Retry-After header is still honored. If delay <= 0, 100ms is
+// used. Combine with WithRetryOnTooEarly to also retry 425.
+func WithRetryOnConflict(delay time.Duration) Option {
+	return func(c *Client) {
+		if delay <= 0 {
+			delay = defaultConflictDelay
+		}
+		c.setStatusOverrides([]int{http.StatusConflict, http.StatusLocked}, true)
+
+		strategy := BackoffStrategy{InitialDelay: delay, MaxDelay: 10 * delay, Multiplier: 2}
+		WithStatusBackoff(map[int]BackoffStrategy{
+			http.StatusConflict: strategy,
+			http.StatusLocked:   strategy,
+		})(c)
+	}
+}
+
+// WithProxyErrorPolicy retries 502 Bad Gateway and 504 Gateway Timeout
+// responses by where they come from: a proxy or gateway in front of the origin
+// (a load balancer, CDN or service mesh), or the origin itself, as told by
+// policy's Proxies, Headers or Classify. A proxy's 502 (e.g. a connection to a
+// backend that just went away) is usually instantly retryable, while an
+// origin's 502 means the origin failed the request and usually is not:
+//
+//	retry.WithProxyErrorPolicy(retry.ProxyErrorPolicy{
+//	    Proxies:      []string{"envoy", "awselb"},
+//	    RetryProxy:   true,
+//	    ProxyBackoff: retry.BackoffStrategy{InitialDelay: 10 * time.Millisecond},
+//	})
+//
+// The policy takes precedence over WithRetryableStatuses and
+// WithNonRetryableStatuses for 502 and 504.
+func WithProxyErrorPolicy(policy ProxyErrorPolicy) Option {
+	return func(c *Client) {
+		c.proxyErrors = &policy
+	}
+}
+
+// setStatusOverrides records a retry decision for each status code.
+func (c *Client) setStatusOverrides(codes []int, retry bool) {
+	if c.statusOverrides == nil {
+		c.statusOverrides = make(map[int]bool, len(codes))
+	}
+	for _, code := range codes {
+		c.statusOverrides[code] = retry
+	}
+}
+
 // WithJitter enables random jitter to prevent thundering herd problem.
 // When enabled, retry delays will be randomized by ±25% to avoid synchronized retries
 // from multiple clients hitting the server at the same time.
@@ -97,6 +297,17 @@ func WithRespectRetryAfter(enabled bool) Option {
 	}
 }
 
+// WithRetryAfterJitterMode controls jitter on delays taken from the Retry-After
+// header. RetryAfterJitterNone (default) waits exactly the server's value;
+// RetryAfterJitterAdditive adds 0-25% so clients told the same time do not all
+// return at once, without ever retrying early; RetryAfterJitterFull applies
+// the usual ±25%. WithJitter does not affect Retry-After delays.
+func WithRetryAfterJitterMode(mode RetryAfterJitterMode) Option {
+	return func(c *Client) {
+		c.retryAfterJitter = mode
+	}
+}
+
 // WithPerAttemptTimeout sets a timeout for each individual retry attempt.
 // This prevents a single slow request from consuming all available retry time.
 // If set to 0 (default), no per-attempt timeout is applied.
@@ -109,6 +320,563 @@ func WithPerAttemptTimeout(d time.Duration) Option {
 	}
 }
 
+// WithCloseIdleOnFailureStreak closes the transport's idle keep-alive connections
+// after n consecutive failed attempts to the same host, forcing the next attempt
+// to dial a fresh connection. This breaks out of pooled connections that are
+// stuck on a dead backend (e.g. behind an L4 load balancer).
+//
+// Streaks are tracked per host and reset on any successful attempt. Because
+// net/http has no per-host API, all idle connections of the transport are
+// closed; in-flight requests are not affected. The transport must implement
+// CloseIdleConnections (as *http.Transport does). If n <= 0, this is disabled.
+func WithCloseIdleOnFailureStreak(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.closeIdleStreak = n
+		}
+	}
+}
+
+// WithLatencyBudget declines to retry once an operation's attempts have used
+// up budget, typically the caller's p99 latency target: a retry started that
+// late can only add tail latency. The operation then returns the failed
+// attempt's result as a RetryError with GiveUpReason GiveUpBudgetExceeded. The
+// budget is measured from the first attempt; if it is not positive, this is
+// disabled.
+func WithLatencyBudget(p99 time.Duration) Option {
+	return func(c *Client) {
+		c.latencyBudget = max(p99, 0)
+	}
+}
+
+// WithDeadlineAwareBackoff fits retries into the context deadline (the
+// caller's, or WithOverallTimeout's). Assuming a retry takes as long as the
+// last attempt, a retry delay is shortened to at most half of the time that
+// would be left before the deadline, so the retry can finish in time; when not
+// even an attempt that long fits, the operation stops with GiveUpReason
+// GiveUpDeadline instead of sleeping into the deadline. Retry-After delays are
+// shortened too. This suits invocation-scoped deadlines such as serverless
+// functions.
+func WithDeadlineAwareBackoff(enabled bool) Option {
+	return func(c *Client) {
+		c.deadlineAware = enabled
+	}
+}
+
+// WithConnectivityChecker asks checker before every attempt whether the device
+// is online. While it is offline, attempts are not made, so retries are not
+// burned on a network that cannot be reached: the operation stops with a
+// RetryError wrapping ErrOffline. A Deliverer sending through the client keeps
+// such deliveries queued and replays them once checker reports online again.
+func WithConnectivityChecker(checker ConnectivityChecker) Option {
+	return func(c *Client) {
+		c.connectivity = checker
+	}
+}
+
+// WithOfflineQueue parks idempotent requests (GET, HEAD, OPTIONS, TRACE, PUT,
+// DELETE, or any request with an Idempotency-Key header) while the network is
+// down, instead of spending retries on them: the request is saved to store
+// and its operation returns a RetryError wrapping ErrQueuedOffline. Parked
+// requests are replayed in order once checker reports online again, each with
+// the client's retry policy and the Deliverer's redelivery rules; opts
+// configure those, and WithReceipts reports each replay's outcome.
+//
+// The network is down while checker reports offline. A nil checker keeps the
+// one set by WithConnectivityChecker, or else uses a ConnectivityMonitor
+// without probe URL: the network is down after 3 consecutive attempts found
+// no network reachable (see ConnectivityMonitor). Requests a previous process left in
+// store are replayed too; give each client a store of its own. Other requests
+// fail with ErrOffline, as with WithConnectivityChecker.
+func WithOfflineQueue(
+	store StateStore,
+	checker ConnectivityChecker,
+	opts ...DelivererOption,
+) Option {
+	return func(c *Client) {
+		if store == nil {
+			c.offlineQueue = nil
+			return
+		}
+		if checker != nil {
+			c.connectivity = checker
+		}
+		c.offlineQueue = &offlineQueue{store: store, opts: opts}
+	}
+}
+
+// WithRegionFailover routes requests to a service deployed in several regions
+// through policy.Regions. Requests are written against any region's base URL
+// and sent to the active region, the primary (first) one at first. After
+// FailoverAfter consecutive failed attempts there, the client fails over to
+// the next region, so the remaining retries and later requests land there.
+// While failed over, the primary is probed every ProbeInterval; after
+// FailbackAfter consecutive healthy probes, the client fails back to it and
+// sticks to it. Every failover and failback is logged and reported to
+// policy.OnEvent:
+//
+//	retry.WithRegionFailover(retry.RegionPolicy{
+//	    Regions: []retry.Region{
+//	        {Name: "eu-west-1", URL: "https://eu-west-1.api.example.com"},
+//	        {Name: "eu-central-1", URL: "https://eu-central-1.api.example.com"},
+//	    },
+//	    ProbePath: "/healthz",
+//	})
+//
+// NewClient fails with ErrInvalidRegion when a region has no name or no
+// absolute URL. Probes run until Close.
+func WithRegionFailover(policy RegionPolicy) Option {
+	return func(c *Client) {
+		c.regionPolicy = &policy
+	}
+}
+
+// WithTrafficSplit routes a percentage of the requests to primary's host to
+// canary endpoints, by base URL (only the scheme and host of either are
+// used), e.g. 5% to a canary deployment:
+//
+//	retry.WithTrafficSplit("https://api.example.com",
+//	    map[string]float64{"https://canary.api.example.com": 5})
+//
+// The other requests to the primary keep their own URL; requests to other
+// hosts are not split. The endpoint is picked once per request, and all its
+// retries stay there (see WithCanaryFailover). Split requests are tagged
+// (WithMetricTag) with EndpointTag: the canary's host, or "primary", so their
+// metrics and spans can be compared. NewClient fails with
+// ErrInvalidTrafficSplit when a base URL is not absolute, or the percentages
+// are negative or add up to more than 100. Calling it again replaces the
+// split.
+func WithTrafficSplit(primary string, percents map[string]float64) Option {
+	return func(c *Client) {
+		c.trafficSplitPrimary = primary
+		c.trafficSplitPercents = maps.Clone(percents)
+	}
+}
+
+// WithCanaryFailover lets a request routed to a canary by WithTrafficSplit
+// fail over to the primary: after a failed attempt on the canary, its retries
+// are sent to the request's own URL instead. Disabled by default.
+func WithCanaryFailover(enabled bool) Option {
+	return func(c *Client) {
+		c.canaryFailover = enabled
+	}
+}
+
+// WithEndpointSelector calls selector before every attempt to choose the host
+// it targets, for failover logic the built-in strategies (WithRegionFailover,
+// WithTrafficSplit) do not cover. attempt is 1 for the first attempt; req is
+// the attempt's request, already routed by those strategies, and lastErr the
+// previous attempt's error (nil on the first attempt; see also
+// AttemptInfoFromContext(req.Context()) for its status). selector returns a
+// host ("api-2.example.com:8443"), a base URL to change the scheme too
+// ("http://10.0.0.2"; only its scheme and host are used), or "" to keep the
+// request's host:
+//
+//	hosts := []string{"api-1.example.com", "api-2.example.com", "api-3.example.com"}
+//	retry.WithEndpointSelector(func(attempt int, req *http.Request, lastErr error) string {
+//	    return hosts[(attempt-1)%len(hosts)]
+//	})
+//
+// A base URL that cannot be parsed fails the operation with
+// ErrInvalidEndpoint. selector may be called concurrently.
+func WithEndpointSelector(selector EndpointSelector) Option {
+	return func(c *Client) {
+		c.endpointSelector = selector
+	}
+}
+
+// WithAttemptTransformer calls transform on the request of every attempt, so
+// later attempts can fall back to something cheaper or different: a backup
+// API key, another auth scheme, degraded query parameters. attempt is 1 for
+// the first attempt; req is the attempt's own copy, already routed (see
+// WithEndpointSelector to change its host) and with a fresh body, so changes
+// do not carry over to the next attempt:
+//
+//	retry.WithAttemptTransformer(func(attempt int, req *http.Request) error {
+//	    if attempt > 1 {
+//	        q := req.URL.Query()
+//	        q.Set("page_size", "20") // Smaller pages are cheaper for the server
+//	        req.URL.RawQuery = q.Encode()
+//	    }
+//	    if attempt > 2 {
+//	        req.Header.Set("Authorization", "Bearer "+backupKey)
+//	    }
+//	    return nil
+//	})
+//
+// A non-nil error stops the operation without further attempts. Repeated
+// calls add transformers that run in order. transform may be called
+// concurrently.
+func WithAttemptTransformer(transform AttemptTransformer) Option {
+	return func(c *Client) {
+		c.attemptTransformers = append(c.attemptTransformers, transform)
+	}
+}
+
+// WithRequestCoalescing merges identical GET requests (same URL and headers,
+// no body) into one upstream call, for extremely hot keys: a request joins
+// the call of an identical one that is still in flight, or that completed less
+// than window (e.g. 10ms) ago, and gets a copy of its response. Unlike
+// singleflight, a burst arriving just after a response is still served by
+// it. The shared call runs with the retry policy and middleware of the
+// client, and with the values and deadline of the first request's context; it
+// is canceled only once every request sharing it is. Each response body is
+// read into memory to be fanned out. A window <= 0 disables coalescing.
+func WithRequestCoalescing(window time.Duration) Option {
+	return func(c *Client) {
+		if window > 0 {
+			c.coalescer = newCoalescer(window)
+		} else {
+			c.coalescer = nil
+		}
+	}
+}
+
+// WithRoutePolicyConfig applies the retry policies of cfg (see
+// ParseRoutePolicyConfig): cfg.Default to the client, and each route's policy
+// to the requests matching its method and path pattern, on top of the
+// client's other settings. Routes are checked in order, after those of
+// earlier calls, and the first match wins. A path pattern uses path.Match
+// syntax, where "*" matches one path segment ("/orders/*"); a pattern ending
+// in "/**" matches everything below its prefix ("/admin/**").
+//
+// Each route's client is built as in WithRoutePolicy: like Client.Clone, and
+// sharing the client's stats and learned per-host state.
+func WithRoutePolicyConfig(cfg *RoutePolicyConfig) Option {
+	return func(c *Client) {
+		if cfg == nil {
+			return
+		}
+		if cfg.Default != nil {
+			for _, opt := range cfg.Default.Options() {
+				opt(c)
+			}
+		}
+		for _, route := range cfg.Routes {
+			c.routes = append(c.routes, routePolicy{
+				method:  route.Method,
+				pattern: route.Path,
+				opts:    route.Policy.Options(),
+			})
+		}
+	}
+}
+
+// WithRoutePolicy applies opts to the requests matching method and pathGlob,
+// on top of the client's other settings, so one client can retry
+// "GET /search" aggressively and never retry "POST /orders":
+//
+//	retry.WithRoutePolicy(http.MethodGet, "/search", retry.WithMaxRetries(5)),
+//	retry.WithRoutePolicy(http.MethodPost, "/orders", retry.WithMaxRetries(0)),
+//
+// An empty or "*" method matches any method, and pathGlob is a path pattern
+// as in WithRoutePolicyConfig. Routes are matched at request time, in the
+// order they were added, and the first match wins. NewClient fails with
+// ErrInvalidRoute for a malformed pathGlob, and with the error of any of opts.
+//
+// The route's client is built like Client.Clone, so options such as
+// WithLogger or WithPerAttemptMiddleware take effect, and it shares the
+// client's stats and learned per-host state unless opts replace them.
+// Background work (WithKeepaliveProbe, WithRegionFailover, WithOfflineQueue,
+// WithConnectivityChecker) is the client's, and has no effect in opts.
+func WithRoutePolicy(method, pathGlob string, opts ...Option) Option {
+	return func(c *Client) {
+		c.routes = append(c.routes, routePolicy{
+			method:  method,
+			pattern: pathGlob,
+			opts:    opts,
+		})
+	}
+}
+
+// WithEndpointRateLimits limits the request rate of individual endpoints
+// with independent token buckets, so one chatty endpoint cannot exhaust the
+// quota of critical ones behind the same client. Keys starting with "/" are
+// path patterns as in WithRoutePolicy; other keys are hosts, with or without
+// the port:
+//
+//	retry.WithEndpointRateLimits(map[string]retry.Rate{
+//	    "/search":             {N: 5, Per: time.Second},
+//	    "/reports/**":         {N: 1, Per: time.Minute},
+//	    "billing.example.com": {N: 100, Per: time.Second},
+//	})
+//
+// Every attempt, retries included, waits for a token of each limit its
+// request matches, after endpoint routing (e.g. WithTrafficSplit). Bursts of
+// up to N requests are allowed. NewClient fails with ErrInvalidRateLimit for
+// a non-positive rate or a malformed pattern. Calling it again replaces the
+// limits.
+func WithEndpointRateLimits(limits map[string]Rate) Option {
+	return func(c *Client) {
+		c.endpointRates = maps.Clone(limits)
+	}
+}
+
+// WithAdmissionControl sheds low-priority requests while the client is
+// overloaded, instead of letting them and their retries pile onto a
+// struggling backend. The client is overloaded while any threshold of policy
+// is exceeded: requests in flight, attempts queued for a connection slot
+// (WithMaxConcurrentPerHost), concurrency capacity (WithAdaptiveConcurrency)
+// or rate-limit token (WithEndpointRateLimits), or the moving average of
+// attempt durations, which halves every 5s without attempts. Requests below
+// policy.MinPriority (see WithPriority) then fail with ErrShed, without any
+// attempt, and their failed attempts are not retried (GiveUpShed):
+//
+//	client, _ := retry.NewClient(retry.WithAdmissionControl(retry.AdmissionPolicy{
+//	    MaxInFlight: 200,
+//	    MaxLatency:  2 * time.Second,
+//	}))
+//	resp, err := client.Get(ctx, url, retry.WithPriority(retry.PriorityLow))
+func WithAdmissionControl(policy AdmissionPolicy) Option {
+	return func(c *Client) {
+		c.admission = &admission{policy: policy}
+	}
+}
+
+// WithKeepaliveProbe sends a HEAD request to url every interval (default 30s)
+// in the background, for as long as the client lives (until Close). It keeps
+// the pooled connection to the host warm in networks whose NATs or firewalls
+// silently drop idle connections, and feeds the host's health to
+// WithRetrySuppression and WithPhiDetector between real requests. Repeat the
+// option for more hosts. See StartKeepAliveProbes for probes bound to a
+// context.
+func WithKeepaliveProbe(url string, interval time.Duration) Option {
+	return func(c *Client) {
+		c.keepAliveProbes = append(c.keepAliveProbes, KeepAliveProbe{
+			URLs:     []string{url},
+			Interval: interval,
+		})
+	}
+}
+
+// WithRetrySuppression skips retries entirely while an outage is in progress:
+// the client tracks the success rate of attempts per host over a sliding
+// window, and when it falls below minSuccessRate (0-1) an operation that fails
+// returns after its current attempt, since retries would only add load to a
+// dependency that is down. Normal retrying resumes as soon as first attempts
+// bring the rate back up.
+//
+// An attempt counts as failed when the client would retry it (a network error
+// or retryable status). The rate is only trusted once the window holds 10
+// attempts. Operations cut short return a RetryError wrapping
+// ErrRetriesSuppressed. If minSuccessRate or window is not positive, this is
+// disabled.
+func WithRetrySuppression(minSuccessRate float64, window time.Duration) Option {
+	return func(c *Client) {
+		if minSuccessRate > 0 && window > 0 {
+			c.hostHealth = &hostHealth{minSuccessRate: minSuccessRate, window: window}
+		} else {
+			c.hostHealth = nil
+		}
+	}
+}
+
+// WithPhiDetector feeds the outcome of every attempt to detector, which keeps
+// a phi accrual suspicion level per host. Share one detector between clients
+// calling the same hosts, and use PhiDetector.CircuitBreaker with
+// PerHostCircuitBreakerMiddleware to stop sending requests to suspected hosts.
+// With a collector implementing SuspicionMetricsCollector, the client reports
+// the host's suspicion level after each attempt.
+func WithPhiDetector(detector *PhiDetector) Option {
+	return func(c *Client) {
+		c.phiDetector = detector
+	}
+}
+
+// WithCoordinator registers the client with a process-wide Coordinator.
+// Every retry is reported to the coordinator, and while a destination host is
+// in a coordinated cooldown the client delays its retries to that host until
+// the cooldown ends. Share one Coordinator across all clients in the process.
+// If nil is provided, coordination is disabled.
+func WithCoordinator(co *Coordinator) Option {
+	return func(c *Client) {
+		c.coordinator = co
+	}
+}
+
+// WithHostThrottle makes the client remember the rate-limit window announced
+// by a 429 response's Retry-After header, per host. Until it passes, every other
+// attempt to that host from this client waits for the window to end, or, if
+// failFast is true, fails immediately with an error wrapping ErrHostThrottled.
+// This keeps concurrent requests from each rediscovering the limit and burning
+// their attempts on it.
+func WithHostThrottle(failFast bool) Option {
+	return func(c *Client) {
+		c.hostThrottle = &hostThrottle{
+			failFast: failFast,
+			until:    make(map[string]time.Time),
+		}
+	}
+}
+
+// WithQuotaPacing makes the client pace requests to each host from the quota
+// it advertises in X-RateLimit-Remaining / X-RateLimit-Reset (or the IETF
+// RateLimit-Remaining / RateLimit-Reset) response headers: the remaining
+// requests are spread evenly over the time left in the window, and requests
+// wait for the reset once the quota is used up. This avoids 429 responses
+// instead of only reacting to them. Hosts that send no quota headers are not
+// paced.
+func WithQuotaPacing(enabled bool) Option {
+	return func(c *Client) {
+		c.quotaPacer = nil
+		if enabled {
+			c.quotaPacer = &quotaPacer{hosts: make(map[string]*hostQuota)}
+		}
+	}
+}
+
+// WithMaxConcurrentPerHost caps the number of attempts from this client that
+// may be in flight to a single host at once; further attempts wait for a slot
+// (or until their context is done). This keeps retries against one slow host
+// from monopolizing capacity shared with healthy hosts. A slot is held from
+// sending the request until the response headers arrive, not while the body
+// is read. If n <= 0, this is disabled.
+func WithMaxConcurrentPerHost(n int) Option {
+	return func(c *Client) {
+		if n > 0 {
+			c.hostLimiter = &hostLimiter{limit: n, slots: make(map[string]chan struct{})}
+		}
+	}
+}
+
+// WithAdaptiveConcurrency limits the attempts in flight to each host with a
+// limit adapted to the host's latency, in the style of Netflix's gradient
+// concurrency limiter. The limit grows while latency stays near its
+// long-term average, shrinks as latency rises (requests are queueing at the
+// host), and drops by 10% on a timeout or a 429, 503 or 504 response, so it
+// tracks the capacity of autoscaling backends where a static
+// WithMaxConcurrentPerHost cannot. Attempts over the limit wait for capacity
+// (or until their context is done). Both options can be combined; the static
+// cap then bounds the adaptive limit.
+//
+//	retry.WithAdaptiveConcurrency(retry.AdaptiveLimits(10, 2, 100))
+//
+// Read a host's current limit with Client.ConcurrencyLimit.
+func WithAdaptiveConcurrency(opts ...AdaptiveOption) Option {
+	return func(c *Client) {
+		c.adaptive = newAdaptiveLimiter(opts)
+	}
+}
+
+// WithBandwidthLimit caps the combined throughput of request body uploads and
+// response body downloads to bytesPerSec, using a token bucket shared by every
+// attempt made through the client. This keeps large transfers (and their
+// retries) from saturating pod network limits and triggering further failures.
+// Uploads and downloads draw from the same budget, with bursts of up to one
+// second's worth of data. If bytesPerSec <= 0, no limit is applied.
+func WithBandwidthLimit(bytesPerSec int64) Option {
+	return func(c *Client) {
+		if bytesPerSec > 0 {
+			c.bandwidth = NewTokenBucketLimiter(int(bytesPerSec), time.Second)
+		}
+	}
+}
+
+// WithOverallTimeout sets a deadline for each logical request, covering every
+// attempt, retry delay, and request-level middleware, even when the caller passes
+// context.Background(). It combines with the caller's context: whichever
+// deadline is earlier wins. The response body remains readable until closed.
+// If set to 0 (default), only the caller's context bounds the request.
+func WithOverallTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		if d >= 0 {
+			c.overallTimeout = d
+		}
+	}
+}
+
+// WithBufferResponseBody reads every response body fully into memory before
+// the retry decision is made, so the retryable checker can inspect
+// resp.Trailer (see TrailerRetryableChecker). A body that fails to read
+// completely is treated as a failed attempt and retried, and discarded: when
+// retries run out, no response is returned. A complete body is returned to
+// the caller buffered. Only enable this for bounded responses.
+func WithBufferResponseBody(enabled bool) Option {
+	return func(c *Client) {
+		c.bufferResponses = enabled
+	}
+}
+
+// WithResponseValidator marks a 2xx response as a failed attempt when validate
+// returns a non-nil error, for APIs that report failures inside successful
+// responses (e.g. 200 with {"status":"retry"}). The attempt error wraps
+// ErrInvalidResponse and is retried like a transport error; wrap the returned
+// error with Permanent to fail without retrying. The body is buffered before
+// validate is called and rewound afterwards, so validate may read it and the
+// caller still receives it. Only use this for responses of bounded size.
+func WithResponseValidator(validate func(*http.Response) error) Option {
+	return func(c *Client) {
+		c.responseValidator = validate
+	}
+}
+
+// WithErrorDecoder decodes the body of the final response into a typed error
+// when that response has an error status (>= 400), whether retries were
+// exhausted or the status was not retryable. The decoded error becomes
+// RetryError.LastErr, so callers can retrieve it with errors.As instead of
+// re-reading the body. DecodeAPIError handles common JSON error bodies. The
+// decoder reads a copy of at most the first 64 KiB of the body and the caller
+// still reads it in full (see WithErrorBodyCapture); a nil result keeps the
+// default behavior.
+func WithErrorDecoder(decode func(*http.Response) error) Option {
+	return func(c *Client) {
+		c.errorDecoder = decode
+	}
+}
+
+// WithDecoder registers decoder for responses of mediaType (e.g.
+// "application/msgpack"), for DecodeResponse and DoAndDecode. JSON
+// (application/json, text/json and +json types) and XML (application/xml,
+// text/xml and +xml types) are built in; a decoder registered for one of
+// those media types replaces the built-in one.
+//
+// Example:
+//
+//	client, err := retry.NewClient(
+//	    retry.WithDecoder("application/msgpack", func(r io.Reader, v any) error {
+//	        return msgpack.NewDecoder(r).Decode(v)
+//	    }),
+//	)
+func WithDecoder(mediaType string, decoder BodyDecoder) Option {
+	return func(c *Client) {
+		if c.decoders == nil {
+			c.decoders = make(map[string]BodyDecoder)
+		}
+		c.decoders[strings.ToLower(mediaType)] = decoder
+	}
+}
+
+// WithErrorBodyCapture configures how WithErrorDecoder reads error bodies:
+// how many bytes the decoder sees, which content types are decoded at all,
+// and whether the body is consumed or left readable for the caller. Use it to
+// keep large HTML error pages from proxies out of memory.
+func WithErrorBodyCapture(capture ErrorBodyCapture) Option {
+	return func(c *Client) {
+		c.errorBody = capture
+	}
+}
+
+// WithRedirectPolicy makes the client follow redirects itself instead of
+// leaving them to the embedded http.Client. At most maxRedirects redirects are
+// followed per request; redirects do not consume retry attempts. 301, 302 and
+// 303 switch to a body-less GET (HEAD stays HEAD), while 307 and 308 resend the
+// same method and body, which requires req.GetBody for non-empty bodies.
+//
+// If retryOnRedirectTarget is true, every redirect target gets the full retry
+// budget; otherwise only the original URL is retried and each target is tried
+// once. A maxRedirects of 0 returns redirect responses to the caller as-is.
+// Negative values are ignored.
+func WithRedirectPolicy(maxRedirects int, retryOnRedirectTarget bool) Option {
+	return func(c *Client) {
+		if maxRedirects >= 0 {
+			c.redirectPolicy = &redirectPolicy{
+				maxRedirects:  maxRedirects,
+				retryOnTarget: retryOnRedirectTarget,
+			}
+		}
+	}
+}
+
 // WithMetrics sets the metrics collector for observability.
 // The collector will receive metrics events for each request attempt, retry, and completion.
 // If nil is provided, metrics collection will be disabled (no-op).
@@ -121,6 +889,86 @@ func WithMetrics(collector MetricsCollector) Option {
 	}
 }
 
+// WithDisabledMetrics stops the client from reporting the given instruments
+// to the metrics collector, e.g. to drop per-attempt events and keep only
+// per-request ones. The other instruments are reported as usual.
+//
+// Example:
+//
+//	client, err := retry.NewClient(
+//		retry.WithMetrics(collector),
+//		retry.WithDisabledMetrics(retry.MetricAttempts, retry.MetricConnections),
+//	)
+func WithDisabledMetrics(instruments ...MetricInstrument) Option {
+	return func(c *Client) {
+		if c.disabledMetrics == nil {
+			c.disabledMetrics = make(map[MetricInstrument]bool, len(instruments))
+		}
+		for _, instrument := range instruments {
+			c.disabledMetrics[instrument] = true
+		}
+	}
+}
+
+// WithPprofLabels runs each retry operation, including request middleware,
+// backoff waits and every attempt, under pprof labels so CPU and goroutine
+// profiles taken during an incident attribute time to the requests being
+// retried. The labels are "http.method", "http.host" and, when the request
+// carries a WithMetricTag("route", template) tag, "http.route". Disabled by
+// default.
+//
+// Example:
+//
+//	resp, err := client.Get(ctx, "https://api.example.com/users/42",
+//		retry.WithMetricTag("route", "/users/{id}"),
+//	)
+func WithPprofLabels(enabled bool) Option {
+	return func(c *Client) {
+		c.pprofLabels = enabled
+	}
+}
+
+// WithBackoffState carries the backoff across the client's calls through
+// state instead of starting every call at the initial delay, and resets it
+// after the consecutive successful calls configured in NewBackoffState. Use it
+// for long-lived polling or consumer loops, where a dependency that stays
+// down should not be hit at the initial delay on every iteration.
+//
+// Example:
+//
+//	client, err := retry.NewClient(
+//		retry.WithBackoffState(retry.NewBackoffState(3)), // Reset after 3 successes
+//	)
+//	for ctx.Err() == nil {
+//		resp, err := client.Get(ctx, queueURL)
+//		// ...
+//	}
+func WithBackoffState(state *BackoffState) Option {
+	return func(c *Client) {
+		c.backoffState = state
+	}
+}
+
+// WithStateStore checkpoints requests marked with WithCheckpoint to store
+// before each retry, so that long-horizon retries (e.g. NewCriticalClient's
+// multi-minute delays) survive a deploy or crash: a new process lists the
+// checkpoints and continues them with Client.Resume. Checkpoints are deleted
+// once their operation finishes.
+//
+// Example:
+//
+//	store, err := retry.NewFileStateStore("/var/lib/myapp/retries")
+//	client, err := retry.NewCriticalClient(retry.WithStateStore(store))
+//	resp, err := client.Post(ctx, url,
+//		retry.WithBody("application/json", bytes.NewReader(payload)),
+//		retry.WithCheckpoint(orderID, "orders/"+orderID+".json"),
+//	)
+func WithStateStore(store StateStore) Option {
+	return func(c *Client) {
+		c.stateStore = store
+	}
+}
+
 // WithTracer sets the distributed tracer for observability.
 // The tracer will create spans for each request and attempt, providing distributed tracing support.
 // If nil is provided, tracing will be disabled (no-op).
@@ -133,6 +981,282 @@ func WithTracer(tracer Tracer) Option {
 	}
 }
 
+// WithRequestID gives every request a correlation ID, sent as the X-Request-ID
+// header on each attempt (including retries and redirects) and included in
+// the request's logs, its request span and RetryError.RequestID. A request
+// that already has an X-Request-ID header keeps it, and an ID set with
+// ContextWithRequestID is reused; otherwise generator is called once per
+// request. A nil generator uses random 32-character hex IDs.
+func WithRequestID(generator func() string) Option {
+	return func(c *Client) {
+		if generator == nil {
+			generator = newRequestID
+		}
+		c.requestIDGenerator = generator
+	}
+}
+
+// WithRetryTelemetryHeaders sends headers describing the retry state on every
+// attempt, so server operators can measure client retry amplification and
+// recognize retries of one operation: X-Retry-Attempt (1 for the first
+// attempt), X-Retry-Max-Attempts, X-Retry-First-Sent (start of the first
+// attempt, RFC 3339 in UTC, the same on every retry) and X-Retry-Policy (see
+// WithRetryPolicyID). They do not replace an Idempotency-Key or X-Request-ID
+// header and can be combined with both.
+func WithRetryTelemetryHeaders(enabled bool) Option {
+	return func(c *Client) {
+		c.retryTelemetry = enabled
+	}
+}
+
+// WithRetryPolicyID sets the policy ID sent as X-Retry-Policy by
+// WithRetryTelemetryHeaders, e.g. the name and version of a shared policy.
+// Without it, the ID is a fingerprint of the client's retry settings, and
+// NewClientFromSpec uses the spec's name and version.
+func WithRetryPolicyID(id string) Option {
+	return func(c *Client) {
+		c.retryPolicyID = id
+	}
+}
+
+// WithRetryEvents writes every retry decision to w as a RetryEvent, one JSON
+// object per line, for ingestion into analytics pipelines separately from the
+// human-oriented logs:
+//
+//	{"schema":1,"time":"...","decision":"retry","method":"GET",
+//	 "url":"https://api.example.com/items","endpoint":"api.example.com",
+//	 "attempt":1,"reason":"5xx","status":503,"delay_ms":100,"elapsed_ms":12}
+//
+// Writes are serialized; w should not block for long, as it is written to
+// from the retry loop. Combine with WithRetryEventFunc to receive the events
+// as values.
+func WithRetryEvents(w io.Writer) Option {
+	return func(c *Client) {
+		if w != nil {
+			c.retryEvents = append(c.retryEvents, jsonEventWriter(w))
+		}
+	}
+}
+
+// WithRetryEventFunc calls fn with every retry decision as a RetryEvent (see
+// WithRetryEvents). fn is called from the retry loop and may be called
+// concurrently.
+func WithRetryEventFunc(fn func(RetryEvent)) Option {
+	return func(c *Client) {
+		if fn != nil {
+			c.retryEvents = append(c.retryEvents, fn)
+		}
+	}
+}
+
+// WithClockSkewRetry signs every attempt with policy.Sign, as of the client's
+// estimate of the server's time, and recovers from clock skew. Signature
+// schemes such as SigV4 or HMAC reject requests signed too far from the
+// server's clock with a 403 that looks permanent. When policy.Detect (default
+// IsClockSkewError) recognizes such a response, the client resynchronizes its
+// clock offset from the response's Date header, re-signs the request and
+// resends it once, within the same attempt and without a backoff delay. The
+// offset then applies to every later request (see Client.ClockOffset).
+//
+// Sign runs after the client's own changes to the request (e.g. WithUserAgent,
+// WithRequestID), just before the attempt is sent; per-attempt middleware
+// must not change signed headers. A Sign error fails the operation without
+// retries. Requests whose body cannot be replayed (no GetBody) are not
+// resent. A nil Sign disables the option.
+//
+//	retry.WithClockSkewRetry(retry.ClockSkewPolicy{
+//	    Sign: func(req *http.Request, now time.Time) error {
+//	        return signer.SignHTTP(req.Context(), creds, req, payloadHash, "s3", region, now)
+//	    },
+//	})
+func WithClockSkewRetry(policy ClockSkewPolicy) Option {
+	return func(c *Client) {
+		c.clockSkew = nil
+		if policy.Sign != nil {
+			c.clockSkew = &clockSkew{policy: policy}
+		}
+	}
+}
+
+// WithUserAgent sets the User-Agent header on every attempt, for APIs that
+// require clients to identify themselves. The library's product token is
+// appended, e.g. "my-service/1.4 go-httpretry/1.2.0"; disable that with
+// WithUserAgentSuffix(false). A User-Agent header set on the request itself
+// takes precedence.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// WithUserAgentSuffix controls whether WithUserAgent appends the library's
+// "go-httpretry/x.y.z" product token (enabled by default).
+func WithUserAgentSuffix(enabled bool) Option {
+	return func(c *Client) {
+		c.omitUserAgentSuffix = !enabled
+	}
+}
+
+// WithMaxResponseHeaderBytes limits the size of response headers the transport
+// accepts. A response exceeding it fails with an error wrapping
+// ErrResponseHeadersTooLarge that is not retried, since the server would send
+// the same headers again. It applies to an *http.Transport (the default),
+// which the client clones rather than modifies; other transports must
+// enforce their own limit.
+func WithMaxResponseHeaderBytes(n int64) Option {
+	return func(c *Client) {
+		c.maxResponseHeaderBytes = n
+	}
+}
+
+// WithRequireContentLength rejects responses whose body has no Content-Length
+// (e.g. chunked or streamed until close), protecting callers that read bodies
+// into memory from unbounded responses. The wire Content-Length counts, so
+// gzip bodies the client decompresses pass when sent with one. A retryable
+// response is retried as usual; the response an operation ends with fails
+// with an error wrapping ErrMissingContentLength instead, returned with its
+// body closed. Responses without a body are not affected.
+func WithRequireContentLength(enabled bool) Option {
+	return func(c *Client) {
+		c.requireContentLength = enabled
+	}
+}
+
+// WithDecompressionLimit bounds gzip-encoded response bodies that are
+// decompressed transparently: a body fails with an error wrapping
+// ErrDecompressionLimit, which is not retried, once it decompresses to more
+// than maxBytes, or (past the first MiB) to more than maxRatio times its
+// compressed size. This keeps a malicious or buggy server from making the
+// client allocate unbounded memory when bodies are buffered. Zero disables
+// either limit.
+//
+// It applies where the transport would decompress, i.e. an *http.Transport
+// (the default) without DisableCompression; requests that set their own
+// Accept-Encoding receive the body as sent and are not affected.
+func WithDecompressionLimit(maxBytes int64, maxRatio float64) Option {
+	return func(c *Client) {
+		if maxBytes <= 0 && maxRatio <= 0 {
+			c.decompressionLimit = nil
+			return
+		}
+		c.decompressionLimit = &decompressionLimit{maxBytes: maxBytes, maxRatio: maxRatio}
+	}
+}
+
+// WithAllowedHosts restricts requests, including redirect targets, to hosts
+// matching patterns: an exact host name ("api.example.com") or a wildcard
+// covering its subdomains ("*.example.com"). Attempts to other hosts fail
+// before sending with an error wrapping ErrBlockedDestination, and are not
+// retried. The host checked is the attempt's final one, after
+// WithEndpointSelector, WithRegionFailover and WithTrafficSplit. Use it in
+// services that fetch user-supplied URLs. Repeated calls accumulate patterns.
+func WithAllowedHosts(patterns ...string) Option {
+	return func(c *Client) {
+		g := c.guard()
+		g.allowedHosts = append(g.allowedHosts, patterns...)
+	}
+}
+
+// WithBlockPrivateNetworks rejects requests, including redirect targets, whose
+// destination is a loopback, private, link-local, multicast, unspecified or
+// carrier-grade NAT address, with an error wrapping ErrBlockedDestination.
+// Host names are checked after DNS resolution: with an *http.Transport the
+// client dials the checked address itself, so DNS rebinding cannot bypass the
+// check. Other transports, and proxied requests, are resolved and checked
+// before each attempt instead. A proxy on a private address is blocked too.
+func WithBlockPrivateNetworks(enabled bool) Option {
+	return func(c *Client) {
+		c.guard().blockPrivate = enabled
+	}
+}
+
+// WithRequestRewriter runs rewrite once per logical request, before request
+// middleware and the first attempt, to adjust the request every retry will
+// send: inject an API version path prefix, map a logical service name to a
+// host, and so on. rewrite receives a clone, so the caller's request is not
+// modified; a non-nil error aborts the request without sending it. Repeated
+// calls add rewriters that run in order. It is a lighter-weight alternative
+// to request middleware for simple URL manipulation.
+//
+// Example:
+//
+//	client, err := retry.NewClient(
+//		retry.WithRequestRewriter(func(req *http.Request) error {
+//			req.URL.Path = "/v2" + req.URL.Path
+//			return nil
+//		}),
+//	)
+func WithRequestRewriter(rewrite func(*http.Request) error) Option {
+	return func(c *Client) {
+		c.requestRewriters = append(c.requestRewriters, rewrite)
+	}
+}
+
+// WithResponseInterceptor runs intercept on the response of every attempt,
+// before it is validated and the retry decision is made, so the retry logic,
+// metrics and the caller all see the normalized response: map a proxy's
+// non-standard status codes, strip hop-by-hop headers, and so on. intercept
+// may modify resp in place or return a replacement (nil keeps resp); a
+// replacement must take over closing the original body. Repeated calls add
+// interceptors that run in order.
+//
+// Example:
+//
+//	client, err := retry.NewClient(
+//		retry.WithResponseInterceptor(func(resp *http.Response) *http.Response {
+//			// The proxy reports upstream timeouts as 598
+//			if resp.StatusCode == 598 {
+//				resp.StatusCode = http.StatusGatewayTimeout
+//				resp.Status = "504 Gateway Timeout"
+//			}
+//			return resp
+//		}),
+//	)
+func WithResponseInterceptor(intercept func(*http.Response) *http.Response) Option {
+	return func(c *Client) {
+		c.responseInterceptors = append(c.responseInterceptors, intercept)
+	}
+}
+
+// WithDefaultRequestOptions sets RequestOptions applied to every request made
+// through the convenience methods (Get, Post, Put, Patch, Delete, Head), before
+// the options passed to the call, so per-call options can override them.
+// Repeated calls accumulate. Requests sent with Do or DoWithContext are not
+// affected.
+//
+// Example:
+//
+//	client, err := retry.NewClient(
+//		retry.WithDefaultRequestOptions(
+//			retry.WithQuery("api-version", "2024-01"),
+//			retry.WithHeader("Accept", "application/json"),
+//		),
+//	)
+func WithDefaultRequestOptions(opts ...RequestOption) Option {
+	return func(c *Client) {
+		c.defaultRequestOptions = append(c.defaultRequestOptions, opts...)
+	}
+}
+
+// WithSpanNameFormatter names the spans created by the client, replacing the
+// default "http.retry.request" and "http.retry.attempt". kind is
+// SpanKindRequest or SpanKindAttempt. Use it to follow existing tracing
+// conventions, e.g. "GET /users/{id}". Passing nil restores the default names.
+func WithSpanNameFormatter(format func(req *http.Request, kind string) string) Option {
+	return func(c *Client) {
+		c.spanNameFormatter = format
+	}
+}
+
+// WithSpanAttributes adds the attributes returned by fn to every request and
+// attempt span, e.g. peer.service or a tenant ID, without wrapping the Tracer.
+// fn is called once per span and must be safe for concurrent use.
+func WithSpanAttributes(fn func(req *http.Request) []Attribute) Option {
+	return func(c *Client) {
+		c.spanAttributesFunc = fn
+	}
+}
+
 // WithLogger sets the structured logger for observability.
 // The logger will output structured logs for request lifecycle events.
 // By default, the client uses slog.Default() which outputs to stderr at INFO level.
@@ -185,7 +1309,21 @@ func WithNoLogging() Option {
 //   - Request/response inspection and modification
 func WithPerAttemptMiddleware(middleware ...Middleware) Option {
 	return func(c *Client) {
-		c.perAttemptMiddleware = append(c.perAttemptMiddleware, middleware...)
+		for _, mw := range middleware {
+			c.perAttemptMiddleware = append(c.perAttemptMiddleware, mw)
+			c.perAttemptMiddlewareNames = append(c.perAttemptMiddlewareNames, "")
+		}
+	}
+}
+
+// WithNamedPerAttemptMiddleware adds per-attempt middleware under name, so it
+// can be listed with MiddlewareNames and removed or replaced in a Clone. If a
+// middleware with the same name is already registered, it is replaced in
+// place and keeps its position in the chain.
+func WithNamedPerAttemptMiddleware(name string, middleware Middleware) Option {
+	return func(c *Client) {
+		c.perAttemptMiddleware, c.perAttemptMiddlewareNames = setNamed(
+			c.perAttemptMiddleware, c.perAttemptMiddlewareNames, name, middleware)
 	}
 }
 
@@ -221,7 +1359,40 @@ func WithPerAttemptMiddleware(middleware ...Middleware) Option {
 //	}
 func WithRequestMiddleware(middleware ...RequestMiddleware) Option {
 	return func(c *Client) {
-		c.requestMiddleware = append(c.requestMiddleware, middleware...)
+		for _, mw := range middleware {
+			c.requestMiddleware = append(c.requestMiddleware, mw)
+			c.requestMiddlewareNames = append(c.requestMiddlewareNames, "")
+		}
+	}
+}
+
+// WithNamedRequestMiddleware adds request-level middleware under name, so it
+// can be listed with MiddlewareNames and removed or replaced in a Clone. If a
+// middleware with the same name is already registered, it is replaced in
+// place and keeps its position in the chain.
+//
+// Example:
+//
+//	base, _ := retry.NewClient(
+//	    retry.WithNamedRequestMiddleware("ratelimit", retry.RateLimitMiddleware(limiter)),
+//	    retry.WithNamedRequestMiddleware("breaker", retry.CircuitBreakerMiddleware(cb)),
+//	)
+//	batch, _ := base.Clone(retry.WithoutMiddleware("ratelimit"))
+func WithNamedRequestMiddleware(name string, middleware RequestMiddleware) Option {
+	return func(c *Client) {
+		c.requestMiddleware, c.requestMiddlewareNames = setNamed(
+			c.requestMiddleware, c.requestMiddlewareNames, name, middleware)
+	}
+}
+
+// WithoutMiddleware removes the named middleware, per-attempt or request-level,
+// registered by earlier options. It is mainly useful with Clone.
+func WithoutMiddleware(names ...string) Option {
+	return func(c *Client) {
+		c.perAttemptMiddleware, c.perAttemptMiddlewareNames = removeNamed(
+			c.perAttemptMiddleware, c.perAttemptMiddlewareNames, names)
+		c.requestMiddleware, c.requestMiddlewareNames = removeNamed(
+			c.requestMiddleware, c.requestMiddlewareNames, names)
 	}
 }
 
@@ -367,3 +1538,104 @@ func WithHeaders(headers map[string]string) RequestOption {
 		}
 	}
 }
+
+// WithAccept sets the Accept header to the given media types, in order of
+// preference, e.g. WithAccept("application/json", "application/xml;q=0.5").
+func WithAccept(types ...string) RequestOption {
+	return func(req *http.Request) {
+		req.Header.Set("Accept", strings.Join(types, ", "))
+	}
+}
+
+// WithQuery sets a query parameter on the request URL, replacing any existing
+// values for key.
+func WithQuery(key, value string) RequestOption {
+	return func(req *http.Request) {
+		query := req.URL.Query()
+		query.Set(key, value)
+		req.URL.RawQuery = query.Encode()
+	}
+}
+
+// requestTimeoutKey carries the WithTimeout duration from the RequestOption to doRequest.
+type requestTimeoutKey struct{}
+
+// WithTimeout bounds the whole call (all attempts and retry delays) to d,
+// without the caller having to derive a context before every convenience-method call.
+// The deadline is combined with the caller's context: whichever expires first wins.
+// The response body remains readable until it is closed.
+// If d <= 0, no additional timeout is applied.
+//
+// Example:
+//
+//	resp, err := client.Get(ctx, url, retry.WithTimeout(5*time.Second))
+func WithTimeout(d time.Duration) RequestOption {
+	return func(req *http.Request) {
+		if d <= 0 {
+			return
+		}
+		*req = *req.WithContext(context.WithValue(req.Context(), requestTimeoutKey{}, d))
+	}
+}
+
+// maxPagesKey carries the WithMaxPages limit from the RequestOption to
+// GetAllPages.
+type maxPagesKey struct{}
+
+// WithMaxPages sets how many pages GetAllPages fetches at most (default
+// DefaultMaxPages), guarding against servers that link pages in a cycle or
+// never stop. It has no effect on other calls. If n <= 0, the default applies.
+func WithMaxPages(n int) RequestOption {
+	return func(req *http.Request) {
+		if n <= 0 {
+			return
+		}
+		*req = *req.WithContext(context.WithValue(req.Context(), maxPagesKey{}, n))
+	}
+}
+
+// WithCheckpoint marks a request for checkpointing to the client's
+// StateStore (see WithStateStore) under id, which must be unique among
+// in-flight requests. The body is not persisted; bodyRef is saved instead so
+// the caller can find it again when resuming (e.g. a blob key or file path).
+// Without a StateStore the option has no effect.
+func WithCheckpoint(id, bodyRef string) RequestOption {
+	return func(req *http.Request) {
+		cp := checkpoint{id: id, bodyRef: bodyRef}
+		*req = *req.WithContext(context.WithValue(req.Context(), checkpointKey{}, cp))
+	}
+}
+
+// WithMetricTag tags the request with key=value for metrics and tracing.
+// Collectors implementing TaggedMetricsCollector record the request's metrics
+// with its tags, and the tags are added to the request span as attributes.
+// Use it to slice retry metrics by feature, tenant or operation; keep values
+// low-cardinality. Repeated calls accumulate tags.
+//
+// Example:
+//
+//	resp, err := client.Get(ctx, url, retry.WithMetricTag("operation", "list_users"))
+func WithMetricTag(key, value string) RequestOption {
+	return func(req *http.Request) {
+		existing, _ := req.Context().Value(metricTagsKey{}).(map[string]string)
+		tags := make(map[string]string, len(existing)+1)
+		for k, v := range existing {
+			tags[k] = v
+		}
+		tags[key] = value
+		*req = *req.WithContext(context.WithValue(req.Context(), metricTagsKey{}, tags))
+	}
+}
+
+// WithPriority sets the priority of the request for load shedding: under
+// WithAdmissionControl, low-priority requests are shed first when the client
+// is overloaded. Requests are PriorityNormal by default.
+//
+// Example:
+//
+//	resp, err := client.Get(ctx, url, retry.WithPriority(retry.PriorityLow))
+func WithPriority(p Priority) RequestOption {
+	return func(req *http.Request) {
+		*req = *req.WithContext(context.WithValue(req.Context(), priorityKey{}, p))
+	}
+}
@@ -0,0 +1,175 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// relayBatchSize is how many due messages a Relay asks its store for at once.
+const relayBatchSize = 100
+
+// OutboxStore is the part of a transactional outbox a Relay needs: reading
+// due messages and recording what happened to them. Messages are RetryStates
+// with an inline Body; Attempt counts the deliveries made so far.
+type OutboxStore interface {
+	// Due returns up to limit messages whose NextAt is not after now, oldest
+	// first. When several relays share the outbox, Due should claim the
+	// messages it returns (e.g. SELECT ... FOR UPDATE SKIP LOCKED, or by
+	// pushing NextAt forward) so that each is sent by one relay at a time.
+	Due(ctx context.Context, now time.Time, limit int) ([]RetryState, error)
+
+	// Save replaces a message after a failed delivery (new Attempt and NextAt)
+	Save(ctx context.Context, msg RetryState) error
+
+	// Delete removes a message once its delivery has a final outcome
+	Delete(ctx context.Context, id string) error
+}
+
+// Outbox is a transactional outbox: a table in the application's database
+// that outgoing requests are written to in the same transaction as the data
+// they announce, so a request is sent if and only if the transaction commits.
+// Tx is the transaction type of the database library, e.g. *sql.Tx. A Relay
+// drains the outbox through the retry client.
+type Outbox[Tx any] interface {
+	OutboxStore
+
+	// Enqueue adds msg to the outbox within tx
+	Enqueue(ctx context.Context, tx Tx, msg RetryState) error
+}
+
+// NewOutboxMessage builds a message for Outbox.Enqueue from a request
+// described like the client's convenience methods do. It is stamped with an
+// Idempotency-Key header unless opts set one, and the key is the message ID.
+// The body must be replayable (WithBody, WithJSON, ...). Client-wide request
+// options (WithDefaultRequestOptions) are applied by the Relay when sending.
+//
+// Example:
+//
+//	tx, err := db.BeginTx(ctx, nil)
+//	// ... write the order ...
+//	msg, err := retry.NewOutboxMessage(http.MethodPost, "https://hooks.example.com/orders",
+//	    retry.WithJSON(order))
+//	if err := outbox.Enqueue(ctx, tx, msg); err != nil { ... }
+//	err = tx.Commit()
+func NewOutboxMessage(method, url string, opts ...RequestOption) (RetryState, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return RetryState{}, err
+	}
+	for _, opt := range opts {
+		opt(req)
+	}
+	return newDeliveryState(req)
+}
+
+// Relay drains an outbox through a retry client with at-least-once
+// semantics: each due message is sent with the client's retry policy, deleted
+// once it has a final outcome (reported through WithReceipts), and otherwise
+// rescheduled with the redelivery backoff.
+type Relay struct {
+	client   *Client
+	store    OutboxStore
+	interval time.Duration
+	policy   deliveryPolicy
+}
+
+// NewRelay creates a Relay sending the messages of store through client,
+// checking for due messages every pollInterval.
+//
+// Example:
+//
+//	relay := retry.NewRelay(client, outbox, time.Second,
+//	    retry.WithReceipts(func(r retry.Receipt) { ... }),
+//	)
+//	go relay.Run(ctx)
+func NewRelay(
+	client *Client,
+	store OutboxStore,
+	pollInterval time.Duration,
+	opts ...DelivererOption,
+) *Relay {
+	return &Relay{
+		client:   client,
+		store:    store,
+		interval: pollInterval,
+		policy:   newDeliveryPolicy(opts),
+	}
+}
+
+// Run relays due messages until ctx is canceled, then returns ctx's error.
+// Messages in flight when ctx is canceled stay in the outbox. Errors reading
+// the outbox are logged and retried on the next poll.
+func (r *Relay) Run(ctx context.Context) error {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.drain(ctx)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// drain relays due messages, batch by batch, until none are left or the
+// store failed to record an outcome.
+func (r *Relay) drain(ctx context.Context) {
+	for ctx.Err() == nil {
+		msgs, err := r.store.Due(ctx, time.Now(), relayBatchSize)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.client.logger.Warn("failed to read outbox", "error", err.Error())
+			}
+			return
+		}
+
+		var wg sync.WaitGroup
+		var storeFailed atomic.Bool
+		for _, msg := range msgs {
+			wg.Go(func() {
+				if !r.relay(ctx, msg) {
+					storeFailed.Store(true)
+				}
+			})
+		}
+		wg.Wait()
+
+		// Messages the store failed to reschedule are due again at once; leave
+		// them for the next poll instead of resending them back-to-back
+		if len(msgs) < relayBatchSize || storeFailed.Load() {
+			return
+		}
+	}
+}
+
+// relay delivers msg once and records the outcome in the outbox. It reports
+// whether the store recorded it.
+func (r *Relay) relay(ctx context.Context, msg RetryState) bool {
+	msg.Attempt++
+	receipt, final := r.client.deliverOnce(ctx, msg)
+	if !r.policy.drop(ctx, r.client.logger, msg, receipt, final) {
+		if ctx.Err() != nil {
+			return true // Shutting down; the message is still due
+		}
+		msg.NextAt = r.policy.nextDelivery(msg.Attempt)
+		if err := r.store.Save(ctx, msg); err != nil {
+			r.client.logger.Warn("failed to reschedule outbox message",
+				"id", msg.ID, "error", err.Error())
+			return false
+		}
+		return true
+	}
+
+	ok := true
+	if err := r.store.Delete(context.WithoutCancel(ctx), msg.ID); err != nil {
+		r.client.logger.Warn("failed to delete outbox message", "id", msg.ID, "error", err.Error())
+		ok = false
+	}
+	r.policy.onReceipt(receipt)
+	return ok
+}
@@ -0,0 +1,203 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryTx stands in for a database transaction: messages enqueued in it
+// reach the outbox only on commit.
+type memoryTx struct {
+	pending []RetryState
+}
+
+// memoryOutbox is an Outbox[*memoryTx] kept in memory.
+type memoryOutbox struct {
+	mu   sync.Mutex
+	msgs map[string]RetryState
+}
+
+func newMemoryOutbox() *memoryOutbox {
+	return &memoryOutbox{msgs: make(map[string]RetryState)}
+}
+
+func (o *memoryOutbox) Enqueue(_ context.Context, tx *memoryTx, msg RetryState) error {
+	tx.pending = append(tx.pending, msg)
+	return nil
+}
+
+func (o *memoryOutbox) commit(tx *memoryTx) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for _, msg := range tx.pending {
+		o.msgs[msg.ID] = msg
+	}
+}
+
+func (o *memoryOutbox) Due(_ context.Context, now time.Time, limit int) ([]RetryState, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var due []RetryState
+	for _, msg := range o.msgs {
+		if !msg.NextAt.After(now) {
+			due = append(due, msg)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].NextAt.Before(due[j].NextAt) })
+	return due[:min(len(due), limit)], nil
+}
+
+func (o *memoryOutbox) Save(_ context.Context, msg RetryState) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.msgs[msg.ID] = msg
+	return nil
+}
+
+func (o *memoryOutbox) Delete(_ context.Context, id string) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	delete(o.msgs, id)
+	return nil
+}
+
+func (o *memoryOutbox) len() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return len(o.msgs)
+}
+
+var _ Outbox[*memoryTx] = (*memoryOutbox)(nil)
+
+func TestNewOutboxMessage(t *testing.T) {
+	msg, err := NewOutboxMessage(http.MethodPost, "https://example.com/hooks",
+		WithJSON(map[string]int{"id": 1}))
+	if err != nil {
+		t.Fatalf("NewOutboxMessage() error = %v", err)
+	}
+	if msg.ID == "" || msg.Header.Get(IdempotencyKeyHeader) != msg.ID {
+		t.Errorf("ID = %q, Idempotency-Key = %q; want the same non-empty key",
+			msg.ID, msg.Header.Get(IdempotencyKeyHeader))
+	}
+	if string(msg.Body) != `{"id":1}` || msg.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("body %q, Content-Type %q; want the JSON request",
+			msg.Body, msg.Header.Get("Content-Type"))
+	}
+}
+
+func TestRelay(t *testing.T) {
+	var mu sync.Mutex
+	var bodies []string
+	fails := 1
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		bodies = append(bodies, string(body))
+		if fails > 0 {
+			fails--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	outbox := newMemoryOutbox()
+
+	// A rolled-back transaction never reaches the outbox
+	for _, commit := range []bool{true, false} {
+		tx := &memoryTx{}
+		msg, err := NewOutboxMessage(http.MethodPost, server.URL,
+			WithBody("text/plain", strings.NewReader("committed")))
+		if err != nil {
+			t.Fatalf("NewOutboxMessage() error = %v", err)
+		}
+		if err := outbox.Enqueue(context.Background(), tx, msg); err != nil {
+			t.Fatalf("Enqueue() error = %v", err)
+		}
+		if commit {
+			outbox.commit(tx)
+		}
+	}
+
+	client, err := NewClient(WithMaxRetries(0))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	receipts := newReceiptRecorder()
+	relay := NewRelay(client, outbox, 5*time.Millisecond,
+		WithReceipts(receipts.record),
+		WithRedeliveryBackoff(10*time.Millisecond, 10*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- relay.Run(ctx) }()
+
+	receipt := receipts.wait(t)
+	if !receipt.Delivered() || receipt.Deliveries != 2 {
+		t.Errorf("receipt = %+v, want delivered on the 2nd delivery", receipt)
+	}
+	if n := outbox.len(); n != 0 {
+		t.Errorf("outbox holds %d messages, want 0", n)
+	}
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run() = %v, want context.Canceled", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(bodies) != 2 || bodies[0] != "committed" || bodies[1] != "committed" {
+		t.Errorf("server received %q, want the committed message twice", bodies)
+	}
+}
+
+// saveFailingOutbox is a memoryOutbox whose Save always fails.
+type saveFailingOutbox struct {
+	*memoryOutbox
+}
+
+func (o saveFailingOutbox) Save(context.Context, RetryState) error {
+	return errors.New("database unavailable")
+}
+
+func TestRelay_SaveFailureWaitsForNextPoll(t *testing.T) {
+	server, hits := countingServer(t, http.StatusServiceUnavailable)
+	outbox := saveFailingOutbox{newMemoryOutbox()}
+	tx := &memoryTx{}
+	for range relayBatchSize {
+		msg, err := NewOutboxMessage(http.MethodPost, server.URL)
+		if err != nil {
+			t.Fatalf("NewOutboxMessage() error = %v", err)
+		}
+		_ = outbox.Enqueue(context.Background(), tx, msg)
+	}
+	outbox.commit(tx)
+
+	client, err := NewClient(WithMaxRetries(0), WithNoLogging())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	relay := NewRelay(client, outbox, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	relay.drain(ctx)
+	if ctx.Err() != nil {
+		t.Fatal("drain() kept resending messages the store failed to reschedule")
+	}
+	if n := hits.Load(); n != relayBatchSize {
+		t.Errorf("server received %d requests, want one batch of %d", n, relayBatchSize)
+	}
+}
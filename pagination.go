@@ -0,0 +1,106 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// DefaultMaxPages is how many pages GetAllPages fetches at most unless
+// WithMaxPages says otherwise.
+const DefaultMaxPages = 100
+
+// ErrTooManyPages is returned by GetAllPages when the pages keep linking to a
+// next page beyond the max-pages guard.
+var ErrTooManyPages = errors.New("retry: too many pages")
+
+// GetAllPages fetches url and the pages after it, following the RFC 8288
+// `Link: <...>; rel="next"` headers used by GitHub, GitLab and many other
+// APIs, and passes each response to handler in order. Every page is a GET
+// with the client's full retry behavior, with opts applied to each page
+// (WithTimeout bounds each page). Relative next links are resolved against
+// the page's URL.
+//
+// handler sees every page response, including non-retryable error statuses,
+// and must not close the body; GetAllPages drains and closes it. Fetching
+// stops at the first page without a next link, when handler returns an error
+// (which is returned as is), when a page fails, or with ErrTooManyPages when
+// the max-pages guard (WithMaxPages) is reached.
+//
+// Example:
+//
+//	var repos []Repo
+//	err := client.GetAllPages(ctx, "https://api.github.com/orgs/golang/repos?per_page=100",
+//	    func(resp *http.Response) error {
+//	        var page []Repo
+//	        if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+//	            return err
+//	        }
+//	        repos = append(repos, page...)
+//	        return nil
+//	    },
+//	    retry.WithHeader("Authorization", "Bearer "+token),
+//	    retry.WithMaxPages(50),
+//	)
+func (c *Client) GetAllPages(
+	ctx context.Context,
+	url string,
+	handler func(*http.Response) error,
+	opts ...RequestOption,
+) error {
+	maxPages := DefaultMaxPages
+	for page := 1; url != ""; page++ {
+		req, err := c.newRequest(ctx, http.MethodGet, url, opts...)
+		if err != nil {
+			return err
+		}
+		if n, ok := req.Context().Value(maxPagesKey{}).(int); ok {
+			maxPages = n
+		}
+		if page > maxPages {
+			return fmt.Errorf("%w: stopped after %d pages, next: %s", ErrTooManyPages, maxPages, url)
+		}
+
+		resp, err := c.doPrepared(ctx, req)
+		if err != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return err
+		}
+
+		url = nextPageURL(resp, req.URL)
+		err = handler(resp)
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// nextPageURL returns the absolute URL of resp's rel="next" link, or "" when
+// there is none. Relative links are resolved against the URL of the request
+// that produced resp (after redirects), or base.
+func nextPageURL(resp *http.Response, base *url.URL) string {
+	if resp.Request != nil {
+		base = resp.Request.URL
+	}
+	for _, h := range resp.Header.Values("Link") {
+		for _, l := range ParseLinkHeader(h) {
+			if !l.HasRel("next") {
+				continue
+			}
+			next, err := base.Parse(l.URL)
+			if err != nil {
+				return ""
+			}
+			return next.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pagedServer serves pages 1..pages of /items, linking each to the next with
+// a relative Link header. The first request for page 2 fails with a 503.
+func pagedServer(t *testing.T, pages int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var failed atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		page = max(page, 1)
+		if page == 2 && failed.CompareAndSwap(false, true) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if pages <= 0 || page < pages {
+			next := fmt.Sprintf(`</items?page=%d>; rel="next"`, page+1)
+			w.Header().Add("Link", next+`, </items?page=1>; rel="first"`)
+		}
+		fmt.Fprintf(w, "page %d", page)
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestGetAllPages(t *testing.T) {
+	server, requests := pagedServer(t, 3)
+	client, err := NewClient(WithInitialRetryDelay(time.Millisecond), WithJitter(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var bodies []string
+	handler := func(resp *http.Response) error {
+		body, err := io.ReadAll(resp.Body)
+		bodies = append(bodies, string(body))
+		return err
+	}
+	err = client.GetAllPages(context.Background(), server.URL+"/items", handler,
+		WithHeader("Authorization", "Bearer token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fmt.Sprint(bodies) != "[page 1 page 2 page 3]" {
+		t.Errorf("Unexpected pages %q", bodies)
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("Expected 4 requests (one retry), got %d", got)
+	}
+}
+
+func TestGetAllPages_MaxPages(t *testing.T) {
+	server, _ := pagedServer(t, 0) // Never ends
+	client, err := NewClient(WithInitialRetryDelay(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pages := 0
+	err = client.GetAllPages(context.Background(), server.URL, func(*http.Response) error {
+		pages++
+		return nil
+	}, WithMaxPages(5))
+	if !errors.Is(err, ErrTooManyPages) {
+		t.Fatalf("Expected ErrTooManyPages, got %v", err)
+	}
+	if pages != 5 {
+		t.Errorf("Expected 5 pages before the guard, got %d", pages)
+	}
+}
+
+func TestGetAllPages_HandlerError(t *testing.T) {
+	server, requests := pagedServer(t, 3)
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := errors.New("stop")
+	err = client.GetAllPages(context.Background(), server.URL, func(*http.Response) error {
+		return stop
+	})
+	if err != stop {
+		t.Fatalf("Expected the handler's error, got %v", err)
+	}
+	if got := requests.Load(); got != 1 {
+		t.Errorf("Expected fetching to stop after the first page, got %d requests", got)
+	}
+}
+
+func TestNextPageURL(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "https://api.example.com/v1/items?page=1", nil)
+	resp := &http.Response{Header: http.Header{}, Request: req}
+	if got := nextPageURL(resp, req.URL); got != "" {
+		t.Errorf("Expected no next page, got %q", got)
+	}
+
+	resp.Header.Add("Link", `<https://api.example.com/v1/items?page=9>; rel="last"`)
+	resp.Header.Add("Link", `<items?page=2>; REL="prev NEXT"`)
+	if got := nextPageURL(resp, req.URL); got != "https://api.example.com/v1/items?page=2" {
+		t.Errorf("Unexpected next page %q", got)
+	}
+}
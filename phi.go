@@ -0,0 +1,226 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// DefaultPhiThreshold is the suspicion level at which a PhiDetector considers
+// a host failed. A phi of 8 means the detector is wrong about one time in 10^8.
+const DefaultPhiThreshold = 8.0
+
+// phiSampleSize is how many intervals between successes a PhiDetector keeps
+// per host.
+const phiSampleSize = 100
+
+// ErrHostSuspected is returned by the circuit breakers of a PhiDetector while
+// the host's suspicion level is at or above the threshold.
+var ErrHostSuspected = errors.New("retry: host suspected of failure")
+
+// SuspicionMetricsCollector is an optional extension of MetricsCollector. When
+// the collector passed to WithMetrics also implements this interface and the
+// client has a PhiDetector (WithPhiDetector), the client reports the host's
+// suspicion level after every attempt.
+type SuspicionMetricsCollector interface {
+	// RecordHostSuspicion records the phi suspicion level of host
+	RecordHostSuspicion(host string, phi float64)
+}
+
+// PhiDetector is a phi accrual failure detector keeping a suspicion level per
+// host. Every successful attempt is a heartbeat: the detector learns the
+// distribution of intervals between successes, and once attempts start
+// failing, the suspicion level (phi) rises continuously with the time since
+// the last success, by how unlikely that silence is under the learned
+// distribution. A slow dependency therefore becomes suspect gradually, before
+// enough hard failures pile up to trip a failure-count circuit breaker.
+//
+// A host that is idle, or whose last attempt succeeded, has a phi of 0. The
+// detector is fed by clients registered with WithPhiDetector and is safe for
+// concurrent use.
+type PhiDetector struct {
+	threshold float64
+
+	mu    sync.Mutex
+	hosts map[string]*phiHost
+}
+
+// phiHost is the per-host state of a PhiDetector.
+type phiHost struct {
+	lastSuccess time.Time
+	lastProbe   time.Time // When a circuit breaker last let a request through while suspected
+	failing     bool      // An attempt failed since lastSuccess
+
+	intervals []time.Duration // Ring buffer of intervals between successes
+	next      int             // Index the next interval is written to
+}
+
+// NewPhiDetector creates a PhiDetector that suspects a host once its phi
+// reaches threshold (DefaultPhiThreshold if threshold <= 0).
+func NewPhiDetector(threshold float64) *PhiDetector {
+	if threshold <= 0 {
+		threshold = DefaultPhiThreshold
+	}
+	return &PhiDetector{threshold: threshold}
+}
+
+// Record feeds the outcome of an attempt to host into the detector. ok is
+// false when the attempt failed in a way the client would retry.
+func (d *PhiDetector) Record(host string, ok bool) {
+	d.record(host, ok, time.Now())
+}
+
+// record is Record at a given time.
+func (d *PhiDetector) record(host string, ok bool, now time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.hosts == nil {
+		d.hosts = make(map[string]*phiHost)
+	}
+	h, found := d.hosts[host]
+	if !found {
+		h = &phiHost{}
+		d.hosts[host] = h
+	}
+
+	if !ok {
+		h.failing = true
+		return
+	}
+	if !h.lastSuccess.IsZero() {
+		if len(h.intervals) < phiSampleSize {
+			h.intervals = append(h.intervals, now.Sub(h.lastSuccess))
+		} else {
+			h.intervals[h.next] = now.Sub(h.lastSuccess)
+		}
+		h.next = (h.next + 1) % phiSampleSize
+	}
+	h.lastSuccess = now
+	h.failing = false
+}
+
+// Phi returns the current suspicion level of host.
+func (d *PhiDetector) Phi(host string) float64 {
+	return d.phi(host, time.Now())
+}
+
+// Suspected reports whether host's suspicion level reached the threshold.
+func (d *PhiDetector) Suspected(host string) bool {
+	return d.Phi(host) >= d.threshold
+}
+
+// phi is Phi at a given time.
+func (d *PhiDetector) phi(host string, now time.Time) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	phi, _ := d.hosts[host].phi(now)
+	return phi
+}
+
+// phi returns the suspicion level of h at now and the mean interval between
+// successes it is based on.
+func (h *phiHost) phi(now time.Time) (float64, time.Duration) {
+	if h == nil || !h.failing || len(h.intervals) == 0 {
+		return 0, 0
+	}
+
+	var sum float64
+	for _, interval := range h.intervals {
+		sum += float64(interval)
+	}
+	mean := sum / float64(len(h.intervals))
+	var variance float64
+	for _, interval := range h.intervals {
+		variance += (float64(interval) - mean) * (float64(interval) - mean)
+	}
+	// A floor on the deviation keeps perfectly regular traffic from making
+	// phi jump on the first late heartbeat
+	stddev := max(math.Sqrt(variance/float64(len(h.intervals))), mean/4, float64(time.Millisecond))
+
+	return phiOf(float64(now.Sub(h.lastSuccess)), mean, stddev), time.Duration(mean)
+}
+
+// allow reports whether a circuit breaker lets a request to host through at
+// now. A suspected host gets a probe request about once per usual interval
+// between successes (at most once a second), so that it can recover.
+func (d *PhiDetector) allow(host string, now time.Time) (bool, float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	h := d.hosts[host]
+	phi, mean := h.phi(now)
+	if phi < d.threshold {
+		return true, phi
+	}
+	if now.Sub(h.lastProbe) < max(mean, time.Second) {
+		return false, phi
+	}
+	h.lastProbe = now
+	return true, phi
+}
+
+// phiOf returns -log10 of the probability that a heartbeat arrives later than
+// elapsed, for normally distributed intervals (using the logistic
+// approximation of the normal CDF).
+func phiOf(elapsed, mean, stddev float64) float64 {
+	y := (elapsed - mean) / stddev
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if elapsed > mean {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
+
+// CircuitBreaker returns a CircuitBreaker for host that is open while the host
+// is suspected, apart from a probe request about once per usual interval
+// between successes (at most once a second) to detect recovery. Its
+// RecordSuccess and RecordFailure do nothing: the detector learns from the
+// attempts of clients registered with WithPhiDetector. Its signature fits
+// PerHostCircuitBreakerMiddleware, to eject suspected hosts.
+//
+// Example:
+//
+//	detector := retry.NewPhiDetector(retry.DefaultPhiThreshold)
+//	client, _ := retry.NewClient(
+//	    retry.WithPhiDetector(detector),
+//	    retry.WithRequestMiddleware(retry.PerHostCircuitBreakerMiddleware(detector.CircuitBreaker)),
+//	)
+func (d *PhiDetector) CircuitBreaker(host string) CircuitBreaker {
+	return phiBreaker{detector: d, host: host}
+}
+
+// phiBreaker is the CircuitBreaker of one host of a PhiDetector.
+type phiBreaker struct {
+	detector *PhiDetector
+	host     string
+}
+
+// Allow fails while the host is suspected, except for probes.
+func (b phiBreaker) Allow() error {
+	if ok, phi := b.detector.allow(b.host, time.Now()); !ok {
+		return fmt.Errorf("%w: %s (phi %.1f)", ErrHostSuspected, b.host, phi)
+	}
+	return nil
+}
+
+// RecordSuccess does nothing; see PhiDetector.CircuitBreaker.
+func (phiBreaker) RecordSuccess() {}
+
+// RecordFailure does nothing; see PhiDetector.CircuitBreaker.
+func (phiBreaker) RecordFailure() {}
+
+// observePhi feeds an attempt outcome to the client's PhiDetector and reports
+// the host's suspicion level.
+func (c *Client) observePhi(host string, ok bool) {
+	if c.phiDetector == nil {
+		return
+	}
+	c.phiDetector.Record(host, ok)
+	if c.suspicionMetrics != nil {
+		c.suspicionMetrics.RecordHostSuspicion(host, c.phiDetector.Phi(host))
+	}
+}
@@ -0,0 +1,154 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// feedPhi records n successes to host, spaced by every, and returns the time
+// of the last one.
+func feedPhi(d *PhiDetector, host string, start time.Time, every time.Duration, n int) time.Time {
+	now := start
+	for range n {
+		d.record(host, true, now)
+		now = now.Add(every)
+	}
+	return now.Add(-every)
+}
+
+func TestPhiDetector_AccruesWhileFailing(t *testing.T) {
+	d := NewPhiDetector(0)
+	if d.threshold != DefaultPhiThreshold {
+		t.Fatalf("threshold = %v, want default", d.threshold)
+	}
+
+	last := feedPhi(d, "a", time.Now(), 100*time.Millisecond, 20)
+	if phi := d.phi("a", last.Add(time.Hour)); phi != 0 {
+		t.Errorf("Idle host phi = %v, want 0", phi)
+	}
+
+	d.record("a", false, last.Add(50*time.Millisecond))
+	early := d.phi("a", last.Add(100*time.Millisecond))
+	late := d.phi("a", last.Add(300*time.Millisecond))
+	if early >= late {
+		t.Errorf("phi must rise with silence: %v then %v", early, late)
+	}
+	if early >= DefaultPhiThreshold || late < DefaultPhiThreshold {
+		t.Errorf("Expected suspicion between 100ms (%v) and 300ms (%v)", early, late)
+	}
+
+	d.record("a", true, last.Add(400*time.Millisecond))
+	if phi := d.phi("a", last.Add(time.Hour)); phi != 0 {
+		t.Errorf("phi after success = %v, want 0", phi)
+	}
+	if phi := d.phi("unknown", time.Now()); phi != 0 {
+		t.Errorf("Unknown host phi = %v, want 0", phi)
+	}
+}
+
+func TestPhiDetector_CircuitBreakerProbes(t *testing.T) {
+	d := NewPhiDetector(DefaultPhiThreshold)
+	last := feedPhi(d, "a", time.Now(), 10*time.Millisecond, 20)
+	d.record("a", false, last)
+
+	suspected := last.Add(time.Minute)
+	if ok, _ := d.allow("a", suspected); !ok {
+		t.Fatal("Expected the first request to a suspected host to be a probe")
+	}
+	ok, phi := d.allow("a", suspected.Add(100*time.Millisecond))
+	if ok || phi < DefaultPhiThreshold {
+		t.Errorf("Expected the host to be ejected, got allowed=%v phi=%v", ok, phi)
+	}
+	if ok, _ := d.allow("a", suspected.Add(time.Second)); !ok {
+		t.Error("Expected another probe a second later")
+	}
+	if ok, _ := d.allow("b", suspected); !ok {
+		t.Error("Hosts must be tracked independently")
+	}
+}
+
+type suspicionCollector struct {
+	MockMetricsCollector
+
+	mu  sync.Mutex
+	phi map[string]float64
+}
+
+func (s *suspicionCollector) RecordHostSuspicion(host string, phi float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.phi == nil {
+		s.phi = make(map[string]float64)
+	}
+	s.phi[host] = phi
+}
+
+func TestWithPhiDetector_EjectsSuspectedHost(t *testing.T) {
+	var failing atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	detector := NewPhiDetector(1)
+	collector := &suspicionCollector{}
+	client, err := NewClient(
+		WithMaxRetries(0),
+		WithPhiDetector(detector),
+		WithMetrics(collector),
+		WithRequestMiddleware(PerHostCircuitBreakerMiddleware(detector.CircuitBreaker)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for range 5 {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+
+	failing.Store(true)
+	host := server.Listener.Addr().String()
+	deadline := time.Now().Add(5 * time.Second)
+	for !detector.Suspected(host) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Host never became suspected, phi %v", detector.Phi(host))
+		}
+		resp, err := client.Get(context.Background(), server.URL)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if errors.Is(err, ErrHostSuspected) {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The probe is spent by now or goes out here; the next request is refused
+	for range 2 {
+		resp, err := client.Get(context.Background(), server.URL)
+		if resp != nil {
+			resp.Body.Close()
+		}
+		if errors.Is(err, ErrHostSuspected) {
+			collector.mu.Lock()
+			defer collector.mu.Unlock()
+			if collector.phi[host] <= 0 {
+				t.Errorf("Expected a reported suspicion level, got %v", collector.phi[host])
+			}
+			return
+		}
+	}
+	t.Error("Expected requests to a suspected host to be refused")
+}
@@ -0,0 +1,142 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// PoolMetricsCollector is an optional extension of MetricsCollector. When the
+// collector passed to WithMetrics also implements this interface, the client
+// reports the behavior of its connection pool: dials, connections closed and
+// why, and the number of open connections per host, so retry spikes can be
+// correlated with pool churn or exhaustion. The host is the dialed address
+// ("host:port").
+type PoolMetricsCollector interface {
+	// RecordDial records a connection attempt and how long it took
+	RecordDial(host string, duration time.Duration, err error)
+
+	// RecordConnClose records a closed connection (reason is a ConnClose* constant)
+	RecordConnClose(host string, reason string)
+
+	// RecordOpenConns records the number of open (idle or in-use) connections to host
+	RecordOpenConns(host string, open int)
+}
+
+// Reasons a pooled connection was closed, reported to PoolMetricsCollector.
+const (
+	ConnCloseLocal  = "local"  // Closed by the client (idle timeout, pool reset)
+	ConnCloseRemote = "remote" // Closed by the server (EOF)
+	ConnCloseError  = "error"  // Closed after a read or write error
+)
+
+// poolTracker wraps a transport's dials to report pool metrics.
+type poolTracker struct {
+	metrics PoolMetricsCollector
+
+	mu   sync.Mutex
+	open map[string]int
+}
+
+// adjust changes the open connection count of host by delta and reports it.
+func (p *poolTracker) adjust(host string, delta int) {
+	p.mu.Lock()
+	if p.open == nil {
+		p.open = make(map[string]int)
+	}
+	p.open[host] += delta
+	open := p.open[host]
+	if open == 0 {
+		delete(p.open, host)
+	}
+	p.mu.Unlock()
+
+	p.metrics.RecordOpenConns(host, open)
+}
+
+// trackDial wraps dial to record each dial and track the connections it opens.
+func (p *poolTracker) trackDial(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		start := time.Now()
+		conn, err := dial(ctx, network, addr)
+		p.metrics.RecordDial(addr, time.Since(start), err)
+		if err != nil {
+			return nil, err
+		}
+		p.adjust(addr, 1)
+		return &trackedConn{Conn: conn, pool: p, host: addr}, nil
+	}
+}
+
+// trackedConn reports its close, and why, to the pool tracker.
+type trackedConn struct {
+	net.Conn
+	pool   *poolTracker
+	host   string
+	reason atomic.Value // First ConnCloseRemote/ConnCloseError seen on Read or Write
+	closed atomic.Bool
+}
+
+func (c *trackedConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	c.noteError(err)
+	return n, err
+}
+
+func (c *trackedConn) Write(p []byte) (int, error) {
+	n, err := c.Conn.Write(p)
+	c.noteError(err)
+	return n, err
+}
+
+// noteError records why the connection is about to be closed. Errors after
+// the client closed the connection itself do not count.
+func (c *trackedConn) noteError(err error) {
+	if err == nil || c.closed.Load() {
+		return
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return // Deadlines set by the transport, not a broken connection
+	}
+	reason := ConnCloseError
+	if errors.Is(err, io.EOF) {
+		reason = ConnCloseRemote
+	}
+	c.reason.CompareAndSwap(nil, reason)
+}
+
+func (c *trackedConn) Close() error {
+	if c.closed.Swap(true) {
+		return c.Conn.Close()
+	}
+	reason, _ := c.reason.Load().(string)
+	if reason == "" {
+		reason = ConnCloseLocal
+	}
+	c.pool.metrics.RecordConnClose(c.host, reason)
+	c.pool.adjust(c.host, -1)
+	return c.Conn.Close()
+}
+
+// installPoolTracker wraps the transport's dials to report pool metrics. It
+// applies to an *http.Transport; other transports are left alone. A custom
+// DialTLSContext is not tracked: the transport needs the *tls.Conn it returns
+// unwrapped to negotiate HTTP/2.
+func (c *Client) installPoolTracker() {
+	tracker := &poolTracker{metrics: c.poolMetrics}
+	c.tuneTransport(func(transport *http.Transport) {
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+		}
+		transport.DialContext = tracker.trackDial(dial)
+	})
+}
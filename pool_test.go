@@ -0,0 +1,139 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// poolMetricsCollector records the pool metrics reported by the client.
+type poolMetricsCollector struct {
+	MockMetricsCollector
+	mu     sync.Mutex
+	dials  int
+	closes []string
+	open   []int
+}
+
+func (m *poolMetricsCollector) RecordDial(host string, duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.dials++
+}
+
+func (m *poolMetricsCollector) RecordConnClose(host string, reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.closes = append(m.closes, reason)
+}
+
+func (m *poolMetricsCollector) RecordOpenConns(host string, open int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.open = append(m.open, open)
+}
+
+func (m *poolMetricsCollector) snapshot() (dials int, closes []string, open []int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.dials, append([]string(nil), m.closes...), append([]int(nil), m.open...)
+}
+
+func newPoolTestClient(t *testing.T, collector *poolMetricsCollector, opts ...Option) *Client {
+	t.Helper()
+	opts = append([]Option{
+		WithMetrics(collector),
+		WithHTTPClient(&http.Client{Transport: &http.Transport{}}),
+		WithMaxRetries(0),
+	}, opts...)
+	client, err := NewClient(opts...)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func TestClient_PoolMetrics(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	collector := &poolMetricsCollector{}
+	client := newPoolTestClient(t, collector)
+
+	for range 2 {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		resp.Body.Close()
+	}
+	client.closeIdleConnections()
+
+	dials, closes, open := collector.snapshot()
+	if dials != 1 {
+		t.Errorf("dials = %d, want 1 (second request reuses the connection)", dials)
+	}
+	if len(closes) != 1 || closes[0] != ConnCloseLocal {
+		t.Errorf("closes = %v, want [%s]", closes, ConnCloseLocal)
+	}
+	if len(open) != 2 || open[0] != 1 || open[1] != 0 {
+		t.Errorf("open = %v, want [1 0]", open)
+	}
+}
+
+func TestClient_PoolMetricsRemoteClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	collector := &poolMetricsCollector{}
+	client := newPoolTestClient(t, collector)
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	server.CloseClientConnections()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		_, closes, _ := collector.snapshot()
+		if len(closes) == 1 {
+			if closes[0] != ConnCloseRemote {
+				t.Errorf("close reason = %q, want %q", closes[0], ConnCloseRemote)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("closes = %v, want one remote close", closes)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestClient_PoolMetricsDisabled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	collector := &poolMetricsCollector{}
+	client := newPoolTestClient(t, collector, WithDisabledMetrics(MetricPool))
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if dials, _, _ := collector.snapshot(); dials != 0 {
+		t.Errorf("dials = %d, want 0 with MetricPool disabled", dials)
+	}
+}
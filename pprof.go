@@ -0,0 +1,26 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"runtime/pprof"
+)
+
+// routeTag is the WithMetricTag key whose value becomes the "http.route"
+// pprof label.
+const routeTag = "route"
+
+// withPprofLabels wraps next so it runs under pprof labels describing req.
+// Goroutines started during the operation inherit the labels.
+func withPprofLabels(next RetryFunc) RetryFunc {
+	return func(ctx context.Context, req *http.Request) (resp *http.Response, err error) {
+		labels := []string{"http.method", req.Method, "http.host", req.URL.Host}
+		if route := metricTagsOf(ctx, req)[routeTag]; route != "" {
+			labels = append(labels, "http.route", route)
+		}
+		pprof.Do(ctx, pprof.Labels(labels...), func(ctx context.Context) {
+			resp, err = next(ctx, req)
+		})
+		return resp, err
+	}
+}
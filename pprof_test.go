@@ -0,0 +1,73 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"testing"
+)
+
+// pprofLabelsOf returns the pprof labels carried by the attempts of a request.
+func pprofLabelsOf(t *testing.T, enabled bool, opts ...RequestOption) map[string]string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	labels := make(map[string]string)
+	client, err := NewClient(
+		WithPprofLabels(enabled),
+		WithPerAttemptMiddleware(func(next http.RoundTripper) http.RoundTripper {
+			return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				pprof.ForLabels(req.Context(), func(key, value string) bool {
+					labels[key] = value
+					return true
+				})
+				return next.RoundTrip(req)
+			})
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL+"/users/42", opts...)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+	return labels
+}
+
+func TestWithPprofLabels(t *testing.T) {
+	labels := pprofLabelsOf(t, true, WithMetricTag("route", "/users/{id}"))
+
+	if labels["http.method"] != http.MethodGet {
+		t.Errorf("http.method = %q, want %q", labels["http.method"], http.MethodGet)
+	}
+	if labels["http.host"] == "" {
+		t.Error("http.host label missing")
+	}
+	if labels["http.route"] != "/users/{id}" {
+		t.Errorf("http.route = %q, want %q", labels["http.route"], "/users/{id}")
+	}
+}
+
+func TestWithPprofLabels_NoRoute(t *testing.T) {
+	labels := pprofLabelsOf(t, true)
+
+	if _, ok := labels["http.route"]; ok {
+		t.Errorf("http.route = %q, want no label without a route tag", labels["http.route"])
+	}
+	if labels["http.method"] != http.MethodGet {
+		t.Errorf("http.method = %q, want %q", labels["http.method"], http.MethodGet)
+	}
+}
+
+func TestWithPprofLabels_Disabled(t *testing.T) {
+	if labels := pprofLabelsOf(t, false); len(labels) != 0 {
+		t.Errorf("labels = %v, want none when disabled", labels)
+	}
+}
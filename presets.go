@@ -1,6 +1,7 @@
 package retry
 
 import (
+	"net/http"
 	"time"
 )
 
@@ -13,19 +14,14 @@ import (
 //   - Initial delay: 100ms (minimal wait time)
 //   - Max delay: 1s (short maximum delay)
 //   - Per-attempt timeout: 3s (prevent slow requests)
+//   - Overall timeout: 10s (bounded worst-case latency)
 //
 // Use cases:
 //   - User-initiated API calls
 //   - Real-time search and autocomplete
 //   - Interactive UI operations requiring fast failure
 func NewRealtimeClient(opts ...Option) (*Client, error) {
-	defaults := []Option{
-		WithMaxRetries(2),
-		WithInitialRetryDelay(100 * time.Millisecond),
-		WithMaxRetryDelay(1 * time.Second),
-		WithPerAttemptTimeout(3 * time.Second),
-	}
-	return NewClient(append(defaults, opts...)...)
+	return NewClientFromSpec(builtinPresets["realtime"], opts...)
 }
 
 // NewBackgroundClient creates a client optimized for background tasks.
@@ -46,15 +42,7 @@ func NewRealtimeClient(opts ...Option) (*Client, error) {
 //   - Data export/import operations
 //   - Async task processing
 func NewBackgroundClient(opts ...Option) (*Client, error) {
-	defaults := []Option{
-		WithMaxRetries(10),
-		WithInitialRetryDelay(5 * time.Second),
-		WithMaxRetryDelay(60 * time.Second),
-		WithRetryDelayMultiple(3.0),
-		WithPerAttemptTimeout(30 * time.Second),
-		WithJitter(true),
-	}
-	return NewClient(append(defaults, opts...)...)
+	return NewClientFromSpec(builtinPresets["background"], opts...)
 }
 
 // NewRateLimitedClient creates a client optimized for APIs with strict rate limits.
@@ -75,15 +63,7 @@ func NewBackgroundClient(opts ...Option) (*Client, error) {
 //   - APIs with published rate limits
 //   - Services providing Retry-After headers
 func NewRateLimitedClient(opts ...Option) (*Client, error) {
-	defaults := []Option{
-		WithMaxRetries(5),
-		WithInitialRetryDelay(2 * time.Second),
-		WithMaxRetryDelay(30 * time.Second),
-		WithPerAttemptTimeout(15 * time.Second),
-		WithRespectRetryAfter(true),
-		WithJitter(true),
-	}
-	return NewClient(append(defaults, opts...)...)
+	return NewClientFromSpec(builtinPresets["rate_limited"], opts...)
 }
 
 // NewMicroserviceClient creates a client optimized for internal microservice communication.
@@ -103,14 +83,7 @@ func NewRateLimitedClient(opts ...Option) (*Client, error) {
 //   - Low-latency internal APIs
 //   - gRPC fallback to HTTP
 func NewMicroserviceClient(opts ...Option) (*Client, error) {
-	defaults := []Option{
-		WithMaxRetries(3),
-		WithInitialRetryDelay(50 * time.Millisecond),
-		WithMaxRetryDelay(500 * time.Millisecond),
-		WithPerAttemptTimeout(2 * time.Second),
-		WithJitter(true),
-	}
-	return NewClient(append(defaults, opts...)...)
+	return NewClientFromSpec(builtinPresets["microservice"], opts...)
 }
 
 // NewAggressiveClient creates a client with aggressive retry behavior.
@@ -129,14 +102,7 @@ func NewMicroserviceClient(opts ...Option) (*Client, error) {
 //   - Services with frequent transient failures
 //   - Scenarios where eventual success is expected
 func NewAggressiveClient(opts ...Option) (*Client, error) {
-	defaults := []Option{
-		WithMaxRetries(10),
-		WithInitialRetryDelay(100 * time.Millisecond),
-		WithMaxRetryDelay(5 * time.Second),
-		WithPerAttemptTimeout(10 * time.Second),
-		WithJitter(true),
-	}
-	return NewClient(append(defaults, opts...)...)
+	return NewClientFromSpec(builtinPresets["aggressive"], opts...)
 }
 
 // NewConservativeClient creates a client with conservative retry behavior.
@@ -154,13 +120,7 @@ func NewAggressiveClient(opts ...Option) (*Client, error) {
 //   - Operations where failures are likely permanent
 //   - Preventing retry storms during outages
 func NewConservativeClient(opts ...Option) (*Client, error) {
-	defaults := []Option{
-		WithMaxRetries(2),
-		WithInitialRetryDelay(5 * time.Second),
-		WithPerAttemptTimeout(20 * time.Second),
-		WithJitter(true),
-	}
-	return NewClient(append(defaults, opts...)...)
+	return NewClientFromSpec(builtinPresets["conservative"], opts...)
 }
 
 // NewWebhookClient creates a client optimized for webhook/callback scenarios.
@@ -180,14 +140,7 @@ func NewConservativeClient(opts ...Option) (*Client, error) {
 //   - Event notification systems
 //   - Outbound webhook deliveries
 func NewWebhookClient(opts ...Option) (*Client, error) {
-	defaults := []Option{
-		WithMaxRetries(1),
-		WithInitialRetryDelay(500 * time.Millisecond),
-		WithMaxRetryDelay(1 * time.Second),
-		WithPerAttemptTimeout(5 * time.Second),
-		WithJitter(true),
-	}
-	return NewClient(append(defaults, opts...)...)
+	return NewClientFromSpec(builtinPresets["webhook"], opts...)
 }
 
 // NewCriticalClient creates a client for mission-critical operations.
@@ -202,6 +155,7 @@ func NewWebhookClient(opts ...Option) (*Client, error) {
 //   - Per-attempt timeout: 60s (generous timeout per attempt)
 //   - Jitter: enabled (prevent synchronized retries)
 //   - Respect Retry-After: enabled (honor server guidance)
+//   - Overall timeout: 15m (bounded worst-case latency)
 //
 // Use cases:
 //   - Payment processing
@@ -209,16 +163,7 @@ func NewWebhookClient(opts ...Option) (*Client, error) {
 //   - Critical data synchronization
 //   - Operations that cannot fail
 func NewCriticalClient(opts ...Option) (*Client, error) {
-	defaults := []Option{
-		WithMaxRetries(15),
-		WithInitialRetryDelay(1 * time.Second),
-		WithMaxRetryDelay(120 * time.Second),
-		WithRetryDelayMultiple(2.0),
-		WithPerAttemptTimeout(60 * time.Second),
-		WithJitter(true),
-		WithRespectRetryAfter(true),
-	}
-	return NewClient(append(defaults, opts...)...)
+	return NewClientFromSpec(builtinPresets["critical"], opts...)
 }
 
 // NewFastFailClient creates a client optimized for fast failure scenarios.
@@ -238,11 +183,83 @@ func NewCriticalClient(opts ...Option) (*Client, error) {
 //   - Quick availability probes
 //   - Circuit breaker implementations
 func NewFastFailClient(opts ...Option) (*Client, error) {
+	return NewClientFromSpec(builtinPresets["fast_fail"], opts...)
+}
+
+// NewServerlessClient creates a client for functions-as-a-service (AWS Lambda,
+// Cloud Functions, ...). The other presets assume long-lived processes; a
+// function invocation instead has a small total time budget and a deadline,
+// and its container is reused between invocations while warm.
+//
+// Configuration:
+//   - Max retries: 2 (there is no time for more)
+//   - Initial delay: 50ms, max delay: 500ms (no long sleeps)
+//   - Per-attempt timeout: 3s
+//   - Overall timeout: 8s (bounded even without an invocation deadline)
+//   - Deadline-aware backoff: enabled (retries fit into the invocation
+//     context's deadline, or are skipped)
+//   - Jitter: enabled (prevent synchronized retries)
+//   - Transport: 2s dial and TLS handshake timeouts, idle connections kept
+//     for 10 minutes so warm invocations reuse them
+//
+// Pass the invocation context to every call so its deadline drives the
+// backoff.
+//
+// Use cases:
+//   - AWS Lambda, Google Cloud Functions, Azure Functions
+//   - Short-lived jobs with a hard deadline
+func NewServerlessClient(opts ...Option) (*Client, error) {
+	transport := NewTransport(TransportConfig{
+		DialTimeout:         2 * time.Second,
+		TLSHandshakeTimeout: 2 * time.Second,
+		IdleConnTimeout:     10 * time.Minute,
+	})
 	defaults := []Option{
-		WithMaxRetries(1),
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxRetries(2),
 		WithInitialRetryDelay(50 * time.Millisecond),
-		WithMaxRetryDelay(200 * time.Millisecond),
-		WithPerAttemptTimeout(1 * time.Second),
+		WithMaxRetryDelay(500 * time.Millisecond),
+		WithPerAttemptTimeout(3 * time.Second),
+		WithOverallTimeout(8 * time.Second),
+		WithDeadlineAwareBackoff(true),
+		WithJitter(true),
+	}
+	return NewClient(append(defaults, opts...)...)
+}
+
+// NewOfflineTolerantClient creates a client for devices with intermittent
+// connectivity (mobile, edge, field equipment), using checker to tell whether
+// the device is online. While it is offline, requests fail fast with
+// ErrOffline instead of burning retries; sent through a Deliverer, they are
+// queued in its StateStore and replayed automatically once checker reports
+// online again.
+//
+// Configuration:
+//   - Connectivity checker: checker (see WithConnectivityChecker)
+//   - Max retries: 3 (retries are for flaky links, not for being offline)
+//   - Initial delay: 1s
+//   - Max delay: 15s
+//   - Per-attempt timeout: 20s (slow cellular links)
+//   - Jitter: enabled (prevent synchronized retries)
+//
+// Use cases:
+//   - Mobile and desktop apps syncing with a backend
+//   - Edge devices and IoT gateways on unreliable links
+//
+// Example:
+//
+//	client, _ := retry.NewOfflineTolerantClient(retry.ConnectivityFunc(reachability.Online))
+//	store, _ := retry.NewFileStateStore(filepath.Join(dataDir, "outbox"))
+//	queue := retry.NewDeliverer(client, store)
+//	_ = queue.Start(ctx) // Replays what was queued before the app restarted
+//	id, err := queue.Deliver(ctx, http.MethodPost, url, retry.WithJSON(event))
+func NewOfflineTolerantClient(checker ConnectivityChecker, opts ...Option) (*Client, error) {
+	defaults := []Option{
+		WithConnectivityChecker(checker),
+		WithMaxRetries(3),
+		WithInitialRetryDelay(1 * time.Second),
+		WithMaxRetryDelay(15 * time.Second),
+		WithPerAttemptTimeout(20 * time.Second),
 		WithJitter(true),
 	}
 	return NewClient(append(defaults, opts...)...)
@@ -32,6 +32,9 @@ func TestNewRealtimeClient(t *testing.T) {
 	if client.perAttemptTimeout != 3*time.Second {
 		t.Errorf("perAttemptTimeout = %v, want 3s", client.perAttemptTimeout)
 	}
+	if client.overallTimeout != 10*time.Second {
+		t.Errorf("overallTimeout = %v, want 10s", client.overallTimeout)
+	}
 }
 
 func TestNewRealtimeClient_WithOverride(t *testing.T) {
@@ -344,6 +347,9 @@ func TestNewCriticalClient(t *testing.T) {
 	if client.perAttemptTimeout != 60*time.Second {
 		t.Errorf("perAttemptTimeout = %v, want 60s", client.perAttemptTimeout)
 	}
+	if client.overallTimeout != 15*time.Minute {
+		t.Errorf("overallTimeout = %v, want 15m", client.overallTimeout)
+	}
 	if !client.jitterEnabled {
 		t.Error("jitterEnabled = false, want true")
 	}
@@ -489,3 +495,48 @@ func TestPresets_Integration(t *testing.T) {
 		})
 	}
 }
+
+func TestNewServerlessClient(t *testing.T) {
+	client, err := NewServerlessClient()
+	if err != nil {
+		t.Fatalf("NewServerlessClient() error = %v", err)
+	}
+
+	if client.maxRetries != 2 {
+		t.Errorf("maxRetries = %d, want 2", client.maxRetries)
+	}
+	if client.maxRetryDelay != 500*time.Millisecond {
+		t.Errorf("maxRetryDelay = %v, want 500ms", client.maxRetryDelay)
+	}
+	if client.overallTimeout != 8*time.Second {
+		t.Errorf("overallTimeout = %v, want 8s", client.overallTimeout)
+	}
+	if !client.deadlineAware {
+		t.Error("Expected deadline-aware backoff")
+	}
+	transport, ok := client.baseTransport.(*http.Transport)
+	if !ok {
+		t.Fatalf("Expected an *http.Transport, got %T", client.baseTransport)
+	}
+	if transport.IdleConnTimeout != 10*time.Minute {
+		t.Errorf("IdleConnTimeout = %v, want 10m", transport.IdleConnTimeout)
+	}
+}
+
+func TestNewOfflineTolerantClient(t *testing.T) {
+	checker := ConnectivityFunc(func(context.Context) bool { return true })
+	client, err := NewOfflineTolerantClient(checker, WithMaxRetries(1))
+	if err != nil {
+		t.Fatalf("NewOfflineTolerantClient() error = %v", err)
+	}
+
+	if client.connectivity == nil {
+		t.Error("Expected the connectivity checker to be installed")
+	}
+	if client.maxRetries != 1 {
+		t.Errorf("maxRetries = %d, want 1 (overridden)", client.maxRetries)
+	}
+	if client.perAttemptTimeout != 20*time.Second {
+		t.Errorf("perAttemptTimeout = %v, want 20s", client.perAttemptTimeout)
+	}
+}
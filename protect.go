@@ -0,0 +1,67 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrResponseHeadersTooLarge is returned (wrapped) when a response's headers
+	// exceed the WithMaxResponseHeaderBytes limit. It is never retried.
+	ErrResponseHeadersTooLarge = errors.New("retry: response headers too large")
+
+	// ErrMissingContentLength is returned (wrapped) when WithRequireContentLength
+	// is enabled and a response body has no Content-Length. It is never retried.
+	ErrMissingContentLength = errors.New("retry: response has no Content-Length")
+)
+
+// tuneTransport replaces the base transport with a tuned clone when it is an
+// *http.Transport, leaving the caller's transport untouched. It reports
+// whether the transport could be tuned.
+func (c *Client) tuneTransport(tune func(*http.Transport)) bool {
+	transport, ok := c.baseTransport.(*http.Transport)
+	if !ok {
+		return false
+	}
+
+	transport = transport.Clone()
+	tune(transport)
+	c.baseTransport = transport
+
+	newClient := *c.httpClient
+	newClient.Transport = transport
+	c.httpClient = &newClient
+	return true
+}
+
+// classifyProtectionError maps the transport's header-limit error to
+// ErrResponseHeadersTooLarge. A server that sends oversized headers will do
+// so again, so the error is marked Permanent.
+func (c *Client) classifyProtectionError(err error) error {
+	if c.maxResponseHeaderBytes > 0 && err != nil &&
+		strings.Contains(err.Error(), "server response headers exceeded") {
+		return Permanent(fmt.Errorf("%w: %w", ErrResponseHeadersTooLarge, err))
+	}
+	return err
+}
+
+// unsizedBody reports whether WithRequireContentLength rejects resp: its body
+// has no Content-Length on the wire. It must run before the client
+// decompresses the body, which drops the length; bodies the transport
+// decompressed itself are let through, since their wire length is unknown.
+func (c *Client) unsizedBody(resp *http.Response) bool {
+	return c.requireContentLength && resp != nil && resp.ContentLength < 0 &&
+		!resp.Uncompressed && resp.Body != nil && resp.Body != http.NoBody
+}
+
+// rejectUnsizedBody fails the response an operation ends with when its body
+// has no Content-Length (see unsizedBody). Retryable responses are retried
+// first, as any other. The body is closed and the response is returned so
+// the status is still reported.
+func rejectUnsizedBody(resp *http.Response) (*http.Response, error) {
+	resp.Body.Close()
+	resp.Body = http.NoBody
+	return resp, Permanent(fmt.Errorf("%w: HTTP %d", ErrMissingContentLength, resp.StatusCode))
+}
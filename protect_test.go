@@ -0,0 +1,142 @@
+package retry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithMaxResponseHeaderBytes(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.Header().Set("X-Large", strings.Repeat("a", 4096))
+	}))
+	defer server.Close()
+
+	transport := &http.Transport{}
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxResponseHeaderBytes(1024),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, ErrResponseHeadersTooLarge) {
+		t.Fatalf("Expected ErrResponseHeadersTooLarge, got %v", err)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 1 {
+		t.Errorf("Expected no retries, got %d attempts", n)
+	}
+	if transport.MaxResponseHeaderBytes != 0 {
+		t.Error("The caller's transport must not be modified")
+	}
+}
+
+func TestWithRequireContentLength(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		if r.URL.Path == "/chunked" {
+			w.(http.Flusher).Flush() // Forces chunked encoding: no Content-Length
+		}
+		_, _ = w.Write([]byte("body"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithRequireContentLength(true),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL+"/sized")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get(context.Background(), server.URL+"/chunked")
+	if !errors.Is(err, ErrMissingContentLength) {
+		t.Fatalf("Expected ErrMissingContentLength, got %v", err)
+	}
+	if resp == nil || resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected the response to be returned, got %v", resp)
+	}
+	if n := atomic.LoadInt32(&attempts); n != 2 {
+		t.Errorf("Expected no retries, got %d attempts", n)
+	}
+}
+
+func TestWithRequireContentLength_GzipAndRetries(t *testing.T) {
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	_, _ = gz.Write([]byte("hello"))
+	gz.Close()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&attempts, 1)
+		switch {
+		case r.URL.Path == "/gzip":
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Set("Content-Length", strconv.Itoa(compressed.Len()))
+			_, _ = w.Write(compressed.Bytes())
+		case n == 2: // First attempt of /flaky: a chunked 503
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.(http.Flusher).Flush()
+			_, _ = w.Write([]byte("try later"))
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithRequireContentLength(true),
+		WithDecompressionLimit(1<<20, 0),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL+"/gzip")
+	if err != nil {
+		t.Fatalf("Expected a gzip body with Content-Length to pass, got %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "hello" {
+		t.Errorf("Expected the decompressed body, got %q", body)
+	}
+
+	resp, err = client.Get(context.Background(), server.URL+"/flaky")
+	if err != nil {
+		t.Fatalf("Expected a chunked 503 to be retried, got %v", err)
+	}
+	resp.Body.Close()
+	if n := atomic.LoadInt32(&attempts); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+}
@@ -0,0 +1,98 @@
+package retry
+
+import (
+	"net/http"
+	"strings"
+)
+
+// ProxyErrorPolicy configures WithProxyErrorPolicy: how to tell a 502 Bad
+// Gateway or 504 Gateway Timeout generated by a proxy or gateway in front of
+// the origin from one the origin returned itself, and how to retry each.
+type ProxyErrorPolicy struct {
+	// Proxies names the proxies and gateways in front of the origin, matched
+	// case-insensitively as substrings, e.g. "cloudflare", "envoy", "awselb",
+	// "varnish". A gateway error comes from a proxy when its Server header
+	// names one, or, without a Server header, when the last hop of its Via
+	// header does: a proxy answering itself has no origin Server to relay.
+	Proxies []string
+
+	// Headers are headers only proxies set on their own errors, e.g.
+	// "X-Envoy-Overloaded" or "X-Amzn-ErrorType". A gateway error carrying
+	// any of them comes from a proxy.
+	Headers []string
+
+	// Classify, when set, replaces Proxies and Headers: it reports whether a
+	// gateway error response was generated by a proxy.
+	Classify func(resp *http.Response) bool
+
+	// RetryProxy and RetryOrigin decide whether gateway errors from a proxy
+	// and from the origin are retried.
+	RetryProxy  bool
+	RetryOrigin bool
+
+	// ProxyBackoff and OriginBackoff are the backoff curves of those retries;
+	// a zero strategy keeps the global curve (or a WithStatusBackoff one).
+	ProxyBackoff  BackoffStrategy
+	OriginBackoff BackoffStrategy
+}
+
+// FromProxy reports whether resp is a 502 or 504 generated by a proxy or
+// gateway rather than by the origin.
+func (p *ProxyErrorPolicy) FromProxy(resp *http.Response) bool {
+	if !isGatewayError(resp) {
+		return false
+	}
+	if p.Classify != nil {
+		return p.Classify(resp)
+	}
+
+	for _, h := range p.Headers {
+		if resp.Header.Get(h) != "" {
+			return true
+		}
+	}
+	if server := resp.Header.Get("Server"); server != "" {
+		return p.namesProxy(server)
+	}
+	if via := resp.Header.Values("Via"); len(via) > 0 {
+		hops := strings.Split(via[len(via)-1], ",")
+		return p.namesProxy(hops[len(hops)-1])
+	}
+	return false
+}
+
+// namesProxy reports whether a Server or Via value names one of p.Proxies.
+func (p *ProxyErrorPolicy) namesProxy(value string) bool {
+	value = strings.ToLower(value)
+	for _, proxy := range p.Proxies {
+		if proxy != "" && strings.Contains(value, strings.ToLower(proxy)) {
+			return true
+		}
+	}
+	return false
+}
+
+// decide returns the policy's retry decision for a gateway error.
+func (p *ProxyErrorPolicy) decide(resp *http.Response) bool {
+	if p.FromProxy(resp) {
+		return p.RetryProxy
+	}
+	return p.RetryOrigin
+}
+
+// backoff returns the policy's backoff curve for a gateway error, and whether
+// it has one.
+func (p *ProxyErrorPolicy) backoff(resp *http.Response) (BackoffStrategy, bool) {
+	strategy := p.OriginBackoff
+	if p.FromProxy(resp) {
+		strategy = p.ProxyBackoff
+	}
+	return strategy, strategy != BackoffStrategy{}
+}
+
+// isGatewayError reports whether resp is a 502 Bad Gateway or 504 Gateway
+// Timeout.
+func isGatewayError(resp *http.Response) bool {
+	return resp != nil &&
+		(resp.StatusCode == http.StatusBadGateway || resp.StatusCode == http.StatusGatewayTimeout)
+}
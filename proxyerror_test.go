@@ -0,0 +1,118 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestProxyErrorPolicy_FromProxy(t *testing.T) {
+	policy := &ProxyErrorPolicy{
+		Proxies: []string{"Envoy", "varnish"},
+		Headers: []string{"X-Amzn-ErrorType"},
+	}
+
+	tests := []struct {
+		name   string
+		status int
+		header http.Header
+		want   bool
+	}{
+		{"proxy server", http.StatusBadGateway, http.Header{"Server": {"envoy"}}, true},
+		{"origin server", http.StatusBadGateway,
+			http.Header{"Server": {"nginx"}, "Via": {"1.1 varnish"}}, false},
+		{"via without server", http.StatusGatewayTimeout,
+			http.Header{"Via": {"1.1 cdn, 1.1 varnish (Varnish/7.4)"}}, true},
+		{"via other hop", http.StatusGatewayTimeout,
+			http.Header{"Via": {"1.1 varnish, 1.1 cdn"}}, false},
+		{"proxy header", http.StatusBadGateway,
+			http.Header{"Server": {"nginx"}, "X-Amzn-Errortype": {"InternalFailure"}}, true},
+		{"no headers", http.StatusBadGateway, http.Header{}, false},
+		{"not a gateway error", http.StatusServiceUnavailable,
+			http.Header{"Server": {"envoy"}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: tt.header}
+			if got := policy.FromProxy(resp); got != tt.want {
+				t.Errorf("FromProxy() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProxyErrorPolicy_Classify(t *testing.T) {
+	policy := &ProxyErrorPolicy{
+		Proxies: []string{"envoy"},
+		Classify: func(resp *http.Response) bool {
+			return resp.Header.Get("X-Origin") == ""
+		},
+	}
+	resp := &http.Response{
+		StatusCode: http.StatusBadGateway,
+		Header:     http.Header{"Server": {"envoy"}, "X-Origin": {"api-1"}},
+	}
+	if policy.FromProxy(resp) {
+		t.Error("Expected Classify to replace Proxies")
+	}
+}
+
+func TestWithProxyErrorPolicy(t *testing.T) {
+	tests := []struct {
+		name         string
+		server       string
+		wantAttempts int32
+	}{
+		{"proxy 502 retried", "envoy", 3},
+		{"origin 502 not retried", "gunicorn", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				attempts.Add(1)
+				w.Header().Set("Server", tt.server)
+				w.WriteHeader(http.StatusBadGateway)
+			}))
+			defer server.Close()
+
+			client, err := NewClient(
+				WithMaxRetries(2),
+				WithInitialRetryDelay(time.Hour), // Only the proxy backoff may be used
+				WithProxyErrorPolicy(ProxyErrorPolicy{
+					Proxies:      []string{"envoy"},
+					RetryProxy:   true,
+					ProxyBackoff: BackoffStrategy{InitialDelay: time.Millisecond, MaxDelay: time.Millisecond},
+				}),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			resp, err := client.Get(context.Background(), server.URL)
+			if err == nil {
+				resp.Body.Close()
+			}
+			if n := attempts.Load(); n != tt.wantAttempts {
+				t.Errorf("Expected %d attempts, got %d", tt.wantAttempts, n)
+			}
+		})
+	}
+}
+
+func TestWithProxyErrorPolicy_OverridesStatuses(t *testing.T) {
+	client, err := NewClient(
+		WithNonRetryableStatuses(http.StatusBadGateway),
+		WithProxyErrorPolicy(ProxyErrorPolicy{Proxies: []string{"envoy"}, RetryProxy: true}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &http.Response{StatusCode: http.StatusBadGateway, Header: http.Header{"Server": {"envoy"}}}
+	if !client.isRetryable(nil, resp) {
+		t.Error("Expected the policy to retry a proxy 502")
+	}
+}
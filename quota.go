@@ -0,0 +1,142 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate-limit quota headers understood by WithQuotaPacing. Both the common
+// X-RateLimit-* headers and the IETF RateLimit-* draft headers are supported.
+var (
+	quotaRemainingHeaders = []string{"X-RateLimit-Remaining", "RateLimit-Remaining"}
+	quotaResetHeaders     = []string{"X-RateLimit-Reset", "RateLimit-Reset"}
+)
+
+// resetEpochThreshold separates reset values given as Unix timestamps from
+// values given as seconds until reset.
+const resetEpochThreshold = 1_000_000_000
+
+// quotaPacer spreads the remaining request quota of each host evenly over the
+// time left in its rate-limit window.
+type quotaPacer struct {
+	mu    sync.Mutex
+	hosts map[string]*hostQuota
+}
+
+// hostQuota is the last quota a host reported, plus the pacing schedule.
+type hostQuota struct {
+	remaining int       // Requests left in the window (decremented locally as they are sent)
+	reset     time.Time // When the window ends
+	next      time.Time // Earliest time the next request may be sent
+}
+
+// update records the quota reported by resp's headers, if any.
+func (p *quotaPacer) update(host string, resp *http.Response, now time.Time) {
+	remaining, reset, ok := parseQuota(resp, now)
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	q, exists := p.hosts[host]
+	if !exists {
+		q = &hostQuota{}
+		p.hosts[host] = q
+	}
+	q.remaining = remaining
+	q.reset = reset
+}
+
+// reserve books the next send slot for host and returns how long to wait for it.
+func (p *quotaPacer) reserve(host string, now time.Time) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	q, ok := p.hosts[host]
+	if !ok || !now.Before(q.reset) {
+		// Unknown quota or the window is over: don't pace
+		return 0
+	}
+
+	if q.remaining <= 0 {
+		// Quota exhausted: hold everything until the window resets
+		return q.reset.Sub(now)
+	}
+
+	start := now
+	if q.next.After(start) {
+		start = q.next
+	}
+	q.next = start.Add(q.reset.Sub(start) / time.Duration(q.remaining))
+	q.remaining--
+	return start.Sub(now)
+}
+
+// parseQuota extracts the remaining requests and window reset time from resp.
+func parseQuota(resp *http.Response, now time.Time) (int, time.Time, bool) {
+	if resp == nil {
+		return 0, time.Time{}, false
+	}
+
+	remaining, okRemaining := firstIntHeader(resp.Header, quotaRemainingHeaders)
+	reset, okReset := firstIntHeader(resp.Header, quotaResetHeaders)
+	if !okRemaining || !okReset || remaining < 0 || reset < 0 {
+		return 0, time.Time{}, false
+	}
+
+	if reset >= resetEpochThreshold {
+		return remaining, time.Unix(int64(reset), 0), true
+	}
+	return remaining, now.Add(time.Duration(reset) * time.Second), true
+}
+
+// firstIntHeader returns the integer value of the first of names present in h.
+func firstIntHeader(h http.Header, names []string) (int, bool) {
+	for _, name := range names {
+		if v := h.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			return n, err == nil
+		}
+	}
+	return 0, false
+}
+
+// recordQuota updates req's host quota from resp when WithQuotaPacing is enabled.
+func (c *Client) recordQuota(req *http.Request, resp *http.Response) {
+	if c.quotaPacer != nil {
+		c.quotaPacer.update(req.URL.Host, resp, time.Now())
+	}
+}
+
+// awaitQuota waits for req's paced send slot when WithQuotaPacing is enabled.
+func (c *Client) awaitQuota(ctx context.Context, req *http.Request) error {
+	if c.quotaPacer == nil {
+		return nil
+	}
+
+	wait := c.quotaPacer.reserve(req.URL.Host, time.Now())
+	if wait <= 0 {
+		return nil
+	}
+
+	if c.loggerEnabled {
+		c.logger.Debug("pacing request to stay within rate-limit quota",
+			"host", req.URL.Host,
+			"wait_ms", wait.Milliseconds(),
+		)
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,120 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestQuotaPacer_SpreadsRemainingOverWindow(t *testing.T) {
+	p := &quotaPacer{hosts: make(map[string]*hostQuota)}
+	now := time.Now()
+
+	resp := &http.Response{Header: http.Header{}}
+	resp.Header.Set("X-RateLimit-Remaining", "4")
+	resp.Header.Set("X-RateLimit-Reset", "8") // Seconds until reset
+	p.update("api", resp, now)
+
+	// 4 requests over 8s: one every 2s
+	for i, expected := range []time.Duration{0, 2 * time.Second, 4 * time.Second, 6 * time.Second} {
+		if got := p.reserve("api", now); got != expected {
+			t.Errorf("Request %d: expected wait %v, got %v", i+1, expected, got)
+		}
+	}
+
+	// Quota used up: wait for the reset
+	if got := p.reserve("api", now); got != 8*time.Second {
+		t.Errorf("Expected to wait for reset once quota is used, got %v", got)
+	}
+
+	// Other hosts and expired windows are not paced
+	if got := p.reserve("other", now); got != 0 {
+		t.Errorf("Expected unknown host not to be paced, got %v", got)
+	}
+	if got := p.reserve("api", now.Add(9*time.Second)); got != 0 {
+		t.Errorf("Expected no pacing after the window reset, got %v", got)
+	}
+}
+
+func TestParseQuota(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	tests := []struct {
+		name          string
+		headers       map[string]string
+		ok            bool
+		expectedReset time.Time
+	}{
+		{"missing", nil, false, time.Time{}},
+		{"remaining only", map[string]string{"X-RateLimit-Remaining": "5"}, false, time.Time{}},
+		{
+			"delta seconds",
+			map[string]string{"X-RateLimit-Remaining": "5", "X-RateLimit-Reset": "30"},
+			true, now.Add(30 * time.Second),
+		},
+		{
+			"unix timestamp",
+			map[string]string{"X-RateLimit-Remaining": "5", "X-RateLimit-Reset": "1700000060"},
+			true, now.Add(time.Minute),
+		},
+		{
+			"ietf draft headers",
+			map[string]string{"RateLimit-Remaining": "5", "RateLimit-Reset": "10"},
+			true, now.Add(10 * time.Second),
+		},
+		{
+			"invalid",
+			map[string]string{"X-RateLimit-Remaining": "many", "X-RateLimit-Reset": "10"},
+			false, time.Time{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{Header: http.Header{}}
+			for k, v := range tt.headers {
+				resp.Header.Set(k, v)
+			}
+			remaining, reset, ok := parseQuota(resp, now)
+			if ok != tt.ok {
+				t.Fatalf("Expected ok=%v, got %v", tt.ok, ok)
+			}
+			if ok && (remaining != 5 || !reset.Equal(tt.expectedReset)) {
+				t.Errorf("Expected 5 remaining until %v, got %d until %v",
+					tt.expectedReset, remaining, reset)
+			}
+		})
+	}
+}
+
+func TestWithQuotaPacing_WaitsForResetWhenExhausted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", "1")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithQuotaPacing(true), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	start := time.Now()
+	resp, err = client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("Expected second request to wait for the quota reset, took %v", elapsed)
+	}
+}
@@ -0,0 +1,116 @@
+package retry
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// TokenBucketLimiter is a RateLimiter backed by a token bucket.
+// It is safe for concurrent use, so a single instance can be shared by any
+// number of Clients to keep them under one combined quota.
+type TokenBucketLimiter struct {
+	mu       sync.Mutex
+	capacity float64   // Maximum tokens (burst size)
+	tokens   float64   // Currently available tokens
+	rate     float64   // Tokens added per second
+	last     time.Time // Last refill time
+}
+
+// NewTokenBucketLimiter creates a limiter allowing n requests per interval,
+// with bursts of up to n requests. For example, NewTokenBucketLimiter(10, time.Second)
+// allows 10 requests per second. Non-positive arguments are treated as 1 and
+// time.Second respectively.
+func NewTokenBucketLimiter(n int, per time.Duration) *TokenBucketLimiter {
+	if n <= 0 {
+		n = 1
+	}
+	if per <= 0 {
+		per = time.Second
+	}
+	return &TokenBucketLimiter{
+		capacity: float64(n),
+		tokens:   float64(n),
+		rate:     float64(n) / per.Seconds(),
+		last:     time.Now(),
+	}
+}
+
+// refill adds tokens accrued since the last call. Callers must hold l.mu.
+func (l *TokenBucketLimiter) refill(now time.Time) {
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.capacity {
+		l.tokens = l.capacity
+	}
+	l.last = now
+}
+
+// Allow reports whether a token is available right now, consuming it if so.
+func (l *TokenBucketLimiter) Allow() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill(time.Now())
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// Wait blocks until a token is available or ctx is done.
+func (l *TokenBucketLimiter) Wait(ctx context.Context) error {
+	return l.waitN(ctx, 1)
+}
+
+// waitN blocks until n tokens are available or ctx is done.
+// n must not exceed the bucket capacity.
+func (l *TokenBucketLimiter) waitN(ctx context.Context, n float64) error {
+	for {
+		l.mu.Lock()
+		l.refill(time.Now())
+		if l.tokens >= n {
+			l.tokens -= n
+			l.mu.Unlock()
+			return nil
+		}
+		wait := time.Duration((n - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			// Re-check: another waiter may have taken the tokens
+		}
+	}
+}
+
+// sharedLimiters is the process-wide registry behind SharedLimiter.
+var sharedLimiters = struct {
+	sync.Mutex
+	byName map[string]*TokenBucketLimiter
+}{byName: make(map[string]*TokenBucketLimiter)}
+
+// SharedLimiter returns the process-wide TokenBucketLimiter registered under
+// name, creating it with NewTokenBucketLimiter(n, per) on first use.
+// Subsequent calls with the same name return the same instance and ignore
+// n and per, so every subsystem calling the same third-party API shares one quota:
+//
+//	limiter := retry.SharedLimiter("github", 5000, time.Hour)
+//	client, _ := retry.NewClient(
+//	    retry.WithRequestMiddleware(retry.RateLimitMiddleware(limiter)),
+//	)
+func SharedLimiter(name string, n int, per time.Duration) *TokenBucketLimiter {
+	sharedLimiters.Lock()
+	defer sharedLimiters.Unlock()
+
+	if l, ok := sharedLimiters.byName[name]; ok {
+		return l
+	}
+	l := NewTokenBucketLimiter(n, per)
+	sharedLimiters.byName[name] = l
+	return l
+}
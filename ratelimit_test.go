@@ -0,0 +1,121 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketLimiter_Allow(t *testing.T) {
+	limiter := NewTokenBucketLimiter(2, time.Hour)
+
+	if !limiter.Allow() || !limiter.Allow() {
+		t.Fatal("Expected burst of 2 to be allowed")
+	}
+	if limiter.Allow() {
+		t.Error("Expected third request to be rejected")
+	}
+}
+
+func TestTokenBucketLimiter_WaitRefills(t *testing.T) {
+	limiter := NewTokenBucketLimiter(1, 50*time.Millisecond)
+	ctx := context.Background()
+
+	start := time.Now()
+	for range 3 {
+		if err := limiter.Wait(ctx); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	// First token is immediate, the next two each wait ~50ms
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Expected Wait to pace requests, took only %v", elapsed)
+	}
+}
+
+func TestTokenBucketLimiter_WaitContextCanceled(t *testing.T) {
+	limiter := NewTokenBucketLimiter(1, time.Hour)
+	limiter.Allow() // Drain the bucket
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+}
+
+func TestNewTokenBucketLimiter_InvalidArgs(t *testing.T) {
+	limiter := NewTokenBucketLimiter(0, 0)
+	if limiter.capacity != 1 || limiter.rate != 1 {
+		t.Errorf("Expected 1 req/s defaults, got capacity=%v rate=%v", limiter.capacity, limiter.rate)
+	}
+}
+
+func TestSharedLimiter_SameInstanceByName(t *testing.T) {
+	a := SharedLimiter("test-shared-a", 1, time.Hour)
+	b := SharedLimiter("test-shared-a", 100, time.Second) // args ignored after first call
+	c := SharedLimiter("test-shared-c", 1, time.Hour)
+
+	if a != b {
+		t.Error("Expected same limiter for the same name")
+	}
+	if a == c {
+		t.Error("Expected different limiters for different names")
+	}
+	if a.capacity != 1 {
+		t.Errorf("Expected first registration to win, got capacity=%v", a.capacity)
+	}
+}
+
+func TestSharedLimiter_AcrossClients(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	newClient := func() *Client {
+		client, err := NewClient(
+			WithRequestMiddleware(RateLimitMiddleware(
+				SharedLimiter("test-shared-clients", 2, time.Hour),
+			)),
+			WithNoLogging(),
+		)
+		if err != nil {
+			t.Fatalf("Failed to create client: %v", err)
+		}
+		return client
+	}
+	clients := []*Client{newClient(), newClient(), newClient()}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var limited int32
+	for _, client := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := client.Get(ctx, server.URL)
+			if err != nil {
+				atomic.AddInt32(&limited, 1)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+
+	// Three clients share one bucket of 2: exactly one must be held back
+	if hits != 2 || limited != 1 {
+		t.Errorf("Expected 2 requests to pass and 1 to be limited, got %d and %d", hits, limited)
+	}
+}
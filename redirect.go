@@ -0,0 +1,142 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrTooManyRedirects is returned (wrapped) when a request exceeds the
+// WithRedirectPolicy limit. The last redirect response is returned alongside it
+// and its body must still be closed by the caller.
+var ErrTooManyRedirects = errors.New("retry: too many redirects")
+
+// redirectPolicy holds the WithRedirectPolicy settings.
+type redirectPolicy struct {
+	maxRedirects  int
+	retryOnTarget bool
+}
+
+// sensitiveHeaders are dropped when a redirect leaves the original host,
+// matching what net/http does for its own redirects.
+var sensitiveHeaders = []string{"Authorization", "Www-Authenticate", "Cookie", "Cookie2"}
+
+// doWithRedirects runs the retry loop for req and follows redirects between
+// loops, so redirects are counted separately from retry attempts. The
+// operation gets one request span and completion metric, covering every hop.
+func (c *Client) doWithRedirects(ctx context.Context, req *http.Request) (*http.Response, error) {
+	maxRetries := c.maxRetries
+	ctx, op := c.startRequestOp(ctx, req, maxRetries, metricTagsOf(ctx, req))
+	defer op.end()
+	ctx = context.WithValue(ctx, requestOpKey{}, op)
+
+	for redirects := 0; ; redirects++ {
+		resp, err := c.retryLoop(ctx, req, maxRetries)
+		if err != nil || !isRedirect(resp) || c.redirectPolicy.maxRedirects == 0 {
+			return resp, err
+		}
+
+		if redirects >= c.redirectPolicy.maxRedirects {
+			err := fmt.Errorf("%w: stopped after %d redirects", ErrTooManyRedirects, redirects)
+			op.success = false
+			if c.tracerEnabled {
+				setSpanStatus(op.span, err)
+			}
+			return resp, err
+		}
+		next, ok := redirectRequest(req, resp)
+		if !ok {
+			// Not followable (no Location, or a 307/308 body we cannot resend)
+			return resp, nil
+		}
+
+		if c.loggerEnabled {
+			c.logger.Debug("following redirect",
+				attrMethod, next.Method,
+				attrURL, next.URL.String(),
+				"status", resp.StatusCode,
+				"redirects", redirects+1,
+			)
+		}
+
+		// Drain so the connection can be reused for the next hop
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		if !c.redirectPolicy.retryOnTarget {
+			maxRetries = 0
+		}
+		if redirects == 0 && resumedAttempts(ctx) > 0 {
+			// Attempts resumed from a checkpoint belong to the first hop only
+			ctx = context.WithValue(ctx, resumedAttemptsKey{}, 0)
+		}
+		req = next
+	}
+}
+
+// isRedirect reports whether resp is a redirect the client knows how to follow.
+func isRedirect(resp *http.Response) bool {
+	if resp == nil {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// redirectRequest builds the request for the target of a redirect response.
+// It returns false when the redirect cannot be followed.
+func redirectRequest(req *http.Request, resp *http.Response) (*http.Request, bool) {
+	loc := resp.Header.Get("Location")
+	if loc == "" {
+		return nil, false
+	}
+	target, err := req.URL.Parse(loc)
+	if err != nil {
+		return nil, false
+	}
+
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	// 307 and 308 must resend the same method and body
+	preserve := resp.StatusCode == http.StatusTemporaryRedirect ||
+		resp.StatusCode == http.StatusPermanentRedirect
+	if preserve && hasBody && req.GetBody == nil {
+		return nil, false
+	}
+
+	next := req.Clone(req.Context())
+	next.URL = target
+	next.Host = ""
+
+	if preserve {
+		if hasBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, false
+			}
+			next.Body = body
+		}
+	} else {
+		if next.Method != http.MethodHead {
+			next.Method = http.MethodGet
+		}
+		next.Body = nil
+		next.GetBody = nil
+		next.ContentLength = 0
+		next.Header.Del("Content-Type")
+		next.Header.Del("Content-Length")
+	}
+
+	if target.Host != req.URL.Host {
+		for _, h := range sensitiveHeaders {
+			next.Header.Del(h)
+		}
+	}
+
+	return next, true
+}
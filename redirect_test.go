@@ -0,0 +1,246 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWithRedirectPolicy_PreservesMethodAndBodyOn307(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusTemporaryRedirect)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || string(body) != "payload" {
+			t.Errorf("Expected POST with body, got %s %q", r.Method, body)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(WithRedirectPolicy(5, true), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Post(context.Background(), server.URL+"/old",
+		WithBody("text/plain", strings.NewReader("payload")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestWithRedirectPolicy_SwitchesToGetOn303(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/submit", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/result", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/result", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.ContentLength != 0 {
+			t.Errorf("Expected body-less GET, got %s with length %d", r.Method, r.ContentLength)
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	client, err := NewClient(WithRedirectPolicy(5, true), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Post(context.Background(), server.URL+"/submit",
+		WithBody("text/plain", strings.NewReader("payload")))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected 200, got %d", resp.StatusCode)
+	}
+}
+
+func TestWithRedirectPolicy_RetriesRedirectTarget(t *testing.T) {
+	tests := []struct {
+		name           string
+		retryOnTarget  bool
+		expectedStatus int
+		expectedCalls  int32
+	}{
+		{"retry on target", true, http.StatusOK, 3},
+		{"single attempt on target", false, http.StatusServiceUnavailable, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var targetCalls int32
+			mux := http.NewServeMux()
+			mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+				http.Redirect(w, r, "/new", http.StatusFound)
+			})
+			mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&targetCalls, 1) < 3 {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			client, err := NewClient(
+				WithMaxRetries(2),
+				WithInitialRetryDelay(time.Millisecond),
+				WithRedirectPolicy(5, tt.retryOnTarget),
+				WithNoLogging(),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp, _ := client.Get(context.Background(), server.URL+"/old")
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+			if got := atomic.LoadInt32(&targetCalls); got != tt.expectedCalls {
+				t.Errorf("Expected %d calls to target, got %d", tt.expectedCalls, got)
+			}
+		})
+	}
+}
+
+func TestWithRedirectPolicy_RecordsOperationOnce(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/new", http.StatusFound)
+	})
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) {})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	metrics := &MockMetricsCollector{}
+	tracer := &MockTracer{}
+	client, err := NewClient(
+		WithRedirectPolicy(5, false),
+		WithMetrics(metrics),
+		WithTracer(tracer),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL+"/old")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	if len(metrics.RequestsComplete) != 1 {
+		t.Fatalf("Expected 1 completed request for 2 hops, got %+v", metrics.RequestsComplete)
+	}
+	if got := metrics.RequestsComplete[0]; got.TotalAttempts != 2 || !got.Success ||
+		got.StatusCode != http.StatusOK {
+		t.Errorf("Expected a successful 200 after 2 attempts, got %+v", got)
+	}
+
+	tracer.mu.Lock()
+	defer tracer.mu.Unlock()
+	var requestSpans int
+	for _, span := range tracer.Spans {
+		if span.Name == "http.retry.request" {
+			requestSpans++
+			if !span.Ended {
+				t.Error("Expected the request span to be ended")
+			}
+		}
+	}
+	if requestSpans != 1 {
+		t.Errorf("Expected 1 request span for 2 hops, got %d", requestSpans)
+	}
+}
+
+func TestWithRedirectPolicy_TooManyRedirects(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/loop", http.StatusFound)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithRedirectPolicy(2, true), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrTooManyRedirects) {
+		t.Fatalf("Expected ErrTooManyRedirects, got %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusFound {
+		t.Errorf("Expected last redirect response, got %d", resp.StatusCode)
+	}
+}
+
+func TestWithRedirectPolicy_ZeroReturnsRedirect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "/elsewhere", http.StatusMovedPermanently)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithRedirectPolicy(0, false), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMovedPermanently {
+		t.Errorf("Expected 301 returned as-is, got %d", resp.StatusCode)
+	}
+}
+
+func TestRedirectRequest_StripsSensitiveHeadersAcrossHosts(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodGet, "http://a.example/path", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	req.Header.Set("X-Trace", "1")
+
+	resp := &http.Response{
+		StatusCode: http.StatusFound,
+		Header:     http.Header{"Location": []string{"http://b.example/other"}},
+	}
+
+	next, ok := redirectRequest(req, resp)
+	if !ok {
+		t.Fatal("Expected redirect to be followable")
+	}
+	if next.Header.Get("Authorization") != "" {
+		t.Error("Authorization must not be forwarded to another host")
+	}
+	if next.Header.Get("X-Trace") != "1" {
+		t.Error("Non-sensitive headers should be preserved")
+	}
+}
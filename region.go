@@ -0,0 +1,283 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults of RegionPolicy.
+const (
+	defaultRegionFailoverAfter = 3
+	defaultRegionFailbackAfter = 3
+	defaultRegionProbeInterval = 10 * time.Second
+	regionProbeTimeout         = 5 * time.Second
+)
+
+// ErrInvalidRegion is returned by NewClient when a RegionPolicy has no
+// regions, or a region without a name or an absolute base URL.
+var ErrInvalidRegion = errors.New("retry: invalid region")
+
+// Region is one regional deployment of a service, for WithRegionFailover.
+type Region struct {
+	Name string // Region name reported in RegionEvents, e.g. "eu-west-1"
+	URL  string // Base URL of the region; only its scheme and host are used
+}
+
+// RegionEventKind is the kind of a RegionEvent.
+type RegionEventKind string
+
+// Region event kinds.
+const (
+	RegionFailover RegionEventKind = "failover" // Moved away from a failing region
+	RegionFailback RegionEventKind = "failback" // Moved back to the recovered primary region
+)
+
+// RegionEvent reports a change of the active region.
+type RegionEvent struct {
+	Kind RegionEventKind
+	From string // Name of the region left
+	To   string // Name of the region now active
+	Time time.Time
+}
+
+// RegionPolicy configures WithRegionFailover.
+type RegionPolicy struct {
+	// Regions in order of preference. The first one is the primary, usually
+	// the local region; the others are failed over to in turn.
+	Regions []Region
+
+	// FailoverAfter is how many consecutive attempts must fail in the active
+	// region before moving to the next one (default 3).
+	FailoverAfter int
+
+	// FailbackAfter is how many consecutive health probes of the primary
+	// region must succeed before moving back to it (default 3).
+	FailbackAfter int
+
+	// ProbeInterval is the time between health probes of the primary region
+	// while failed over (default 10s).
+	ProbeInterval time.Duration
+
+	// ProbePath is the path probed with a HEAD request on the primary region,
+	// e.g. "/healthz" (default "/").
+	ProbePath string
+
+	// OnEvent, when set, is called on every failover and failback.
+	OnEvent func(RegionEvent)
+}
+
+// regionRouter routes the requests of a client with WithRegionFailover to
+// its active region.
+type regionRouter struct {
+	policy RegionPolicy
+	urls   []*url.URL
+
+	mu       sync.Mutex
+	active   int // Index of the active region
+	failures int // Consecutive failed attempts in the active region
+	healthy  int // Consecutive healthy probes of the primary while failed over
+}
+
+// newRegionRouter validates policy, fills in its defaults and returns a router
+// starting in the primary region.
+func newRegionRouter(policy RegionPolicy) (*regionRouter, error) {
+	if len(policy.Regions) == 0 {
+		return nil, fmt.Errorf("%w: no regions", ErrInvalidRegion)
+	}
+	r := &regionRouter{policy: policy}
+	for _, region := range policy.Regions {
+		u, err := url.Parse(region.URL)
+		if err != nil || u.Scheme == "" || u.Host == "" || region.Name == "" {
+			return nil, fmt.Errorf("%w: %q (%s)", ErrInvalidRegion, region.Name, region.URL)
+		}
+		r.urls = append(r.urls, u)
+	}
+
+	if r.policy.FailoverAfter <= 0 {
+		r.policy.FailoverAfter = defaultRegionFailoverAfter
+	}
+	if r.policy.FailbackAfter <= 0 {
+		r.policy.FailbackAfter = defaultRegionFailbackAfter
+	}
+	if r.policy.ProbeInterval <= 0 {
+		r.policy.ProbeInterval = defaultRegionProbeInterval
+	}
+	if r.policy.ProbePath == "" {
+		r.policy.ProbePath = "/"
+	}
+	return r, nil
+}
+
+// route points req, an attempt request, at the active region when it targets
+// one of the regions, and returns the name of the region it was routed to (""
+// when it targets another host).
+func (r *regionRouter) route(req *http.Request) string {
+	if !r.serves(req.URL) {
+		return ""
+	}
+	r.mu.Lock()
+	active := r.active
+	r.mu.Unlock()
+
+	req.URL.Scheme, req.URL.Host = r.urls[active].Scheme, r.urls[active].Host
+	req.Host = "" // Follow the URL
+	return r.policy.Regions[active].Name
+}
+
+// serves reports whether u targets one of the regions.
+func (r *regionRouter) serves(u *url.URL) bool {
+	for _, region := range r.urls {
+		if strings.EqualFold(u.Host, region.Host) {
+			return true
+		}
+	}
+	return false
+}
+
+// record feeds the outcome of an attempt routed to region, and fails over to
+// the next region once the active one kept failing.
+func (r *regionRouter) record(c *Client, region string, ok bool) {
+	r.mu.Lock()
+	if region != r.policy.Regions[r.active].Name {
+		r.mu.Unlock()
+		return // Routed before a failover; the region is no longer active
+	}
+	if ok {
+		r.failures = 0
+		r.mu.Unlock()
+		return
+	}
+	r.failures++
+	if r.failures < r.policy.FailoverAfter || len(r.urls) == 1 {
+		r.mu.Unlock()
+		return
+	}
+	event := r.switchTo(RegionFailover, (r.active+1)%len(r.urls))
+	r.mu.Unlock()
+
+	c.logger.Warn("region failover", "from", event.From, "to", event.To)
+	r.emit(event)
+}
+
+// switchTo makes region i active and returns the event reporting it; r.mu
+// must be held.
+func (r *regionRouter) switchTo(kind RegionEventKind, i int) RegionEvent {
+	event := RegionEvent{
+		Kind: kind,
+		From: r.policy.Regions[r.active].Name,
+		To:   r.policy.Regions[i].Name,
+		Time: time.Now(),
+	}
+	r.active, r.failures, r.healthy = i, 0, 0
+	return event
+}
+
+// emit reports event to the policy's OnEvent.
+func (r *regionRouter) emit(event RegionEvent) {
+	if r.policy.OnEvent != nil {
+		r.policy.OnEvent(event)
+	}
+}
+
+// routeRegion points an attempt request at the active region, with
+// WithRegionFailover, and returns the region's name.
+func (c *Client) routeRegion(req *http.Request) string {
+	if c.regions == nil {
+		return ""
+	}
+	return c.regions.route(req)
+}
+
+// recordRegion feeds the outcome of an attempt routed to region.
+func (c *Client) recordRegion(region string, ok bool) {
+	if region != "" {
+		c.regions.record(c, region, ok)
+	}
+}
+
+// startRegionProbes probes the primary region while failed over, until Close,
+// and fails back once it is healthy again.
+func (c *Client) startRegionProbes() {
+	ctx := c.background()
+	go func() {
+		ticker := time.NewTicker(c.regions.policy.ProbeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.probeRegion(ctx)
+			}
+		}
+	}()
+}
+
+// probeRegion sends one health probe to the primary region when failed over.
+func (c *Client) probeRegion(ctx context.Context) {
+	r := c.regions
+	r.mu.Lock()
+	failedOver := r.active != 0
+	r.mu.Unlock()
+	if !failedOver {
+		return
+	}
+
+	probeCtx, cancel := context.WithTimeout(ctx, regionProbeTimeout)
+	defer cancel()
+	target := r.urls[0].JoinPath(r.policy.ProbePath)
+	req, err := http.NewRequestWithContext(probeCtx, http.MethodHead, target.String(), nil)
+	if err != nil {
+		return
+	}
+	c.setUserAgent(req)
+
+	resp, err := c.httpClient.Do(req)
+	healthy := err == nil && !c.isRetryable(nil, resp)
+	if err == nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if ctx.Err() != nil {
+		return // Stopped while probing
+	}
+
+	r.mu.Lock()
+	if r.active == 0 {
+		r.mu.Unlock()
+		return
+	}
+	if !healthy {
+		r.healthy = 0
+		r.mu.Unlock()
+		return
+	}
+	r.healthy++
+	if r.healthy < r.policy.FailbackAfter {
+		r.mu.Unlock()
+		return
+	}
+	event := r.switchTo(RegionFailback, 0)
+	r.mu.Unlock()
+
+	c.logger.Info("region failback", "from", event.From, "to", event.To)
+	r.emit(event)
+}
+
+// ActiveRegion returns the name of the region requests are currently routed
+// to with WithRegionFailover, or "" without it.
+func (c *Client) ActiveRegion() string {
+	if c.regions == nil {
+		return ""
+	}
+	c.regions.mu.Lock()
+	defer c.regions.mu.Unlock()
+	return c.regions.policy.Regions[c.regions.active].Name
+}
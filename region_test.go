@@ -0,0 +1,154 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// regionEvents records the RegionEvents of a client.
+type regionEvents struct {
+	mu     sync.Mutex
+	events []RegionEvent
+}
+
+func (r *regionEvents) record(event RegionEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *regionEvents) list() []RegionEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]RegionEvent(nil), r.events...)
+}
+
+func TestWithRegionFailover(t *testing.T) {
+	var primaryUp atomic.Bool
+	var primaryHits, secondaryHits atomic.Int32
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodHead {
+			primaryHits.Add(1)
+		}
+		if !primaryUp.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer primary.Close()
+	secondary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secondaryHits.Add(1)
+		if r.URL.Path != "/items" {
+			t.Errorf("Expected the path kept on failover, got %s", r.URL.Path)
+		}
+	}))
+	defer secondary.Close()
+
+	events := &regionEvents{}
+	client, err := NewClient(
+		WithMaxRetries(5),
+		WithInitialRetryDelay(time.Millisecond),
+		WithRegionFailover(RegionPolicy{
+			Regions: []Region{
+				{Name: "local", URL: primary.URL},
+				{Name: "remote", URL: secondary.URL},
+			},
+			FailoverAfter: 2,
+			FailbackAfter: 2,
+			ProbeInterval: 10 * time.Millisecond,
+			OnEvent:       events.record,
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), primary.URL+"/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if primaryHits.Load() != 2 || secondaryHits.Load() != 1 {
+		t.Errorf("Expected 2 attempts in the primary and 1 in the secondary, got %d and %d",
+			primaryHits.Load(), secondaryHits.Load())
+	}
+	if got := client.ActiveRegion(); got != "remote" {
+		t.Errorf("Expected the remote region active, got %q", got)
+	}
+
+	// Requests stick to the secondary while the primary is down
+	resp, err = client.Get(context.Background(), primary.URL+"/items")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if primaryHits.Load() != 2 {
+		t.Errorf("Expected no attempt in the failed primary, got %d", primaryHits.Load())
+	}
+
+	primaryUp.Store(true)
+	deadline := time.Now().Add(2 * time.Second)
+	for client.ActiveRegion() != "local" {
+		if time.Now().After(deadline) {
+			t.Fatal("Expected a failback to the recovered primary")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	got := events.list()
+	if len(got) != 2 ||
+		got[0].Kind != RegionFailover || got[0].From != "local" || got[0].To != "remote" ||
+		got[1].Kind != RegionFailback || got[1].From != "remote" || got[1].To != "local" {
+		t.Errorf("Unexpected region events %+v", got)
+	}
+}
+
+func TestWithRegionFailover_OtherHosts(t *testing.T) {
+	var hits atomic.Int32
+	other := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		hits.Add(1)
+	}))
+	defer other.Close()
+
+	client, err := NewClient(WithRegionFailover(RegionPolicy{
+		Regions: []Region{{Name: "local", URL: "https://local.example.com"}},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	resp, err := client.Get(context.Background(), other.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if hits.Load() != 1 {
+		t.Error("Expected requests to other hosts sent unchanged")
+	}
+}
+
+func TestWithRegionFailover_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		regions []Region
+	}{
+		{"no regions", nil},
+		{"no name", []Region{{URL: "https://api.example.com"}}},
+		{"relative URL", []Region{{Name: "local", URL: "/api"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(WithRegionFailover(RegionPolicy{Regions: tt.regions}))
+			if !errors.Is(err, ErrInvalidRegion) {
+				t.Errorf("Expected ErrInvalidRegion, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net/http"
+)
+
+// RequestIDHeader carries the request ID (WithRequestID, ContextWithRequestID)
+// on every attempt.
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDKey carries the request ID of the current operation in the context.
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying id as the request ID.
+// Requests made with the returned context send id as the X-Request-ID header
+// on every attempt and include it in logs, spans and RetryError, even when
+// WithRequestID is not used. This lets correlation IDs minted at the edge
+// flow through outgoing calls without adding a header option to each one.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx by
+// ContextWithRequestID, or "" if there is none.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID returns 16 random bytes, hex-encoded.
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// withRequestID resolves the request ID for req and stores it in the returned
+// context for the attempts. An X-Request-ID header already on the request
+// wins, then an ID from ContextWithRequestID (on ctx or the request's own
+// context), then a new ID from the WithRequestID generator.
+func (c *Client) withRequestID(ctx context.Context, req *http.Request) context.Context {
+	id := RequestIDFromContext(ctx)
+	if id == "" {
+		id = RequestIDFromContext(req.Context())
+	}
+	if id == "" && c.requestIDGenerator == nil {
+		return ctx
+	}
+
+	if header := req.Header.Get(RequestIDHeader); header != "" {
+		id = header
+	} else if id == "" {
+		id = c.requestIDGenerator()
+	}
+	return ContextWithRequestID(ctx, id)
+}
+
+// setRequestID sets the operation's request ID header on an attempt request.
+func setRequestID(ctx context.Context, req *http.Request) {
+	if id := RequestIDFromContext(ctx); id != "" {
+		req.Header.Set(RequestIDHeader, id)
+	}
+}
+
+// attachRequestID records the operation's request ID on a returned RetryError.
+func attachRequestID(ctx context.Context, err error) {
+	var retryErr *RetryError
+	if id := RequestIDFromContext(ctx); id != "" && errors.As(err, &retryErr) {
+		retryErr.RequestID = id
+	}
+}
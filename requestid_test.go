@@ -0,0 +1,152 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithRequestID(t *testing.T) {
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		seen = append(seen, r.Header.Get(RequestIDHeader))
+		mu.Unlock()
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	mockLogger := &MockLogger{}
+	mockTracer := &MockTracer{}
+	client, err := NewClient(
+		WithRequestID(func() string { return "req-1" }),
+		WithMaxRetries(2),
+		WithInitialRetryDelay(time.Millisecond),
+		WithLogger(mockLogger),
+		WithTracer(mockTracer),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	if len(seen) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(seen))
+	}
+	for i, id := range seen {
+		if id != "req-1" {
+			t.Errorf("Attempt %d: expected request ID req-1, got %q", i+1, id)
+		}
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.RequestID != "req-1" {
+		t.Errorf("Expected RetryError with request ID, got %v", err)
+	}
+
+	for _, record := range mockLogger.WarnLogs {
+		found := false
+		for i := 0; i+1 < len(record.Args); i += 2 {
+			if record.Args[i] == "request_id" && record.Args[i+1] == "req-1" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected request_id in log %q, got %v", record.Message, record.Args)
+		}
+	}
+
+	found := false
+	for _, attr := range mockTracer.Spans[0].Attributes {
+		if attr.Key == "http.request_id" && attr.Value == "req-1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected request ID on the request span, got %v", mockTracer.Spans[0].Attributes)
+	}
+}
+
+func TestWithRequestID_ReusesHeader(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get(RequestIDHeader)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithRequestID(nil), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL, WithHeader(RequestIDHeader, "edge-42"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if got != "edge-42" {
+		t.Errorf("Expected existing request ID to be kept, got %q", got)
+	}
+
+	// Without a header, the default generator produces a 32-character ID
+	resp, err = client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if len(got) != 32 {
+		t.Errorf("Expected generated 32-character ID, got %q", got)
+	}
+}
+
+func TestContextWithRequestID(t *testing.T) {
+	var got []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(RequestIDHeader))
+	}))
+	defer server.Close()
+
+	ctx := ContextWithRequestID(context.Background(), "edge-7")
+	if id := RequestIDFromContext(ctx); id != "edge-7" {
+		t.Fatalf("Expected edge-7 from context, got %q", id)
+	}
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		reqOpts  []RequestOption
+		expected string
+	}{
+		{"without WithRequestID", nil, nil, "edge-7"},
+		{"context wins over generator", []Option{WithRequestID(func() string { return "gen" })}, nil, "edge-7"},
+		{"header wins over context", nil, []RequestOption{WithHeader(RequestIDHeader, "hdr")}, "hdr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(append(tt.opts, WithNoLogging())...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			got = nil
+			resp, err := client.Get(ctx, server.URL, tt.reqOpts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if len(got) != 1 || got[0] != tt.expected {
+				t.Errorf("Expected request ID %q, got %v", tt.expected, got)
+			}
+		})
+	}
+}
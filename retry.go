@@ -7,6 +7,7 @@ import (
 	"io"
 	"math/rand"
 	"net/http"
+	"slices"
 	"strconv"
 	"time"
 )
@@ -17,6 +18,8 @@ const (
 	defaultInitialRetryDelay  = 1 * time.Second
 	defaultMaxRetryDelay      = 10 * time.Second
 	defaultRetryDelayMultiple = 2.0
+	defaultTooEarlyDelay      = 100 * time.Millisecond
+	defaultConflictDelay      = 100 * time.Millisecond
 )
 
 // Logging and span attribute keys.
@@ -25,6 +28,7 @@ const (
 	attrMethod      = "method"
 	attrURL         = "url"
 	attrNextDelayMs = "next_delay_ms"
+	attrRequestID   = "request_id"
 )
 
 // Middleware wraps an http.RoundTripper to add custom behavior per HTTP attempt.
@@ -81,12 +85,92 @@ type Client struct {
 	retryDelayMultiple float64
 	httpClient         *http.Client
 	retryableChecker   RetryableChecker
-	jitterEnabled      bool // Add random jitter to retry delays
+	statusOverrides    map[int]bool // Per-status retry decisions that take precedence over retryableChecker
+	jitterEnabled      bool         // Add random jitter to retry delays
 	onRetryFunc        OnRetryFunc
 	respectRetryAfter  bool          // Respect Retry-After header from responses
 	perAttemptTimeout  time.Duration // Timeout for each individual attempt (0 = no per-attempt timeout)
+	overallTimeout     time.Duration // Deadline for each logical request, attempts + delays (0 = none)
+	tooEarlyDelay      time.Duration // Delay before retrying a 425 Too Early (set by WithRetryOnTooEarly)
+	bufferResponses    bool          // Read each response body before the retry decision
 	err                error
 
+	// Per-status backoff curves replacing the global one (set by WithStatusBackoff)
+	statusBackoff map[int]BackoffStrategy
+
+	// Separate retry policies for proxy and origin 502/504 (nil = none)
+	proxyErrors *ProxyErrorPolicy
+
+	// Marks successful-looking responses as failed attempts (nil = none)
+	responseValidator func(*http.Response) error
+
+	// Decodes the body of a final error response into a typed error (nil = none)
+	errorDecoder func(*http.Response) error
+
+	// Limits on the error body handed to errorDecoder (set by WithErrorBodyCapture)
+	errorBody ErrorBodyCapture
+
+	// Response body decoders by media type, beside JSON and XML (WithDecoder)
+	decoders map[string]BodyDecoder
+
+	// Jitter applied to server-provided Retry-After delays
+	retryAfterJitter RetryAfterJitterMode
+
+	// Generates the X-Request-ID for each request (nil = WithRequestID not used)
+	requestIDGenerator func() string
+
+	// Retry telemetry headers on every attempt (WithRetryTelemetryHeaders)
+	retryTelemetry bool
+	retryPolicyID  string
+
+	// Response protections (set by WithMaxResponseHeaderBytes, WithRequireContentLength)
+	maxResponseHeaderBytes int64
+	requireContentLength   bool
+
+	// Custom connection dialing (nil = the transport's own dialer)
+	dialer DialFunc
+
+	// Guard against attempts that can hang forever (WithSafeDefaults, WithStrictTimeouts)
+	safeDefaults   bool
+	strictTimeouts bool
+
+	// Bounds transparently decompressed bodies (nil = WithDecompressionLimit not used)
+	decompressionLimit *decompressionLimit
+
+	// Rejects disallowed destinations (nil = no restrictions)
+	destinationGuard *destinationGuard
+
+	// Run once per logical request before retries (set by WithRequestRewriter)
+	requestRewriters []func(*http.Request) error
+
+	// Run on every attempt's response before the retry decision (WithResponseInterceptor)
+	responseInterceptors []func(*http.Response) *http.Response
+
+	// Applied before per-call options in the convenience methods
+	defaultRequestOptions []RequestOption
+
+	// User-Agent sent on every attempt, suffix included ("" = Go default)
+	userAgent           string
+	omitUserAgentSuffix bool
+
+	// Names request and attempt spans (nil = "http.retry.request"/"http.retry.attempt")
+	spanNameFormatter func(req *http.Request, kind string) string
+
+	// Adds user attributes to request and attempt spans (nil = none)
+	spanAttributesFunc func(req *http.Request) []Attribute
+
+	// Per-host rate-limit windows learned from 429 responses (nil = disabled)
+	hostThrottle *hostThrottle
+
+	// Per-host cap on in-flight attempts (nil = unlimited)
+	hostLimiter *hostLimiter
+
+	// Per-host in-flight limit adapted to latency (WithAdaptiveConcurrency; nil = none)
+	adaptive *adaptiveLimiter
+
+	// Per-host pacing from rate-limit quota headers (nil = disabled)
+	quotaPacer *quotaPacer
+
 	// Observability (default to no-op implementations, can be replaced via Options)
 	metrics MetricsCollector
 	tracer  Tracer
@@ -97,9 +181,111 @@ type Client struct {
 	tracerEnabled  bool // true if tracer is not nopTracer
 	loggerEnabled  bool // true if logger is not nopLogger
 
+	// Instruments switched off by WithDisabledMetrics
+	disabledMetrics map[MetricInstrument]bool
+
+	// Optional metrics extensions (nil when the collector does not implement them)
+	connMetrics   ConnectionMetricsCollector
+	taggedMetrics TaggedMetricsCollector
+	poolMetrics   PoolMetricsCollector
+	waitMetrics   RetryWaitMetricsCollector
+
+	// Phi accrual failure detection (WithPhiDetector; nil = disabled)
+	phiDetector      *PhiDetector
+	suspicionMetrics SuspicionMetricsCollector
+
+	// Label retry operations for CPU and goroutine profiles (WithPprofLabels)
+	pprofLabels bool
+
+	// Checkpoints of WithCheckpoint requests, for resuming after a restart
+	stateStore StateStore
+
+	// Backoff carried across calls (WithBackoffState)
+	backoffState *BackoffState
+
+	// Client-wide counters exposed via Stats(), shared with route clients
+	stats *clientStats
+
+	// Connection pool management
+	baseTransport   http.RoundTripper // Transport beneath per-attempt middleware
+	closeIdleStreak int               // Close idle conns after N consecutive failures to a host (0 = off)
+	failureStreaks  *failureStreaks
+
+	// Per-host success rates for retry suppression (nil = disabled)
+	hostHealth *hostHealth
+
+	// End-to-end latency target retries must fit in (WithLatencyBudget; 0 = none)
+	latencyBudget time.Duration
+
+	// Fit retries into the context deadline (WithDeadlineAwareBackoff)
+	deadlineAware bool
+
+	// Skip attempts while offline (WithConnectivityChecker; nil = always online)
+	connectivity ConnectivityChecker
+
+	// Requests parked while offline, replayed on reconnect (nil = disabled)
+	offlineQueue *offlineQueue
+
+	// Region-aware failover of requests to the regions' hosts (nil = disabled)
+	regionPolicy *RegionPolicy
+	regions      *regionRouter
+
+	// Share of requests to the primary routed to canary endpoints (WithTrafficSplit; nil = none)
+	trafficSplitPrimary  string
+	trafficSplitPercents map[string]float64
+	canaryFailover       bool
+	trafficSplit         *trafficSplit
+
+	// Chooses the host of each attempt (WithEndpointSelector; nil = none)
+	endpointSelector EndpointSelector
+
+	// Adjust the request of each attempt, in order (WithAttemptTransformer)
+	attemptTransformers []AttemptTransformer
+
+	// Merges identical GETs into one upstream call (WithRequestCoalescing; nil = none)
+	coalescer *coalescer
+
+	// Sinks of machine-readable retry decisions (WithRetryEvents, WithRetryEventFunc)
+	retryEvents []func(RetryEvent)
+
+	// Retry policies by method and path, first match wins (WithRoutePolicy, WithRoutePolicyConfig)
+	routes []routePolicy
+
+	// Token buckets per path pattern or host, shared with route clients (WithEndpointRateLimits)
+	endpointRates  map[string]Rate
+	endpointLimits []endpointLimit
+
+	// Signs attempts with a clock resynchronized on skew errors (WithClockSkewRetry; nil = none)
+	clockSkew *clockSkew
+
+	// Sheds low-priority requests under pressure, shared with route clients (nil = disabled)
+	admission *admission
+
+	// Background work (WithKeepaliveProbe, WithOfflineQueue, WithRegionFailover), stopped by Close
+	route           bool // A route policy's client, served by its parent's background work
+	keepAliveProbes []KeepAliveProbe
+	backgroundCtx   context.Context
+	stopBackground  context.CancelFunc
+
+	// Cross-client coordination (nil = disabled)
+	coordinator *Coordinator
+
+	// Byte-rate limiter shared by all request/response bodies (nil = unlimited)
+	bandwidth *TokenBucketLimiter
+
+	// Client-managed redirects (nil = left to the embedded http.Client)
+	redirectPolicy *redirectPolicy
+
 	// Middleware chains
 	perAttemptMiddleware []Middleware        // Applied to each HTTP attempt (wraps Transport)
 	requestMiddleware    []RequestMiddleware // Applied to entire retry operation
+
+	// Middleware names, parallel to the chains ("" = unnamed)
+	perAttemptMiddlewareNames []string
+	requestMiddlewareNames    []string
+
+	// Options the client was built with, replayed by Clone
+	options []Option
 }
 
 // RetryableChecker determines if an error or response should trigger a retry
@@ -113,6 +299,7 @@ type RetryInfo struct {
 	StatusCode   int           // HTTP status code (0 if request failed)
 	RetryAfter   time.Duration // Retry-After duration from response header (0 if not present)
 	TotalElapsed time.Duration // Total time elapsed since first attempt
+	Reason       string        // Why the attempt is retried (RetryReason* constants, e.g. "connect_timeout")
 }
 
 // RetryError is returned when all retry attempts have been exhausted.
@@ -122,6 +309,11 @@ type RetryError struct {
 	LastErr    error         // The last error that occurred (nil if last attempt had non-retryable status)
 	LastStatus int           // HTTP status code from the last attempt (0 if request failed)
 	Elapsed    time.Duration // Total time elapsed from first attempt to final failure
+	RequestID  string        // Request ID sent with every attempt (set by WithRequestID)
+
+	// Why retrying stopped before the retries ran out (a GiveUp* constant; ""
+	// when they ran out or the operation was stopped otherwise)
+	GiveUpReason string
 }
 
 // Error implements the error interface
@@ -164,8 +356,12 @@ func NewClient(opts ...Option) (*Client, error) {
 		metrics: defaultMetrics,
 		tracer:  defaultTracer,
 		logger:  defaultLogger,
+
+		stats:          &clientStats{},
+		failureStreaks: &failureStreaks{},
 	}
 
+	c.options = slices.Clone(opts)
 	for _, opt := range opts {
 		opt(c)
 	}
@@ -175,6 +371,77 @@ func NewClient(opts ...Option) (*Client, error) {
 		return nil, c.err
 	}
 
+	if err := c.buildEndpointPolicies(); err != nil {
+		return nil, err
+	}
+
+	if c.userAgent != "" && !c.omitUserAgentSuffix {
+		c.userAgent += " " + userAgentSuffix()
+	}
+
+	if c.retryTelemetry && c.retryPolicyID == "" {
+		c.retryPolicyID = c.policyFingerprint()
+	}
+
+	c.detectObservability()
+
+	if err := c.buildTransport(); err != nil {
+		return nil, err
+	}
+
+	// Set up the offline queue before the routes, which share it
+	if c.offlineQueue != nil && !c.route {
+		c.setupOfflineQueue()
+	}
+
+	// Derive the route clients from the fully built client
+	if len(c.routes) > 0 {
+		if err := c.buildRoutes(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Start background work last, so it uses the fully built client; route
+	// clients are served by their parent's
+	if c.route {
+		return c, nil
+	}
+	if err := c.startBackgroundWork(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// buildEndpointPolicies validates and builds the endpoint routing and rate
+// limit policies set by options.
+func (c *Client) buildEndpointPolicies() error {
+	if c.regionPolicy != nil {
+		regions, err := newRegionRouter(*c.regionPolicy)
+		if err != nil {
+			return err
+		}
+		c.regions = regions
+	}
+	if c.trafficSplitPercents != nil {
+		split, err := newTrafficSplit(c.trafficSplitPrimary, c.trafficSplitPercents, c.canaryFailover)
+		if err != nil {
+			return err
+		}
+		c.trafficSplit = split
+	}
+	if c.endpointRates != nil {
+		limits, err := newEndpointLimits(c.endpointRates)
+		if err != nil {
+			return err
+		}
+		c.endpointLimits = limits
+	}
+	return nil
+}
+
+// detectObservability notes which observability components and optional
+// metrics extensions are enabled, once instead of on every attempt.
+func (c *Client) detectObservability() {
 	// Detect whether each observability component is enabled
 	// Use type assertion to check if the component is a no-op implementation
 	_, isNopMetrics := c.metrics.(nopMetricsCollector)
@@ -186,12 +453,86 @@ func NewClient(opts ...Option) (*Client, error) {
 	_, isNopLogger := c.logger.(nopLogger)
 	c.loggerEnabled = !isNopLogger
 
+	// Detect optional metrics extensions once instead of on every attempt
+	c.connMetrics, _ = c.metrics.(ConnectionMetricsCollector)
+	c.taggedMetrics, _ = c.metrics.(TaggedMetricsCollector)
+	c.poolMetrics, _ = c.metrics.(PoolMetricsCollector)
+	if !c.recordsMetric(MetricConnections) {
+		c.connMetrics = nil
+	}
+	if !c.recordsMetric(MetricPool) {
+		c.poolMetrics = nil
+	}
+	c.suspicionMetrics, _ = c.metrics.(SuspicionMetricsCollector)
+	if !c.recordsMetric(MetricSuspicion) {
+		c.suspicionMetrics = nil
+	}
+	c.waitMetrics, _ = c.metrics.(RetryWaitMetricsCollector)
+	if !c.recordsMetric(MetricRetryWaits) {
+		c.waitMetrics = nil
+	}
+}
+
+// buildTransport wraps the transport with the protections, dialer, pool
+// tracking and per-attempt middleware set by options, without mutating the
+// caller's http.Client.
+func (c *Client) buildTransport() error {
+	// Remember the unwrapped transport for connection pool management
+	c.baseTransport = c.httpClient.Transport
+	if c.baseTransport == nil {
+		c.baseTransport = http.DefaultTransport
+	}
+
+	// Hand redirect responses back to the retry loop instead of following them
+	if c.redirectPolicy != nil {
+		newClient := *c.httpClient
+		newClient.CheckRedirect = func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		}
+		c.httpClient = &newClient
+	}
+
+	// Bound or reject clients without any timeout
+	if c.safeDefaults || c.strictTimeouts {
+		if err := c.applySafeDefaults(); err != nil {
+			return err
+		}
+	}
+
+	// Enforce response header limits in the transport
+	if c.maxResponseHeaderBytes > 0 {
+		c.tuneTransport(func(transport *http.Transport) {
+			transport.MaxResponseHeaderBytes = c.maxResponseHeaderBytes
+		})
+	}
+
+	// Decompression is only guarded where the transport would decompress
+	if c.decompressionLimit != nil {
+		if transport, ok := c.baseTransport.(*http.Transport); !ok || transport.DisableCompression {
+			c.decompressionLimit = nil
+		}
+	}
+
+	// Dial through the custom dialer; installed first so the destination guard wraps it
+	if c.dialer != nil {
+		if err := c.installDialer(); err != nil {
+			return err
+		}
+	}
+
+	// Validate destinations on redirects and dials (WithAllowedHosts, WithBlockPrivateNetworks)
+	if c.destinationGuard != nil {
+		c.installDestinationGuard()
+	}
+
+	// Report dials and connection closes (PoolMetricsCollector)
+	if c.poolMetrics != nil {
+		c.installPoolTracker()
+	}
+
 	// Apply per-attempt middleware to Transport
 	if len(c.perAttemptMiddleware) > 0 {
-		transport := c.httpClient.Transport
-		if transport == nil {
-			transport = http.DefaultTransport
-		}
+		transport := c.baseTransport
 
 		// Chain middleware from last to first (first middleware is outermost)
 		// Note: We wrap the Transport, not modify it - middleware pattern is non-invasive
@@ -205,12 +546,48 @@ func NewClient(opts ...Option) (*Client, error) {
 		newClient.Transport = transport
 		c.httpClient = &newClient
 	}
+	return nil
+}
 
-	return c, nil
+// startBackgroundWork starts the keep-alive probes, region probes and
+// offline queue set by options.
+func (c *Client) startBackgroundWork() error {
+	if len(c.keepAliveProbes) > 0 {
+		c.startKeepAliveProbes()
+	}
+	if c.regionPolicy != nil {
+		c.startRegionProbes()
+	}
+	if c.offlineQueue != nil {
+		return c.startOfflineQueue()
+	}
+	return nil
+}
+
+// MaxRetries returns the maximum number of retries after the initial attempt
+// (WithMaxRetries).
+func (c *Client) MaxRetries() int {
+	return c.maxRetries
+}
+
+// MaxAttempts returns the maximum number of attempts per request, counting
+// the initial attempt: MaxRetries() + 1 (WithMaxAttempts).
+func (c *Client) MaxAttempts() int {
+	return c.maxRetries + 1
 }
 
 // DefaultRetryableChecker is the default implementation for determining retryable errors
-// It retries on network errors and 5xx/429 status codes
+// It retries on network errors and 5xx/429/408/421 status codes.
+//
+// 408 Request Timeout is included because the server sends it when it closed an
+// idle or slow connection before a request was fully received, so the request
+// was not processed and RFC 9110 explicitly allows repeating it. Use
+// WithNonRetryableStatuses(http.StatusRequestTimeout) to opt out.
+//
+// 421 Misdirected Request is included because it means the connection reached a
+// server that cannot answer for the request's origin (typically HTTP/2 connection
+// coalescing); RFC 9110 allows retrying it over a different connection. The client
+// retries the first 421 of a request immediately after closing idle connections.
 func DefaultRetryableChecker(err error, resp *http.Response) bool {
 	if err != nil {
 		// Network errors, timeouts, connection errors are retryable
@@ -221,9 +598,31 @@ func DefaultRetryableChecker(err error, resp *http.Response) bool {
 		return false
 	}
 
-	// Retry on 5xx server errors and 429 Too Many Requests
+	// Retry on 5xx server errors, 429 Too Many Requests, 408 Request Timeout
+	// and 421 Misdirected Request
 	statusCode := resp.StatusCode
-	return statusCode >= 500 || statusCode == http.StatusTooManyRequests
+	return statusCode >= 500 ||
+		statusCode == http.StatusTooManyRequests ||
+		statusCode == http.StatusRequestTimeout ||
+		statusCode == http.StatusMisdirectedRequest
+}
+
+// isRetryable applies the client's status overrides (WithRetryableStatuses,
+// WithNonRetryableStatuses) before falling back to the retryable checker.
+// Errors marked with Permanent are never retried.
+func (c *Client) isRetryable(err error, resp *http.Response) bool {
+	if isPermanent(err) {
+		return false
+	}
+	if err == nil && c.proxyErrors != nil && isGatewayError(resp) {
+		return c.proxyErrors.decide(resp)
+	}
+	if err == nil && resp != nil {
+		if retry, ok := c.statusOverrides[resp.StatusCode]; ok {
+			return retry
+		}
+	}
+	return c.retryableChecker(err, resp)
 }
 
 // statusCodeOf returns the HTTP status code of resp, or 0 when resp is nil.
@@ -246,6 +645,8 @@ func setSpanStatus(span Span, err error) {
 
 // parseRetryAfter parses the Retry-After header and returns the duration to wait.
 // The Retry-After header can be either a number of seconds or an HTTP-date.
+// An HTTP-date is compared against the response's Date header when present,
+// falling back to local time otherwise.
 // Returns 0 if the header is not present or cannot be parsed.
 func parseRetryAfter(resp *http.Response) time.Duration {
 	if resp == nil {
@@ -264,7 +665,13 @@ func parseRetryAfter(resp *http.Response) time.Duration {
 
 	// Try parsing as HTTP-date (RFC1123, RFC850, or ANSI C asctime format)
 	if t, err := http.ParseTime(retryAfter); err == nil {
-		duration := time.Until(t)
+		// Measure against the server's own clock when it sent a Date header,
+		// so client/server clock skew does not distort the wait
+		now := time.Now()
+		if date, err := http.ParseTime(resp.Header.Get("Date")); err == nil {
+			now = date
+		}
+		duration := t.Sub(now)
 		if duration > 0 {
 			return duration
 		}
@@ -302,6 +709,15 @@ func computeNextDelay(
 func (c *Client) applyDelayModifiers(
 	baseDelay time.Duration,
 	resp *http.Response,
+) (time.Duration, time.Duration) {
+	return c.applyDelayModifiersCapped(baseDelay, resp, c.maxRetryDelay)
+}
+
+// applyDelayModifiersCapped is applyDelayModifiers with an explicit max cap.
+func (c *Client) applyDelayModifiersCapped(
+	baseDelay time.Duration,
+	resp *http.Response,
+	maxDelay time.Duration,
 ) (time.Duration, time.Duration) {
 	actualDelay := baseDelay
 	retryAfterDelay := time.Duration(0)
@@ -313,25 +729,72 @@ func (c *Client) applyDelayModifiers(
 
 	switch {
 	case retryAfterDelay > 0:
-		// Honor the server-provided Retry-After exactly. Jitter is intentionally
-		// NOT applied here: it would only shorten the wait below what the server
-		// explicitly asked for. Jitter exists to de-synchronize our own
-		// exponential backoff, not to override a server instruction. The max cap
-		// is still enforced below as a safety bound against absurd values.
-		actualDelay = retryAfterDelay
+		// Honor the server-provided Retry-After. By default jitter is NOT applied
+		// here: it would only shorten the wait below what the server explicitly
+		// asked for. Jitter exists to de-synchronize our own exponential backoff,
+		// not to override a server instruction; WithRetryAfterJitterMode opts in.
+		// The max cap is still enforced below as a safety bound against absurd values.
+		actualDelay = c.retryAfterJitter.apply(retryAfterDelay)
 	case c.jitterEnabled:
 		// Apply jitter to the exponential backoff delay to avoid thundering herd.
 		actualDelay = applyJitter(actualDelay)
 	}
 
 	// Apply max cap
-	if actualDelay > c.maxRetryDelay {
-		actualDelay = c.maxRetryDelay
+	if actualDelay > maxDelay {
+		actualDelay = maxDelay
 	}
 
 	return actualDelay, retryAfterDelay
 }
 
+// nextRetryDelay computes the wait before the next attempt. prevBase is the
+// backoff base used for the previous retry (ignored on the first retry), and
+// misdirectedBefore reports whether an earlier attempt already got a 421.
+// Returns: (new backoff base, actual delay, Retry-After delay)
+func (c *Client) nextRetryDelay(
+	req *http.Request,
+	resp *http.Response,
+	attempt int,
+	prevBase time.Duration,
+	misdirectedBefore bool,
+) (time.Duration, time.Duration, time.Duration) {
+	// Calculate base delay for next attempt, continuing a carried backoff
+	base := c.initialRetryDelay
+	if attempt > 0 || prevBase > 0 {
+		base = computeNextDelay(prevBase, c.retryDelayMultiple, c.maxRetryDelay)
+	}
+
+	// A per-status strategy replaces the global curve for this retry only
+	delayBase, maxDelay := base, c.maxRetryDelay
+	if strategy, ok := c.statusBackoff[statusCodeOf(resp)]; ok {
+		delayBase, maxDelay = strategy.delay(c, attempt)
+	}
+	if c.proxyErrors != nil && isGatewayError(resp) {
+		if strategy, ok := c.proxyErrors.backoff(resp); ok {
+			delayBase, maxDelay = strategy.delay(c, attempt)
+		}
+	}
+
+	// Apply Retry-After, jitter, and max cap
+	delay, retryAfter := c.applyDelayModifiersCapped(delayBase, resp, maxDelay)
+
+	switch {
+	case isMisdirected(resp) && !misdirectedBefore:
+		// The first 421 is retried immediately on a fresh connection; an
+		// origin that keeps sending it backs off like any other failure
+		delay = 0
+	case isTooEarly(resp) && c.tooEarlyDelay > 0 && retryAfter == 0:
+		// A 425 waits the short, fixed WithRetryOnTooEarly delay
+		delay = c.tooEarlyDelay
+	}
+
+	// Push the retry past any coordinated cooldown for this host
+	delay = c.coordinateDelay(req.URL.Host, delay)
+
+	return base, delay, retryAfter
+}
+
 // cancelOnCloseBody wraps an io.ReadCloser and calls a cancel function when Close() is called.
 // This ensures the per-attempt context timeout is released when the response body is closed.
 type cancelOnCloseBody struct {
@@ -374,6 +837,9 @@ type attemptResult struct {
 	err             error
 	attemptDuration time.Duration
 	cancelAttempt   context.CancelFunc
+	region          string // Region the attempt was routed to (WithRegionFailover)
+	endpoint        string // Host the attempt was sent to
+	unsized         bool   // The body had no Content-Length (WithRequireContentLength)
 }
 
 // executeAttempt performs a single HTTP request attempt with tracing
@@ -388,9 +854,11 @@ func (c *Client) executeAttempt(
 	var attemptSpan Span
 	attemptCtx := ctx
 	if c.tracerEnabled {
-		attemptCtx, attemptSpan = c.tracer.StartSpan(ctx, "http.retry.attempt",
-			Attribute{Key: "retry.attempt", Value: attempt + 1},
-			Attribute{Key: attrHTTPMethod, Value: req.Method},
+		attemptCtx, attemptSpan = c.tracer.StartSpan(ctx, c.spanName(req, SpanKindAttempt),
+			c.spanAttributes(req,
+				Attribute{Key: "retry.attempt", Value: attempt + 1},
+				Attribute{Key: attrHTTPMethod, Value: req.Method},
+			)...,
 		)
 	} else {
 		// Return no-op span to maintain interface consistency
@@ -403,17 +871,72 @@ func (c *Client) executeAttempt(
 		attemptCtx, cancelAttempt = context.WithTimeout(attemptCtx, c.perAttemptTimeout)
 	}
 
+	// Trace connection reuse for stats, metrics, and spans
+	trace := &attemptTrace{}
+	attemptCtx = trace.withTrace(attemptCtx)
+
 	// Clone the request for retry (important: body might be consumed)
 	reqClone := req.Clone(attemptCtx)
+	routeEndpoint(ctx, reqClone)
+	region := c.routeRegion(reqClone)
+	setRequestID(ctx, reqClone)
+	c.setRetryTelemetry(ctx, reqClone, attempt)
+	c.setUserAgent(reqClone)
+	gzipRequested := c.requestGzip(reqClone)
 
-	//nolint:bodyclose // Response body is returned to caller
-	resp, err := c.httpClient.Do(reqClone)
+	var resp *http.Response
+	var unsized bool
+	info, _ := AttemptInfoFromContext(ctx)
+	rerouted, err := c.selectEndpointHost(attempt+1, reqClone, info.LastErr)
+	if rerouted {
+		region = "" // Not the region's attempt
+	}
+	if err == nil {
+		err = rewindBody(reqClone, attempt)
+	}
+	if err == nil {
+		err = c.transformAttempt(attempt+1, reqClone)
+	}
+	if err == nil {
+		err = c.checkDestination(attemptCtx, reqClone)
+	}
+	if err == nil {
+		err = c.waitEndpointLimits(ctx, reqClone)
+	}
+	if err == nil {
+		err = c.signRequest(reqClone)
+	}
+	if err == nil {
+		resp, err = c.send(attemptCtx, reqClone)
+		if err == nil {
+			resp, err = c.resendSkewed(attemptCtx, reqClone, resp)
+		}
+		err = c.classifyProtectionError(err)
+		trace.gotHeaders.Store(err == nil)
+	}
+	if err == nil {
+		unsized = c.unsizedBody(resp) // Before decompression drops the length
+		if gzipRequested {
+			c.decompressResponse(resp)
+		}
+		resp = c.interceptResponse(resp)
+	}
+	c.throttleResponseBody(attemptCtx, resp)
+	if err == nil && c.buffersBody(req) && !unsized {
+		resp, err = bufferResponseBody(resp)
+	}
+	if err == nil && !unsized {
+		resp, err = c.validateResponse(resp)
+	}
+	err = classifyTimeout(err, trace)
 	attemptDuration := time.Since(attemptStart)
 
 	// Record metrics for this attempt (conditional on metricsEnabled)
-	if c.metricsEnabled {
-		c.metrics.RecordAttempt(req.Method, statusCodeOf(resp), attemptDuration, err)
+	if c.recordsMetric(MetricAttempts) {
+		c.metricsFor(metricTagsOf(ctx, req)).RecordAttempt(
+			req.Method, statusCodeOf(resp), attemptDuration, err)
 	}
+	c.recordConnection(req, trace, attemptSpan)
 
 	// Update attempt span (conditional on tracerEnabled)
 	if c.tracerEnabled {
@@ -430,9 +953,74 @@ func (c *Client) executeAttempt(
 		err:             err,
 		attemptDuration: attemptDuration,
 		cancelAttempt:   cancelAttempt,
+		region:          region,
+		endpoint:        reqClone.URL.Host,
+		unsized:         unsized,
 	}, attemptSpan
 }
 
+// interceptResponse passes resp through the WithResponseInterceptor hooks.
+func (c *Client) interceptResponse(resp *http.Response) *http.Response {
+	for _, intercept := range c.responseInterceptors {
+		if next := intercept(resp); next != nil {
+			resp = next
+		}
+	}
+	return resp
+}
+
+// send performs the HTTP call for one attempt while holding a per-host
+// concurrency slot (if WithMaxConcurrentPerHost is set) and adaptive
+// concurrency capacity (if WithAdaptiveConcurrency is set) until headers
+// arrive.
+func (c *Client) send(ctx context.Context, req *http.Request) (*http.Response, error) {
+	release, err := c.acquireHostSlot(ctx, req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	done, err := c.acquireAdaptiveSlot(ctx, req.URL.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	c.throttleRequestBody(ctx, req)
+	//nolint:bodyclose // Response body is returned to caller
+	resp, err := c.httpClient.Do(req)
+	done(resp, err)
+	return resp, err
+}
+
+// waitForHost blocks until req's host may be contacted: any rate-limit window
+// from a 429 (WithHostThrottle) has passed and a paced quota slot
+// (WithQuotaPacing) is available.
+func (c *Client) waitForHost(ctx context.Context, req *http.Request) error {
+	if err := c.awaitHostThrottle(ctx, req); err != nil {
+		return err
+	}
+	return c.awaitQuota(ctx, req)
+}
+
+// observeHost records the host-level rate-limit signals carried by resp.
+func (c *Client) observeHost(req *http.Request, resp *http.Response) {
+	c.recordHostThrottle(req, resp)
+	c.recordQuota(req, resp)
+}
+
+// rewindBody gives a retry attempt a fresh copy of the request body via GetBody.
+// A clone shares the original Body reader, which the previous attempt consumed.
+func rewindBody(req *http.Request, attempt int) error {
+	if attempt == 0 || req.GetBody == nil || req.Body == nil || req.Body == http.NoBody {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return fmt.Errorf("retry: rewind request body: %w", err)
+	}
+	req.Body = body
+	return nil
+}
+
 // wrapBodyWithCancel wraps a response body to cancel the context when closed
 func wrapBodyWithCancel(resp *http.Response, cancelAttempt context.CancelFunc) {
 	if cancelAttempt != nil && resp != nil && resp.Body != nil {
@@ -452,204 +1040,302 @@ func (c *Client) DoWithContext(ctx context.Context, req *http.Request) (*http.Re
 	if req == nil {
 		return nil, errors.New("retry: nil Request")
 	}
+	if rc := c.routeClient(req); rc != nil {
+		return rc.DoWithContext(ctx, req)
+	}
+	release, err := c.admission.admit(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Rewrite a copy of the request once, before any middleware or attempt
+	if len(c.requestRewriters) > 0 {
+		req = req.Clone(req.Context())
+		originalHost := req.URL.Host
+		for _, rewrite := range c.requestRewriters {
+			if err := rewrite(req); err != nil {
+				return nil, fmt.Errorf("retry: rewrite request: %w", err)
+			}
+		}
+		// Follow a rewritten URL host unless the Host header was set explicitly
+		if req.Host == originalHost {
+			req.Host = req.URL.Host
+		}
+	}
 
 	// Build retry function
 	retryFunc := c.doWithRetry
+	if c.stateStore != nil {
+		retryFunc = c.withCheckpoints(retryFunc)
+	}
 
 	// Apply request-level middleware (from last to first)
 	for i := len(c.requestMiddleware) - 1; i >= 0; i-- {
 		retryFunc = c.requestMiddleware[i](retryFunc)
 	}
+	if c.coalescer != nil {
+		retryFunc = c.coalescer.wrap(retryFunc)
+	}
+	if c.pprofLabels {
+		retryFunc = withPprofLabels(retryFunc)
+	}
 
-	return retryFunc(ctx, req)
+	if c.overallTimeout <= 0 {
+		return retryFunc(ctx, req)
+	}
+
+	// Bound the whole operation; keep the body readable until the caller closes it
+	ctx, cancel := context.WithTimeout(ctx, c.overallTimeout)
+	resp, err := retryFunc(ctx, req)
+	wrapBodyWithCancel(resp, cancel)
+	return resp, err
 }
 
 // doWithRetry contains the core retry logic (extracted from DoWithContext).
 // This separation allows request-level middleware to wrap the entire retry operation.
 func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Response, error) {
-	var lastErr error
+	ctx = c.withRequestID(ctx, req)
+
 	var resp *http.Response
-	startTime := time.Now()
+	var err error
+	if c.redirectPolicy != nil {
+		resp, err = c.doWithRedirects(ctx, req)
+	} else {
+		resp, err = c.retryLoop(ctx, req, c.maxRetries)
+	}
+	if c.backoffState != nil {
+		c.backoffState.recordCall(err == nil)
+	}
+	c.emitGiveUp(ctx, req, err)
+	attachRequestID(ctx, err)
+	return resp, err
+}
+
+// requestOpKey carries the requestOp of a redirected operation to the retry
+// loop of each hop.
+type requestOpKey struct{}
+
+// requestOp is the request span and completion metrics of one operation.
+// Without redirects, it is the retry loop's; a redirected operation spans the
+// retry loops of all its hops, and is recorded once it ends.
+type requestOp struct {
+	span     Span // nil without a tracer
+	start    time.Time
+	attempts int // Attempts of the hops completed so far
+
+	// Completion of the latest hop, recorded by end
+	metrics MetricsCollector
+	method  string
+	status  int
+	success bool
+}
 
-	// Start outer span for entire retry operation (conditional on tracerEnabled)
-	var requestSpan Span
+// startRequestOp starts the request span of an operation sending req.
+func (c *Client) startRequestOp(
+	ctx context.Context,
+	req *http.Request,
+	maxRetries int,
+	tags map[string]string,
+) (context.Context, *requestOp) {
+	op := &requestOp{start: time.Now()}
 	if c.tracerEnabled {
-		ctx, requestSpan = c.tracer.StartSpan(ctx, "http.retry.request",
-			Attribute{Key: attrHTTPMethod, Value: req.Method},
-			Attribute{Key: "http.url", Value: req.URL.String()},
-			Attribute{Key: "retry.max_attempts", Value: c.maxRetries + 1},
+		ctx, op.span = c.tracer.StartSpan(ctx, c.spanName(req, SpanKindRequest),
+			c.spanAttributes(req, append([]Attribute{
+				{Key: attrHTTPMethod, Value: req.Method},
+				{Key: "http.url", Value: req.URL.String()},
+				{Key: "retry.max_attempts", Value: maxRetries + 1},
+			}, tagAttributes(tags)...)...)...,
 		)
-		defer requestSpan.End()
+		if id := RequestIDFromContext(ctx); id != "" {
+			op.span.SetAttributes(Attribute{Key: "http.request_id", Value: id})
+		}
 	}
+	return ctx, op
+}
 
-	// Log request start (conditional on loggerEnabled)
+// complete notes how a retry loop of the operation ended, for end to record.
+func (op *requestOp) complete(metrics MetricsCollector, method string, status, attempts int, success bool) {
+	op.attempts += attempts
+	op.metrics, op.method, op.status, op.success = metrics, method, status, success
+}
+
+// end records the operation's completion and ends its span.
+func (op *requestOp) end() {
+	if op.metrics != nil {
+		op.metrics.RecordRequestComplete(op.method, op.status, time.Since(op.start), op.attempts, op.success)
+	}
+	if op.span != nil {
+		op.span.End()
+	}
+}
+
+// retryLoop sends req to a single URL, retrying up to maxRetries times.
+func (c *Client) retryLoop(
+	ctx context.Context,
+	req *http.Request,
+	maxRetries int,
+) (*http.Response, error) {
+	var lastErr error
+	var resp *http.Response
+	startTime := time.Now()
+	ctx = c.withRetryTelemetry(ctx, startTime, maxRetries+1)
+	ctx = c.selectEndpoint(ctx, req)
+	var giveUp string           // Why retrying stopped early (GiveUp* constant)
+	var hostSuccessRate float64 // Host success rate when retries were suppressed
+
+	// Per-request tags from WithMetricTag, for metrics and the request span
+	tags := metricTagsOf(ctx, req)
+	metrics := c.metricsFor(tags)
+
+	// Start outer span for entire retry operation, unless a redirected
+	// operation spanning several retry loops started it already
+	op, redirected := ctx.Value(requestOpKey{}).(*requestOp)
+	if !redirected {
+		ctx, op = c.startRequestOp(ctx, req, maxRetries, tags)
+		defer op.end()
+	}
+	requestSpan := op.span
+
+	// Per-request logger carrying the method and URL (conditional on loggerEnabled)
+	logger := c.logger
 	if c.loggerEnabled {
-		c.logger.Debug("starting request",
-			attrMethod, req.Method,
-			attrURL, req.URL.String(),
-			"max_retries", c.maxRetries,
-		)
+		fields := []any{attrMethod, req.Method, attrURL, req.URL.String()}
+		if id := RequestIDFromContext(ctx); id != "" {
+			fields = append(fields, attrRequestID, id)
+		}
+		logger = withFields(c.logger, fields...)
+		logger.Debug("starting request", "max_retries", maxRetries)
 	}
 
 	var nextDelayBase time.Duration   // Base delay for next retry (before modifiers)
 	var nextActualDelay time.Duration // Actual delay (after Retry-After, jitter, cap)
 	var nextRetryAfter time.Duration  // Retry-After duration from response header
+	var retryReason string            // Why the previous attempt is retried
 	var shouldWait bool               // Whether to wait before this attempt
+	var lastEndpoint string           // Host the previous attempt was sent to
+	var misdirected bool              // Whether an attempt got a 421 Misdirected Request
+	var unsized bool                  // Whether the last body had no Content-Length
+
+	// Continue the attempt count and backoff of an operation passed to Resume
+	firstAttempt := min(resumedAttempts(ctx), maxRetries)
+	if firstAttempt > 0 {
+		nextDelayBase = c.delayBaseAt(firstAttempt - 1)
+	} else if c.backoffState != nil {
+		nextDelayBase = c.backoffState.delayBase()
+	}
 
-	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+	for attempt := firstAttempt; attempt <= maxRetries; attempt++ {
 		// === PHASE 1: Wait for delay (if retrying) ===
 		// shouldWait is only ever set on a prior iteration that decided to retry,
 		// so it implies attempt > 0; no separate index check is needed.
 		if shouldWait {
-			// Call onRetry callback
-			if c.onRetryFunc != nil {
-				c.onRetryFunc(RetryInfo{
-					Attempt:      attempt,
-					Delay:        nextActualDelay,
-					Err:          lastErr,
-					StatusCode:   statusCodeOf(resp),
-					RetryAfter:   nextRetryAfter,
-					TotalElapsed: time.Since(startTime),
-				})
+			info := RetryInfo{
+				Attempt:      attempt,
+				Delay:        nextActualDelay,
+				Err:          lastErr,
+				StatusCode:   statusCodeOf(resp),
+				RetryAfter:   nextRetryAfter,
+				TotalElapsed: time.Since(startTime),
+				Reason:       retryReason,
 			}
 
-			// Log retry attempt (conditional on loggerEnabled)
-			if c.loggerEnabled {
-				c.logger.Info("retrying request",
-					attrMethod, req.Method,
-					"attempt", attempt+1,
-					"delay_ms", nextActualDelay.Milliseconds(),
-				)
+			// Run the retry hooks, then wait for the delay
+			fallback, err := c.awaitRetry(ctx, req, logger, info, lastEndpoint)
+			if fallback {
+				return c.coordinator.fallback(req)
 			}
-
-			// Wait for delay
-			timer := time.NewTimer(nextActualDelay)
-			select {
-			case <-ctx.Done():
-				timer.Stop()
-				// Context cancelled during wait
+			if err != nil {
 				return nil, &RetryError{
 					Attempts:   attempt,
-					LastErr:    ctx.Err(),
-					LastStatus: statusCodeOf(resp),
+					LastErr:    err,
+					LastStatus: info.StatusCode,
 					Elapsed:    time.Since(startTime),
 				}
-			case <-timer.C:
-				// Continue to attempt
+			}
+		}
+
+		// Don't burn attempts while the device is offline, and honor rate
+		// limits other requests discovered for this host
+		if err := c.admitAttempt(ctx, req); err != nil {
+			return nil, &RetryError{
+				Attempts:   attempt,
+				LastErr:    err,
+				LastStatus: statusCodeOf(resp),
+				Elapsed:    time.Since(startTime),
 			}
 		}
 
 		// === PHASE 2: Execute the attempt ===
-		result, attemptSpan := c.executeAttempt(ctx, req, attempt)
+		attemptCtx := withAttemptInfo(ctx, AttemptInfo{
+			Attempt:    attempt + 1,
+			Elapsed:    time.Since(startTime),
+			LastErr:    lastErr,
+			LastStatus: statusCodeOf(resp),
+		})
+		attemptStart := time.Now()
+		result, attemptSpan := c.executeAttempt(attemptCtx, req, attempt)
 		attemptSpan.End()
+		attemptTook := time.Since(attemptStart)
+		c.admission.observe(attemptTook)
 
 		resp = result.resp
 		lastErr = result.err
+		lastEndpoint = result.endpoint
+		unsized = result.unsized
+		c.observeHost(req, resp)
 
 		// === PHASE 3: Check if we should retry ===
-		if !c.retryableChecker(lastErr, resp) {
-			// Success or non-retryable error. The request only "succeeded" when
-			// there is no error to return to the caller; a non-retryable error
-			// (e.g. a custom checker declining a network error) is a failure even
-			// though the retry loop stops here.
-			completedSuccessfully := lastErr == nil
-			if c.metricsEnabled {
-				c.metrics.RecordRequestComplete(
-					req.Method,
-					statusCodeOf(resp),
-					time.Since(startTime),
-					attempt+1,
-					completedSuccessfully,
-				)
-			}
-			if c.loggerEnabled {
-				c.logger.Debug("request completed",
-					attrMethod, req.Method,
-					"attempts", attempt+1,
-					"duration", time.Since(startTime),
-				)
-			}
-			if c.tracerEnabled {
-				// Keep the span status consistent with the metrics success flag:
-				// a non-retryable error stops here but is still a failure.
-				setSpanStatus(requestSpan, lastErr)
-			}
-
-			// Wrap the response body to cancel the per-attempt context when the body is closed
-			wrapBodyWithCancel(resp, result.cancelAttempt)
-			return resp, lastErr
+		retryable := c.isRetryable(lastErr, resp)
+		c.recordOutcome(ctx, req, result, retryable)
+		if !retryable {
+			return c.completeRequest(op, metrics, logger, req, result, attempt+1, startTime)
 		}
 
 		// === PHASE 4: Decide whether to retry ===
-		isLastAttempt := attempt == c.maxRetries
+		// Stop retrying early when a retry would not help (GiveUp* reasons)
+		if attempt < maxRetries {
+			giveUp, hostSuccessRate = c.giveUpEarly(ctx, req, startTime, attemptTook)
+			if giveUp != "" {
+				maxRetries = attempt
+				if c.loggerEnabled {
+					logger.Warn("giving up retries early",
+						"reason", giveUp,
+						"elapsed_ms", time.Since(startTime).Milliseconds(),
+					)
+				}
+			}
+		}
+		isLastAttempt := attempt == maxRetries
 
 		if !isLastAttempt {
 			// Going to retry - calculate and record next delay
-
-			// Calculate base delay for next attempt
-			if attempt == 0 {
-				nextDelayBase = c.initialRetryDelay
-			} else {
-				nextDelayBase = computeNextDelay(
-					nextDelayBase,
-					c.retryDelayMultiple,
-					c.maxRetryDelay,
-				)
-			}
-
-			// Apply Retry-After, jitter, and max cap
-			nextActualDelay, nextRetryAfter = c.applyDelayModifiers(nextDelayBase, resp)
-
-			// Record retry decision
-			var retryReason string
-			if c.metricsEnabled || c.loggerEnabled || c.tracerEnabled {
-				retryReason = determineRetryReason(lastErr, resp)
+			nextDelayBase, nextActualDelay, nextRetryAfter = c.nextRetryDelay(
+				req, resp, attempt, nextDelayBase, misdirected)
+			misdirected = misdirected || isMisdirected(resp)
+			nextActualDelay = c.fitDeadline(ctx, nextActualDelay, attemptTook)
+			if c.backoffState != nil {
+				c.backoffState.recordRetry(nextDelayBase)
 			}
-			if c.metricsEnabled {
-				c.metrics.RecordRetry(req.Method, retryReason, attempt+1)
-			}
-
-			if c.loggerEnabled {
-				// Build base log fields
-				logFields := []any{
-					attrMethod, req.Method,
-					attrURL, req.URL.String(),
-					"attempt", attempt + 1,
-					"reason", retryReason,
-					attrNextDelayMs, nextActualDelay.Milliseconds(),
-					"elapsed_ms", time.Since(startTime).Milliseconds(),
-				}
 
-				// Add error message if available (network errors, timeouts)
-				if lastErr != nil {
-					logFields = append(logFields, "error", lastErr.Error())
-				}
-
-				// Add HTTP status code if available (5xx, 429)
-				if resp != nil {
-					logFields = append(logFields, "status", resp.StatusCode)
-				}
-
-				c.logger.Warn("request failed, will retry", logFields...)
+			// Later attempts must not be sent as TLS early data after a 425
+			if isTooEarly(resp) {
+				ctx = withoutEarlyData(ctx)
 			}
 
-			if c.tracerEnabled {
-				requestSpan.AddEvent("retry",
-					Attribute{Key: "retry.attempt", Value: attempt + 1},
-					Attribute{Key: "retry.reason", Value: retryReason},
-					Attribute{Key: "retry.delay_ms", Value: nextActualDelay.Milliseconds()},
-				)
-			}
+			// Record retry decision
+			retryReason = determineRetryReason(lastErr, resp)
+			c.reportRetry(metrics, logger, requestSpan, req, RetryInfo{
+				Attempt:      attempt + 1,
+				Delay:        nextActualDelay,
+				Err:          lastErr,
+				StatusCode:   statusCodeOf(resp),
+				TotalElapsed: time.Since(startTime),
+				Reason:       retryReason,
+			})
 
 			shouldWait = true
-
-			// Close response body for retry
-			if resp != nil && resp.Body != nil {
-				resp.Body.Close()
-			}
-			if result.cancelAttempt != nil {
-				result.cancelAttempt()
-			}
+			c.releaseForRetry(result)
 		} else {
 			// Last attempt - keep response body open
 			wrapBodyWithCancel(resp, result.cancelAttempt)
@@ -657,6 +1343,9 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Resp
 	}
 
 	// All retries exhausted
+	if lastErr == nil && unsized {
+		resp, lastErr = rejectUnsizedBody(resp)
+	}
 	totalDuration := time.Since(startTime)
 	statusCode := statusCodeOf(resp)
 
@@ -664,9 +1353,7 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Resp
 	if c.loggerEnabled {
 		// Build base log fields
 		logFields := []any{
-			attrMethod, req.Method,
-			attrURL, req.URL.String(),
-			"attempts", c.maxRetries + 1,
+			"attempts", maxRetries + 1,
 			"duration_ms", totalDuration.Milliseconds(),
 			"final_status", statusCode,
 		}
@@ -675,19 +1362,16 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Resp
 		if lastErr != nil {
 			logFields = append(logFields, "error", lastErr.Error())
 		}
+		if giveUp != "" {
+			logFields = append(logFields, "give_up_reason", giveUp)
+		}
 
-		c.logger.Error("request failed after all retries", logFields...)
+		logger.Error("request failed after all retries", logFields...)
 	}
 
 	// Record final metrics (conditional on metricsEnabled)
-	if c.metricsEnabled {
-		c.metrics.RecordRequestComplete(
-			req.Method,
-			statusCode,
-			totalDuration,
-			c.maxRetries+1,
-			false,
-		)
+	if c.recordsMetric(MetricRequests) {
+		op.complete(metrics, req.Method, statusCode, maxRetries+1, false)
 	}
 
 	// Update request span (conditional on tracerEnabled)
@@ -696,14 +1380,213 @@ func (c *Client) doWithRetry(ctx context.Context, req *http.Request) (*http.Resp
 		requestSpan.SetAttributes(
 			Attribute{Key: "retry.exhausted", Value: true},
 		)
+		if giveUp != "" {
+			requestSpan.SetAttributes(Attribute{Key: "retry.give_up_reason", Value: giveUp})
+		}
+	}
+
+	// Prefer the decoded error body over a bare status code (WithErrorDecoder)
+	if lastErr == nil {
+		lastErr = c.decodeError(resp)
+	}
+	if giveUp == GiveUpRetriesSuppressed {
+		lastErr = suppressionError(req.URL.Host, hostSuccessRate, lastErr)
 	}
 
 	// All retries exhausted - return RetryError with detailed information
 	return resp, &RetryError{
-		Attempts:   c.maxRetries + 1, // +1 because attempts include the initial request
-		LastErr:    lastErr,
-		LastStatus: statusCode,
-		Elapsed:    totalDuration,
+		Attempts:     maxRetries + 1, // +1 because attempts include the initial request
+		LastErr:      lastErr,
+		LastStatus:   statusCode,
+		Elapsed:      totalDuration,
+		GiveUpReason: giveUp,
+	}
+}
+
+// awaitRetry runs the hooks before a retry (WithOnRetry, retry observers and
+// the retry decision event) and sleeps the retry delay. A non-nil error ends
+// the operation; fallback reports that the coordinator put the host in
+// cooldown and serves the retry with its fallback instead.
+func (c *Client) awaitRetry(
+	ctx context.Context,
+	req *http.Request,
+	logger Logger,
+	info RetryInfo,
+	endpoint string,
+) (fallback bool, err error) {
+	if c.onRetryFunc != nil {
+		c.onRetryFunc(info)
+	}
+
+	// Let observers from request-level middleware abort the operation
+	if err := notifyRetryObservers(ctx, info); err != nil {
+		return false, err
+	}
+	c.emitRetryDecision(ctx, req, endpoint, info)
+
+	// Stop retrying a host the coordinator put in cooldown
+	if c.coordinator.stopsRetries(req.URL.Host) {
+		if c.coordinator.fallback != nil {
+			return true, nil
+		}
+		return false, fmt.Errorf("%w: %s", ErrHostCoolingDown, req.URL.Host)
+	}
+
+	if c.loggerEnabled {
+		logger.Info("retrying request",
+			"attempt", info.Attempt+1,
+			"delay_ms", info.Delay.Milliseconds(),
+		)
+	}
+
+	waitStart := time.Now()
+	timer := time.NewTimer(info.Delay)
+	select {
+	case <-ctx.Done():
+		timer.Stop()
+		err = ctx.Err()
+	case <-timer.C:
+	}
+	c.recordRetryWait(req, endpoint, time.Since(waitStart), info.RetryAfter > 0)
+	return false, err
+}
+
+// admitAttempt holds an attempt back while the device is offline (parking
+// the request with WithOfflineQueue) and while rate limits other requests
+// discovered for the host are in effect. A non-nil error ends the operation.
+func (c *Client) admitAttempt(ctx context.Context, req *http.Request) error {
+	if c.offline(ctx) {
+		return c.parkOffline(ctx, req)
+	}
+	return c.waitForHost(ctx, req)
+}
+
+// recordOutcome feeds the outcome of an attempt to failure streaks,
+// connectivity monitoring, canary failover and host health.
+func (c *Client) recordOutcome(ctx context.Context, req *http.Request, result attemptResult, retryable bool) {
+	c.trackFailureStreak(req, retryable || result.err != nil)
+	c.recordDial(result.err)
+	if retryable {
+		c.failOverEndpoint(ctx)
+	}
+	// Failed dials while offline are the network's fault, not the host's
+	if !c.networkFault(ctx, result.err) {
+		c.hostHealth.record(req.URL.Host, !retryable, time.Now())
+		c.observePhi(req.URL.Host, !retryable)
+		c.recordRegion(result.region, !retryable)
+	}
+}
+
+// completeRequest ends an operation on an attempt that is not retried, after
+// the given number of attempts, and returns what the caller gets.
+func (c *Client) completeRequest(
+	op *requestOp,
+	metrics MetricsCollector,
+	logger Logger,
+	req *http.Request,
+	result attemptResult,
+	attempts int,
+	startTime time.Time,
+) (*http.Response, error) {
+	resp, err := result.resp, result.err
+	if err == nil && result.unsized {
+		resp, err = rejectUnsizedBody(resp)
+	}
+
+	// Success or non-retryable error. The request only "succeeded" when
+	// there is no error to return to the caller; a non-retryable error
+	// (e.g. a custom checker declining a network error) is a failure even
+	// though the retry loop stops here.
+	if c.recordsMetric(MetricRequests) {
+		op.complete(metrics, req.Method, statusCodeOf(resp), attempts, err == nil)
+	}
+	if c.loggerEnabled {
+		logger.Debug("request completed",
+			"attempts", attempts,
+			"duration", time.Since(startTime),
+		)
+	}
+	if c.tracerEnabled {
+		// Keep the span status consistent with the metrics success flag:
+		// a non-retryable error stops here but is still a failure.
+		setSpanStatus(op.span, err)
+	}
+
+	// Wrap the response body to cancel the per-attempt context when the body is closed
+	wrapBodyWithCancel(resp, result.cancelAttempt)
+	if err != nil {
+		return resp, err
+	}
+
+	// Surface a decoded error for non-retryable error statuses (WithErrorDecoder)
+	if decoded := c.decodeError(resp); decoded != nil {
+		return resp, &RetryError{
+			Attempts:   attempts,
+			LastErr:    decoded,
+			LastStatus: resp.StatusCode,
+			Elapsed:    time.Since(startTime),
+		}
+	}
+	return resp, nil
+}
+
+// reportRetry records, logs and traces the decision to retry after the
+// failed attempt info.Attempt.
+func (c *Client) reportRetry(
+	metrics MetricsCollector,
+	logger Logger,
+	requestSpan Span,
+	req *http.Request,
+	info RetryInfo,
+) {
+	if c.recordsMetric(MetricRetries) {
+		metrics.RecordRetry(req.Method, info.Reason, info.Attempt)
+	}
+
+	if c.loggerEnabled {
+		// Build base log fields
+		logFields := []any{
+			"attempt", info.Attempt,
+			"reason", info.Reason,
+			attrNextDelayMs, info.Delay.Milliseconds(),
+			"elapsed_ms", info.TotalElapsed.Milliseconds(),
+		}
+
+		// Add error message if available (network errors, timeouts)
+		if info.Err != nil {
+			logFields = append(logFields, "error", info.Err.Error())
+		}
+
+		// Add HTTP status code if available (5xx, 429)
+		if info.StatusCode != 0 {
+			logFields = append(logFields, "status", info.StatusCode)
+		}
+
+		logger.Warn("request failed, will retry", logFields...)
+	}
+
+	if c.tracerEnabled {
+		requestSpan.AddEvent("retry",
+			Attribute{Key: "retry.attempt", Value: info.Attempt},
+			Attribute{Key: "retry.reason", Value: info.Reason},
+			Attribute{Key: "retry.delay_ms", Value: info.Delay.Milliseconds()},
+		)
+	}
+}
+
+// releaseForRetry closes the response of an attempt that is retried and
+// cancels the attempt's context.
+func (c *Client) releaseForRetry(result attemptResult) {
+	if result.resp != nil && result.resp.Body != nil {
+		result.resp.Body.Close()
+	}
+	if result.cancelAttempt != nil {
+		result.cancelAttempt()
+	}
+
+	// Drop idle connections only after the 421 connection went back to the pool
+	if isMisdirected(result.resp) {
+		c.closeIdleConnections()
 	}
 }
 
@@ -715,14 +1598,46 @@ func (c *Client) doRequest(
 	url string,
 	opts ...RequestOption,
 ) (*http.Response, error) {
+	req, err := c.newRequest(ctx, method, url, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.doPrepared(ctx, req)
+}
+
+// newRequest creates a request and applies the default and given request options.
+func (c *Client) newRequest(
+	ctx context.Context,
+	method string,
+	url string,
+	opts ...RequestOption,
+) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(ctx, method, url, nil)
 	if err != nil {
 		return nil, err
 	}
+	for _, opt := range c.defaultRequestOptions {
+		opt(req)
+	}
 	for _, opt := range opts {
 		opt(req)
 	}
-	return c.DoWithContext(ctx, req)
+	return req, nil
+}
+
+// doPrepared executes a request built by newRequest, honoring WithTimeout.
+func (c *Client) doPrepared(ctx context.Context, req *http.Request) (*http.Response, error) {
+	timeout, ok := req.Context().Value(requestTimeoutKey{}).(time.Duration)
+	if !ok {
+		return c.DoWithContext(ctx, req)
+	}
+
+	// Bound the whole call; keep the body readable until the caller closes it.
+	// Derive from the request context to keep values set by other options.
+	ctx, cancel := context.WithTimeout(req.Context(), timeout)
+	resp, err := c.DoWithContext(ctx, req.WithContext(ctx))
+	wrapBodyWithCancel(resp, cancel)
+	return resp, err
 }
 
 // Get is a convenience method for making GET requests with retry logic.
@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
 	"sync/atomic"
 	"testing"
@@ -111,6 +112,53 @@ func TestNewClient_InvalidOptions(t *testing.T) {
 	}
 }
 
+func TestNewClient_MaxAttempts(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         []Option
+		wantRetries  int
+		wantAttempts int
+	}{
+		{"default", nil, defaultMaxRetries, defaultMaxRetries + 1},
+		{"max retries", []Option{WithMaxRetries(2)}, 2, 3},
+		{"max attempts", []Option{WithMaxAttempts(3)}, 2, 3},
+		{"single attempt", []Option{WithMaxAttempts(1)}, 0, 1},
+		{"invalid attempts ignored", []Option{WithMaxAttempts(0)}, defaultMaxRetries, defaultMaxRetries + 1},
+		{"last wins", []Option{WithMaxAttempts(5), WithMaxRetries(1)}, 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := client.MaxRetries(); got != tt.wantRetries {
+				t.Errorf("MaxRetries() = %d, want %d", got, tt.wantRetries)
+			}
+			if got := client.MaxAttempts(); got != tt.wantAttempts {
+				t.Errorf("MaxAttempts() = %d, want %d", got, tt.wantAttempts)
+			}
+		})
+	}
+
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	client, err := NewClient(WithMaxAttempts(3), WithInitialRetryDelay(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := client.Get(context.Background(), server.URL); err == nil {
+		resp.Body.Close()
+	}
+	if n := hits.Load(); n != 3 {
+		t.Errorf("Expected 3 attempts, got %d", n)
+	}
+}
+
 func TestDefaultRetryableChecker(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -154,6 +202,24 @@ func TestDefaultRetryableChecker(t *testing.T) {
 			resp:     &http.Response{StatusCode: http.StatusServiceUnavailable},
 			expected: true,
 		},
+		{
+			name:     "no error, 408 Request Timeout",
+			err:      nil,
+			resp:     &http.Response{StatusCode: http.StatusRequestTimeout},
+			expected: true,
+		},
+		{
+			name:     "no error, 421 Misdirected Request",
+			err:      nil,
+			resp:     &http.Response{StatusCode: http.StatusMisdirectedRequest},
+			expected: true,
+		},
+		{
+			name:     "no error, 425 Too Early",
+			err:      nil,
+			resp:     &http.Response{StatusCode: http.StatusTooEarly},
+			expected: false,
+		},
 		{
 			name:     "no error, nil response",
 			err:      nil,
@@ -748,16 +814,16 @@ func TestParseRetryAfter(t *testing.T) {
 
 	// Test HTTP-date format separately due to time.Now() dependency
 	t.Run("HTTP-date format", func(t *testing.T) {
-		futureTime := time.Now().Add(5 * time.Second).UTC()
+		// HTTP-date has 1-second precision, so measure against the formatted value
+		futureTime, _ := http.ParseTime(time.Now().Add(5 * time.Second).UTC().Format(http.TimeFormat))
 		resp := &http.Response{
 			Header: http.Header{},
 		}
 		resp.Header.Set("Retry-After", futureTime.Format(http.TimeFormat))
 
+		expected := time.Until(futureTime)
 		result := parseRetryAfter(resp)
-		expected := 5 * time.Second
-		// Allow larger tolerance due to HTTP-date having 1-second precision
-		// and time.Until() calculation happening after header creation
+		// Allow tolerance for time.Until() being evaluated at slightly different times
 		delta := 500 * time.Millisecond
 
 		if result < expected-delta || result > expected+delta {
@@ -778,6 +844,42 @@ func TestParseRetryAfter(t *testing.T) {
 			t.Errorf("expected 0 for past date, got %v", result)
 		}
 	})
+
+	// HTTP-date relative to the server's Date header, regardless of local clock skew
+	skewTests := []struct {
+		name string
+		skew time.Duration // server clock minus local clock
+	}{
+		{"server clock behind", -time.Hour},
+		{"server clock ahead", time.Hour},
+	}
+	for _, tt := range skewTests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverNow := time.Now().Add(tt.skew).UTC()
+			resp := &http.Response{
+				Header: http.Header{},
+			}
+			resp.Header.Set("Date", serverNow.Format(http.TimeFormat))
+			resp.Header.Set("Retry-After", serverNow.Add(30*time.Second).Format(http.TimeFormat))
+
+			result := parseRetryAfter(resp)
+			if result != 30*time.Second {
+				t.Errorf("expected 30s relative to server Date, got %v", result)
+			}
+		})
+	}
+
+	t.Run("invalid Date falls back to local time", func(t *testing.T) {
+		resp := &http.Response{
+			Header: http.Header{},
+		}
+		resp.Header.Set("Date", "not a date")
+		resp.Header.Set("Retry-After", time.Now().Add(-5*time.Second).UTC().Format(http.TimeFormat))
+
+		if result := parseRetryAfter(resp); result != 0 {
+			t.Errorf("expected 0 for past date, got %v", result)
+		}
+	})
 }
 
 func TestApplyJitter(t *testing.T) {
@@ -2620,3 +2722,413 @@ func TestWithRetryDelayMultiple_ConstantDelayAllowed(t *testing.T) {
 			defaultRetryDelayMultiple, c2.retryDelayMultiple)
 	}
 }
+
+// TestClient_Do_RewindsBodyViaGetBody verifies that retries obtain a fresh body
+// from GetBody rather than relying on the transport to rewind the consumed one.
+func TestClient_Do_RewindsBodyViaGetBody(t *testing.T) {
+	var bodies []string
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		data, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(data))
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+	})
+
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxRetries(2),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, _ := client.Post(context.Background(), "http://example.invalid",
+		WithBody("text/plain", strings.NewReader("payload")))
+	resp.Body.Close()
+
+	if len(bodies) != 3 {
+		t.Fatalf("expected 3 attempts, got %d", len(bodies))
+	}
+	for i, body := range bodies {
+		if body != "payload" {
+			t.Errorf("attempt %d: expected body %q, got %q", i+1, "payload", body)
+		}
+	}
+}
+
+func TestClient_Do_GetBodyErrorFailsAttempt(t *testing.T) {
+	var calls int32
+	transport := RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		_, _ = io.Copy(io.Discard, req.Body)
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+	})
+
+	client, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: transport}),
+		WithMaxRetries(1),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodPost,
+		"http://example.invalid", strings.NewReader("payload"))
+	getBodyErr := errors.New("file gone")
+	req.GetBody = func() (io.ReadCloser, error) { return nil, getBodyErr }
+
+	resp, err := client.Do(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, getBodyErr) {
+		t.Errorf("expected GetBody error to surface, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected retry to be skipped after GetBody failure, got %d transport calls", calls)
+	}
+}
+
+func TestWithTimeout_BoundsWholeCall(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithMaxRetries(10),
+		WithInitialRetryDelay(50*time.Millisecond),
+		WithJitter(false),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	start := time.Now()
+	resp, err := client.Get(context.Background(), server.URL, WithTimeout(120*time.Millisecond))
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected call to stop near the 120ms timeout, took %v", elapsed)
+	}
+}
+
+func TestWithTimeout_BodyReadableAfterReturn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithNoLogging())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL, WithTimeout(time.Second))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("expected body to stay readable, got %v", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("expected body %q, got %q", "hello", body)
+	}
+}
+
+func TestWithTimeout_NonPositiveIgnored(t *testing.T) {
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://example.com", nil)
+	WithTimeout(0)(req)
+	if _, ok := req.Context().Value(requestTimeoutKey{}).(time.Duration); ok {
+		t.Error("expected non-positive timeout to be ignored")
+	}
+}
+
+func TestWithOverallTimeout_BoundsBackgroundContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithMaxRetries(10),
+		WithInitialRetryDelay(50*time.Millisecond),
+		WithJitter(false),
+		WithOverallTimeout(120*time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	start := time.Now()
+	resp, err := client.Do(req)
+	if resp != nil {
+		resp.Body.Close()
+	}
+
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected RetryError wrapping context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected request to stop near the 120ms overall timeout, took %v", elapsed)
+	}
+}
+
+func TestWithOverallTimeout_BodyReadableAfterReturn(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithOverallTimeout(time.Second), WithNoLogging())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "hello" {
+		t.Errorf("expected body %q, got %q (err=%v)", "hello", body, err)
+	}
+}
+
+func TestWithOverallTimeout_NegativeIgnored(t *testing.T) {
+	client, err := NewClient(WithOverallTimeout(-time.Second))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	if client.overallTimeout != 0 {
+		t.Errorf("expected overallTimeout=0, got %v", client.overallTimeout)
+	}
+}
+
+func TestStatusOverrides(t *testing.T) {
+	client, err := NewClient(
+		WithRetryableStatuses(http.StatusTooEarly, http.StatusConflict),
+		WithNonRetryableStatuses(http.StatusRequestTimeout, http.StatusConflict),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		status   int
+		expected bool
+	}{
+		{name: "425 opted in", status: http.StatusTooEarly, expected: true},
+		{name: "408 opted out", status: http.StatusRequestTimeout, expected: false},
+		{name: "409 last option wins", status: http.StatusConflict, expected: false},
+		{name: "503 falls back to checker", status: http.StatusServiceUnavailable, expected: true},
+		{name: "404 falls back to checker", status: http.StatusNotFound, expected: false},
+		{
+			name:     "errors ignore overrides",
+			err:      errors.New("connection reset"),
+			status:   http.StatusRequestTimeout,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status}
+			if got := client.isRetryable(tt.err, resp); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestClient_Do_RetriesOn408ByDefault(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusRequestTimeout)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithInitialRetryDelay(time.Millisecond), WithNoLogging())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK || attempts.Load() != 2 {
+		t.Errorf("expected 408 to be retried once, got status %d after %d attempts",
+			resp.StatusCode, attempts.Load())
+	}
+}
+
+func TestWithDefaultRequestOptions(t *testing.T) {
+	var query, accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		accept = r.Header.Get("Accept")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithDefaultRequestOptions(
+			WithQuery("api-version", "2024-01"),
+			WithHeader("Accept", "application/json"),
+		),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL+"?page=2")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if query != "api-version=2024-01&page=2" || accept != "application/json" {
+		t.Errorf("Expected defaults to be applied, got query %q and Accept %q", query, accept)
+	}
+
+	// Per-call options run after the defaults and override them
+	resp, err = client.Get(context.Background(), server.URL,
+		WithQuery("api-version", "2025-06"), WithHeader("Accept", "text/csv"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	if query != "api-version=2025-06" || accept != "text/csv" {
+		t.Errorf("Expected per-call options to win, got query %q and Accept %q", query, accept)
+	}
+}
+
+func TestWithRequestRewriter(t *testing.T) {
+	var gotPath, gotHost string
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotHost = r.Host
+		if attempts++; attempts == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	calls := 0
+	client, err := NewClient(
+		WithRequestRewriter(func(req *http.Request) error {
+			calls++
+			if req.URL.Host != "users-service" {
+				return fmt.Errorf("unknown service %q", req.URL.Host)
+			}
+			req.URL.Host = serverURL.Host
+			return nil
+		}),
+		WithRequestRewriter(func(req *http.Request) error {
+			req.URL.Path = "/v2" + req.URL.Path
+			return nil
+		}),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://users-service/users", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if gotPath != "/v2/users" || gotHost != serverURL.Host {
+		t.Errorf("Expected rewritten request, got path %q host %q", gotPath, gotHost)
+	}
+	if req.URL.Host != "users-service" || req.URL.Path != "/users" {
+		t.Errorf("Caller's request must not be modified, got %s", req.URL)
+	}
+
+	// A rewriter error aborts the request without sending it
+	_, err = client.Get(context.Background(), "http://unknown-service/users")
+	if err == nil || !strings.Contains(err.Error(), "unknown service") {
+		t.Errorf("Expected rewriter error, got %v", err)
+	}
+	if attempts != 2 || calls != 2 {
+		t.Errorf("Expected the rewriter to run once per request, got %d calls for %d attempts",
+			calls, attempts)
+	}
+}
+
+func TestWithResponseInterceptor(t *testing.T) {
+	var attempts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Keep-Alive", "timeout=5")
+		if attempts++; attempts == 1 {
+			w.WriteHeader(598) // Proxy-specific upstream timeout
+		}
+	}))
+	defer server.Close()
+
+	var seen []int
+	client, err := NewClient(
+		WithResponseInterceptor(func(resp *http.Response) *http.Response {
+			if resp.StatusCode == 598 {
+				resp.StatusCode = http.StatusGatewayTimeout
+			}
+			return nil // modified in place
+		}),
+		WithResponseInterceptor(func(resp *http.Response) *http.Response {
+			seen = append(seen, resp.StatusCode)
+			clone := *resp
+			clone.Header = resp.Header.Clone()
+			clone.Header.Del("Keep-Alive")
+			return &clone
+		}),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	// The mapped 504 is retried; later interceptors see the normalized status
+	if attempts != 2 || fmt.Sprint(seen) != "[504 200]" {
+		t.Errorf("Expected the mapped status to be retried, got %d attempts, statuses %v", attempts, seen)
+	}
+	if resp.Header.Get("Keep-Alive") != "" {
+		t.Error("Expected the replacement response to be returned")
+	}
+}
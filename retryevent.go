@@ -0,0 +1,130 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryEventSchema is the version of the RetryEvent schema, reported in its
+// "schema" field. It is bumped when a field changes meaning or is removed;
+// new fields may be added within a version.
+const RetryEventSchema = 1
+
+// Retry event decisions.
+const (
+	RetryDecisionRetry  = "retry"   // A failed attempt is retried
+	RetryDecisionGiveUp = "give_up" // The operation failed with a RetryError
+)
+
+// RetryEvent is a machine-readable record of one retry decision, emitted by
+// WithRetryEvents and WithRetryEventFunc for analytics pipelines.
+type RetryEvent struct {
+	Schema    int       `json:"schema"`               // RetryEventSchema
+	Time      time.Time `json:"time"`                 // When the decision was made
+	Decision  string    `json:"decision"`             // RetryDecisionRetry or RetryDecisionGiveUp
+	RequestID string    `json:"request_id,omitempty"` // See WithRequestID
+	Method    string    `json:"method"`
+	URL       string    `json:"url"`
+	Endpoint  string    `json:"endpoint"` // Host of the failed attempt (of the request for give_up)
+	Attempt   int       `json:"attempt"`  // Attempts made so far
+
+	// Why the attempt is retried (RetryReason* constants), or why retrying
+	// stopped early (GiveUp* constants; empty when retries ran out or the
+	// failure was not retryable)
+	Reason string `json:"reason,omitempty"`
+
+	Status       int    `json:"status,omitempty"`         // Status of the failed attempt
+	Error        string `json:"error,omitempty"`          // Error of the failed attempt
+	DelayMS      int64  `json:"delay_ms"`                 // Delay before the retry (0 for give_up)
+	RetryAfterMS int64  `json:"retry_after_ms,omitempty"` // Retry-After the server asked for
+	ElapsedMS    int64  `json:"elapsed_ms"`               // Time since the first attempt
+
+	// Retries left in the Budget of a BudgetMiddleware (nil without one);
+	// for retry events, after this retry was charged
+	RetryBudget *float64 `json:"retry_budget,omitempty"`
+}
+
+// budgetKey carries the Budget of a BudgetMiddleware in the operation context.
+type budgetKey struct{}
+
+// jsonEventWriter writes RetryEvents to w as JSON lines.
+func jsonEventWriter(w io.Writer) func(RetryEvent) {
+	var mu sync.Mutex
+	return func(event RetryEvent) {
+		line, err := json.Marshal(event)
+		if err != nil {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		_, _ = w.Write(append(line, '\n'))
+	}
+}
+
+// emitRetryEvent completes event with the request and the operation's state
+// and passes it to the client's event sinks.
+func (c *Client) emitRetryEvent(ctx context.Context, req *http.Request, event RetryEvent) {
+	if len(c.retryEvents) == 0 {
+		return
+	}
+	event.Schema = RetryEventSchema
+	event.Time = time.Now()
+	event.RequestID = RequestIDFromContext(ctx)
+	event.Method = req.Method
+	event.URL = req.URL.Redacted()
+	if budget, ok := ctx.Value(budgetKey{}).(*Budget); ok {
+		available := budget.available()
+		event.RetryBudget = &available
+	}
+	for _, emit := range c.retryEvents {
+		emit(event)
+	}
+}
+
+// emitRetryDecision emits the event of a retry.
+func (c *Client) emitRetryDecision(
+	ctx context.Context,
+	req *http.Request,
+	endpoint string,
+	info RetryInfo,
+) {
+	event := RetryEvent{
+		Decision:     RetryDecisionRetry,
+		Endpoint:     endpoint,
+		Attempt:      info.Attempt,
+		Reason:       info.Reason,
+		Status:       info.StatusCode,
+		DelayMS:      info.Delay.Milliseconds(),
+		RetryAfterMS: info.RetryAfter.Milliseconds(),
+		ElapsedMS:    info.TotalElapsed.Milliseconds(),
+	}
+	if info.Err != nil {
+		event.Error = info.Err.Error()
+	}
+	c.emitRetryEvent(ctx, req, event)
+}
+
+// emitGiveUp emits the event of an operation that failed with a RetryError.
+func (c *Client) emitGiveUp(ctx context.Context, req *http.Request, err error) {
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		return
+	}
+	event := RetryEvent{
+		Decision:  RetryDecisionGiveUp,
+		Endpoint:  req.URL.Host,
+		Attempt:   retryErr.Attempts,
+		Reason:    retryErr.GiveUpReason,
+		Status:    retryErr.LastStatus,
+		ElapsedMS: retryErr.Elapsed.Milliseconds(),
+	}
+	if retryErr.LastErr != nil {
+		event.Error = retryErr.LastErr.Error()
+	}
+	c.emitRetryEvent(ctx, req, event)
+}
@@ -0,0 +1,114 @@
+package retry
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithRetryEvents(t *testing.T) {
+	server, _ := countingServer(t, http.StatusServiceUnavailable)
+	host := mustParseURL(t, server.URL).Host
+
+	var buf bytes.Buffer
+	client, err := NewClient(
+		WithMaxRetries(2),
+		WithInitialRetryDelay(time.Millisecond),
+		WithJitter(false),
+		WithRetryEvents(&buf),
+		WithRequestID(func() string { return "req-1" }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL+"/items")
+	if err == nil {
+		t.Fatal("Expected the retries to run out")
+	}
+
+	var events []map[string]any
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var event map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			t.Fatalf("Invalid JSON line %q: %v", scanner.Text(), err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 2 retry events and 1 give_up event, got %d", len(events))
+	}
+
+	first := events[0]
+	if first["schema"] != float64(RetryEventSchema) || first["decision"] != RetryDecisionRetry ||
+		first["attempt"] != float64(1) || first["status"] != float64(503) ||
+		first["reason"] != RetryReason5xx || first["endpoint"] != host ||
+		first["request_id"] != "req-1" || first["url"] != server.URL+"/items" {
+		t.Errorf("Unexpected first retry event %v", first)
+	}
+	if _, ok := first["retry_budget"]; ok {
+		t.Error("Expected no retry budget without a BudgetMiddleware")
+	}
+	if last := events[2]; last["decision"] != RetryDecisionGiveUp || last["attempt"] != float64(3) {
+		t.Errorf("Unexpected give_up event %v", last)
+	}
+}
+
+func TestWithRetryEventFunc_Budget(t *testing.T) {
+	server, _ := countingServer(t, http.StatusServiceUnavailable)
+
+	var mu sync.Mutex
+	var events []RetryEvent
+	budget := NewBudget(1, 0) // Each request earns one retry
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithRequestMiddleware(BudgetMiddleware(budget)),
+		WithRetryEventFunc(func(event RetryEvent) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, event)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("Expected ErrRetryBudgetExhausted, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 {
+		t.Fatalf("Expected 1 retry and 1 give_up event, got %+v", events)
+	}
+	retried, gaveUp := events[0], events[1]
+	if retried.Decision != RetryDecisionRetry || retried.RetryBudget == nil ||
+		*retried.RetryBudget != 0 {
+		t.Errorf("Expected the retry charged to the budget, got %+v", retried)
+	}
+	if gaveUp.Decision != RetryDecisionGiveUp || gaveUp.Error != ErrRetryBudgetExhausted.Error() ||
+		gaveUp.RetryBudget == nil {
+		t.Errorf("Expected a give_up event for the exhausted budget, got %+v", gaveUp)
+	}
+}
+
+// mustParseURL parses rawURL or fails the test.
+func mustParseURL(t *testing.T, rawURL string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
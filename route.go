@@ -0,0 +1,287 @@
+package retry
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+)
+
+// ErrInvalidRoute is returned by ParseRoutePolicyConfig and NewClient for a
+// route with a malformed path pattern.
+var ErrInvalidRoute = errors.New("retry: invalid route")
+
+// openAPIRetryPolicy is the OpenAPI extension carrying a retry policy.
+const openAPIRetryPolicy = "x-retry-policy"
+
+// routePolicy is a retry policy for the requests matching a method and path
+// pattern.
+type routePolicy struct {
+	method  string // "" matches any method
+	pattern string // See matchRoute
+	opts    []Option
+	client  *Client // Built by NewClient
+}
+
+// RoutePolicyConfig maps request methods and paths to retry policies, as
+// published by an API team alongside the API definition. Parse one with
+// ParseRoutePolicyConfig and apply it with WithRoutePolicyConfig.
+type RoutePolicyConfig struct {
+	Default *PresetSpec `json:"default,omitempty"` // Policy of requests matching no route
+	Routes  []RouteSpec `json:"routes,omitempty"`  // Checked in order; the first match wins
+}
+
+// RouteSpec is the retry policy of the requests matching Method and Path.
+type RouteSpec struct {
+	Method string     `json:"method,omitempty"` // HTTP method; empty or "*" for any
+	Path   string     `json:"path"`             // Path pattern, e.g. "/orders/*"
+	Policy PresetSpec `json:"policy"`
+}
+
+// ParseRoutePolicyConfig decodes a RoutePolicyConfig from JSON, in either of
+// two formats. The native one is the RoutePolicyConfig itself:
+//
+//	{
+//	  "default": {"max_retries": 2},
+//	  "routes": [
+//	    {"method": "GET", "path": "/search", "policy": {"max_retries": 5}},
+//	    {"method": "POST", "path": "/orders", "policy": {"max_retries": 0}},
+//	    {"path": "/admin/**", "policy": {"initial_retry_delay": "1s"}}
+//	  ]
+//	}
+//
+// The other is an OpenAPI document with "x-retry-policy" extensions, on the
+// document (the default), a path item (all its operations) or an operation:
+//
+//	{
+//	  "openapi": "3.1.0",
+//	  "paths": {
+//	    "/orders/{id}": {
+//	      "get": {"x-retry-policy": {"max_retries": 5, "retryable_statuses": [409]}}
+//	    }
+//	  }
+//	}
+//
+// Path templates become wildcards ("/orders/*"), and literal paths are
+// checked before templated ones. Policies are PresetSpecs; unknown policy
+// fields are rejected.
+func ParseRoutePolicyConfig(data []byte) (*RoutePolicyConfig, error) {
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("retry: decode route policy config: %w", err)
+	}
+
+	var cfg *RoutePolicyConfig
+	var err error
+	if _, ok := doc["paths"]; ok {
+		cfg, err = parseOpenAPIRetryPolicies(doc)
+	} else {
+		cfg = &RoutePolicyConfig{}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("retry: decode route policy config: %w", err)
+	}
+	return cfg, cfg.Validate()
+}
+
+// parseOpenAPIRetryPolicies collects the x-retry-policy extensions of an
+// OpenAPI document.
+func parseOpenAPIRetryPolicies(doc map[string]json.RawMessage) (*RoutePolicyConfig, error) {
+	cfg := &RoutePolicyConfig{}
+	if raw, ok := doc[openAPIRetryPolicy]; ok {
+		spec, err := decodePolicy(raw)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Default = &spec
+	}
+
+	var paths map[string]map[string]json.RawMessage
+	if err := json.Unmarshal(doc["paths"], &paths); err != nil {
+		return nil, err
+	}
+	for _, template := range slices.SortedFunc(maps.Keys(paths), compareTemplates) {
+		item := paths[template]
+		pattern := templateToPattern(template)
+
+		// Operations first, so they override their path item's policy
+		for _, method := range slices.Sorted(maps.Keys(item)) {
+			var op map[string]json.RawMessage
+			if method == openAPIRetryPolicy || json.Unmarshal(item[method], &op) != nil {
+				continue // Not an operation, e.g. "parameters"
+			}
+			raw, ok := op[openAPIRetryPolicy]
+			if !ok {
+				continue
+			}
+			spec, err := decodePolicy(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", strings.ToUpper(method), template, err)
+			}
+			cfg.Routes = append(cfg.Routes,
+				RouteSpec{Method: strings.ToUpper(method), Path: pattern, Policy: spec})
+		}
+		if raw, ok := item[openAPIRetryPolicy]; ok {
+			spec, err := decodePolicy(raw)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", template, err)
+			}
+			cfg.Routes = append(cfg.Routes, RouteSpec{Path: pattern, Policy: spec})
+		}
+	}
+	return cfg, nil
+}
+
+// decodePolicy decodes a PresetSpec, rejecting unknown fields.
+func decodePolicy(raw json.RawMessage) (PresetSpec, error) {
+	var spec PresetSpec
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&spec)
+	return spec, err
+}
+
+// compareTemplates orders OpenAPI path templates from the most specific:
+// fewer parameters first, then longer paths.
+func compareTemplates(a, b string) int {
+	return cmp.Or(
+		cmp.Compare(strings.Count(a, "{"), strings.Count(b, "{")),
+		cmp.Compare(len(b), len(a)),
+		strings.Compare(a, b),
+	)
+}
+
+// templateToPattern turns an OpenAPI path template into a path pattern:
+// "/orders/{id}/items" becomes "/orders/*/items".
+func templateToPattern(template string) string {
+	segments := strings.Split(template, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// Validate reports the first invalid route pattern or policy of cfg.
+func (cfg *RoutePolicyConfig) Validate() error {
+	if cfg.Default != nil {
+		if err := cfg.Default.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, route := range cfg.Routes {
+		if err := validateRoutePattern(route.Path); err != nil {
+			return err
+		}
+		if err := route.Policy.Validate(); err != nil {
+			return fmt.Errorf("%s %s: %w", route.Method, route.Path, err)
+		}
+	}
+	return nil
+}
+
+// validateRoutePattern reports whether pattern is a well-formed path pattern.
+func validateRoutePattern(pattern string) error {
+	if _, err := path.Match(strings.TrimSuffix(pattern, "/**"), ""); err != nil || pattern == "" {
+		return fmt.Errorf("%w: path pattern %q", ErrInvalidRoute, pattern)
+	}
+	return nil
+}
+
+// matchRoute reports whether a request path matches pattern: a path.Match
+// pattern, where "*" matches one path segment, or a prefix ending in "/**",
+// which matches the prefix and everything below it.
+func matchRoute(pattern, p string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/**"); ok {
+		n := strings.Count(prefix, "/")
+		segments := strings.Split(p, "/")
+		if len(segments) <= n {
+			return false
+		}
+		matched, _ := path.Match(prefix, strings.Join(segments[:n+1], "/"))
+		return matched
+	}
+	matched, _ := path.Match(pattern, p)
+	return matched
+}
+
+// matches reports whether req falls under the route.
+func (r *routePolicy) matches(req *http.Request) bool {
+	if r.method != "" && r.method != "*" && !strings.EqualFold(r.method, req.Method) {
+		return false
+	}
+	return matchRoute(r.pattern, req.URL.Path)
+}
+
+// routeClient returns the client applying the policy of the first route
+// matching req, or nil when none does.
+func (c *Client) routeClient(req *http.Request) *Client {
+	for i := range c.routes {
+		if c.routes[i].matches(req) {
+			return c.routes[i].client
+		}
+	}
+	return nil
+}
+
+// buildRoutes derives the client of every route policy.
+func (c *Client) buildRoutes() error {
+	for i := range c.routes {
+		if err := validateRoutePattern(c.routes[i].pattern); err != nil {
+			return err
+		}
+		rc, err := c.deriveRouteClient(c.routes[i].opts)
+		if err != nil {
+			return fmt.Errorf("route %s %s: %w", c.routes[i].method, c.routes[i].pattern, err)
+		}
+		c.routes[i].client = rc
+	}
+	return nil
+}
+
+// deriveRouteClient builds the client of a route like Clone: from the options
+// c was created with, followed by opts, so every option is honored. The route
+// client shares c's learned state and limits unless opts replace them. It
+// runs no background work: c's probes, connectivity monitoring and offline
+// queue serve it, and background options in opts have no effect.
+func (c *Client) deriveRouteClient(opts []Option) (*Client, error) {
+	inherit := func(rc *Client) {
+		rc.routes = nil
+
+		rc.stats = c.stats
+		rc.failureStreaks = c.failureStreaks
+		rc.hostThrottle = c.hostThrottle
+		rc.hostLimiter = c.hostLimiter
+		rc.adaptive = c.adaptive
+		rc.quotaPacer = c.quotaPacer
+		rc.hostHealth = c.hostHealth
+		rc.coalescer = c.coalescer
+		rc.admission = c.admission
+		rc.bandwidth = c.bandwidth
+		rc.clockSkew = c.clockSkew
+
+		// Built by c's NewClient; built again only when opts configure them
+		rc.trafficSplitPercents, rc.trafficSplit = nil, c.trafficSplit
+		rc.endpointRates, rc.endpointLimits = nil, c.endpointLimits
+	}
+	background := func(rc *Client) {
+		rc.route = true
+		rc.keepAliveProbes = nil
+		rc.regionPolicy, rc.regions = nil, c.regions
+		rc.connectivity = c.connectivity
+		rc.offlineQueue = c.offlineQueue
+		rc.backgroundCtx, rc.stopBackground = c.background(), nil
+	}
+	opts = append(append(slices.Clone(c.options), inherit), opts...)
+	return NewClient(append(opts, background)...)
+}
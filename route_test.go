@@ -0,0 +1,345 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseRoutePolicyConfig_Native(t *testing.T) {
+	cfg, err := ParseRoutePolicyConfig([]byte(`{
+		"default": {"max_retries": 2},
+		"routes": [
+			{"method": "GET", "path": "/search", "policy": {"max_retries": 5}},
+			{"path": "/admin/**", "policy": {"initial_retry_delay": "1s"}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Default == nil || *cfg.Default.MaxRetries != 2 {
+		t.Errorf("Expected a default of 2 retries, got %+v", cfg.Default)
+	}
+	if len(cfg.Routes) != 2 || cfg.Routes[0].Method != "GET" || cfg.Routes[0].Path != "/search" ||
+		*cfg.Routes[0].Policy.MaxRetries != 5 || cfg.Routes[1].Path != "/admin/**" {
+		t.Errorf("Unexpected routes %+v", cfg.Routes)
+	}
+}
+
+func TestParseRoutePolicyConfig_OpenAPI(t *testing.T) {
+	cfg, err := ParseRoutePolicyConfig([]byte(`{
+		"openapi": "3.1.0",
+		"x-retry-policy": {"max_retries": 1},
+		"paths": {
+			"/orders/{id}": {
+				"parameters": [{"name": "id", "in": "path"}],
+				"x-retry-policy": {"max_retries": 2},
+				"get": {"x-retry-policy": {"max_retries": 5, "retryable_statuses": [409]}},
+				"delete": {"summary": "No policy"}
+			},
+			"/orders/latest": {
+				"get": {"x-retry-policy": {"max_retries": 3}}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Default == nil || *cfg.Default.MaxRetries != 1 {
+		t.Errorf("Expected the document policy as the default, got %+v", cfg.Default)
+	}
+
+	want := []struct {
+		method, path string
+		retries      int
+	}{
+		{"GET", "/orders/latest", 3}, // Literal paths first
+		{"GET", "/orders/*", 5},      // Operations before their path item
+		{"", "/orders/*", 2},
+	}
+	if len(cfg.Routes) != len(want) {
+		t.Fatalf("Expected %d routes, got %+v", len(want), cfg.Routes)
+	}
+	for i, w := range want {
+		route := cfg.Routes[i]
+		if route.Method != w.method || route.Path != w.path || *route.Policy.MaxRetries != w.retries {
+			t.Errorf("Route %d: expected %+v, got %+v", i, w, route)
+		}
+	}
+}
+
+func TestParseRoutePolicyConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		is   error
+	}{
+		{"unknown field", `{"routes": [], "retries": 3}`, nil},
+		{"unknown policy field", `{"routes": [{"path": "/a", "policy": {"retries": 3}}]}`, nil},
+		{"bad pattern", `{"routes": [{"path": "/a/[", "policy": {}}]}`, ErrInvalidRoute},
+		{"empty pattern", `{"routes": [{"policy": {}}]}`, ErrInvalidRoute},
+		{"bad openapi policy", `{"paths": {"/a": {"get": {"x-retry-policy": {"x": 1}}}}}`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRoutePolicyConfig([]byte(tt.data))
+			if err == nil {
+				t.Fatal("Expected an error")
+			}
+			if tt.is != nil && !errors.Is(err, tt.is) {
+				t.Errorf("Expected %v, got %v", tt.is, err)
+			}
+		})
+	}
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/search", "/search", true},
+		{"/search", "/search/x", false},
+		{"/orders/*", "/orders/42", true},
+		{"/orders/*", "/orders/42/items", false},
+		{"/orders/*/items", "/orders/42/items", true},
+		{"/admin/**", "/admin", true},
+		{"/admin/**", "/admin/", true},
+		{"/admin/**", "/admin/users/7", true},
+		{"/admin/**", "/administrator/x", false},
+		{"/v*/admin/**", "/v2/admin/users", true},
+	}
+	for _, tt := range tests {
+		if got := matchRoute(tt.pattern, tt.path); got != tt.want {
+			t.Errorf("matchRoute(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestWithRoutePolicyConfig(t *testing.T) {
+	server, hits := countingServer(t, http.StatusServiceUnavailable)
+	cfg, err := ParseRoutePolicyConfig([]byte(`{
+		"default": {"max_retries": 1, "initial_retry_delay": "1ms"},
+		"routes": [
+			{"method": "GET", "path": "/search", "policy": {"max_retries": 3}},
+			{"method": "POST", "path": "/orders", "policy": {"max_retries": 0}}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := NewClient(WithRoutePolicyConfig(cfg), WithJitter(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		send     func() (*http.Response, error)
+		attempts int32
+	}{
+		{"route", func() (*http.Response, error) {
+			return client.Get(context.Background(), server.URL+"/search")
+		}, 4},
+		{"no retries", func() (*http.Response, error) {
+			return client.Post(context.Background(), server.URL+"/orders")
+		}, 1},
+		{"method mismatch", func() (*http.Response, error) {
+			return client.Post(context.Background(), server.URL+"/search")
+		}, 2},
+		{"default", func() (*http.Response, error) {
+			return client.Get(context.Background(), server.URL+"/other")
+		}, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits.Store(0)
+			resp, err := tt.send()
+			if err == nil {
+				resp.Body.Close()
+			}
+			if n := hits.Load(); n != tt.attempts {
+				t.Errorf("Expected %d attempts, got %d", tt.attempts, n)
+			}
+		})
+	}
+}
+
+func TestWithRoutePolicyConfig_SharesState(t *testing.T) {
+	server, _ := countingServer(t, http.StatusOK)
+	retries := 2
+	client, err := NewClient(
+		WithRetryableStatuses(http.StatusConflict),
+		WithRoutePolicyConfig(&RoutePolicyConfig{Routes: []RouteSpec{{
+			Path: "/a",
+			Policy: PresetSpec{
+				MaxRetries:           &retries,
+				InitialRetryDelay:    Duration(time.Millisecond),
+				NonRetryableStatuses: []int{http.StatusConflict},
+			},
+		}}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if client.statusOverrides[http.StatusConflict] != true {
+		t.Error("Expected the route policy to leave the client's status overrides alone")
+	}
+	for _, p := range []string{"/a", "/b"} {
+		resp, err := client.Get(context.Background(), server.URL+p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if stats := client.Stats(); stats.NewConnections+stats.ReusedConnections != 2 {
+		t.Errorf("Expected route requests counted by the client, got %+v", stats)
+	}
+}
+
+func TestWithRoutePolicyConfig_InvalidPattern(t *testing.T) {
+	_, err := NewClient(WithRoutePolicyConfig(&RoutePolicyConfig{
+		Routes: []RouteSpec{{Path: "/a/["}},
+	}))
+	if !errors.Is(err, ErrInvalidRoute) {
+		t.Errorf("Expected ErrInvalidRoute, got %v", err)
+	}
+}
+
+func TestWithRoutePolicy(t *testing.T) {
+	server, hits := countingServer(t, http.StatusServiceUnavailable)
+	client, err := NewClient(
+		WithMaxRetries(1),
+		WithInitialRetryDelay(time.Millisecond),
+		WithRoutePolicy(http.MethodGet, "/search", WithMaxRetries(3)),
+		WithRoutePolicy(http.MethodPost, "/orders", WithMaxRetries(0)),
+		WithRoutePolicy("*", "/orders", WithMaxRetries(2)), // Shadowed for POST
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		attempts     int32
+	}{
+		{http.MethodGet, "/search", 4},
+		{http.MethodPost, "/orders", 1},
+		{http.MethodPut, "/orders", 3},
+		{http.MethodGet, "/other", 2},
+	}
+	for _, tt := range tests {
+		hits.Store(0)
+		req, err := http.NewRequest(tt.method, server.URL+tt.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := client.Do(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		if n := hits.Load(); n != tt.attempts {
+			t.Errorf("%s %s: expected %d attempts, got %d", tt.method, tt.path, tt.attempts, n)
+		}
+	}
+}
+
+func TestWithRoutePolicy_InvalidGlob(t *testing.T) {
+	_, err := NewClient(WithRoutePolicy(http.MethodGet, "/search/[", WithMaxRetries(3)))
+	if !errors.Is(err, ErrInvalidRoute) {
+		t.Errorf("Expected ErrInvalidRoute, got %v", err)
+	}
+}
+
+func TestWithRoutePolicy_ConstructionOptions(t *testing.T) {
+	server, _ := countingServer(t, http.StatusServiceUnavailable)
+	logger := &MockLogger{}
+	var attempts atomic.Int32
+	client, err := NewClient(
+		WithNoLogging(),
+		WithMaxRetries(1),
+		WithInitialRetryDelay(time.Millisecond),
+		WithRoutePolicy("", "/x",
+			WithLogger(logger),
+			WithPerAttemptMiddleware(func(next http.RoundTripper) http.RoundTripper {
+				return RoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+					attempts.Add(1)
+					return next.RoundTrip(req)
+				})
+			}),
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp, err := client.Get(context.Background(), server.URL+"/x"); err == nil {
+		resp.Body.Close()
+	}
+	logger.mu.Lock()
+	logged := len(logger.DebugLogs) + len(logger.InfoLogs) + len(logger.WarnLogs) + len(logger.ErrorLogs)
+	logger.mu.Unlock()
+	if logged == 0 {
+		t.Error("Expected the route's logger to be used")
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("Expected the route's per-attempt middleware on 2 attempts, got %d", n)
+	}
+}
+
+func TestWithRoutePolicy_NamedMiddleware(t *testing.T) {
+	server, _ := countingServer(t, http.StatusOK)
+	var seen []string
+	tag := func(name string) RequestMiddleware {
+		return func(next RetryFunc) RetryFunc {
+			return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+				seen = append(seen, name)
+				return next(ctx, req)
+			}
+		}
+	}
+	client, err := NewClient(
+		WithNamedRequestMiddleware("tag", tag("client")),
+		WithRoutePolicy("", "/x", WithNamedRequestMiddleware("tag", tag("route"))),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, p := range []string{"/x", "/y"} {
+		resp, err := client.Get(context.Background(), server.URL+p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	if len(seen) != 2 || seen[0] != "route" || seen[1] != "client" {
+		t.Errorf("Expected the route to replace the middleware only for itself, got %v", seen)
+	}
+}
+
+func TestWithRoutePolicy_OfflineQueue(t *testing.T) {
+	client, err := NewClient(
+		WithOfflineQueue(newTestStateStore(t), nil),
+		WithRoutePolicy("", "/x",
+			WithMaxRetries(0),
+			WithOfflineQueue(newTestStateStore(t), nil), // Background options are the client's
+		),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	rc := client.routes[0].client
+	if client.connectivity == nil || rc.connectivity != client.connectivity {
+		t.Error("Expected the client and its route to share one connectivity monitor")
+	}
+	if rc.offlineQueue != client.offlineQueue || client.offlineQueue.deliverer.client != client {
+		t.Error("Expected the client's own Deliverer to replay requests of its routes")
+	}
+}
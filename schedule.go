@@ -0,0 +1,162 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// ScheduledRequest is a request handed to Client.DoAfter or
+// Client.ScheduleResume, waiting on a timer instead of a blocked goroutine
+// until it is due.
+type ScheduledRequest struct {
+	ready    chan struct{} // Closed once the fields below are set
+	timer    *time.Timer
+	stopCtx  func() bool // Unregisters the context cancellation hook
+	onCancel func()      // Called when Cancel stops the request before it runs
+
+	done chan struct{}
+	resp *http.Response
+	err  error
+}
+
+// schedule runs run with ctx at the given time. Canceling ctx before then
+// stops the request with ctx's error. onCancel, if not nil, is called when
+// Cancel stops it.
+func schedule(
+	ctx context.Context,
+	at time.Time,
+	run func(ctx context.Context) (*http.Response, error),
+	onCancel func(),
+) *ScheduledRequest {
+	s := &ScheduledRequest{
+		ready:    make(chan struct{}),
+		onCancel: onCancel,
+		done:     make(chan struct{}),
+	}
+	defer close(s.ready)
+
+	s.timer = time.AfterFunc(time.Until(at), func() {
+		<-s.ready
+		s.finish(run(ctx))
+	})
+	s.stopCtx = context.AfterFunc(ctx, func() {
+		<-s.ready
+		if s.timer.Stop() {
+			s.finish(nil, context.Cause(ctx))
+		}
+	})
+	return s
+}
+
+// finish records the outcome; it is called exactly once, either by the timer
+// or by whoever stopped it.
+func (s *ScheduledRequest) finish(resp *http.Response, err error) {
+	s.stopCtx()
+	s.resp, s.err = resp, err
+	close(s.done)
+}
+
+// Done returns a channel that is closed once the request has completed or
+// been canceled.
+func (s *ScheduledRequest) Done() <-chan struct{} {
+	return s.done
+}
+
+// Wait blocks until the request has completed and returns its outcome, as
+// Client.Do would. The caller must close the response body.
+func (s *ScheduledRequest) Wait() (*http.Response, error) {
+	<-s.done
+	return s.resp, s.err
+}
+
+// Cancel stops a request that is not yet due, deleting its checkpoint if it
+// was persisted, and reports whether it did. Wait then returns
+// context.Canceled. A request already running is not affected; cancel the
+// context passed to DoAfter to abort it.
+func (s *ScheduledRequest) Cancel() bool {
+	if !s.timer.Stop() {
+		return false
+	}
+	if s.onCancel != nil {
+		s.onCancel()
+	}
+	s.finish(nil, context.Canceled)
+	return true
+}
+
+// DoAfter hands req off to be sent after delay, with the client's retry
+// policy, and returns immediately. Waiting requests hold a timer rather than
+// a goroutine, so "try again in 10 minutes" costs nothing while it waits.
+// Canceling ctx before the request is due cancels it; use
+// context.WithoutCancel to detach it from a short-lived caller context.
+//
+// When the client has a StateStore (WithStateStore) and req is marked with
+// WithCheckpoint, the deferred request is checkpointed right away, so a new
+// process can pick it up with ScheduleResume if this one exits before it
+// runs. DoAfter only returns an error when that checkpoint cannot be saved.
+//
+// Example:
+//
+//	req, _ := http.NewRequest(http.MethodPost, url, nil)
+//	scheduled, err := client.DoAfter(context.WithoutCancel(ctx), req, 10*time.Minute)
+//	// ...
+//	resp, err := scheduled.Wait()
+func (c *Client) DoAfter(
+	ctx context.Context,
+	req *http.Request,
+	delay time.Duration,
+) (*ScheduledRequest, error) {
+	at := time.Now().Add(delay)
+
+	var onCancel func()
+	if cp, ok := checkpointOf(ctx, req); ok && c.stateStore != nil {
+		state := RetryState{
+			ID:      cp.id,
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Header:  req.Header.Clone(),
+			BodyRef: cp.bodyRef,
+			NextAt:  at,
+		}
+		if err := c.stateStore.Save(ctx, state); err != nil {
+			return nil, err
+		}
+		onCancel = func() {
+			c.deleteCheckpoint(context.WithoutCancel(ctx), cp.id)
+		}
+	}
+
+	return schedule(ctx, at, func(ctx context.Context) (*http.Response, error) {
+		return c.DoWithContext(ctx, req)
+	}, onCancel), nil
+}
+
+// ScheduleResume is the non-blocking form of Resume: it returns immediately
+// and resumes the checkpoint from a timer once state.NextAt is due. Use it on
+// startup to pick up checkpoints, including those saved by DoAfter, without a
+// sleeping goroutine per checkpoint.
+//
+// Example:
+//
+//	states, err := store.List(ctx)
+//	for _, state := range states {
+//	    body := retry.WithBody("application/json", load(state.BodyRef))
+//	    scheduled := client.ScheduleResume(ctx, state, body)
+//	    // ...
+//	}
+func (c *Client) ScheduleResume(
+	ctx context.Context,
+	state RetryState,
+	opts ...RequestOption,
+) *ScheduledRequest {
+	var onCancel func()
+	if c.stateStore != nil {
+		onCancel = func() {
+			c.deleteCheckpoint(context.WithoutCancel(ctx), state.ID)
+		}
+	}
+	return schedule(ctx, state.NextAt, func(ctx context.Context) (*http.Response, error) {
+		return c.Resume(ctx, state, opts...)
+	}, onCancel)
+}
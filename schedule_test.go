@@ -0,0 +1,160 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClient_DoAfter(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+
+	start := time.Now()
+	scheduled, err := client.DoAfter(context.Background(), req, 50*time.Millisecond)
+	if err != nil {
+		t.Fatalf("DoAfter() error = %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 0 {
+		t.Fatalf("requests = %d before the delay, want 0", got)
+	}
+
+	resp, err := scheduled.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	resp.Body.Close()
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("request sent after %v, want at least 50ms", elapsed)
+	}
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("status %d after %d requests, want 200 after 1", resp.StatusCode, requests)
+	}
+}
+
+func TestClient_DoAfter_Cancel(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer server.Close()
+
+	store := newTestStateStore(t)
+	client, err := NewClient(WithStateStore(store))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	WithCheckpoint("job-1", "")(req)
+
+	scheduled, err := client.DoAfter(context.Background(), req, time.Hour)
+	if err != nil {
+		t.Fatalf("DoAfter() error = %v", err)
+	}
+	states, _ := store.List(context.Background())
+	if len(states) != 1 || states[0].ID != "job-1" || states[0].Attempt != 0 {
+		t.Fatalf("List() = %+v, want the job-1 checkpoint saved by DoAfter", states)
+	}
+
+	if !scheduled.Cancel() {
+		t.Fatal("Cancel() = false, want true for a pending request")
+	}
+	if scheduled.Cancel() {
+		t.Error("second Cancel() = true, want false")
+	}
+	if _, err := scheduled.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+	if states, _ := store.List(context.Background()); len(states) != 0 {
+		t.Errorf("List() = %+v, want the checkpoint deleted by Cancel", states)
+	}
+	if got := atomic.LoadInt32(&requests); got != 0 {
+		t.Errorf("requests = %d, want 0", got)
+	}
+}
+
+func TestClient_DoAfter_ContextCanceled(t *testing.T) {
+	store := newTestStateStore(t)
+	client, err := NewClient(WithStateStore(store))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	WithCheckpoint("job-2", "")(req)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	scheduled, err := client.DoAfter(ctx, req, time.Hour)
+	if err != nil {
+		t.Fatalf("DoAfter() error = %v", err)
+	}
+	cancel()
+
+	select {
+	case <-scheduled.Done():
+	case <-time.After(time.Second):
+		t.Fatal("scheduled request not stopped after its context was canceled")
+	}
+	if _, err := scheduled.Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Wait() error = %v, want context.Canceled", err)
+	}
+	// Shutting down keeps the checkpoint for the next process
+	if states, _ := store.List(context.Background()); len(states) != 1 {
+		t.Errorf("List() = %+v, want the checkpoint kept", states)
+	}
+}
+
+func TestClient_ScheduleResume(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store := newTestStateStore(t)
+	client, err := NewClient(WithStateStore(store))
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	// A checkpoint left behind by DoAfter in a previous process
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	WithCheckpoint("job-3", "")(req)
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err := client.DoAfter(ctx, req, 30*time.Millisecond); err != nil {
+		t.Fatalf("DoAfter() error = %v", err)
+	}
+	cancel()
+
+	states, err := store.List(context.Background())
+	if err != nil || len(states) != 1 {
+		t.Fatalf("List() = %+v, %v; want one checkpoint", states, err)
+	}
+	scheduled := client.ScheduleResume(context.Background(), states[0])
+	resp, err := scheduled.Wait()
+	if err != nil {
+		t.Fatalf("Wait() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+	if states, _ := store.List(context.Background()); len(states) != 0 {
+		t.Errorf("List() = %+v, want the checkpoint deleted after completion", states)
+	}
+}
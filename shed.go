@@ -0,0 +1,153 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ErrShed is returned by the Client when WithAdmissionControl rejects a
+// request because the client is overloaded.
+var ErrShed = errors.New("retry: request shed")
+
+// admissionLatencyWeight is the weight of the latest attempt in the latency
+// moving average of WithAdmissionControl.
+const admissionLatencyWeight = 0.1
+
+// admissionLatencyHalfLife is how fast the latency moving average of
+// WithAdmissionControl decays without new attempts. Shed requests make no
+// attempts, so without decay an average over MaxLatency would shed
+// low-priority traffic forever once nothing else is sent.
+const admissionLatencyHalfLife = 5 * time.Second
+
+// Priority ranks requests for load shedding (see WithPriority and
+// WithAdmissionControl).
+type Priority int
+
+// Request priorities. Requests are PriorityNormal unless set otherwise.
+const (
+	PriorityLow      Priority = -1 // Shed first under pressure
+	PriorityNormal   Priority = 0
+	PriorityCritical Priority = 1
+)
+
+// AdmissionPolicy configures WithAdmissionControl. A zero threshold is
+// disabled.
+type AdmissionPolicy struct {
+	MaxInFlight int           // Requests in progress, from call to response headers
+	MaxQueued   int           // Attempts waiting for a connection slot, capacity or rate-limit token
+	MaxLatency  time.Duration // Moving average of attempt durations, decaying while idle
+
+	// Requests below this priority are shed while any threshold is exceeded.
+	// The zero value, PriorityNormal, sheds PriorityLow requests only.
+	MinPriority Priority
+}
+
+// priorityKey carries the WithPriority priority in a request context.
+type priorityKey struct{}
+
+// priorityOf returns the priority set by WithPriority on ctx or req.
+func priorityOf(ctx context.Context, req *http.Request) Priority {
+	if p, ok := ctx.Value(priorityKey{}).(Priority); ok {
+		return p
+	}
+	p, _ := req.Context().Value(priorityKey{}).(Priority)
+	return p
+}
+
+// admission sheds low-priority requests under pressure (WithAdmissionControl).
+type admission struct {
+	policy   AdmissionPolicy
+	inFlight atomic.Int64
+	queued   atomic.Int64
+
+	mu      sync.Mutex
+	latency float64   // Moving average of attempt durations, in nanoseconds
+	sampled time.Time // When latency was last updated
+}
+
+// pressure reports the first threshold the client exceeds, or "" when none
+// is.
+func (a *admission) pressure() string {
+	p := a.policy
+	switch {
+	case p.MaxInFlight > 0 && a.inFlight.Load() >= int64(p.MaxInFlight):
+		return fmt.Sprintf("%d requests in flight", a.inFlight.Load())
+	case p.MaxQueued > 0 && a.queued.Load() >= int64(p.MaxQueued):
+		return fmt.Sprintf("%d attempts queued", a.queued.Load())
+	}
+	if p.MaxLatency > 0 {
+		a.mu.Lock()
+		latency := time.Duration(a.decayedLatency(time.Now()))
+		a.mu.Unlock()
+		if latency >= p.MaxLatency {
+			return fmt.Sprintf("latency %v", latency.Round(time.Millisecond))
+		}
+	}
+	return ""
+}
+
+// shed returns an ErrShed error when a request of priority must be shed.
+func (a *admission) shed(priority Priority) error {
+	if a == nil || priority >= a.policy.MinPriority {
+		return nil
+	}
+	if reason := a.pressure(); reason != "" {
+		return fmt.Errorf("%w: %s", ErrShed, reason)
+	}
+	return nil
+}
+
+// admit counts a request in flight, unless it must be shed. The returned
+// function, never nil, ends the request.
+func (a *admission) admit(ctx context.Context, req *http.Request) (func(), error) {
+	if a == nil {
+		return func() {}, nil
+	}
+	if err := a.shed(priorityOf(ctx, req)); err != nil {
+		return nil, err
+	}
+	a.inFlight.Add(1)
+	return func() { a.inFlight.Add(-1) }, nil
+}
+
+// queue counts an attempt waiting for capacity. The returned function, never
+// nil, ends the wait.
+func (a *admission) queue() func() {
+	if a == nil {
+		return func() {}
+	}
+	a.queued.Add(1)
+	return func() { a.queued.Add(-1) }
+}
+
+// observe adds the duration of an attempt to the latency moving average.
+func (a *admission) observe(d time.Duration) {
+	if a == nil || a.policy.MaxLatency <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	now := time.Now()
+	if a.sampled.IsZero() {
+		a.latency, a.sampled = float64(d), now
+		return
+	}
+	latency := a.decayedLatency(now)
+	a.latency, a.sampled = latency+admissionLatencyWeight*(float64(d)-latency), now
+}
+
+// decayedLatency returns the latency moving average, halved for every
+// admissionLatencyHalfLife since its last update. Callers must hold a.mu.
+func (a *admission) decayedLatency(now time.Time) float64 {
+	age := now.Sub(a.sampled)
+	if a.sampled.IsZero() || age <= 0 {
+		return a.latency
+	}
+	return a.latency * math.Exp2(-float64(age)/float64(admissionLatencyHalfLife))
+}
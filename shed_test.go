@@ -0,0 +1,136 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// slowServer answers status after delay and counts requests.
+func slowServer(t *testing.T, status int, delay time.Duration) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		time.Sleep(delay)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestWithAdmissionControl_InFlight(t *testing.T) {
+	server, hits, release := blockingServer(t)
+	client, err := NewClient(WithAdmissionControl(AdmissionPolicy{MaxInFlight: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		first <- err
+	}()
+	time.Sleep(20 * time.Millisecond) // Let the first request get in flight
+
+	_, err = client.Get(context.Background(), server.URL, WithPriority(PriorityLow))
+	if !errors.Is(err, ErrShed) {
+		t.Errorf("Expected the low-priority request to be shed, got %v", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected no attempt for the shed request, got %d requests", n)
+	}
+
+	// Normal requests are admitted regardless
+	second := make(chan error, 1)
+	go func() {
+		resp, err := client.Get(context.Background(), server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		second <- err
+	}()
+	close(release)
+	for _, done := range []chan error{first, second} {
+		if err := <-done; err != nil {
+			t.Error(err)
+		}
+	}
+
+	// The pressure is gone
+	resp, err := client.Get(context.Background(), server.URL, WithPriority(PriorityLow))
+	if err != nil {
+		t.Fatalf("Expected the low-priority request to be admitted, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWithAdmissionControl_Latency(t *testing.T) {
+	server, _ := slowServer(t, http.StatusOK, 30*time.Millisecond)
+	client, err := NewClient(WithAdmissionControl(AdmissionPolicy{
+		MaxLatency:  10 * time.Millisecond,
+		MinPriority: PriorityCritical,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(p Priority) error {
+		resp, err := client.Get(context.Background(), server.URL, WithPriority(p))
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+	if err := get(PriorityNormal); err != nil {
+		t.Fatal(err)
+	}
+	if err := get(PriorityNormal); !errors.Is(err, ErrShed) {
+		t.Errorf("Expected requests below PriorityCritical to be shed, got %v", err)
+	}
+	if err := get(PriorityCritical); err != nil {
+		t.Errorf("Expected the critical request to be admitted, got %v", err)
+	}
+}
+
+func TestAdmission_LatencyDecays(t *testing.T) {
+	a := &admission{policy: AdmissionPolicy{MaxLatency: 10 * time.Millisecond}}
+	a.observe(40 * time.Millisecond)
+	if err := a.shed(PriorityLow); !errors.Is(err, ErrShed) {
+		t.Fatalf("Expected a slow attempt to shed low-priority requests, got %v", err)
+	}
+
+	// Only shed requests since: nothing updates the average
+	a.sampled = a.sampled.Add(-3 * admissionLatencyHalfLife)
+	if err := a.shed(PriorityLow); err != nil {
+		t.Errorf("Expected the average to decay while no attempts are made, got %v", err)
+	}
+}
+
+func TestWithAdmissionControl_ShedsRetries(t *testing.T) {
+	server, hits := slowServer(t, http.StatusServiceUnavailable, 30*time.Millisecond)
+	client, err := NewClient(
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+		WithAdmissionControl(AdmissionPolicy{MaxLatency: 10 * time.Millisecond}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.Get(context.Background(), server.URL, WithPriority(PriorityLow))
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) || retryErr.GiveUpReason != GiveUpShed {
+		t.Fatalf("Expected retries to stop with GiveUpShed, got %v", err)
+	}
+	if n := hits.Load(); n != 1 {
+		t.Errorf("Expected 1 attempt, got %d", n)
+	}
+}
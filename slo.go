@@ -0,0 +1,153 @@
+package retry
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"time"
+)
+
+// Defaults of the optional SLO fields, and the limits of the derivation.
+const (
+	DefaultSLOSuccessTarget      = 0.99 // Fraction of calls that must succeed
+	DefaultSLOAttemptSuccessRate = 0.9  // Expected fraction of single attempts that succeed
+
+	sloMaxAttempts = 10               // Cap on derived attempts
+	sloMinDelay    = time.Millisecond // Smallest initial delay worth a retry
+	sloJitter      = 1.25             // Worst case of the ±25% jitter
+	sloMultiplier  = 2.0              // Backoff multiplier of derived configs
+	sloEpsilon     = 1e-9             // Absorbs rounding in the attempt count
+)
+
+// SLO describes a latency and availability objective for the calls made
+// through a client. NewClientForSLO derives the retry settings from it.
+type SLO struct {
+	TargetLatency      time.Duration // Deadline of a call, retries included (required)
+	SuccessTarget      float64       // Fraction of calls that must succeed, e.g. 0.999 (default 0.99)
+	AttemptTimeout     time.Duration // Timeout of one attempt (default: derived from TargetLatency)
+	AttemptSuccessRate float64       // Expected fraction of attempts that succeed (default 0.9)
+}
+
+// SLOConfig is the retry configuration derived from an SLO.
+type SLOConfig struct {
+	MaxRetries         int
+	InitialRetryDelay  time.Duration
+	MaxRetryDelay      time.Duration
+	RetryDelayMultiple float64
+	PerAttemptTimeout  time.Duration
+	OverallTimeout     time.Duration // Equal to the SLO's TargetLatency
+
+	// Fraction of calls expected to succeed with MaxRetries+1 attempts. It is
+	// below the SuccessTarget when the latency target leaves too little room
+	// for the attempts the availability target needs.
+	ExpectedSuccess float64
+}
+
+// Derive computes the retry configuration for s:
+//
+//  1. Attempts: with attempts failing independently at 1-p (p =
+//     AttemptSuccessRate), n attempts succeed with probability 1-(1-p)^n, so
+//     the SuccessTarget S needs n = ceil(log(1-S) / log(1-p)), at most 10.
+//  2. Attempt timeout: AttemptTimeout, or TargetLatency/(2n) when unset, which
+//     leaves half the latency target for backoff.
+//  3. Fit: n attempts take up to n*AttemptTimeout; n is reduced until that
+//     leaves room in TargetLatency for the retry delays.
+//  4. Delays: the remaining slack L - n*T is spent on doubling delays d, 2d,
+//     ..., so d = slack / (1.25 * (2^(n-1) - 1)), the 1.25 covering jitter.
+//     The maximum delay is the last one, d*2^(n-2).
+//  5. Overall timeout: TargetLatency, so no call outlives the target.
+func (s SLO) Derive() (SLOConfig, error) {
+	if err := s.validate(); err != nil {
+		return SLOConfig{}, err
+	}
+	target := cmp.Or(s.SuccessTarget, DefaultSLOSuccessTarget)
+	p := cmp.Or(s.AttemptSuccessRate, DefaultSLOAttemptSuccessRate)
+
+	attempts := sloMaxAttempts
+	if p >= 1 {
+		attempts = 1
+	} else if needed := math.Log(1-target) / math.Log(1-p); needed < sloMaxAttempts {
+		attempts = max(int(math.Ceil(needed-sloEpsilon)), 1)
+	}
+
+	timeout := s.AttemptTimeout
+	if timeout == 0 {
+		timeout = s.TargetLatency / time.Duration(2*attempts)
+	}
+	timeout = min(timeout, s.TargetLatency)
+
+	var delay time.Duration
+	for ; attempts > 1; attempts-- {
+		slack := s.TargetLatency - time.Duration(attempts)*timeout
+		growth := math.Pow(sloMultiplier, float64(attempts-1)) - 1
+		delay = time.Duration(float64(slack) / (sloJitter * growth))
+		if delay >= sloMinDelay {
+			break
+		}
+	}
+
+	cfg := SLOConfig{
+		MaxRetries:         attempts - 1,
+		RetryDelayMultiple: sloMultiplier,
+		PerAttemptTimeout:  timeout,
+		OverallTimeout:     s.TargetLatency,
+		ExpectedSuccess:    1 - math.Pow(1-p, float64(attempts)),
+	}
+	if attempts > 1 {
+		cfg.InitialRetryDelay = delay
+		cfg.MaxRetryDelay = delay * time.Duration(math.Pow(sloMultiplier, float64(attempts-2)))
+	}
+	return cfg, nil
+}
+
+// validate checks the fields of s.
+func (s SLO) validate() error {
+	switch {
+	case s.TargetLatency <= 0:
+		return errors.New("retry: SLO target latency must be positive")
+	case s.SuccessTarget < 0 || s.SuccessTarget >= 1:
+		return errors.New("retry: SLO success target must be in [0, 1)")
+	case s.AttemptSuccessRate < 0 || s.AttemptSuccessRate > 1:
+		return errors.New("retry: SLO attempt success rate must be in [0, 1]")
+	case s.AttemptTimeout < 0:
+		return errors.New("retry: SLO attempt timeout must not be negative")
+	}
+	return nil
+}
+
+// Options returns the client options applying cfg.
+func (cfg SLOConfig) Options() []Option {
+	opts := []Option{
+		WithMaxRetries(cfg.MaxRetries),
+		WithRetryDelayMultiple(cfg.RetryDelayMultiple),
+		WithPerAttemptTimeout(cfg.PerAttemptTimeout),
+		WithOverallTimeout(cfg.OverallTimeout),
+	}
+	if cfg.MaxRetries > 0 {
+		opts = append(opts,
+			WithInitialRetryDelay(cfg.InitialRetryDelay),
+			WithMaxRetryDelay(cfg.MaxRetryDelay),
+		)
+	}
+	return opts
+}
+
+// NewClientForSLO creates a client whose retry settings are derived from slo
+// (see SLO.Derive) instead of picked by hand. opts are applied after the
+// derived settings, so they can override them. Use slo.Derive to inspect the
+// derived configuration.
+//
+// Example:
+//
+//	client, err := retry.NewClientForSLO(retry.SLO{
+//	    TargetLatency:  2 * time.Second,
+//	    SuccessTarget:  0.999,
+//	    AttemptTimeout: 300 * time.Millisecond,
+//	})
+func NewClientForSLO(slo SLO, opts ...Option) (*Client, error) {
+	cfg, err := slo.Derive()
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(append(cfg.Options(), opts...)...)
+}
@@ -0,0 +1,128 @@
+package retry
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSLO_Derive(t *testing.T) {
+	cfg, err := SLO{
+		TargetLatency:  2 * time.Second,
+		SuccessTarget:  0.999,
+		AttemptTimeout: 300 * time.Millisecond,
+	}.Derive()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 0.999 with 90% attempts needs 3 attempts; 1.1s of slack over delays d+2d
+	if cfg.MaxRetries != 2 {
+		t.Errorf("MaxRetries = %d, want 2", cfg.MaxRetries)
+	}
+	slack := 1100 * time.Millisecond
+	wantDelay := time.Duration(float64(slack) / (1.25 * 3))
+	if cfg.InitialRetryDelay != wantDelay || cfg.MaxRetryDelay != 2*wantDelay {
+		t.Errorf("delays = %v..%v, want %v..%v",
+			cfg.InitialRetryDelay, cfg.MaxRetryDelay, wantDelay, 2*wantDelay)
+	}
+	if cfg.PerAttemptTimeout != 300*time.Millisecond || cfg.OverallTimeout != 2*time.Second {
+		t.Errorf("timeouts = %v, %v", cfg.PerAttemptTimeout, cfg.OverallTimeout)
+	}
+	if cfg.ExpectedSuccess < 0.999 {
+		t.Errorf("ExpectedSuccess = %v, want >= 0.999", cfg.ExpectedSuccess)
+	}
+
+	// Worst case: every attempt times out and every delay gets maximum jitter
+	worst := 3*cfg.PerAttemptTimeout +
+		time.Duration(1.25*float64(cfg.InitialRetryDelay+cfg.MaxRetryDelay))
+	if worst > 2*time.Second {
+		t.Errorf("Worst case %v exceeds the target latency", worst)
+	}
+}
+
+func TestSLO_DeriveFitsLatency(t *testing.T) {
+	// Four 1s attempts cannot fit in 2.5s with room for delays
+	cfg, err := SLO{
+		TargetLatency:      2500 * time.Millisecond,
+		SuccessTarget:      0.9999,
+		AttemptTimeout:     time.Second,
+		AttemptSuccessRate: 0.9,
+	}.Derive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxRetries != 1 {
+		t.Errorf("MaxRetries = %d, want 1", cfg.MaxRetries)
+	}
+	if cfg.ExpectedSuccess >= 0.9999 {
+		t.Errorf("ExpectedSuccess = %v, want below the unreachable target", cfg.ExpectedSuccess)
+	}
+
+	// A single attempt longer than the target is capped to it
+	cfg, err = SLO{TargetLatency: time.Second, AttemptTimeout: time.Minute}.Derive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxRetries != 0 || cfg.PerAttemptTimeout != time.Second {
+		t.Errorf("Expected a single 1s attempt, got %+v", cfg)
+	}
+}
+
+func TestSLO_DeriveDefaults(t *testing.T) {
+	cfg, err := SLO{TargetLatency: 4 * time.Second}.Derive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 0.99 with 90% attempts needs 2 attempts of 1s, leaving 2s for one delay
+	if cfg.MaxRetries != 1 || cfg.PerAttemptTimeout != time.Second {
+		t.Errorf("Unexpected config %+v", cfg)
+	}
+	if cfg.InitialRetryDelay != 1600*time.Millisecond {
+		t.Errorf("InitialRetryDelay = %v, want 1.6s", cfg.InitialRetryDelay)
+	}
+
+	cfg, err = SLO{TargetLatency: time.Second, AttemptSuccessRate: 1}.Derive()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MaxRetries != 0 {
+		t.Errorf("Perfect attempts need no retries, got %d", cfg.MaxRetries)
+	}
+}
+
+func TestSLO_DeriveInvalid(t *testing.T) {
+	for _, slo := range []SLO{
+		{},
+		{TargetLatency: time.Second, SuccessTarget: 1},
+		{TargetLatency: time.Second, AttemptSuccessRate: 1.5},
+		{TargetLatency: time.Second, AttemptTimeout: -time.Second},
+	} {
+		if _, err := slo.Derive(); err == nil {
+			t.Errorf("Expected an error for %+v", slo)
+		}
+		if _, err := NewClientForSLO(slo); err == nil {
+			t.Errorf("Expected NewClientForSLO to fail for %+v", slo)
+		}
+	}
+}
+
+func TestNewClientForSLO(t *testing.T) {
+	client, err := NewClientForSLO(
+		SLO{TargetLatency: 2 * time.Second, SuccessTarget: 0.999},
+		WithMaxRetries(5),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := SLO{TargetLatency: 2 * time.Second, SuccessTarget: 0.999}.Derive()
+
+	if client.maxRetries != 5 {
+		t.Errorf("Options must override the derived config, maxRetries = %d", client.maxRetries)
+	}
+	if client.initialRetryDelay != cfg.InitialRetryDelay ||
+		client.maxRetryDelay != cfg.MaxRetryDelay ||
+		client.perAttemptTimeout != cfg.PerAttemptTimeout ||
+		client.overallTimeout != cfg.OverallTimeout {
+		t.Errorf("Client does not match derived config %+v", cfg)
+	}
+}
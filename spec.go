@@ -0,0 +1,304 @@
+package retry
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Duration is a time.Duration that encodes to JSON as a string such as "1.5s"
+// and decodes from such a string or from a number of nanoseconds.
+type Duration time.Duration
+
+// MarshalJSON encodes d as a duration string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON decodes a duration string or a number of nanoseconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var ns int64
+		if err := json.Unmarshal(data, &ns); err != nil {
+			return fmt.Errorf("retry: invalid duration %s", data)
+		}
+		*d = Duration(ns)
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("retry: invalid duration %q: %w", s, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// PresetSpec is a retry policy expressed as data, so platform teams can
+// version, diff, review and distribute policies as configuration artifacts
+// (e.g. JSON files or a config service) instead of code calling option
+// functions. Zero fields keep the client defaults; MaxRetries, Jitter and
+// RespectRetryAfter are pointers so that an explicit 0 or false can be told
+// apart from unset.
+type PresetSpec struct {
+	Name    string `json:"name,omitempty"`    // Identifies the policy, e.g. "payments-api"
+	Version string `json:"version,omitempty"` // Version of the policy, for rollouts and diffs
+
+	MaxRetries         *int     `json:"max_retries,omitempty"`
+	InitialRetryDelay  Duration `json:"initial_retry_delay,omitempty"`
+	MaxRetryDelay      Duration `json:"max_retry_delay,omitempty"`
+	RetryDelayMultiple float64  `json:"retry_delay_multiple,omitempty"`
+	Jitter             *bool    `json:"jitter,omitempty"`
+	RespectRetryAfter  *bool    `json:"respect_retry_after,omitempty"`
+
+	PerAttemptTimeout    Duration `json:"per_attempt_timeout,omitempty"`
+	OverallTimeout       Duration `json:"overall_timeout,omitempty"`
+	LatencyBudget        Duration `json:"latency_budget,omitempty"`
+	DeadlineAwareBackoff bool     `json:"deadline_aware_backoff,omitempty"`
+
+	RetryableStatuses    []int  `json:"retryable_statuses,omitempty"`
+	NonRetryableStatuses []int  `json:"non_retryable_statuses,omitempty"`
+	MaxConcurrentPerHost int    `json:"max_concurrent_per_host,omitempty"`
+	UserAgent            string `json:"user_agent,omitempty"`
+}
+
+// ParsePresetSpec decodes a PresetSpec from JSON and validates it. Unknown
+// fields are rejected, so a typo in a policy file fails loudly.
+func ParsePresetSpec(data []byte) (PresetSpec, error) {
+	var spec PresetSpec
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&spec); err != nil {
+		return PresetSpec{}, fmt.Errorf("retry: decode preset spec: %w", err)
+	}
+	return spec, spec.Validate()
+}
+
+// Validate reports the first invalid field of s. The option functions
+// silently ignore out-of-range values; a spec is configuration and fails
+// instead.
+func (s PresetSpec) Validate() error {
+	durations := []struct {
+		name  string
+		value Duration
+	}{
+		{"initial_retry_delay", s.InitialRetryDelay},
+		{"max_retry_delay", s.MaxRetryDelay},
+		{"per_attempt_timeout", s.PerAttemptTimeout},
+		{"overall_timeout", s.OverallTimeout},
+		{"latency_budget", s.LatencyBudget},
+	}
+	for _, d := range durations {
+		if d.value < 0 {
+			return fmt.Errorf("retry: preset spec %s must not be negative", d.name)
+		}
+	}
+
+	switch {
+	case s.MaxRetries != nil && *s.MaxRetries < 0:
+		return errors.New("retry: preset spec max_retries must not be negative")
+	case s.RetryDelayMultiple != 0 && s.RetryDelayMultiple < 1:
+		return errors.New("retry: preset spec retry_delay_multiple must be at least 1")
+	case s.MaxConcurrentPerHost < 0:
+		return errors.New("retry: preset spec max_concurrent_per_host must not be negative")
+	}
+	for _, code := range slices.Concat(s.RetryableStatuses, s.NonRetryableStatuses) {
+		if code < 100 || code > 599 {
+			return fmt.Errorf("retry: preset spec has invalid status code %d", code)
+		}
+	}
+	return nil
+}
+
+// Options returns the client options applying the fields set in s.
+func (s PresetSpec) Options() []Option {
+	var opts []Option
+	if s.MaxRetries != nil {
+		opts = append(opts, WithMaxRetries(*s.MaxRetries))
+	}
+	if s.InitialRetryDelay > 0 {
+		opts = append(opts, WithInitialRetryDelay(time.Duration(s.InitialRetryDelay)))
+	}
+	if s.MaxRetryDelay > 0 {
+		opts = append(opts, WithMaxRetryDelay(time.Duration(s.MaxRetryDelay)))
+	}
+	if s.RetryDelayMultiple != 0 {
+		opts = append(opts, WithRetryDelayMultiple(s.RetryDelayMultiple))
+	}
+	if s.Jitter != nil {
+		opts = append(opts, WithJitter(*s.Jitter))
+	}
+	if s.RespectRetryAfter != nil {
+		opts = append(opts, WithRespectRetryAfter(*s.RespectRetryAfter))
+	}
+	if s.PerAttemptTimeout > 0 {
+		opts = append(opts, WithPerAttemptTimeout(time.Duration(s.PerAttemptTimeout)))
+	}
+	if s.OverallTimeout > 0 {
+		opts = append(opts, WithOverallTimeout(time.Duration(s.OverallTimeout)))
+	}
+	if s.LatencyBudget > 0 {
+		opts = append(opts, WithLatencyBudget(time.Duration(s.LatencyBudget)))
+	}
+	if s.DeadlineAwareBackoff {
+		opts = append(opts, WithDeadlineAwareBackoff(true))
+	}
+	if len(s.RetryableStatuses) > 0 {
+		opts = append(opts, WithRetryableStatuses(s.RetryableStatuses...))
+	}
+	if len(s.NonRetryableStatuses) > 0 {
+		opts = append(opts, WithNonRetryableStatuses(s.NonRetryableStatuses...))
+	}
+	if s.MaxConcurrentPerHost > 0 {
+		opts = append(opts, WithMaxConcurrentPerHost(s.MaxConcurrentPerHost))
+	}
+	if s.UserAgent != "" {
+		opts = append(opts, WithUserAgent(s.UserAgent))
+	}
+	if id := s.policyID(); id != "" {
+		opts = append(opts, WithRetryPolicyID(id))
+	}
+	return opts
+}
+
+// policyID identifies s in retry telemetry headers: "name@version", or just
+// the name.
+func (s PresetSpec) policyID() string {
+	if s.Name == "" || s.Version == "" {
+		return s.Name
+	}
+	return s.Name + "@" + s.Version
+}
+
+// NewClientFromSpec creates a client from a retry policy expressed as data.
+// opts are applied after the spec, for settings that cannot be expressed as
+// data (loggers, metrics, transports, ...).
+//
+// Example:
+//
+//	data, _ := os.ReadFile("retry-policies/payments-api.json")
+//	spec, err := retry.ParsePresetSpec(data)
+//	if err != nil { ... }
+//	client, err := retry.NewClientFromSpec(spec, retry.WithLogger(logger))
+func NewClientFromSpec(spec PresetSpec, opts ...Option) (*Client, error) {
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	return NewClient(append(spec.Options(), opts...)...)
+}
+
+// BuiltinPresetSpec returns the spec behind one of the preset constructors, as
+// a starting point for an organization's own policies: "realtime",
+// "background", "rate_limited", "microservice", "aggressive", "conservative",
+// "webhook", "critical" or "fast_fail".
+func BuiltinPresetSpec(name string) (PresetSpec, bool) {
+	spec, ok := builtinPresets[name]
+	if !ok {
+		return PresetSpec{}, false
+	}
+	return spec.clone(), true
+}
+
+// clone returns a copy of s that shares no pointers or slices with it.
+func (s PresetSpec) clone() PresetSpec {
+	if s.MaxRetries != nil {
+		s.MaxRetries = ptr(*s.MaxRetries)
+	}
+	if s.Jitter != nil {
+		s.Jitter = ptr(*s.Jitter)
+	}
+	if s.RespectRetryAfter != nil {
+		s.RespectRetryAfter = ptr(*s.RespectRetryAfter)
+	}
+	s.RetryableStatuses = slices.Clone(s.RetryableStatuses)
+	s.NonRetryableStatuses = slices.Clone(s.NonRetryableStatuses)
+	return s
+}
+
+// ptr returns a pointer to v.
+func ptr[T any](v T) *T {
+	return &v
+}
+
+// builtinPresets holds the specs of the preset constructors in presets.go.
+var builtinPresets = map[string]PresetSpec{
+	"realtime": {
+		Name:              "realtime",
+		MaxRetries:        ptr(2),
+		InitialRetryDelay: Duration(100 * time.Millisecond),
+		MaxRetryDelay:     Duration(1 * time.Second),
+		PerAttemptTimeout: Duration(3 * time.Second),
+		OverallTimeout:    Duration(10 * time.Second),
+	},
+	"background": {
+		Name:               "background",
+		MaxRetries:         ptr(10),
+		InitialRetryDelay:  Duration(5 * time.Second),
+		MaxRetryDelay:      Duration(60 * time.Second),
+		RetryDelayMultiple: 3.0,
+		PerAttemptTimeout:  Duration(30 * time.Second),
+		Jitter:             ptr(true),
+	},
+	"rate_limited": {
+		Name:              "rate_limited",
+		MaxRetries:        ptr(5),
+		InitialRetryDelay: Duration(2 * time.Second),
+		MaxRetryDelay:     Duration(30 * time.Second),
+		PerAttemptTimeout: Duration(15 * time.Second),
+		RespectRetryAfter: ptr(true),
+		Jitter:            ptr(true),
+	},
+	"microservice": {
+		Name:              "microservice",
+		MaxRetries:        ptr(3),
+		InitialRetryDelay: Duration(50 * time.Millisecond),
+		MaxRetryDelay:     Duration(500 * time.Millisecond),
+		PerAttemptTimeout: Duration(2 * time.Second),
+		Jitter:            ptr(true),
+	},
+	"aggressive": {
+		Name:              "aggressive",
+		MaxRetries:        ptr(10),
+		InitialRetryDelay: Duration(100 * time.Millisecond),
+		MaxRetryDelay:     Duration(5 * time.Second),
+		PerAttemptTimeout: Duration(10 * time.Second),
+		Jitter:            ptr(true),
+	},
+	"conservative": {
+		Name:              "conservative",
+		MaxRetries:        ptr(2),
+		InitialRetryDelay: Duration(5 * time.Second),
+		PerAttemptTimeout: Duration(20 * time.Second),
+		Jitter:            ptr(true),
+	},
+	"webhook": {
+		Name:              "webhook",
+		MaxRetries:        ptr(1),
+		InitialRetryDelay: Duration(500 * time.Millisecond),
+		MaxRetryDelay:     Duration(1 * time.Second),
+		PerAttemptTimeout: Duration(5 * time.Second),
+		Jitter:            ptr(true),
+	},
+	"critical": {
+		Name:               "critical",
+		MaxRetries:         ptr(15),
+		InitialRetryDelay:  Duration(1 * time.Second),
+		MaxRetryDelay:      Duration(120 * time.Second),
+		RetryDelayMultiple: 2.0,
+		PerAttemptTimeout:  Duration(60 * time.Second),
+		Jitter:             ptr(true),
+		RespectRetryAfter:  ptr(true),
+		OverallTimeout:     Duration(15 * time.Minute),
+	},
+	"fast_fail": {
+		Name:              "fast_fail",
+		MaxRetries:        ptr(1),
+		InitialRetryDelay: Duration(50 * time.Millisecond),
+		MaxRetryDelay:     Duration(200 * time.Millisecond),
+		PerAttemptTimeout: Duration(1 * time.Second),
+		Jitter:            ptr(true),
+	},
+}
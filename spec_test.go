@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParsePresetSpec(t *testing.T) {
+	spec, err := ParsePresetSpec([]byte(`{
+		"name": "payments-api",
+		"version": "3",
+		"max_retries": 0,
+		"initial_retry_delay": "250ms",
+		"max_retry_delay": 2000000000,
+		"jitter": false,
+		"retryable_statuses": [502, 503]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.Name != "payments-api" || spec.Version != "3" {
+		t.Errorf("Unexpected identity %q@%q", spec.Name, spec.Version)
+	}
+	if spec.MaxRetries == nil || *spec.MaxRetries != 0 {
+		t.Error("Expected an explicit max_retries of 0")
+	}
+	if spec.InitialRetryDelay != Duration(250*time.Millisecond) ||
+		spec.MaxRetryDelay != Duration(2*time.Second) {
+		t.Errorf("Unexpected delays %v, %v", spec.InitialRetryDelay, spec.MaxRetryDelay)
+	}
+
+	client, err := NewClientFromSpec(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.maxRetries != 0 || client.jitterEnabled ||
+		client.initialRetryDelay != 250*time.Millisecond {
+		t.Errorf("Spec not applied: retries %d, jitter %v, delay %v",
+			client.maxRetries, client.jitterEnabled, client.initialRetryDelay)
+	}
+}
+
+func TestParsePresetSpec_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown field":     `{"max_retry": 3}`,
+		"bad duration":      `{"initial_retry_delay": "soon"}`,
+		"negative retries":  `{"max_retries": -1}`,
+		"negative duration": `{"overall_timeout": "-1s"}`,
+		"small multiplier":  `{"retry_delay_multiple": 0.5}`,
+		"bad status":        `{"retryable_statuses": [600]}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParsePresetSpec([]byte(data)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestPresetSpec_RoundTrip(t *testing.T) {
+	spec, ok := BuiltinPresetSpec("critical")
+	if !ok {
+		t.Fatal("Expected the critical preset")
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"overall_timeout":"15m0s"`) {
+		t.Errorf("Expected durations as strings, got %s", data)
+	}
+
+	decoded, err := ParsePresetSpec(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(decoded)
+	if string(again) != string(data) {
+		t.Errorf("Round trip changed the spec:\n%s\n%s", data, again)
+	}
+}
+
+func TestBuiltinPresetSpec(t *testing.T) {
+	spec, ok := BuiltinPresetSpec("realtime")
+	if !ok {
+		t.Fatal("Expected the realtime preset")
+	}
+	*spec.MaxRetries = 99
+	if again, _ := BuiltinPresetSpec("realtime"); *again.MaxRetries != 2 {
+		t.Error("Modifying a returned spec must not change the built-in preset")
+	}
+	if _, ok := BuiltinPresetSpec("missing"); ok {
+		t.Error("Expected no spec for an unknown preset")
+	}
+
+	client, err := NewRealtimeClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.maxRetries != 2 || client.overallTimeout != 10*time.Second {
+		t.Errorf("Realtime preset changed: retries %d, overall %v",
+			client.maxRetries, client.overallTimeout)
+	}
+}
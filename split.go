@@ -0,0 +1,133 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// EndpointTag is the WithMetricTag key WithTrafficSplit tags requests with:
+// the host of the canary a request was routed to, or "primary".
+const EndpointTag = "endpoint"
+
+// ErrInvalidTrafficSplit is returned by NewClient when a WithTrafficSplit
+// primary or canary base URL is not absolute, or the percentages are negative
+// or add up to more than 100.
+var ErrInvalidTrafficSplit = errors.New("retry: invalid traffic split")
+
+// trafficSplit routes a share of a client's requests to canary endpoints
+// (WithTrafficSplit).
+type trafficSplit struct {
+	primary  *url.URL // Only requests to its host are split
+	canaries []canary
+	failover bool // Retry failed canary attempts on the primary (WithCanaryFailover)
+}
+
+// canary is one WithTrafficSplit endpoint and its share of requests.
+type canary struct {
+	base    *url.URL
+	percent float64
+}
+
+// endpointKey carries the endpointSelection of an operation in its context.
+type endpointKey struct{}
+
+// endpointSelection is the canary an operation was routed to.
+type endpointSelection struct {
+	base       *url.URL
+	failedOver atomic.Bool // A canary attempt failed; later attempts go to the primary
+}
+
+// newTrafficSplit validates the WithTrafficSplit primary and percentages by
+// base URL.
+func newTrafficSplit(primary string, percents map[string]float64, failover bool) (*trafficSplit, error) {
+	u, err := url.Parse(primary)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("%w: primary %q", ErrInvalidTrafficSplit, primary)
+	}
+	s := &trafficSplit{primary: u, failover: failover}
+	var total float64
+	// Sorted, for a stable order of the shares
+	for _, baseURL := range slices.Sorted(maps.Keys(percents)) {
+		percent := percents[baseURL]
+		u, err := url.Parse(baseURL)
+		if err != nil || u.Scheme == "" || u.Host == "" || percent < 0 {
+			return nil, fmt.Errorf("%w: %s (%v%%)", ErrInvalidTrafficSplit, baseURL, percent)
+		}
+		total += percent
+		s.canaries = append(s.canaries, canary{base: u, percent: percent})
+	}
+	if total > 100 {
+		return nil, fmt.Errorf("%w: %v%% in total", ErrInvalidTrafficSplit, total)
+	}
+	return s, nil
+}
+
+// serves reports whether requests to u are split: those to the primary's
+// host.
+func (s *trafficSplit) serves(u *url.URL) bool {
+	return strings.EqualFold(u.Host, s.primary.Host)
+}
+
+// pick returns the canary for a new operation, or nil for the primary.
+func (s *trafficSplit) pick() *url.URL {
+	// #nosec G404 - Cryptographic randomness not required for traffic splitting
+	n := rand.Float64() * 100
+	for _, c := range s.canaries {
+		if n < c.percent {
+			return c.base
+		}
+		n -= c.percent
+	}
+	return nil
+}
+
+// selectEndpoint picks the endpoint of an operation to the primary once, so
+// all its attempts go to the same one, and tags the operation's metrics with
+// it. Requests to other hosts are not split.
+func (c *Client) selectEndpoint(ctx context.Context, req *http.Request) context.Context {
+	if c.trafficSplit == nil || ctx.Value(endpointKey{}) != nil || !c.trafficSplit.serves(req.URL) {
+		return ctx
+	}
+	endpoint := "primary"
+	sel := &endpointSelection{base: c.trafficSplit.pick()}
+	if sel.base != nil {
+		endpoint = sel.base.Host
+	}
+
+	existing := metricTagsOf(ctx, req)
+	tags := make(map[string]string, len(existing)+1)
+	maps.Copy(tags, existing)
+	tags[EndpointTag] = endpoint
+	ctx = context.WithValue(ctx, metricTagsKey{}, tags)
+	return context.WithValue(ctx, endpointKey{}, sel)
+}
+
+// routeEndpoint points an attempt request at the operation's canary, unless
+// an earlier attempt there failed over to the primary.
+func routeEndpoint(ctx context.Context, req *http.Request) {
+	sel, _ := ctx.Value(endpointKey{}).(*endpointSelection)
+	if sel == nil || sel.base == nil || sel.failedOver.Load() {
+		return
+	}
+	req.URL.Scheme, req.URL.Host = sel.base.Scheme, sel.base.Host
+	req.Host = "" // Follow the URL
+}
+
+// failOverEndpoint sends the remaining attempts of an operation whose canary
+// attempt failed to the primary, with WithCanaryFailover.
+func (c *Client) failOverEndpoint(ctx context.Context) {
+	if c.trafficSplit == nil || !c.trafficSplit.failover {
+		return
+	}
+	if sel, _ := ctx.Value(endpointKey{}).(*endpointSelection); sel != nil && sel.base != nil {
+		sel.failedOver.Store(true)
+	}
+}
@@ -0,0 +1,181 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingServer returns a server answering status and counting its requests.
+func countingServer(t *testing.T, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, &hits
+}
+
+func TestWithTrafficSplit_RetriesStayOnCanary(t *testing.T) {
+	primary, primaryHits := countingServer(t, http.StatusOK)
+	canary, canaryHits := countingServer(t, http.StatusServiceUnavailable)
+
+	collector := &taggedMetricsCollector{tagged: make(map[string]*MockMetricsCollector)}
+	client, err := NewClient(
+		WithMaxRetries(2),
+		WithInitialRetryDelay(time.Millisecond),
+		WithMetrics(collector),
+		WithTrafficSplit(primary.URL, map[string]float64{canary.URL: 100}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), primary.URL)
+	if err == nil {
+		resp.Body.Close()
+	}
+	if canaryHits.Load() != 3 || primaryHits.Load() != 0 {
+		t.Errorf("Expected all 3 attempts on the canary, got %d (primary %d)",
+			canaryHits.Load(), primaryHits.Load())
+	}
+
+	u, _ := url.Parse(canary.URL)
+	tagged := collector.tagged[EndpointTag+"="+u.Host+","]
+	if tagged == nil || len(tagged.Attempts) != 3 {
+		t.Errorf("Expected the attempts tagged with the canary host, got %v", collector.tagged)
+	}
+}
+
+func TestWithTrafficSplit_Primary(t *testing.T) {
+	primary, primaryHits := countingServer(t, http.StatusOK)
+	canary, canaryHits := countingServer(t, http.StatusOK)
+
+	collector := &taggedMetricsCollector{tagged: make(map[string]*MockMetricsCollector)}
+	client, err := NewClient(
+		WithMetrics(collector),
+		WithTrafficSplit(primary.URL, map[string]float64{canary.URL: 0}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), primary.URL, WithMetricTag("tenant", "acme"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if primaryHits.Load() != 1 || canaryHits.Load() != 0 {
+		t.Errorf("Expected the request on the primary, got %d (canary %d)",
+			primaryHits.Load(), canaryHits.Load())
+	}
+	if collector.tagged[EndpointTag+"=primary,tenant=acme,"] == nil {
+		t.Errorf("Expected the request tagged as primary, got %v", collector.tagged)
+	}
+}
+
+func TestWithTrafficSplit_OtherHosts(t *testing.T) {
+	primary, primaryHits := countingServer(t, http.StatusOK)
+	other, otherHits := countingServer(t, http.StatusOK)
+	canary, canaryHits := countingServer(t, http.StatusOK)
+
+	collector := &taggedMetricsCollector{tagged: make(map[string]*MockMetricsCollector)}
+	client, err := NewClient(
+		WithMetrics(collector),
+		WithTrafficSplit(primary.URL, map[string]float64{canary.URL: 100}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), other.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if otherHits.Load() != 1 || canaryHits.Load() != 0 || primaryHits.Load() != 0 {
+		t.Errorf("Expected a request to another host to stay there, got %d (canary %d)",
+			otherHits.Load(), canaryHits.Load())
+	}
+	if len(collector.tagged) != 0 {
+		t.Errorf("Expected no endpoint tag on an unsplit request, got %v", collector.tagged)
+	}
+}
+
+func TestWithCanaryFailover(t *testing.T) {
+	primary, primaryHits := countingServer(t, http.StatusOK)
+	canary, canaryHits := countingServer(t, http.StatusServiceUnavailable)
+
+	client, err := NewClient(
+		WithMaxRetries(2),
+		WithInitialRetryDelay(time.Millisecond),
+		WithTrafficSplit(primary.URL, map[string]float64{canary.URL: 100}),
+		WithCanaryFailover(true),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), primary.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if canaryHits.Load() != 1 || primaryHits.Load() != 1 {
+		t.Errorf("Expected 1 canary attempt then 1 primary attempt, got %d and %d",
+			canaryHits.Load(), primaryHits.Load())
+	}
+}
+
+func TestTrafficSplit_Pick(t *testing.T) {
+	split, err := newTrafficSplit("https://api.example.com", map[string]float64{
+		"https://a.example.com": 20,
+		"https://b.example.com": 30,
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	counts := map[string]int{}
+	for range 10000 {
+		if base := split.pick(); base != nil {
+			counts[base.Host]++
+		} else {
+			counts["primary"]++
+		}
+	}
+	wants := map[string]int{"a.example.com": 2000, "b.example.com": 3000, "primary": 5000}
+	for host, want := range wants {
+		if got := counts[host]; got < want*8/10 || got > want*12/10 {
+			t.Errorf("Expected about %d requests to %s, got %d", want, host, got)
+		}
+	}
+}
+
+func TestWithTrafficSplit_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		primary  string
+		percents map[string]float64
+	}{
+		{"relative URL", "https://api.example.com", map[string]float64{"/canary": 5}},
+		{"relative primary", "/api", map[string]float64{"https://canary.example.com": 5}},
+		{"negative", "https://api.example.com", map[string]float64{"https://canary.example.com": -1}},
+		{"over 100", "https://api.example.com", map[string]float64{"https://a.example.com": 60, "https://b.example.com": 50}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewClient(WithTrafficSplit(tt.primary, tt.percents))
+			if !errors.Is(err, ErrInvalidTrafficSplit) {
+				t.Errorf("Expected ErrInvalidTrafficSplit, got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrBlockedDestination is returned (wrapped) when WithAllowedHosts or
+// WithBlockPrivateNetworks rejects the destination of a request or redirect.
+// It is never retried.
+var ErrBlockedDestination = errors.New("retry: destination not allowed")
+
+// cgnatPrefix is the shared address space of RFC 6598, not covered by IsPrivate.
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// destinationGuard validates request destinations against a host allowlist
+// and, optionally, private network addresses.
+type destinationGuard struct {
+	allowedHosts []string // Exact hosts or "*.example.com" patterns (empty = any)
+	blockPrivate bool
+
+	// Resolve and check hostnames before each attempt, for transports whose
+	// dials the client cannot guard (custom RoundTrippers, proxies)
+	resolveBeforeAttempt bool
+}
+
+// allowsHost reports whether host matches the allowlist.
+func (g *destinationGuard) allowsHost(host string) bool {
+	if len(g.allowedHosts) == 0 {
+		return true
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	for _, pattern := range g.allowedHosts {
+		pattern = strings.ToLower(pattern)
+		if suffix, ok := strings.CutPrefix(pattern, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+		} else if host == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateAddr reports whether ip is loopback, private, link-local,
+// unspecified, multicast or in the carrier-grade NAT range.
+func isPrivateAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		ip.IsUnspecified() || cgnatPrefix.Contains(ip)
+}
+
+// checkAddrs rejects host when any of its addresses is private.
+func (g *destinationGuard) checkAddrs(host string, ips []netip.Addr) error {
+	if !g.blockPrivate {
+		return nil
+	}
+	for _, ip := range ips {
+		if isPrivateAddr(ip) {
+			return fmt.Errorf("%w: %s resolves to private address %s", ErrBlockedDestination, host, ip)
+		}
+	}
+	return nil
+}
+
+// check validates u before an attempt: the allowlist, IP literals and, when
+// the dial cannot be guarded, the resolved addresses of the host.
+func (g *destinationGuard) check(ctx context.Context, u *url.URL) error {
+	host := u.Hostname()
+	if !g.allowsHost(host) {
+		return fmt.Errorf("%w: host %q is not in the allowlist", ErrBlockedDestination, host)
+	}
+	if !g.blockPrivate {
+		return nil
+	}
+
+	if ip, err := netip.ParseAddr(host); err == nil {
+		return g.checkAddrs(host, []netip.Addr{ip})
+	}
+	if !g.resolveBeforeAttempt {
+		return nil
+	}
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	return g.checkAddrs(host, ips)
+}
+
+// guardDial wraps dial so that every connection goes to an address that was
+// checked: the host is resolved once, all addresses are validated, and the
+// checked IP is dialed directly, so DNS rebinding cannot slip past the check.
+func (g *destinationGuard) guardDial(
+	dial func(ctx context.Context, network, addr string) (net.Conn, error),
+) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, err
+		}
+		if err := g.checkAddrs(host, ips); err != nil {
+			return nil, Permanent(err)
+		}
+
+		var lastErr error
+		for _, ip := range ips {
+			conn, err := dial(ctx, network, net.JoinHostPort(ip.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+			lastErr = err
+		}
+		return nil, lastErr
+	}
+}
+
+// guard returns the client's destination guard, creating it on first use.
+func (c *Client) guard() *destinationGuard {
+	if c.destinationGuard == nil {
+		c.destinationGuard = &destinationGuard{}
+	}
+	return c.destinationGuard
+}
+
+// checkDestination validates the final URL of an attempt, after endpoint
+// routing, region failover, traffic splitting, the endpoint selector and
+// attempt transformers changed it. Rejected destinations end the operation.
+func (c *Client) checkDestination(ctx context.Context, req *http.Request) error {
+	if c.destinationGuard == nil {
+		return nil
+	}
+	err := c.destinationGuard.check(ctx, req.URL)
+	if errors.Is(err, ErrBlockedDestination) {
+		return Permanent(err)
+	}
+	return err
+}
+
+// installDestinationGuard hooks the guard into redirects and dials. Redirects
+// followed by the embedded http.Client are checked in CheckRedirect (redirects
+// followed by WithRedirectPolicy go through the attempt's check). Dials of
+// an *http.Transport are guarded directly; any other transport falls back to
+// resolving hostnames before each attempt.
+func (c *Client) installDestinationGuard() {
+	g := c.destinationGuard
+
+	if c.redirectPolicy == nil {
+		newClient := *c.httpClient
+		checkRedirect := newClient.CheckRedirect
+		newClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+			if err := g.check(req.Context(), req.URL); err != nil {
+				return Permanent(err)
+			}
+			if checkRedirect != nil {
+				return checkRedirect(req, via)
+			}
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return nil
+		}
+		c.httpClient = &newClient
+	}
+
+	if !g.blockPrivate {
+		return
+	}
+	// Check resolved addresses up front unless every dial can be guarded:
+	// custom transports and proxied targets are not dialed by the client
+	g.resolveBeforeAttempt = true
+	c.tuneTransport(func(transport *http.Transport) {
+		dial := transport.DialContext
+		if dial == nil {
+			dial = (&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}).DialContext
+		}
+		transport.DialContext = g.guardDial(dial)
+		if transport.DialTLSContext != nil {
+			transport.DialTLSContext = g.guardDial(transport.DialTLSContext)
+		}
+		g.resolveBeforeAttempt = transport.Proxy != nil
+	})
+}
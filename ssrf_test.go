@@ -0,0 +1,192 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestIsPrivateAddr(t *testing.T) {
+	tests := []struct {
+		addr     string
+		expected bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true}, // Cloud metadata endpoint
+		{"100.64.0.1", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"fd00::1", true},
+		{"fe80::1", true},
+		{"::ffff:127.0.0.1", true},
+		{"8.8.8.8", false},
+		{"2606:4700::1111", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isPrivateAddr(netip.MustParseAddr(tt.addr)); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestDestinationGuard_AllowsHost(t *testing.T) {
+	g := &destinationGuard{allowedHosts: []string{"api.example.com", "*.cdn.example.com"}}
+
+	tests := []struct {
+		host     string
+		expected bool
+	}{
+		{"api.example.com", true},
+		{"API.Example.com.", true},
+		{"img.cdn.example.com", true},
+		{"cdn.example.com", false},
+		{"evil.com", false},
+		{"api.example.com.evil.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := g.allowsHost(tt.host); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestWithBlockPrivateNetworks(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+
+	tests := []struct {
+		name      string
+		transport http.RoundTripper
+		url       string
+	}{
+		{"IP literal", nil, server.URL},
+		{"host name checked at dial time", &http.Transport{}, "http://localhost:" + serverURL.Port()},
+		{"host name checked before attempt", http.DefaultTransport, "http://localhost:" + serverURL.Port()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(
+				WithHTTPClient(&http.Client{Transport: tt.transport}),
+				WithBlockPrivateNetworks(true),
+				WithNoLogging(),
+			)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp, err := client.Get(context.Background(), tt.url)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, ErrBlockedDestination) {
+				t.Fatalf("Expected ErrBlockedDestination, got %v", err)
+			}
+			var retryErr *RetryError
+			if errors.As(err, &retryErr) && retryErr.Attempts != 1 {
+				t.Errorf("Expected a blocked dial not to be retried, got %d attempts", retryErr.Attempts)
+			}
+			if n := atomic.LoadInt32(&attempts); n != 0 {
+				t.Errorf("Expected no request to reach the server, got %d", n)
+			}
+		})
+	}
+}
+
+func TestWithAllowedHosts_Redirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Redirect target must not be contacted")
+	}))
+	defer target.Close()
+	targetURL, _ := url.Parse(target.URL)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "http://localhost:"+targetURL.Port()+"/internal", http.StatusFound)
+	}))
+	defer origin.Close()
+
+	tests := []struct {
+		name string
+		opts []Option
+	}{
+		{"embedded client redirects", nil},
+		{"redirect policy", []Option{WithRedirectPolicy(5, false)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(append(tt.opts, WithAllowedHosts("127.0.0.1"), WithNoLogging())...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp, err := client.Get(context.Background(), origin.URL)
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, ErrBlockedDestination) || !strings.Contains(err.Error(), "localhost") {
+				t.Errorf("Expected redirect to localhost to be blocked, got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithAllowedHosts_RewrittenHosts(t *testing.T) {
+	server, hits := countingServer(t, http.StatusOK)
+	serverHost := mustParseURL(t, server.URL).Host
+
+	tests := []struct {
+		name string
+		opt  Option
+	}{
+		{"endpoint selector", WithEndpointSelector(func(int, *http.Request, error) string {
+			return server.URL
+		})},
+		{"traffic split", WithTrafficSplit("http://allowed.example", map[string]float64{server.URL: 100})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(
+				WithMaxRetries(2),
+				WithInitialRetryDelay(time.Millisecond),
+				WithAllowedHosts("allowed.example"),
+				tt.opt,
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			hits.Store(0)
+			resp, err := client.Get(context.Background(), "http://allowed.example/")
+			if resp != nil {
+				resp.Body.Close()
+			}
+			if !errors.Is(err, ErrBlockedDestination) {
+				t.Errorf("Expected the rewritten host to be blocked, got %v", err)
+			}
+			if n := hits.Load(); n != 0 {
+				t.Errorf("Expected no request to reach %s, got %d", serverHost, n)
+			}
+		})
+	}
+}
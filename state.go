@@ -0,0 +1,261 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// RetryState is a checkpoint of an in-progress logical request, saved to a
+// StateStore before each retry so a new process can resume it with
+// Client.Resume after a deploy or crash.
+type RetryState struct {
+	ID      string      // Caller-chosen identifier (WithCheckpoint)
+	Method  string      // Request method
+	URL     string      // Request URL
+	Header  http.Header // Request headers, including credentials set on the request
+	BodyRef string      // Caller-defined reference to the request body (e.g. a blob key)
+	Body    []byte      // Inline request body, for small payloads (used instead of BodyRef)
+	Attempt int         // Attempts made so far
+	NextAt  time.Time   // Earliest time the next attempt may start
+}
+
+// StateStore persists RetryState checkpoints. Implementations must be safe for
+// concurrent use.
+type StateStore interface {
+	// Save creates or replaces the checkpoint with state.ID
+	Save(ctx context.Context, state RetryState) error
+
+	// Delete removes the checkpoint with id; deleting a missing one is not an error
+	Delete(ctx context.Context, id string) error
+
+	// List returns all saved checkpoints
+	List(ctx context.Context) ([]RetryState, error)
+}
+
+// checkpointKey carries the WithCheckpoint settings in the request context.
+type checkpointKey struct{}
+
+// checkpoint identifies a request whose retry state is persisted.
+type checkpoint struct {
+	id      string
+	bodyRef string
+}
+
+// checkpointOf returns the WithCheckpoint settings for a request, looking at
+// the operation context first and the request's own context second.
+func checkpointOf(ctx context.Context, req *http.Request) (checkpoint, bool) {
+	if cp, ok := ctx.Value(checkpointKey{}).(checkpoint); ok {
+		return cp, true
+	}
+	cp, ok := req.Context().Value(checkpointKey{}).(checkpoint)
+	return cp, ok
+}
+
+// resumedAttemptsKey carries the attempt count of a resumed operation.
+type resumedAttemptsKey struct{}
+
+// resumedAttempts returns how many attempts a resumed operation already made.
+func resumedAttempts(ctx context.Context) int {
+	attempts, _ := ctx.Value(resumedAttemptsKey{}).(int)
+	return attempts
+}
+
+// delayBaseAt returns the backoff base delay of the retry after attempt
+// (0-indexed), before Retry-After, jitter and per-status strategies.
+func (c *Client) delayBaseAt(attempt int) time.Duration {
+	base := c.initialRetryDelay
+	for range attempt {
+		base = computeNextDelay(base, c.retryDelayMultiple, c.maxRetryDelay)
+	}
+	return base
+}
+
+// withCheckpoints wraps next to save a checkpoint to the client's StateStore
+// before each retry of requests marked with WithCheckpoint, and to delete it
+// once the operation finishes. An operation abandoned by canceling its context
+// keeps its checkpoint so that it can be resumed.
+func (c *Client) withCheckpoints(next RetryFunc) RetryFunc {
+	return func(ctx context.Context, req *http.Request) (*http.Response, error) {
+		cp, ok := checkpointOf(ctx, req)
+		if !ok {
+			return next(ctx, req)
+		}
+
+		ctx = ContextWithRetryObserver(ctx, func(info RetryInfo) error {
+			state := RetryState{
+				ID:      cp.id,
+				Method:  req.Method,
+				URL:     req.URL.String(),
+				Header:  req.Header.Clone(),
+				BodyRef: cp.bodyRef,
+				Attempt: info.Attempt,
+				NextAt:  time.Now().Add(info.Delay),
+			}
+			// A failed checkpoint must not fail the request itself
+			if err := c.stateStore.Save(ctx, state); err != nil {
+				c.logger.Warn("failed to save retry checkpoint", "id", cp.id, "error", err.Error())
+			}
+			return nil
+		})
+
+		resp, err := next(ctx, req)
+		if ctx.Err() == nil {
+			c.deleteCheckpoint(ctx, cp.id)
+		}
+		return resp, err
+	}
+}
+
+// deleteCheckpoint removes the checkpoint with id, logging a failure.
+func (c *Client) deleteCheckpoint(ctx context.Context, id string) {
+	if err := c.stateStore.Delete(ctx, id); err != nil {
+		c.logger.Warn("failed to delete retry checkpoint", "id", id, "error", err.Error())
+	}
+}
+
+// Resume continues a logical request from a checkpoint loaded from the
+// client's StateStore, typically after a restart. It waits until state.NextAt,
+// rebuilds the request from the checkpoint and the given options (which must
+// supply the body, e.g. WithBody with the content behind state.BodyRef), and
+// retries it with the attempts the checkpoint has left, continuing the backoff
+// where it stopped.
+//
+// Example:
+//
+//	states, err := store.List(ctx)
+//	for _, state := range states {
+//	    body, _ := loadBody(state.BodyRef)
+//	    resp, err := client.Resume(ctx, state, retry.WithBody("application/json", body))
+//	    // ...
+//	}
+func (c *Client) Resume(
+	ctx context.Context,
+	state RetryState,
+	opts ...RequestOption,
+) (*http.Response, error) {
+	if wait := time.Until(state.NextAt); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	ctx = context.WithValue(ctx, resumedAttemptsKey{}, state.Attempt)
+	opts = append([]RequestOption{WithCheckpoint(state.ID, state.BodyRef)}, opts...)
+	req, err := c.newStateRequest(ctx, state, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return c.doPrepared(ctx, req)
+}
+
+// newStateRequest rebuilds the request saved in state, with its headers and
+// inline body, then applies opts.
+func (c *Client) newStateRequest(
+	ctx context.Context,
+	state RetryState,
+	opts ...RequestOption,
+) (*http.Request, error) {
+	restore := func(req *http.Request) {
+		for key, values := range state.Header {
+			req.Header[key] = slices.Clone(values)
+		}
+		if state.Body != nil {
+			WithBody(req.Header.Get("Content-Type"), bytes.NewReader(state.Body))(req)
+		}
+	}
+	return c.newRequest(ctx, state.Method, state.URL, append([]RequestOption{restore}, opts...)...)
+}
+
+// FileStateStore is a StateStore keeping each checkpoint as a JSON file in a
+// directory. It suits a single process, or several sharing a volume, that
+// must survive restarts.
+type FileStateStore struct {
+	dir string
+}
+
+// NewFileStateStore creates a FileStateStore in dir, creating the directory if
+// needed.
+func NewFileStateStore(dir string) (*FileStateStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("retry: create state directory: %w", err)
+	}
+	return &FileStateStore{dir: dir}, nil
+}
+
+// path returns the file holding the checkpoint with id.
+func (s *FileStateStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+".json")
+}
+
+// Save writes state atomically, so a crash never leaves a partial checkpoint.
+func (s *FileStateStore) Save(_ context.Context, state RetryState) error {
+	if state.ID == "" {
+		return errors.New("retry: checkpoint without ID")
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, ".checkpoint-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(state.ID))
+}
+
+// Delete removes the checkpoint with id.
+func (s *FileStateStore) Delete(_ context.Context, id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// List returns all checkpoints in the directory.
+func (s *FileStateStore) List(_ context.Context) ([]RetryState, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var states []RetryState
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || filepath.Ext(name) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(s.dir, name))
+		if errors.Is(err, os.ErrNotExist) {
+			continue // Deleted since ReadDir
+		}
+		if err != nil {
+			return nil, err
+		}
+		var state RetryState
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, fmt.Errorf("retry: decode checkpoint %s: %w", name, err)
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
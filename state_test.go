@@ -0,0 +1,231 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingStateStore wraps a StateStore and records the checkpoints saved.
+type recordingStateStore struct {
+	StateStore
+	mu    sync.Mutex
+	saved []RetryState
+}
+
+func (s *recordingStateStore) Save(ctx context.Context, state RetryState) error {
+	s.mu.Lock()
+	s.saved = append(s.saved, state)
+	s.mu.Unlock()
+	return s.StateStore.Save(ctx, state)
+}
+
+func newTestStateStore(t *testing.T) *recordingStateStore {
+	t.Helper()
+	store, err := NewFileStateStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStateStore() error = %v", err)
+	}
+	return &recordingStateStore{StateStore: store}
+}
+
+func TestWithStateStore_CheckpointsRetries(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store := newTestStateStore(t)
+	client, err := NewClient(
+		WithStateStore(store),
+		WithInitialRetryDelay(time.Millisecond),
+		WithJitter(false),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	resp, err := client.Post(context.Background(), server.URL,
+		WithBody("text/plain", bytes.NewReader([]byte("payload"))),
+		WithHeader("X-Tenant", "acme"),
+		WithCheckpoint("order-1", "orders/1"),
+	)
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if len(store.saved) != 2 {
+		t.Fatalf("saved %d checkpoints, want 2", len(store.saved))
+	}
+	state := store.saved[1]
+	if state.ID != "order-1" || state.BodyRef != "orders/1" || state.Attempt != 2 {
+		t.Errorf("checkpoint = %+v, want order-1, orders/1, attempt 2", state)
+	}
+	if state.Method != http.MethodPost || state.URL != server.URL {
+		t.Errorf("checkpoint request = %s %s, want POST %s", state.Method, state.URL, server.URL)
+	}
+	if state.Header.Get("X-Tenant") != "acme" {
+		t.Errorf("checkpoint header X-Tenant = %q, want acme", state.Header.Get("X-Tenant"))
+	}
+
+	states, err := store.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(states) != 0 {
+		t.Errorf("List() = %v, want the checkpoint deleted after completion", states)
+	}
+}
+
+func TestWithStateStore_CanceledKeepsCheckpoint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	store := newTestStateStore(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client, err := NewClient(
+		WithStateStore(store),
+		WithInitialRetryDelay(time.Minute),
+		WithOnRetry(func(RetryInfo) { cancel() }), // Shut down while waiting
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	_, err = client.Get(ctx, server.URL, WithCheckpoint("job-7", ""))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Get() error = %v, want context.Canceled", err)
+	}
+
+	states, err := store.List(context.Background())
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(states) != 1 || states[0].ID != "job-7" || states[0].Attempt != 1 {
+		t.Fatalf("List() = %+v, want the job-7 checkpoint after 1 attempt", states)
+	}
+	if time.Until(states[0].NextAt) < 5*time.Second {
+		t.Errorf("NextAt = %v, want the backoff delay from now", states[0].NextAt)
+	}
+}
+
+func TestClient_Resume(t *testing.T) {
+	var requests int32
+	var gotBody, gotTenant string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		body, _ := io.ReadAll(r.Body)
+		gotBody, gotTenant = string(body), r.Header.Get("X-Tenant")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	store := newTestStateStore(t)
+	state := RetryState{
+		ID:      "order-2",
+		Method:  http.MethodPost,
+		URL:     server.URL,
+		Header:  http.Header{"X-Tenant": {"acme"}},
+		BodyRef: "orders/2",
+		Attempt: 2,
+		NextAt:  time.Now().Add(20 * time.Millisecond),
+	}
+	if err := store.Save(context.Background(), state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	client, err := NewClient(
+		WithStateStore(store),
+		WithMaxRetries(3),
+		WithInitialRetryDelay(time.Millisecond),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	start := time.Now()
+	_, err = client.Resume(context.Background(), state,
+		WithBody("text/plain", bytes.NewReader([]byte("payload"))))
+	var retryErr *RetryError
+	if !errors.As(err, &retryErr) {
+		t.Fatalf("Resume() error = %v, want RetryError", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Resume() returned after %v, want it to wait until NextAt", elapsed)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("requests = %d, want 2 (attempts 3 and 4 of 4)", got)
+	}
+	if retryErr.Attempts != 4 {
+		t.Errorf("Attempts = %d, want 4", retryErr.Attempts)
+	}
+	if gotBody != "payload" || gotTenant != "acme" {
+		t.Errorf("request body %q, X-Tenant %q; want payload, acme", gotBody, gotTenant)
+	}
+	if store.saved[len(store.saved)-1].Attempt != 3 {
+		t.Errorf("last checkpoint attempt = %d, want 3", store.saved[len(store.saved)-1].Attempt)
+	}
+	if states, _ := store.List(context.Background()); len(states) != 0 {
+		t.Errorf("List() = %v, want the checkpoint deleted", states)
+	}
+}
+
+func TestFileStateStore(t *testing.T) {
+	store, err := NewFileStateStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStateStore() error = %v", err)
+	}
+	ctx := context.Background()
+
+	state := RetryState{
+		ID:      "tenant/../42",
+		Method:  http.MethodGet,
+		URL:     "https://example.com",
+		Attempt: 1,
+	}
+	if err := store.Save(ctx, state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	state.Attempt = 2
+	if err := store.Save(ctx, state); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if err := store.Save(ctx, RetryState{}); err == nil {
+		t.Error("Save() without ID succeeded, want error")
+	}
+
+	states, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(states) != 1 || states[0].ID != state.ID || states[0].Attempt != 2 {
+		t.Fatalf("List() = %+v, want the replaced checkpoint", states)
+	}
+
+	if err := store.Delete(ctx, state.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := store.Delete(ctx, state.ID); err != nil {
+		t.Errorf("Delete() of a missing checkpoint error = %v, want nil", err)
+	}
+	if states, _ := store.List(ctx); len(states) != 0 {
+		t.Errorf("List() = %v, want empty", states)
+	}
+}
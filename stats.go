@@ -0,0 +1,85 @@
+package retry
+
+import (
+	"maps"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a point-in-time snapshot of client-wide counters.
+// All counters are cumulative since the client was created.
+type Stats struct {
+	NewConnections    int64 // Attempts that had to dial a new connection
+	ReusedConnections int64 // Attempts served by an idle keep-alive connection
+
+	// Time slept before retries: waits servers asked for with Retry-After
+	// (see Client.RetryAfterWaitByHost), and the client's own backoff
+	RetryAfterWait time.Duration
+	BackoffWait    time.Duration
+}
+
+// clientStats holds the live counters behind Client.Stats.
+type clientStats struct {
+	newConns       atomic.Int64
+	reusedConns    atomic.Int64
+	retryAfterWait atomic.Int64 // Nanoseconds
+	backoffWait    atomic.Int64 // Nanoseconds
+
+	mu               sync.Mutex
+	retryAfterByHost map[string]time.Duration
+}
+
+// Stats returns a snapshot of the client's cumulative counters.
+// It is safe to call concurrently with in-flight requests.
+//
+// A high NewConnections to ReusedConnections ratio usually means the
+// connection pool is being thrashed (e.g. MaxIdleConnsPerHost too small,
+// or response bodies not being drained before close). A large
+// RetryAfterWait points at upstreams throttling the client.
+func (c *Client) Stats() Stats {
+	return Stats{
+		NewConnections:    c.stats.newConns.Load(),
+		ReusedConnections: c.stats.reusedConns.Load(),
+		RetryAfterWait:    time.Duration(c.stats.retryAfterWait.Load()),
+		BackoffWait:       time.Duration(c.stats.backoffWait.Load()),
+	}
+}
+
+// RetryAfterWaitByHost returns Stats.RetryAfterWait broken down by the host
+// that sent the Retry-After, to see which upstreams throttle the client and
+// by how much.
+func (c *Client) RetryAfterWaitByHost() map[string]time.Duration {
+	c.stats.mu.Lock()
+	defer c.stats.mu.Unlock()
+	return maps.Clone(c.stats.retryAfterByHost)
+}
+
+// recordRetryWait reports a wait before a retry of req, after an attempt to
+// host, to the client stats and the optional RetryWaitMetricsCollector.
+func (c *Client) recordRetryWait(
+	req *http.Request,
+	host string,
+	wait time.Duration,
+	retryAfter bool,
+) {
+	if host == "" {
+		host = req.URL.Host
+	}
+	if retryAfter {
+		c.stats.retryAfterWait.Add(int64(wait))
+		c.stats.mu.Lock()
+		if c.stats.retryAfterByHost == nil {
+			c.stats.retryAfterByHost = make(map[string]time.Duration)
+		}
+		c.stats.retryAfterByHost[host] += wait
+		c.stats.mu.Unlock()
+	} else {
+		c.stats.backoffWait.Add(int64(wait))
+	}
+
+	if c.waitMetrics != nil {
+		c.waitMetrics.RecordRetryWait(req.Method, host, wait, retryAfter)
+	}
+}
@@ -0,0 +1,113 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// waitMetricsCollector records RetryWaitMetricsCollector calls.
+type waitMetricsCollector struct {
+	MockMetricsCollector
+	mu    sync.Mutex
+	waits []waitRecord
+}
+
+type waitRecord struct {
+	host       string
+	wait       time.Duration
+	retryAfter bool
+}
+
+func (m *waitMetricsCollector) RecordRetryWait(
+	_ string,
+	host string,
+	wait time.Duration,
+	retryAfter bool,
+) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.waits = append(m.waits, waitRecord{host: host, wait: wait, retryAfter: retryAfter})
+}
+
+func TestStats_RetryWaits(t *testing.T) {
+	var hits atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		switch hits.Add(1) {
+		case 1:
+			w.Header().Set("Retry-After", "1") // Capped by WithMaxRetryDelay
+			w.WriteHeader(http.StatusTooManyRequests)
+		case 2:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	host := mustParseURL(t, server.URL).Host
+
+	collector := &waitMetricsCollector{}
+	client, err := NewClient(
+		WithMaxRetries(2),
+		WithInitialRetryDelay(5*time.Millisecond),
+		WithMaxRetryDelay(20*time.Millisecond),
+		WithJitter(false),
+		WithMetrics(collector),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	stats := client.Stats()
+	if stats.RetryAfterWait < 20*time.Millisecond || stats.RetryAfterWait > time.Second {
+		t.Errorf("Expected about 20ms of Retry-After wait, got %v", stats.RetryAfterWait)
+	}
+	if stats.BackoffWait < 10*time.Millisecond || stats.BackoffWait >= stats.RetryAfterWait {
+		t.Errorf("Expected about 10ms of backoff wait, got %v", stats.BackoffWait)
+	}
+	byHost := client.RetryAfterWaitByHost()
+	if len(byHost) != 1 || byHost[host] != stats.RetryAfterWait {
+		t.Errorf("Expected the Retry-After wait attributed to %s, got %v", host, byHost)
+	}
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if len(collector.waits) != 2 || !collector.waits[0].retryAfter || collector.waits[1].retryAfter ||
+		collector.waits[0].host != host {
+		t.Errorf("Expected a Retry-After wait then a backoff wait, got %+v", collector.waits)
+	}
+}
+
+func TestStats_RetryWaitsDisabled(t *testing.T) {
+	server, _ := countingServer(t, http.StatusServiceUnavailable)
+	collector := &waitMetricsCollector{}
+	client, err := NewClient(
+		WithMaxRetries(1),
+		WithInitialRetryDelay(time.Millisecond),
+		WithMetrics(collector),
+		WithDisabledMetrics(MetricRetryWaits),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp, err := client.Get(context.Background(), server.URL); err == nil {
+		resp.Body.Close()
+	}
+
+	if client.Stats().BackoffWait == 0 {
+		t.Error("Expected stats to count waits regardless of metrics")
+	}
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	if len(collector.waits) != 0 {
+		t.Errorf("Expected no wait metrics when disabled, got %+v", collector.waits)
+	}
+}
@@ -0,0 +1,71 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent on every attempt with WithRetryTelemetryHeaders.
+const (
+	RetryAttemptHeader     = "X-Retry-Attempt"      // Attempt number, 1 for the first attempt
+	RetryMaxAttemptsHeader = "X-Retry-Max-Attempts" // Attempts the client may make in total
+	RetryFirstSentHeader   = "X-Retry-First-Sent"   // Start of the first attempt, RFC 3339 in UTC
+	RetryPolicyHeader      = "X-Retry-Policy"       // ID of the client's retry policy
+)
+
+// retryTelemetryKey carries the operation's retryTelemetry in the context.
+type retryTelemetryKey struct{}
+
+// retryTelemetry is what the telemetry headers report about an operation.
+type retryTelemetry struct {
+	firstSent   string
+	maxAttempts string
+}
+
+// withRetryTelemetry stores the operation's start and attempt limit for the
+// telemetry headers. An operation spanning several retry loops (redirects)
+// keeps the start of its first one.
+func (c *Client) withRetryTelemetry(
+	ctx context.Context,
+	start time.Time,
+	maxAttempts int,
+) context.Context {
+	if !c.retryTelemetry {
+		return ctx
+	}
+	t := retryTelemetry{
+		firstSent:   start.UTC().Format(time.RFC3339Nano),
+		maxAttempts: strconv.Itoa(maxAttempts),
+	}
+	if prev, ok := ctx.Value(retryTelemetryKey{}).(retryTelemetry); ok {
+		t.firstSent = prev.firstSent
+	}
+	return context.WithValue(ctx, retryTelemetryKey{}, t)
+}
+
+// setRetryTelemetry sets the telemetry headers on an attempt request; attempt
+// is zero-based.
+func (c *Client) setRetryTelemetry(ctx context.Context, req *http.Request, attempt int) {
+	t, ok := ctx.Value(retryTelemetryKey{}).(retryTelemetry)
+	if !ok {
+		return
+	}
+	req.Header.Set(RetryAttemptHeader, strconv.Itoa(attempt+1))
+	req.Header.Set(RetryMaxAttemptsHeader, t.maxAttempts)
+	req.Header.Set(RetryFirstSentHeader, t.firstSent)
+	req.Header.Set(RetryPolicyHeader, c.retryPolicyID)
+}
+
+// policyFingerprint returns an ID derived from the client's retry settings,
+// so clients configured alike report the same policy.
+func (c *Client) policyFingerprint() string {
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%d|%v|%v|%v|%v|%v|%v|%v",
+		c.maxRetries, c.initialRetryDelay, c.maxRetryDelay, c.retryDelayMultiple,
+		c.jitterEnabled, c.respectRetryAfter, c.perAttemptTimeout, c.overallTimeout)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
@@ -0,0 +1,91 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestWithRetryTelemetryHeaders(t *testing.T) {
+	var mu sync.Mutex
+	var seen []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen = append(seen, r.Header.Clone())
+		if len(seen) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithMaxRetries(4),
+		WithInitialRetryDelay(time.Millisecond),
+		WithRetryTelemetryHeaders(true),
+		WithRequestID(nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := client.Get(context.Background(), server.URL,
+		WithHeader(IdempotencyKeyHeader, "key-1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	if len(seen) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(seen))
+	}
+	first, err := time.Parse(time.RFC3339Nano, seen[0].Get(RetryFirstSentHeader))
+	if err != nil || time.Since(first) > time.Minute {
+		t.Errorf("Unexpected first-sent timestamp %q", seen[0].Get(RetryFirstSentHeader))
+	}
+	for i, h := range seen {
+		if got, want := h.Get(RetryAttemptHeader), []string{"1", "2", "3"}[i]; got != want {
+			t.Errorf("Attempt %d: %s = %q, want %q", i, RetryAttemptHeader, got, want)
+		}
+		if h.Get(RetryMaxAttemptsHeader) != "5" || h.Get(RetryPolicyHeader) != client.retryPolicyID {
+			t.Errorf("Attempt %d: unexpected telemetry %v", i, h)
+		}
+		if h.Get(RetryFirstSentHeader) != seen[0].Get(RetryFirstSentHeader) {
+			t.Errorf("Attempt %d: first-sent changed to %q", i, h.Get(RetryFirstSentHeader))
+		}
+		if h.Get(IdempotencyKeyHeader) != "key-1" || h.Get(RequestIDHeader) == "" {
+			t.Errorf("Attempt %d: telemetry must compose with other headers, got %v", i, h)
+		}
+	}
+}
+
+func TestRetryPolicyID(t *testing.T) {
+	a, _ := NewClient(WithRetryTelemetryHeaders(true), WithMaxRetries(3))
+	b, _ := NewClient(WithMaxRetries(3), WithRetryTelemetryHeaders(true))
+	c, _ := NewClient(WithRetryTelemetryHeaders(true), WithMaxRetries(4))
+	if a.retryPolicyID == "" || a.retryPolicyID != b.retryPolicyID {
+		t.Errorf("Expected equal settings to share a fingerprint, got %q and %q",
+			a.retryPolicyID, b.retryPolicyID)
+	}
+	if a.retryPolicyID == c.retryPolicyID {
+		t.Error("Expected different settings to differ")
+	}
+
+	spec := PresetSpec{Name: "payments-api", Version: "3"}
+	fromSpec, err := NewClientFromSpec(spec, WithRetryTelemetryHeaders(true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromSpec.retryPolicyID != "payments-api@3" {
+		t.Errorf("Expected the spec's name and version, got %q", fromSpec.retryPolicyID)
+	}
+
+	off, _ := NewClient()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+	off.setRetryTelemetry(off.withRetryTelemetry(context.Background(), time.Now(), 1), req, 0)
+	if req.Header.Get(RetryAttemptHeader) != "" {
+		t.Error("Expected no telemetry headers when disabled")
+	}
+}
@@ -0,0 +1,97 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrHostThrottled is returned (wrapped in a RetryError) when WithHostThrottle
+// is in fail-fast mode and the destination host is still inside a rate-limit
+// window announced by an earlier 429 response.
+var ErrHostThrottled = errors.New("retry: host is rate limited")
+
+// hostThrottle remembers, per host, until when a 429 Retry-After asked
+// clients to stay away.
+type hostThrottle struct {
+	failFast bool
+
+	mu    sync.Mutex
+	until map[string]time.Time
+}
+
+// extend moves host's throttle window to end at until, unless it already ends later.
+func (t *hostThrottle) extend(host string, until time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if until.After(t.until[host]) {
+		t.until[host] = until
+	}
+}
+
+// remaining returns how long host stays throttled (0 if it is not).
+func (t *hostThrottle) remaining(host string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	until, ok := t.until[host]
+	if !ok {
+		return 0
+	}
+	remaining := time.Until(until)
+	if remaining <= 0 {
+		delete(t.until, host)
+		return 0
+	}
+	return remaining
+}
+
+// recordHostThrottle starts or extends req's host throttle window when resp is
+// a 429 carrying a Retry-After header.
+func (c *Client) recordHostThrottle(req *http.Request, resp *http.Response) {
+	if c.hostThrottle == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		return
+	}
+	if retryAfter := parseRetryAfter(resp); retryAfter > 0 {
+		c.hostThrottle.extend(req.URL.Host, time.Now().Add(retryAfter))
+	}
+}
+
+// awaitHostThrottle blocks until req's host is out of its throttle window, or
+// returns ErrHostThrottled immediately in fail-fast mode.
+func (c *Client) awaitHostThrottle(ctx context.Context, req *http.Request) error {
+	if c.hostThrottle == nil {
+		return nil
+	}
+
+	host := req.URL.Host
+	for {
+		remaining := c.hostThrottle.remaining(host)
+		if remaining <= 0 {
+			return nil
+		}
+		if c.hostThrottle.failFast {
+			return fmt.Errorf("%w: %s for another %v", ErrHostThrottled, host, remaining)
+		}
+
+		if c.loggerEnabled {
+			c.logger.Debug("waiting for host rate limit window",
+				"host", host,
+				"wait_ms", remaining.Milliseconds(),
+			)
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+			// Re-check: another 429 may have extended the window
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newRateLimitedServer returns 429 with Retry-After: 1 on the first request and 200 afterwards
+func newRateLimitedServer(calls *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(calls, 1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+}
+
+func TestWithHostThrottle_OtherRequestsWait(t *testing.T) {
+	var calls int32
+	server := newRateLimitedServer(&calls)
+	defer server.Close()
+
+	client, err := NewClient(WithMaxRetries(0), WithHostThrottle(false), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, _ := client.Get(context.Background(), server.URL)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("Expected 429, got %d", resp.StatusCode)
+	}
+
+	start := time.Now()
+	resp, err = client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("Expected second request to wait out the 1s window, took %v", elapsed)
+	}
+	if calls != 2 {
+		t.Errorf("Expected 2 server calls, got %d", calls)
+	}
+}
+
+func TestWithHostThrottle_FailFast(t *testing.T) {
+	var calls, otherCalls int32
+	server := newRateLimitedServer(&calls)
+	defer server.Close()
+	other := newRateLimitedServer(&otherCalls)
+	defer other.Close()
+	atomic.StoreInt32(&otherCalls, 1) // Other host is never rate limited
+
+	client, err := NewClient(WithMaxRetries(0), WithHostThrottle(true), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, _ := client.Get(context.Background(), server.URL)
+	resp.Body.Close()
+
+	resp, err = client.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrHostThrottled) {
+		t.Fatalf("Expected ErrHostThrottled, got %v", err)
+	}
+	if resp != nil {
+		t.Error("Expected no response when failing fast")
+	}
+	if calls != 1 {
+		t.Errorf("Expected throttled host not to be called again, got %d calls", calls)
+	}
+
+	resp, err = client.Get(context.Background(), other.URL)
+	if err != nil {
+		t.Fatalf("Expected other host to be unaffected, got %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestWithHostThrottle_ContextCanceledWhileWaiting(t *testing.T) {
+	var calls int32
+	server := newRateLimitedServer(&calls)
+	defer server.Close()
+
+	client, err := NewClient(WithMaxRetries(0), WithHostThrottle(false), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, _ := client.Get(context.Background(), server.URL)
+	resp.Body.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = client.Get(ctx, server.URL)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context deadline while waiting, got %v", err)
+	}
+}
+
+func TestHostThrottle_ExtendOnlyMovesLater(t *testing.T) {
+	th := &hostThrottle{until: make(map[string]time.Time)}
+
+	th.extend("a", time.Now().Add(time.Hour))
+	th.extend("a", time.Now().Add(time.Second))
+	if remaining := th.remaining("a"); remaining < 59*time.Minute {
+		t.Errorf("Expected the later window to be kept, got %v", remaining)
+	}
+	if remaining := th.remaining("b"); remaining != 0 {
+		t.Errorf("Expected unknown host not to be throttled, got %v", remaining)
+	}
+}
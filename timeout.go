@@ -0,0 +1,63 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// TimeoutKind identifies the phase of an attempt in which a timeout occurred.
+type TimeoutKind string
+
+const (
+	TimeoutConnect        TimeoutKind = "connect"         // DNS lookup or TCP connect
+	TimeoutTLSHandshake   TimeoutKind = "tls_handshake"   // TLS handshake
+	TimeoutResponseHeader TimeoutKind = "response_header" // Waiting for the response headers
+	TimeoutBodyRead       TimeoutKind = "body_read"       // Reading the response body
+)
+
+// TimeoutError wraps an attempt error caused by a timeout, recording the phase
+// in which it occurred. errors.Is and errors.As see through it, so checks for
+// context.DeadlineExceeded or net.Error keep working.
+type TimeoutError struct {
+	Kind TimeoutKind
+	Err  error
+}
+
+func (e *TimeoutError) Error() string {
+	return string(e.Kind) + " timeout: " + e.Err.Error()
+}
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// Timeout reports true, so TimeoutError satisfies net.Error's Timeout method.
+func (e *TimeoutError) Timeout() bool { return true }
+
+// isTimeout reports whether err is a deadline or network timeout.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout())
+}
+
+// classifyTimeout wraps a timeout err in a TimeoutError, using the phases
+// the attempt reached (recorded in t) to tell where it timed out.
+func classifyTimeout(err error, t *attemptTrace) error {
+	if err == nil || !isTimeout(err) {
+		return err
+	}
+	var timeoutErr *TimeoutError
+	if errors.As(err, &timeoutErr) {
+		return err
+	}
+
+	kind := TimeoutConnect
+	switch {
+	case t.gotHeaders.Load():
+		kind = TimeoutBodyRead
+	case t.gotConn.Load():
+		kind = TimeoutResponseHeader
+	case t.tlsStarted.Load():
+		kind = TimeoutTLSHandshake
+	}
+	return &TimeoutError{Kind: kind, Err: err}
+}
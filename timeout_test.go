@@ -0,0 +1,106 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeoutClassification(t *testing.T) {
+	// Accepts connections but never speaks, stalling the TLS handshake
+	silent, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to listen: %v", err)
+	}
+	defer silent.Close()
+	go func() {
+		for {
+			conn, err := silent.Accept()
+			if err != nil {
+				return
+			}
+			t.Cleanup(func() { conn.Close() })
+		}
+	}()
+
+	slowHeaders := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer slowHeaders.Close()
+
+	slowBody := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer slowBody.Close()
+
+	stalledDial := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		},
+	}}
+
+	tests := []struct {
+		name   string
+		url    string
+		opts   []Option
+		kind   TimeoutKind
+		reason string
+	}{
+		{
+			"connect", "http://example.invalid", []Option{WithHTTPClient(stalledDial)},
+			TimeoutConnect, RetryReasonConnectTimeout,
+		},
+		{
+			"tls handshake", "https://" + silent.Addr().String(), nil,
+			TimeoutTLSHandshake, RetryReasonTLSTimeout,
+		},
+		{
+			"response header", slowHeaders.URL, nil,
+			TimeoutResponseHeader, RetryReasonHeaderTimeout,
+		},
+		{
+			"body read", slowBody.URL, []Option{WithBufferResponseBody(true)},
+			TimeoutBodyRead, RetryReasonBodyTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reasons []string
+			client, err := NewClient(append([]Option{
+				WithMaxRetries(1),
+				WithInitialRetryDelay(time.Millisecond),
+				WithPerAttemptTimeout(50 * time.Millisecond),
+				WithOnRetry(func(info RetryInfo) { reasons = append(reasons, info.Reason) }),
+				WithNoLogging(),
+			}, tt.opts...)...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp, err := client.Get(context.Background(), tt.url)
+			if resp != nil {
+				resp.Body.Close()
+			}
+
+			var timeoutErr *TimeoutError
+			if !errors.As(err, &timeoutErr) || timeoutErr.Kind != tt.kind {
+				t.Fatalf("Expected %s timeout, got %v", tt.kind, err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("Expected the timeout to still match context.DeadlineExceeded: %v", err)
+			}
+			if len(reasons) != 1 || reasons[0] != tt.reason {
+				t.Errorf("Expected retry reason %q, got %v", tt.reason, reasons)
+			}
+		})
+	}
+}
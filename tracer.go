@@ -1,6 +1,9 @@
 package retry
 
-import "context"
+import (
+	"context"
+	"net/http"
+)
 
 // Attribute represents a key-value pair attribute
 type Attribute struct {
@@ -38,3 +41,30 @@ func (nopSpan) AddEvent(string, ...Attribute) {}
 
 // defaultTracer is the package-level singleton (internal use, not exported)
 var defaultTracer = nopTracer{}
+
+// Span kinds passed to a WithSpanNameFormatter function.
+const (
+	SpanKindRequest = "request" // Span covering the whole request, all attempts included
+	SpanKindAttempt = "attempt" // Span covering a single attempt
+)
+
+// defaultSpanName names spans "http.retry.request" and "http.retry.attempt".
+func defaultSpanName(_ *http.Request, kind string) string {
+	return "http.retry." + kind
+}
+
+// spanName returns the name of the span of the given kind for req.
+func (c *Client) spanName(req *http.Request, kind string) string {
+	if c.spanNameFormatter == nil {
+		return defaultSpanName(req, kind)
+	}
+	return c.spanNameFormatter(req, kind)
+}
+
+// spanAttributes appends the WithSpanAttributes attributes for req to attrs.
+func (c *Client) spanAttributes(req *http.Request, attrs ...Attribute) []Attribute {
+	if c.spanAttributesFunc == nil {
+		return attrs
+	}
+	return append(attrs, c.spanAttributesFunc(req)...)
+}
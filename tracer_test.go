@@ -186,3 +186,83 @@ func TestClient_WithTracer_NonRetryableError_SpanStatusError(t *testing.T) {
 			requestSpan.Status)
 	}
 }
+
+func TestClient_WithSpanNameFormatter(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 2 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mockTracer := &MockTracer{}
+	client, err := NewClient(
+		WithInitialRetryDelay(time.Millisecond),
+		WithTracer(mockTracer),
+		WithSpanNameFormatter(func(req *http.Request, kind string) string {
+			return req.Method + " /users/{id} " + kind
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL+"/users/42")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	expected := []string{"GET /users/{id} request", "GET /users/{id} attempt", "GET /users/{id} attempt"}
+	if len(mockTracer.Spans) != len(expected) {
+		t.Fatalf("Expected %d spans, got %d", len(expected), len(mockTracer.Spans))
+	}
+	for i, span := range mockTracer.Spans {
+		if span.Name != expected[i] {
+			t.Errorf("Span %d: expected name %q, got %q", i, expected[i], span.Name)
+		}
+	}
+}
+
+func TestClient_WithSpanAttributes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	mockTracer := &MockTracer{}
+	client, err := NewClient(
+		WithTracer(mockTracer),
+		WithSpanAttributes(func(req *http.Request) []Attribute {
+			return []Attribute{{Key: "peer.service", Value: "users-api"}}
+		}),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if len(mockTracer.Spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(mockTracer.Spans))
+	}
+	for _, span := range mockTracer.Spans {
+		found := false
+		for _, attr := range span.Attributes {
+			if attr.Key == "peer.service" && attr.Value == "users-api" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected peer.service on span %q, got %v", span.Name, span.Attributes)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the http.Transport built by NewTransport. Zero fields
+// take the defaults listed below; a negative duration disables that timeout.
+type TransportConfig struct {
+	DialTimeout           time.Duration // TCP connect timeout (default 10s)
+	KeepAlive             time.Duration // TCP keep-alive period (default 30s)
+	TLSHandshakeTimeout   time.Duration // TLS handshake timeout (default 10s)
+	ResponseHeaderTimeout time.Duration // Wait for response headers after sending the request (default 30s)
+	ExpectContinueTimeout time.Duration // Wait for 100 Continue (default 1s)
+	IdleConnTimeout       time.Duration // Close idle connections after (default 90s)
+
+	MaxIdleConns        int // Idle connections across all hosts (default 100)
+	MaxIdleConnsPerHost int // Idle connections per host (default 16; net/http's default is 2)
+	MaxConnsPerHost     int // Connections per host, including active ones (default 0 = unlimited)
+}
+
+// timeoutOrDefault returns d, def when d is zero, or 0 (no timeout) when d is negative.
+func timeoutOrDefault(d, def time.Duration) time.Duration {
+	switch {
+	case d == 0:
+		return def
+	case d < 0:
+		return 0
+	default:
+		return d
+	}
+}
+
+// NewTransport builds an http.Transport with bounded dial, handshake and
+// header timeouts and a connection pool sized for API clients. It avoids the
+// pitfalls of http.DefaultClient (no overall timeouts) and the default of two
+// idle connections per host, which makes busy clients churn connections:
+// the very failures users then end up retrying. Proxy settings come from the
+// environment and HTTP/2 is attempted, as with http.DefaultTransport.
+func NewTransport(cfg TransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   timeoutOrDefault(cfg.DialTimeout, 10*time.Second),
+		KeepAlive: timeoutOrDefault(cfg.KeepAlive, 30*time.Second),
+	}
+	maxIdle := cfg.MaxIdleConns
+	if maxIdle <= 0 {
+		maxIdle = 100
+	}
+	maxIdlePerHost := cfg.MaxIdleConnsPerHost
+	if maxIdlePerHost <= 0 {
+		maxIdlePerHost = 16
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		TLSHandshakeTimeout:   timeoutOrDefault(cfg.TLSHandshakeTimeout, 10*time.Second),
+		ResponseHeaderTimeout: timeoutOrDefault(cfg.ResponseHeaderTimeout, 30*time.Second),
+		ExpectContinueTimeout: timeoutOrDefault(cfg.ExpectContinueTimeout, time.Second),
+		IdleConnTimeout:       timeoutOrDefault(cfg.IdleConnTimeout, 90*time.Second),
+		MaxIdleConns:          maxIdle,
+		MaxIdleConnsPerHost:   maxIdlePerHost,
+		MaxConnsPerHost:       max(cfg.MaxConnsPerHost, 0),
+	}
+}
+
+// DialFunc opens a connection to addr, like net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// installDialer makes the transport dial through WithDialer's function.
+func (c *Client) installDialer() error {
+	if !c.tuneTransport(func(transport *http.Transport) {
+		transport.DialContext = c.dialer
+	}) {
+		return errors.New("retry: WithDialer requires an *http.Transport")
+	}
+	return nil
+}
+
+// ErrUnboundedClient is returned by NewClient with WithStrictTimeouts when no
+// timeout bounds an attempt, so a stalled server could hang it forever.
+var ErrUnboundedClient = errors.New("retry: no timeout bounds an attempt")
+
+// unbounded reports whether nothing limits how long an attempt waits for a
+// response: no client, per-attempt or overall timeout, and an *http.Transport
+// (such as http.DefaultTransport) without a response header timeout. Other
+// transports are assumed to enforce their own limits.
+func (c *Client) unbounded() bool {
+	if c.httpClient.Timeout > 0 || c.perAttemptTimeout > 0 || c.overallTimeout > 0 {
+		return false
+	}
+	transport, ok := c.baseTransport.(*http.Transport)
+	return ok && transport.ResponseHeaderTimeout <= 0
+}
+
+// applySafeDefaults bounds an unbounded client (WithSafeDefaults) or rejects
+// it (WithStrictTimeouts). http.DefaultTransport is replaced by NewTransport;
+// a caller's transport gets a clone with NewTransport's header timeout.
+func (c *Client) applySafeDefaults() error {
+	if !c.unbounded() {
+		return nil
+	}
+	if c.strictTimeouts {
+		return fmt.Errorf("%w: set WithPerAttemptTimeout, WithOverallTimeout, "+
+			"an http.Client Timeout or a transport ResponseHeaderTimeout", ErrUnboundedClient)
+	}
+
+	defaults := NewTransport(TransportConfig{})
+	if c.baseTransport == http.DefaultTransport {
+		c.baseTransport = defaults
+		newClient := *c.httpClient
+		newClient.Transport = defaults
+		c.httpClient = &newClient
+		return nil
+	}
+	c.tuneTransport(func(transport *http.Transport) {
+		transport.ResponseHeaderTimeout = defaults.ResponseHeaderTimeout
+	})
+	return nil
+}
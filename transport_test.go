@@ -0,0 +1,160 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewTransport(t *testing.T) {
+	transport := NewTransport(TransportConfig{})
+	if transport.TLSHandshakeTimeout != 10*time.Second ||
+		transport.ResponseHeaderTimeout != 30*time.Second ||
+		transport.MaxIdleConnsPerHost != 16 || transport.MaxIdleConns != 100 {
+		t.Errorf("Unexpected defaults: %+v", transport)
+	}
+
+	transport = NewTransport(TransportConfig{
+		ResponseHeaderTimeout: -1,
+		TLSHandshakeTimeout:   3 * time.Second,
+		MaxIdleConnsPerHost:   64,
+		MaxConnsPerHost:       8,
+	})
+	if transport.ResponseHeaderTimeout != 0 || transport.TLSHandshakeTimeout != 3*time.Second ||
+		transport.MaxIdleConnsPerHost != 64 || transport.MaxConnsPerHost != 8 {
+		t.Errorf("Config not applied: %+v", transport)
+	}
+}
+
+func TestWithTransportDefaults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	base := &http.Client{Timeout: time.Minute}
+	client, err := NewClient(
+		WithHTTPClient(base),
+		WithTransportDefaults(TransportConfig{ResponseHeaderTimeout: 20 * time.Millisecond}),
+		WithMaxRetries(0),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+	if client.httpClient.Timeout != time.Minute || base.Transport != nil {
+		t.Error("Expected the http.Client settings to be kept without modifying it")
+	}
+
+	// The tuned response header timeout applies
+	resp, err := client.Get(context.Background(), server.URL)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err == nil {
+		t.Fatal("Expected a response header timeout")
+	}
+}
+
+func TestWithSafeDefaults(t *testing.T) {
+	custom := &http.Transport{MaxIdleConnsPerHost: 7}
+
+	tests := []struct {
+		name          string
+		opts          []Option
+		headerTimeout time.Duration // 0 = transport left alone
+	}{
+		{"default client", nil, 30 * time.Second},
+		{"custom transport", []Option{WithHTTPClient(&http.Client{Transport: custom})}, 30 * time.Second},
+		{"per-attempt timeout", []Option{WithPerAttemptTimeout(time.Second)}, 0},
+		{"client timeout", []Option{WithHTTPClient(&http.Client{Timeout: time.Second})}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(append(tt.opts, WithSafeDefaults())...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			if tt.headerTimeout == 0 {
+				if client.baseTransport != http.DefaultTransport {
+					t.Error("Expected a bounded client to be left alone")
+				}
+				return
+			}
+			transport, _ := client.baseTransport.(*http.Transport)
+			if transport == nil || transport.ResponseHeaderTimeout != tt.headerTimeout {
+				t.Fatalf("Expected response header timeout %v, got %+v", tt.headerTimeout, transport)
+			}
+			if transport == http.DefaultTransport || transport == custom {
+				t.Error("Shared transports must not be modified")
+			}
+		})
+	}
+
+	if custom.ResponseHeaderTimeout != 0 || custom.MaxIdleConnsPerHost != 7 {
+		t.Error("The caller's transport must not be modified")
+	}
+}
+
+func TestWithStrictTimeouts(t *testing.T) {
+	if _, err := NewClient(WithStrictTimeouts()); !errors.Is(err, ErrUnboundedClient) {
+		t.Errorf("Expected ErrUnboundedClient, got %v", err)
+	}
+	if _, err := NewClient(WithStrictTimeouts(), WithOverallTimeout(time.Minute)); err != nil {
+		t.Errorf("Expected a bounded client to be accepted, got %v", err)
+	}
+	if _, err := NewClient(WithStrictTimeouts(), WithTransportDefaults(TransportConfig{})); err != nil {
+		t.Errorf("Expected a tuned transport to be accepted, got %v", err)
+	}
+}
+
+func TestWithDialer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Host))
+	}))
+	defer server.Close()
+
+	var dialed []string
+	dialer := &net.Dialer{}
+	client, err := NewClient(
+		// Send every connection to the test server, whatever the host
+		WithDialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = append(dialed, addr)
+			return dialer.DialContext(ctx, network, server.Listener.Addr().String())
+		}),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), "http://service.internal/")
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	if string(body) != "service.internal" || len(dialed) != 1 || dialed[0] != "service.internal:80" {
+		t.Errorf("Expected the custom dialer to be used, got body %q, dials %v", body, dialed)
+	}
+}
+
+func TestWithDialer_UnsupportedTransport(t *testing.T) {
+	_, err := NewClient(
+		WithHTTPClient(&http.Client{Transport: RoundTripperFunc(func(*http.Request) (*http.Response, error) {
+			return nil, errors.New("unused")
+		})}),
+		WithDialer((&net.Dialer{}).DialContext),
+	)
+	if err == nil {
+		t.Error("Expected an error for a transport that cannot take a dialer")
+	}
+}
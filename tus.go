@@ -0,0 +1,203 @@
+package retry
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// tusVersion is the tus protocol version sent in the Tus-Resumable header.
+const tusVersion = "1.0.0"
+
+// TusUpload describes an upload made with the tus resumable upload protocol
+// (https://tus.io/protocols/resumable-upload).
+type TusUpload struct {
+	Endpoint  string            // Creation endpoint, used when URL is empty
+	URL       string            // Upload URL; set by UploadTus once the upload is created
+	Metadata  map[string]string // Sent as Upload-Metadata when creating the upload
+	ChunkSize int64             // Bytes per PATCH request (0 = DefaultChunkSize)
+}
+
+// UploadTus uploads size bytes of body with the tus protocol: it creates the
+// upload at upload.Endpoint (or resumes upload.URL, asking the server for its
+// offset), then sends the data in chunks with their offsets. Each request is
+// retried with the client's policy; when a chunk still fails, the server's
+// offset is queried and the upload resumes from there as long as it makes
+// progress.
+//
+// upload.URL is set as soon as the upload is created. Persist it to resume an
+// interrupted upload later, even from another process.
+//
+// opts are applied to every request (e.g. authentication headers).
+//
+// Example:
+//
+//	upload := &retry.TusUpload{
+//	    Endpoint: "https://uploads.example.com/files/",
+//	    Metadata: map[string]string{"filename": "backup.tar"},
+//	}
+//	err := client.UploadTus(ctx, upload, file, info.Size())
+func (c *Client) UploadTus(
+	ctx context.Context,
+	upload *TusUpload,
+	body io.ReaderAt,
+	size int64,
+	opts ...RequestOption,
+) error {
+	chunkSize := upload.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	var offset int64
+	var err error
+	if upload.URL == "" {
+		if upload.URL, err = c.tusCreate(ctx, upload, size, opts); err != nil {
+			return err
+		}
+	} else if offset, err = c.tusOffset(ctx, upload.URL, opts); err != nil {
+		return err
+	}
+
+	for offset < size {
+		next, patchErr := c.tusPatch(ctx, upload.URL, body, offset, min(chunkSize, size-offset), opts)
+		if patchErr == nil {
+			if next <= offset {
+				return fmt.Errorf("retry: tus upload at offset %d: server made no progress", offset)
+			}
+			offset = next
+			continue
+		}
+
+		// Ask the server how much it kept, and resume from there if it moved
+		next, err := c.tusOffset(ctx, upload.URL, opts)
+		if err != nil || next <= offset {
+			return patchErr
+		}
+		offset = next
+	}
+	return nil
+}
+
+// tusRequest creates a tus request with the protocol header and opts applied.
+func (c *Client) tusRequest(
+	ctx context.Context,
+	method string,
+	url string,
+	opts []RequestOption,
+) (*http.Request, error) {
+	req, err := c.newRequest(ctx, method, url, opts...)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Tus-Resumable", tusVersion)
+	return req, nil
+}
+
+// tusDo sends req and returns the response headers if the status is want.
+func (c *Client) tusDo(req *http.Request, step string, want int) (http.Header, error) {
+	resp, err := c.doPrepared(req.Context(), req)
+	if resp != nil {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("retry: tus %s: %w", step, err)
+	}
+	if resp.StatusCode != want {
+		return nil, fmt.Errorf("retry: tus %s: unexpected status %d", step, resp.StatusCode)
+	}
+	return resp.Header, nil
+}
+
+// tusCreate creates the upload and returns its absolute URL.
+func (c *Client) tusCreate(
+	ctx context.Context,
+	upload *TusUpload,
+	size int64,
+	opts []RequestOption,
+) (string, error) {
+	req, err := c.tusRequest(ctx, http.MethodPost, upload.Endpoint, opts)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Upload-Length", strconv.FormatInt(size, 10))
+	if len(upload.Metadata) > 0 {
+		req.Header.Set("Upload-Metadata", encodeTusMetadata(upload.Metadata))
+	}
+
+	header, err := c.tusDo(req, "create", http.StatusCreated)
+	if err != nil {
+		return "", err
+	}
+	location, err := url.Parse(header.Get("Location"))
+	if err != nil || header.Get("Location") == "" {
+		return "", fmt.Errorf("retry: tus create: invalid Location %q", header.Get("Location"))
+	}
+	return req.URL.ResolveReference(location).String(), nil
+}
+
+// tusOffset asks the server for the current offset of the upload.
+func (c *Client) tusOffset(ctx context.Context, uploadURL string, opts []RequestOption) (int64, error) {
+	req, err := c.tusRequest(ctx, http.MethodHead, uploadURL, opts)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Cache-Control", "no-store")
+
+	header, err := c.tusDo(req, "offset", http.StatusOK)
+	if err != nil {
+		return 0, err
+	}
+	return parseTusOffset(header, "offset")
+}
+
+// tusPatch sends n bytes at offset and returns the server's new offset.
+func (c *Client) tusPatch(
+	ctx context.Context,
+	uploadURL string,
+	body io.ReaderAt,
+	offset, n int64,
+	opts []RequestOption,
+) (int64, error) {
+	req, err := c.tusRequest(ctx, http.MethodPatch, uploadURL, opts)
+	if err != nil {
+		return 0, err
+	}
+	setSectionBody(req, body, offset, n)
+	req.Header.Set("Content-Type", "application/offset+octet-stream")
+	req.Header.Set("Upload-Offset", strconv.FormatInt(offset, 10))
+
+	step := "upload at offset " + strconv.FormatInt(offset, 10)
+	header, err := c.tusDo(req, step, http.StatusNoContent)
+	if err != nil {
+		return 0, err
+	}
+	return parseTusOffset(header, step)
+}
+
+// parseTusOffset reads the Upload-Offset header of a tus response.
+func parseTusOffset(header http.Header, step string) (int64, error) {
+	offset, err := strconv.ParseInt(header.Get("Upload-Offset"), 10, 64)
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("retry: tus %s: invalid Upload-Offset %q", step, header.Get("Upload-Offset"))
+	}
+	return offset, nil
+}
+
+// encodeTusMetadata encodes metadata as the Upload-Metadata header value:
+// comma-separated "key base64(value)" pairs, in key order.
+func encodeTusMetadata(metadata map[string]string) string {
+	pairs := make([]string, 0, len(metadata))
+	for key, value := range metadata {
+		pairs = append(pairs, key+" "+base64.StdEncoding.EncodeToString([]byte(value)))
+	}
+	slices.Sort(pairs)
+	return strings.Join(pairs, ",")
+}
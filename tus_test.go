@@ -0,0 +1,143 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// tusTestServer is a minimal in-memory tus server. The failPatch-th PATCH
+// stores only the first 4 bytes of its chunk and then fails.
+type tusTestServer struct {
+	mu        sync.Mutex
+	data      []byte
+	length    int64
+	metadata  string
+	failPatch int
+	patches   int
+}
+
+func (s *tusTestServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Header.Get("Tus-Resumable") != tusVersion {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		s.length, _ = strconv.ParseInt(r.Header.Get("Upload-Length"), 10, 64)
+		s.metadata = r.Header.Get("Upload-Metadata")
+		w.Header().Set("Location", "/files/1")
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodHead:
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+	case http.MethodPatch:
+		s.patches++
+		if r.Header.Get("Upload-Offset") != strconv.Itoa(len(s.data)) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		chunk, _ := io.ReadAll(r.Body)
+		if s.patches == s.failPatch {
+			s.data = append(s.data, chunk[:4]...)
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		s.data = append(s.data, chunk...)
+		w.Header().Set("Upload-Offset", strconv.Itoa(len(s.data)))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestUploadTus(t *testing.T) {
+	tus := &tusTestServer{failPatch: 2}
+	server := httptest.NewServer(tus)
+	defer server.Close()
+
+	client, err := NewClient(WithMaxRetries(0), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	data := []byte(strings.Repeat("abcdefghij", 3))
+	upload := &TusUpload{
+		Endpoint:  server.URL + "/files/",
+		Metadata:  map[string]string{"filename": "a.txt", "type": "text"},
+		ChunkSize: 10,
+	}
+
+	err = client.UploadTus(context.Background(), upload, bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+
+	if upload.URL != server.URL+"/files/1" {
+		t.Errorf("Expected upload URL to be set, got %q", upload.URL)
+	}
+	if !bytes.Equal(tus.data, data) || tus.length != int64(len(data)) {
+		t.Errorf("Server received %q (length %d)", tus.data, tus.length)
+	}
+	// The failed chunk resumes from the offset the server reports: 0-10,
+	// 10-20 (fails after 14), 14-24, 24-30
+	if tus.patches != 4 {
+		t.Errorf("Expected 4 PATCH requests, got %d", tus.patches)
+	}
+	expected := "filename " + base64.StdEncoding.EncodeToString([]byte("a.txt")) +
+		",type " + base64.StdEncoding.EncodeToString([]byte("text"))
+	if tus.metadata != expected {
+		t.Errorf("Expected metadata %q, got %q", expected, tus.metadata)
+	}
+}
+
+func TestUploadTus_Resume(t *testing.T) {
+	data := []byte("0123456789")
+	tus := &tusTestServer{data: []byte("01234"), length: 10}
+	server := httptest.NewServer(tus)
+	defer server.Close()
+
+	client, err := NewClient(WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	// An existing upload URL skips creation and continues from the server offset
+	upload := &TusUpload{URL: server.URL + "/files/1"}
+	if err := client.UploadTus(context.Background(), upload, bytes.NewReader(data), 10); err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	if !bytes.Equal(tus.data, data) || tus.patches != 1 {
+		t.Errorf("Expected one PATCH completing the data, got %q after %d", tus.data, tus.patches)
+	}
+}
+
+func TestUploadTus_NoProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			w.Header().Set("Upload-Offset", "0")
+		case http.MethodPatch:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	upload := &TusUpload{URL: server.URL + "/files/1"}
+	err = client.UploadTus(context.Background(), upload, strings.NewReader("data"), 4)
+	if err == nil || !strings.Contains(err.Error(), "upload at offset 0: unexpected status 403") {
+		t.Errorf("Expected the PATCH error, got %v", err)
+	}
+}
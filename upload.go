@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// DefaultChunkSize is the chunk size UploadChunked uses when none is given.
+const DefaultChunkSize = 8 << 20 // 8 MiB
+
+// statusResumeIncomplete is the status resumable upload servers (Google Cloud
+// Storage, Drive) send for an accepted chunk when more are expected.
+const statusResumeIncomplete = 308
+
+// UploadChunked uploads size bytes of body to url as a series of chunkSize-byte
+// requests, each carrying a "Content-Range: bytes first-last/size" header.
+// Every chunk is retried on its own with the client's policy, so a transient
+// failure near the end of a large upload resends one chunk, not the whole
+// file. A chunkSize <= 0 means DefaultChunkSize.
+//
+// opts are applied to every chunk request (headers, WithTimeout per chunk);
+// body options such as WithBody are overridden. Chunks other than the last
+// must be answered with a 2xx or 308 (Resume Incomplete) status. The response
+// to the last chunk is returned; the caller must close its body.
+//
+// Example:
+//
+//	f, _ := os.Open("backup.tar")
+//	defer f.Close()
+//	info, _ := f.Stat()
+//	resp, err := client.UploadChunked(ctx, http.MethodPut, uploadURL, f, info.Size(), 16<<20,
+//	    retry.WithHeader("Content-Type", "application/x-tar"))
+func (c *Client) UploadChunked(
+	ctx context.Context,
+	method string,
+	url string,
+	body io.ReaderAt,
+	size int64,
+	chunkSize int64,
+	opts ...RequestOption,
+) (*http.Response, error) {
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	for offset := int64(0); ; offset += chunkSize {
+		n := min(chunkSize, size-offset)
+		last := offset+n >= size
+
+		req, err := c.newRequest(ctx, method, url, opts...)
+		if err != nil {
+			return nil, err
+		}
+		setChunkBody(req, body, offset, n, size)
+
+		resp, err := c.doPrepared(ctx, req)
+		if err != nil {
+			return resp, fmt.Errorf("retry: upload chunk at offset %d: %w", offset, err)
+		}
+		if last {
+			return resp, nil
+		}
+		if !chunkAccepted(resp) {
+			return resp, fmt.Errorf("retry: upload chunk at offset %d: unexpected status %d",
+				offset, resp.StatusCode)
+		}
+
+		// Drain so the connection can be reused for the next chunk
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
+// setChunkBody sets the n bytes of body at offset as the request body, with
+// the Content-Range header for a file of size bytes.
+func setChunkBody(req *http.Request, body io.ReaderAt, offset, n, size int64) {
+	setSectionBody(req, body, offset, n)
+	if n == 0 {
+		req.Header.Set("Content-Range", "bytes */"+strconv.FormatInt(size, 10))
+		return
+	}
+	req.Header.Set("Content-Range",
+		fmt.Sprintf("bytes %d-%d/%d", offset, offset+n-1, size))
+}
+
+// setSectionBody sets the n bytes of body at offset as the request body, with
+// GetBody so each retry resends the same section.
+func setSectionBody(req *http.Request, body io.ReaderAt, offset, n int64) {
+	req.ContentLength = n
+	if n == 0 {
+		req.Body = http.NoBody
+		return
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(io.NewSectionReader(body, offset, n)), nil
+	}
+	req.Body, _ = req.GetBody()
+}
+
+// chunkAccepted reports whether resp acknowledges an intermediate chunk.
+func chunkAccepted(resp *http.Response) bool {
+	return resp.StatusCode == statusResumeIncomplete ||
+		(resp.StatusCode >= 200 && resp.StatusCode < 300)
+}
@@ -0,0 +1,120 @@
+package retry
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestUploadChunked(t *testing.T) {
+	data := []byte(strings.Repeat("0123456789", 25)) // 250 bytes
+
+	var mu sync.Mutex
+	var ranges []string
+	received := make([]byte, len(data))
+	failed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		contentRange := r.Header.Get("Content-Range")
+		ranges = append(ranges, contentRange)
+		if r.Header.Get("X-Upload") != "backup" {
+			t.Errorf("Expected request options on every chunk")
+		}
+
+		// Fail the first attempt of the middle chunk
+		if contentRange == "bytes 100-199/250" && !failed {
+			failed = true
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var first, last, size int
+		if _, err := fmt.Sscanf(contentRange, "bytes %d-%d/%d", &first, &last, &size); err != nil {
+			t.Errorf("Bad Content-Range %q: %v", contentRange, err)
+		}
+		chunk, _ := io.ReadAll(r.Body)
+		copy(received[first:], chunk)
+		if last == size-1 {
+			w.WriteHeader(http.StatusCreated)
+		} else {
+			w.WriteHeader(statusResumeIncomplete)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithInitialRetryDelay(time.Millisecond), WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.UploadChunked(context.Background(), http.MethodPut, server.URL,
+		bytes.NewReader(data), int64(len(data)), 100, WithHeader("X-Upload", "backup"))
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Errorf("Expected final status 201, got %d", resp.StatusCode)
+	}
+	expected := "bytes 0-99/250,bytes 100-199/250,bytes 100-199/250,bytes 200-249/250"
+	if got := strings.Join(ranges, ","); got != expected {
+		t.Errorf("Expected ranges %s, got %s", expected, got)
+	}
+	if !bytes.Equal(received, data) {
+		t.Errorf("Uploaded data does not match")
+	}
+}
+
+func TestUploadChunked_RejectedChunk(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.UploadChunked(context.Background(), http.MethodPut, server.URL,
+		strings.NewReader("abcdef"), 6, 3)
+	if resp != nil {
+		resp.Body.Close()
+	}
+	if err == nil || !strings.Contains(err.Error(), "offset 0: unexpected status 403") {
+		t.Errorf("Expected rejected first chunk, got %v", err)
+	}
+}
+
+func TestUploadChunked_Empty(t *testing.T) {
+	var contentRange string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentRange = r.Header.Get("Content-Range")
+	}))
+	defer server.Close()
+
+	client, err := NewClient(WithNoLogging())
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.UploadChunked(context.Background(), http.MethodPut, server.URL,
+		strings.NewReader(""), 0, 0)
+	if err != nil {
+		t.Fatalf("Upload failed: %v", err)
+	}
+	resp.Body.Close()
+	if contentRange != "bytes */0" {
+		t.Errorf("Expected Content-Range bytes */0, got %q", contentRange)
+	}
+}
@@ -0,0 +1,55 @@
+package retry
+
+import (
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// modulePath identifies this library in the build info and the User-Agent suffix.
+const modulePath = "github.com/appleboy/go-httpretry"
+
+// userAgentProduct is the product token appended to WithUserAgent values.
+const userAgentProduct = "go-httpretry"
+
+// libraryVersion returns the version of this module as recorded in the
+// binary's build info, e.g. "v1.2.0", or "" when it is unknown (tests, local
+// replace directives, binaries built without module support).
+func libraryVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	version := ""
+	if info.Main.Path == modulePath {
+		version = info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			version = dep.Version
+		}
+	}
+	if version == "(devel)" {
+		return ""
+	}
+	return version
+}
+
+// userAgentSuffix returns "go-httpretry/x.y.z", or just "go-httpretry" when the
+// version is unknown.
+func userAgentSuffix() string {
+	version := strings.TrimPrefix(libraryVersion(), "v")
+	if version == "" {
+		return userAgentProduct
+	}
+	return userAgentProduct + "/" + version
+}
+
+// setUserAgent sets the WithUserAgent value on an attempt request, unless the
+// request already has its own User-Agent header.
+func (c *Client) setUserAgent(req *http.Request) {
+	if c.userAgent != "" && req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+}
@@ -0,0 +1,62 @@
+package retry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithUserAgent(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		opts     []Option
+		reqOpts  []RequestOption
+		expected string
+	}{
+		{"with suffix", []Option{WithUserAgent("my-service/1.4")}, nil, "my-service/1.4 " + userAgentSuffix()},
+		{
+			"suffix disabled",
+			[]Option{WithUserAgent("my-service/1.4"), WithUserAgentSuffix(false)},
+			nil,
+			"my-service/1.4",
+		},
+		{
+			"request header wins",
+			[]Option{WithUserAgent("my-service/1.4")},
+			[]RequestOption{WithHeader("User-Agent", "custom/2.0")},
+			"custom/2.0",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClient(append(tt.opts, WithNoLogging())...)
+			if err != nil {
+				t.Fatalf("Failed to create client: %v", err)
+			}
+
+			resp, err := client.Get(context.Background(), server.URL, tt.reqOpts...)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			resp.Body.Close()
+			if got != tt.expected {
+				t.Errorf("Expected User-Agent %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestUserAgentSuffix(t *testing.T) {
+	if suffix := userAgentSuffix(); !strings.HasPrefix(suffix, "go-httpretry") {
+		t.Errorf("Expected suffix to start with go-httpretry, got %q", suffix)
+	}
+}
@@ -0,0 +1,60 @@
+package retry
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// ErrInvalidResponse is returned (wrapped) when the WithResponseValidator
+// function rejects a response.
+var ErrInvalidResponse = errors.New("retry: invalid response")
+
+// permanentError marks an error as never retryable.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so that the client never retries it, whatever the
+// retryable checker says. It is mainly useful for WithResponseValidator
+// functions and request middleware that know a failure cannot succeed on
+// retry. Permanent(nil) returns nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// isPermanent reports whether err was marked with Permanent.
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// validateResponse runs the WithResponseValidator function against a 2xx resp.
+// The body is buffered first so the validator can read it, and rewound
+// afterwards so the caller can read it too. A rejected response is returned
+// together with an error wrapping ErrInvalidResponse.
+func (c *Client) validateResponse(resp *http.Response) (*http.Response, error) {
+	if c.responseValidator == nil || resp == nil ||
+		resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return resp, nil
+	}
+
+	resp, err := bufferResponseBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	validationErr := c.responseValidator(resp)
+	rewindResponseBody(resp)
+	if validationErr != nil {
+		return resp, fmt.Errorf("%w: %w", ErrInvalidResponse, validationErr)
+	}
+	return resp, nil
+}
@@ -0,0 +1,149 @@
+package retry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// statusValidator rejects bodies of the form {"status":"retry"} or {"status":"fatal"}
+func statusValidator(resp *http.Response) error {
+	var body struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return err
+	}
+	switch body.Status {
+	case "retry":
+		return errors.New("server asked to retry")
+	case "fatal":
+		return Permanent(errors.New("server reported a fatal error"))
+	}
+	return nil
+}
+
+func TestWithResponseValidator_RetriesRejectedResponse(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			_, _ = w.Write([]byte(`{"status":"retry"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	var reasons []string
+	client, err := NewClient(
+		WithResponseValidator(statusValidator),
+		WithInitialRetryDelay(time.Millisecond),
+		WithOnRetry(func(info RetryInfo) {
+			reasons = append(reasons, determineRetryReason(info.Err, nil))
+		}),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"status":"ok"}` {
+		t.Errorf("Expected validated body to remain readable, got %q", body)
+	}
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, got %d", attempts)
+	}
+	if len(reasons) != 1 || reasons[0] != RetryReasonInvalid {
+		t.Errorf("Expected one %q retry, got %v", RetryReasonInvalid, reasons)
+	}
+}
+
+func TestWithResponseValidator_PermanentStopsRetrying(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		_, _ = w.Write([]byte(`{"status":"fatal"}`))
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithResponseValidator(statusValidator),
+		WithInitialRetryDelay(time.Millisecond),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if !errors.Is(err, ErrInvalidResponse) {
+		t.Fatalf("Expected ErrInvalidResponse, got %v", err)
+	}
+	if resp == nil {
+		t.Fatal("Expected the rejected response to be returned")
+	}
+	resp.Body.Close()
+
+	if attempts != 1 {
+		t.Errorf("Expected a permanent failure not to be retried, got %d attempts", attempts)
+	}
+}
+
+func TestWithResponseValidator_SkipsNon2xx(t *testing.T) {
+	var validated int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client, err := NewClient(
+		WithResponseValidator(func(*http.Response) error {
+			atomic.AddInt32(&validated, 1)
+			return errors.New("invalid")
+		}),
+		WithNoLogging(),
+	)
+	if err != nil {
+		t.Fatalf("Failed to create client: %v", err)
+	}
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.Body.Close()
+
+	if validated != 0 {
+		t.Errorf("Expected validator not to run for non-2xx responses, ran %d times", validated)
+	}
+}
+
+func TestPermanent(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Error("Expected Permanent(nil) to be nil")
+	}
+
+	base := errors.New("boom")
+	err := Permanent(base)
+	if !errors.Is(err, base) || err.Error() != "boom" {
+		t.Errorf("Expected Permanent to wrap transparently, got %v", err)
+	}
+
+	client, _ := NewClient(WithRetryableChecker(func(error, *http.Response) bool { return true }))
+	if client.isRetryable(err, nil) {
+		t.Error("Expected permanent errors to override the retryable checker")
+	}
+}
@@ -0,0 +1,145 @@
+package retry
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Warmup prepares the client for traffic to hosts before the first real
+// request, so startup failures (DNS not yet resolvable, slow TLS handshakes,
+// a cold pool) surface here instead of consuming retry attempts. Each target
+// is warmed concurrently:
+//
+//   - A host ("api.example.com" or "api.example.com:8443") is resolved, then
+//     dialed and TLS-handshaken through the client's transport settings
+//     (dialer, destination guard, TLS config) and closed again. This checks
+//     reachability and certificates and warms the resolver and, when the
+//     transport's TLS config has a ClientSessionCache, the TLS session cache,
+//     so the first request resumes the session.
+//   - A URL ("https://api.example.com/healthz") additionally gets a HEAD
+//     request through the client, which leaves a keep-alive connection in the
+//     pool for the first real request. Any response status counts.
+//
+// Like keep-alive probes, warmup bypasses the retry loop, metrics and
+// tracing. The error joins the failures of all targets; bound the whole
+// warmup with ctx.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+//	defer cancel()
+//	if err := client.Warmup(ctx, "https://api.example.com/healthz", "auth.example.com"); err != nil {
+//	    log.Printf("warmup: %v", err) // Start anyway; retries cover the rest
+//	}
+func (c *Client) Warmup(ctx context.Context, hosts ...string) error {
+	errs := make([]error, len(hosts))
+	var wg sync.WaitGroup
+	for i, host := range hosts {
+		wg.Go(func() {
+			start := time.Now()
+			if err := c.warmup(ctx, host); err != nil {
+				errs[i] = fmt.Errorf("retry: warmup %s: %w", host, err)
+				return
+			}
+			if c.loggerEnabled {
+				c.logger.Debug("connection warmed up",
+					"host", host,
+					"duration", time.Since(start),
+				)
+			}
+		})
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// warmup resolves, dials and handshakes target, then sends a HEAD request
+// when target is a URL.
+func (c *Client) warmup(ctx context.Context, target string) error {
+	probe := strings.Contains(target, "://")
+	u := &url.URL{Scheme: "https", Host: target}
+	if probe {
+		var err error
+		if u, err = url.Parse(target); err != nil {
+			return err
+		}
+	}
+	if u.Hostname() == "" {
+		return errors.New("missing host")
+	}
+
+	if _, err := net.DefaultResolver.LookupHost(ctx, u.Hostname()); err != nil {
+		return err
+	}
+	if err := c.warmupConnection(ctx, u); err != nil {
+		return err
+	}
+	if !probe {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	c.setUserAgent(req)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.Body.Close()
+}
+
+// warmupConnection dials u's host with the base transport's dialer and, for
+// https, completes a TLS handshake with its TLS config. Transports other than
+// *http.Transport, and proxied destinations, are left to the HEAD request.
+func (c *Client) warmupConnection(ctx context.Context, u *url.URL) error {
+	transport, ok := c.baseTransport.(*http.Transport)
+	if !ok {
+		return nil
+	}
+	if transport.Proxy != nil {
+		if proxy, err := transport.Proxy(&http.Request{URL: u}); err != nil || proxy != nil {
+			return err
+		}
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	dial := transport.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	conn, err := dial(ctx, "tcp", net.JoinHostPort(u.Hostname(), port))
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if u.Scheme == "http" {
+		return nil
+	}
+
+	config := &tls.Config{}
+	if transport.TLSClientConfig != nil {
+		config = transport.TLSClientConfig.Clone()
+	}
+	if config.ServerName == "" {
+		config.ServerName = u.Hostname()
+	}
+	return tls.Client(conn, config).HandshakeContext(ctx)
+}
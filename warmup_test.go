@@ -0,0 +1,99 @@
+package retry
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWarmup_PrimesPool(t *testing.T) {
+	var heads atomic.Int32
+	var dials atomic.Int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			heads.Add(1)
+		}
+	})
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			dials.Add(1)
+		}
+	}
+	server.StartTLS()
+	defer server.Close()
+
+	client, err := NewClient(WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Warmup(context.Background(), server.URL+"/healthz"); err != nil {
+		t.Fatal(err)
+	}
+	if got := heads.Load(); got != 1 {
+		t.Fatalf("Expected 1 HEAD request, got %d", got)
+	}
+	warmed := dials.Load()
+
+	resp, err := client.Get(context.Background(), server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := dials.Load(); got != warmed {
+		t.Errorf("Expected the first request to reuse the warmed connection, got %d new", got-warmed)
+	}
+}
+
+func TestWarmup_HostOnly(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "https://")
+
+	client, err := NewClient(WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.Warmup(context.Background(), host); err != nil {
+		t.Fatal(err)
+	}
+	if got := requests.Load(); got != 0 {
+		t.Errorf("Expected no request for a bare host, got %d", got)
+	}
+
+	// Without the test CA the handshake fails during warmup, not on first use
+	untrusted, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := untrusted.Warmup(context.Background(), host); err == nil {
+		t.Error("Expected a certificate error")
+	}
+}
+
+func TestWarmup_JoinsErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client, err := NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = client.Warmup(context.Background(), server.URL, "https://", "127.0.0.1:1")
+	if err == nil {
+		t.Fatal("Expected an error")
+	}
+	msg := err.Error()
+	if strings.Contains(msg, server.URL) || !strings.Contains(msg, "https://") ||
+		!strings.Contains(msg, "127.0.0.1:1") {
+		t.Errorf("Expected the failing targets only, got %v", err)
+	}
+}